@@ -13,7 +13,6 @@ require (
 	github.com/gorilla/schema v1.2.0
 	github.com/jackc/pgconn v1.14.1
 	github.com/jackc/pgerrcode v0.0.0-20220416144525-469b46aa5efa
-	github.com/jellydator/ttlcache/v3 v3.1.0
 	github.com/jessevdk/go-flags v1.5.0
 	github.com/markphelps/optional v0.10.0
 	github.com/mattn/go-sqlite3 v1.14.16
@@ -22,6 +21,7 @@ require (
 	github.com/sirupsen/logrus v1.6.0
 	github.com/stretchr/testify v1.8.2
 	github.com/tidwall/jsonc v0.3.2
+	go.mongodb.org/mongo-driver v1.16.1
 	golang.org/x/crypto v0.22.0
 	golang.org/x/exp v0.0.0-20230126173853-a67bb567ff2e
 	golang.org/x/term v0.19.0
@@ -40,7 +40,6 @@ require (
 	github.com/xdg-go/scram v1.1.2 // indirect
 	github.com/xdg-go/stringprep v1.0.4 // indirect
 	github.com/youmark/pkcs8 v0.0.0-20181117223130-1be2e3e5546d // indirect
-	go.mongodb.org/mongo-driver v1.16.1 // indirect
 )
 
 require (
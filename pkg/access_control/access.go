@@ -1,6 +1,10 @@
 package access_control
 
-import "net/http"
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
 
 type AccessType uint32
 type Operation uint32
@@ -104,3 +108,37 @@ func HttpContentInQuery(accessType AccessType) bool {
 	a := NewAccess(uint32(accessType))
 	return a.Check(Get) || a.Check(Delete)
 }
+
+var accessNames = map[string]AccessType{
+	"none":           0,
+	"read":           Read,
+	"get":            Get,
+	"create":         Create,
+	"post":           Post,
+	"update_replace": UpdateReplace,
+	"put":            Put,
+	"update_partial": UpdatePartial,
+	"patch":          Patch,
+	"update":         Update,
+	"delete":         Delete,
+	"all":            All,
+}
+
+// Parse access mask from list of access names, each item can also be a comma separated list.
+func ParseAccess(names ...string) (AccessType, error) {
+	var access AccessType
+	for _, item := range names {
+		for _, name := range strings.Split(item, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			a, ok := accessNames[name]
+			if !ok {
+				return 0, fmt.Errorf("unknown access type %s", name)
+			}
+			access = access | a
+		}
+	}
+	return access, nil
+}
@@ -7,7 +7,7 @@ import (
 
 // Interface for access controllers.
 type AccessControl interface {
-	CheckAccess(ctx op_context.Context, resource Resource, subject Subject, accessType AccessType) bool
+	CheckAccess(ctx op_context.Context, resource Resource, subject Subject, accessType AccessType) (bool, error)
 	DefaultAccess() Access
 	SetDefaultAccess(Access)
 }
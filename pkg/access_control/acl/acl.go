@@ -0,0 +1,81 @@
+package acl
+
+import (
+	"github.com/evgeniums/go-utils/pkg/access_control"
+	"github.com/evgeniums/go-utils/pkg/common"
+)
+
+type AclRole struct {
+	common.ObjectBase
+	common.WithUniqueNameBase
+	common.WithDescriptionBase
+}
+
+func NewRole() *AclRole {
+	r := &AclRole{}
+	return r
+}
+
+func (AclRole) TableName() string {
+	return "acl_roles"
+}
+
+type AclRuleData struct {
+	RESOURCE string `gorm:"index;uniqueIndex:u_acl_rule" json:"resource" validate:"required" vmessage:"Resource path must be specified" long:"resource" description:"Resource path" required:"true"`
+	TAG      string `gorm:"index;uniqueIndex:u_acl_rule" json:"tag,omitempty" long:"tag" description:"Resource tag, empty tag means that rule is applied to resource regardless of tags"`
+	ROLE     string `gorm:"index;uniqueIndex:u_acl_rule" json:"role" validate:"required" vmessage:"Role must be specified" long:"role" description:"Role name" required:"true"`
+	ACCESS   uint32 `gorm:"index" json:"access"`
+}
+
+type AclRule struct {
+	common.ObjectBase
+	AclRuleData
+}
+
+func NewRule() *AclRule {
+	r := &AclRule{}
+	return r
+}
+
+func (AclRule) TableName() string {
+	return "acl_rules"
+}
+
+func (r *AclRule) Resource() access_control.Resource {
+	return access_control.NewResource(r.RESOURCE)
+}
+
+func (r *AclRule) Role() access_control.Role {
+	return access_control.NewRole(r.ROLE)
+}
+
+func (r *AclRule) Access() access_control.Access {
+	a := access_control.NewAccess(r.ACCESS)
+	return &a
+}
+
+func (r *AclRule) Tags() []string {
+	if r.TAG == "" {
+		return []string{}
+	}
+	return []string{r.TAG}
+}
+
+type AclResourceTagData struct {
+	RESOURCE string `gorm:"index;uniqueIndex:u_acl_resource_tag" json:"resource" validate:"required" vmessage:"Resource path must be specified" long:"resource" description:"Resource path" required:"true"`
+	TAG      string `gorm:"index;uniqueIndex:u_acl_resource_tag" json:"tag" validate:"required" vmessage:"Tag must be specified" long:"tag" description:"Resource tag" required:"true"`
+}
+
+type AclResourceTag struct {
+	common.ObjectBase
+	AclResourceTagData
+}
+
+func NewResourceTag() *AclResourceTag {
+	t := &AclResourceTag{}
+	return t
+}
+
+func (AclResourceTag) TableName() string {
+	return "acl_resource_tags"
+}
@@ -0,0 +1,39 @@
+package acl_api
+
+import (
+	"github.com/evgeniums/go-utils/pkg/access_control/acl"
+	"github.com/evgeniums/go-utils/pkg/api"
+)
+
+type RoleResponse struct {
+	api.ResponseBase
+	*acl.AclRole
+}
+
+type RuleResponse struct {
+	api.ResponseBase
+	*acl.AclRule
+}
+
+type ResourceTagResponse struct {
+	api.ResponseBase
+	*acl.AclResourceTag
+}
+
+type ListRolesResponse = api.ResponseList[*acl.AclRole]
+
+type ListRulesResponse = api.ResponseList[*acl.AclRule]
+
+type ListResourceTagsResponse = api.ResponseList[*acl.AclResourceTag]
+
+var (
+	AddRole           = func() api.Operation { return api.Add("add_role") }
+	DeleteRole        = func() api.Operation { return api.Delete("delete_role") }
+	ListRoles         = func() api.Operation { return api.List("list_roles") }
+	SetRule           = func() api.Operation { return api.Post("set_rule") }
+	DeleteRule        = func() api.Operation { return api.Delete("delete_rule") }
+	ListRules         = func() api.Operation { return api.List("list_rules") }
+	AddResourceTag    = func() api.Operation { return api.Add("add_resource_tag") }
+	DeleteResourceTag = func() api.Operation { return api.Delete("delete_resource_tag") }
+	ListResourceTags  = func() api.Operation { return api.List("list_resource_tags") }
+)
@@ -0,0 +1,80 @@
+package acl_client
+
+import (
+	"github.com/evgeniums/go-utils/pkg/access_control/acl"
+	"github.com/evgeniums/go-utils/pkg/access_control/acl/acl_api"
+	"github.com/evgeniums/go-utils/pkg/api"
+	"github.com/evgeniums/go-utils/pkg/api/api_client"
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+	"github.com/evgeniums/go-utils/pkg/utils"
+)
+
+type AclClient struct {
+	api_client.ServiceClient
+
+	RolesResource        api.Resource
+	RoleResource         api.Resource
+	RulesResource        api.Resource
+	RuleResource         api.Resource
+	ResourceTagsResource api.Resource
+	ResourceTagResource  api.Resource
+
+	add_role         api.Operation
+	set_rule         api.Operation
+	add_resource_tag api.Operation
+
+	list_roles         api.Operation
+	list_rules         api.Operation
+	list_resource_tags api.Operation
+}
+
+func NewAclClient(client api_client.Client) *AclClient {
+
+	c := &AclClient{}
+	c.Init(client, "acl")
+
+	_, c.RolesResource, c.RoleResource = api.PrepareCollectionAndNameResource("role")
+	c.AddChild(c.RolesResource)
+	_, c.RulesResource, c.RuleResource = api.PrepareCollectionAndNameResource("rule")
+	c.AddChild(c.RulesResource)
+	_, c.ResourceTagsResource, c.ResourceTagResource = api.PrepareCollectionAndNameResource("resource_tag")
+	c.AddChild(c.ResourceTagsResource)
+
+	c.add_role = acl_api.AddRole()
+	c.list_roles = acl_api.ListRoles()
+	c.RolesResource.AddOperations(c.add_role, c.list_roles)
+
+	c.set_rule = acl_api.SetRule()
+	c.list_rules = acl_api.ListRules()
+	c.RulesResource.AddOperations(c.set_rule, c.list_rules)
+
+	c.add_resource_tag = acl_api.AddResourceTag()
+	c.list_resource_tags = acl_api.ListResourceTags()
+	c.ResourceTagsResource.AddOperations(c.add_resource_tag, c.list_resource_tags)
+
+	return c
+}
+
+func (a *AclClient) FindRole(ctx op_context.Context, id string, idIsName ...bool) (*acl.AclRole, error) {
+
+	c := ctx.TraceInMethod("AclClient.FindRole")
+	defer ctx.TraceOutMethod()
+
+	filter := db.NewFilter()
+	if utils.OptionalArg(false, idIsName...) {
+		filter.AddField("name", id)
+	} else {
+		filter.AddField("id", id)
+	}
+	roles, _, err := a.GetRoles(ctx, filter)
+	if err != nil {
+		return nil, c.SetError(err)
+	}
+	if len(roles) == 0 {
+		ctx.SetGenericErrorCode(acl.ErrorCodeRoleNotFound)
+		return nil, nil
+	}
+
+	return roles[0], nil
+}
@@ -0,0 +1,52 @@
+package acl_client
+
+import (
+	"github.com/evgeniums/go-utils/pkg/access_control/acl"
+	"github.com/evgeniums/go-utils/pkg/access_control/acl/acl_api"
+	"github.com/evgeniums/go-utils/pkg/api"
+	"github.com/evgeniums/go-utils/pkg/api/api_client"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+)
+
+type AddResourceTag struct {
+	cmd    *acl.AclResourceTagData
+	result *acl_api.ResourceTagResponse
+}
+
+func (a *AddResourceTag) Exec(client api_client.Client, ctx op_context.Context, operation api.Operation) error {
+
+	c := ctx.TraceInMethod("AddResourceTag.Exec")
+	defer ctx.TraceOutMethod()
+
+	err := client.Exec(ctx, operation, a.cmd, a.result)
+	c.SetError(err)
+	return err
+}
+
+func (a *AclClient) AddResourceTag(ctx op_context.Context, tag *acl.AclResourceTagData) (*acl.AclResourceTag, error) {
+
+	// setup
+	var err error
+	c := ctx.TraceInMethod("AclClient.AddResourceTag")
+	onExit := func() {
+		if err != nil {
+			c.SetError(err)
+		}
+		ctx.TraceOutMethod()
+	}
+	defer onExit()
+
+	// prepare and exec handler
+	handler := &AddResourceTag{
+		cmd:    tag,
+		result: &acl_api.ResourceTagResponse{},
+	}
+	err = a.add_resource_tag.Exec(ctx, api_client.MakeOperationHandler(a.Client(), handler))
+	if err != nil {
+		c.SetMessage("failed to exec operation")
+		return nil, err
+	}
+
+	// done
+	return handler.result.AclResourceTag, nil
+}
@@ -0,0 +1,52 @@
+package acl_client
+
+import (
+	"github.com/evgeniums/go-utils/pkg/access_control/acl"
+	"github.com/evgeniums/go-utils/pkg/access_control/acl/acl_api"
+	"github.com/evgeniums/go-utils/pkg/api"
+	"github.com/evgeniums/go-utils/pkg/api/api_client"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+)
+
+type AddRole struct {
+	cmd    *acl.AclRole
+	result *acl_api.RoleResponse
+}
+
+func (a *AddRole) Exec(client api_client.Client, ctx op_context.Context, operation api.Operation) error {
+
+	c := ctx.TraceInMethod("AddRole.Exec")
+	defer ctx.TraceOutMethod()
+
+	err := client.Exec(ctx, operation, a.cmd, a.result)
+	c.SetError(err)
+	return err
+}
+
+func (a *AclClient) AddRole(ctx op_context.Context, role *acl.AclRole) (*acl.AclRole, error) {
+
+	// setup
+	var err error
+	c := ctx.TraceInMethod("AclClient.AddRole")
+	onExit := func() {
+		if err != nil {
+			c.SetError(err)
+		}
+		ctx.TraceOutMethod()
+	}
+	defer onExit()
+
+	// prepare and exec handler
+	handler := &AddRole{
+		cmd:    role,
+		result: &acl_api.RoleResponse{},
+	}
+	err = a.add_role.Exec(ctx, api_client.MakeOperationHandler(a.Client(), handler))
+	if err != nil {
+		c.SetMessage("failed to exec operation")
+		return nil, err
+	}
+
+	// done
+	return handler.result.AclRole, nil
+}
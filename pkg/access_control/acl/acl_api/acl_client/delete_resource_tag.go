@@ -0,0 +1,46 @@
+package acl_client
+
+import (
+	"github.com/evgeniums/go-utils/pkg/access_control/acl/acl_api"
+	"github.com/evgeniums/go-utils/pkg/api"
+	"github.com/evgeniums/go-utils/pkg/api/api_client"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+)
+
+type DeleteResourceTag struct{}
+
+func (a *DeleteResourceTag) Exec(client api_client.Client, ctx op_context.Context, operation api.Operation) error {
+
+	c := ctx.TraceInMethod("DeleteResourceTag.Exec")
+	defer ctx.TraceOutMethod()
+
+	err := client.Exec(ctx, operation, nil, nil)
+	c.SetError(err)
+	return err
+}
+
+func (a *AclClient) DeleteResourceTag(ctx op_context.Context, id string) error {
+
+	// setup
+	var err error
+	c := ctx.TraceInMethod("AclClient.DeleteResourceTag")
+	onExit := func() {
+		if err != nil {
+			c.SetError(err)
+		}
+		ctx.TraceOutMethod()
+	}
+	defer onExit()
+
+	// prepare and exec handler
+	handler := &DeleteResourceTag{}
+	op := api.NamedResourceOperation(a.ResourceTagResource, id, acl_api.DeleteResourceTag())
+	err = op.Exec(ctx, api_client.MakeOperationHandler(a.Client(), handler))
+	if err != nil {
+		c.SetMessage("failed to exec operation")
+		return err
+	}
+
+	// done
+	return nil
+}
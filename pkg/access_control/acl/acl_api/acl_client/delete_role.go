@@ -0,0 +1,64 @@
+package acl_client
+
+import (
+	"github.com/evgeniums/go-utils/pkg/access_control/acl"
+	"github.com/evgeniums/go-utils/pkg/access_control/acl/acl_api"
+	"github.com/evgeniums/go-utils/pkg/api"
+	"github.com/evgeniums/go-utils/pkg/api/api_client"
+	"github.com/evgeniums/go-utils/pkg/generic_error"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+	"github.com/evgeniums/go-utils/pkg/utils"
+)
+
+type DeleteRole struct{}
+
+func (a *DeleteRole) Exec(client api_client.Client, ctx op_context.Context, operation api.Operation) error {
+
+	c := ctx.TraceInMethod("DeleteRole.Exec")
+	defer ctx.TraceOutMethod()
+
+	err := client.Exec(ctx, operation, nil, nil)
+	c.SetError(err)
+	return err
+}
+
+func (a *AclClient) DeleteRole(ctx op_context.Context, id string, idIsName ...bool) error {
+
+	// setup
+	var err error
+	c := ctx.TraceInMethod("AclClient.DeleteRole")
+	onExit := func() {
+		if err != nil {
+			c.SetError(err)
+		}
+		ctx.TraceOutMethod()
+	}
+	defer onExit()
+
+	// adjust id
+	roleId := id
+	if utils.OptionalArg(false, idIsName...) {
+		var role *acl.AclRole
+		role, err = a.FindRole(ctx, id, true)
+		if err != nil {
+			return err
+		}
+		if role == nil {
+			err = generic_error.New(acl.ErrorCodeRoleNotFound, "role not found")
+			return err
+		}
+		roleId = role.GetID()
+	}
+
+	// prepare and exec handler
+	handler := &DeleteRole{}
+	op := api.NamedResourceOperation(a.RoleResource, roleId, acl_api.DeleteRole())
+	err = op.Exec(ctx, api_client.MakeOperationHandler(a.Client(), handler))
+	if err != nil {
+		c.SetMessage("failed to exec operation")
+		return err
+	}
+
+	// done
+	return nil
+}
@@ -0,0 +1,59 @@
+package acl_client
+
+import (
+	"github.com/evgeniums/go-utils/pkg/access_control/acl"
+	"github.com/evgeniums/go-utils/pkg/access_control/acl/acl_api"
+	"github.com/evgeniums/go-utils/pkg/api"
+	"github.com/evgeniums/go-utils/pkg/api/api_client"
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+)
+
+type ListResourceTags struct {
+	cmd    api.Query
+	result *acl_api.ListResourceTagsResponse
+}
+
+func (a *ListResourceTags) Exec(client api_client.Client, ctx op_context.Context, operation api.Operation) error {
+
+	c := ctx.TraceInMethod("ListResourceTags.Exec")
+	defer ctx.TraceOutMethod()
+
+	err := client.Exec(ctx, operation, a.cmd, a.result)
+	c.SetError(err)
+	return err
+}
+
+func (a *AclClient) GetResourceTags(ctx op_context.Context, filter *db.Filter) ([]*acl.AclResourceTag, int64, error) {
+
+	// setup
+	var err error
+	c := ctx.TraceInMethod("AclClient.GetResourceTags")
+	onExit := func() {
+		if err != nil {
+			c.SetError(err)
+		}
+		ctx.TraceOutMethod()
+	}
+	defer onExit()
+
+	// set query
+	cmd := api.NewDbQuery(filter)
+
+	// prepare and exec handler
+	handler := &ListResourceTags{
+		cmd:    cmd,
+		result: &acl_api.ListResourceTagsResponse{},
+	}
+	err = a.list_resource_tags.Exec(ctx, api_client.MakeOperationHandler(a.Client(), handler))
+	if err != nil {
+		c.SetMessage("failed to exec operation")
+		return nil, 0, err
+	}
+
+	// keep keyset cursors
+	handler.result.UpdateFilter(filter)
+
+	// done
+	return handler.result.Items, handler.result.Count, nil
+}
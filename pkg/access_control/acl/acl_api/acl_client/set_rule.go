@@ -0,0 +1,52 @@
+package acl_client
+
+import (
+	"github.com/evgeniums/go-utils/pkg/access_control/acl"
+	"github.com/evgeniums/go-utils/pkg/access_control/acl/acl_api"
+	"github.com/evgeniums/go-utils/pkg/api"
+	"github.com/evgeniums/go-utils/pkg/api/api_client"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+)
+
+type SetRule struct {
+	cmd    *acl.AclRuleData
+	result *acl_api.RuleResponse
+}
+
+func (a *SetRule) Exec(client api_client.Client, ctx op_context.Context, operation api.Operation) error {
+
+	c := ctx.TraceInMethod("SetRule.Exec")
+	defer ctx.TraceOutMethod()
+
+	err := client.Exec(ctx, operation, a.cmd, a.result)
+	c.SetError(err)
+	return err
+}
+
+func (a *AclClient) SetRule(ctx op_context.Context, rule *acl.AclRuleData) (*acl.AclRule, error) {
+
+	// setup
+	var err error
+	c := ctx.TraceInMethod("AclClient.SetRule")
+	onExit := func() {
+		if err != nil {
+			c.SetError(err)
+		}
+		ctx.TraceOutMethod()
+	}
+	defer onExit()
+
+	// prepare and exec handler
+	handler := &SetRule{
+		cmd:    rule,
+		result: &acl_api.RuleResponse{},
+	}
+	err = a.set_rule.Exec(ctx, api_client.MakeOperationHandler(a.Client(), handler))
+	if err != nil {
+		c.SetMessage("failed to exec operation")
+		return nil, err
+	}
+
+	// done
+	return handler.result.AclRule, nil
+}
@@ -0,0 +1,64 @@
+package acl_service
+
+import (
+	"github.com/evgeniums/go-utils/pkg/access_control/acl"
+	"github.com/evgeniums/go-utils/pkg/api"
+	"github.com/evgeniums/go-utils/pkg/api/api_server"
+)
+
+type AclEndpoint struct {
+	service *AclService
+	api_server.EndpointBase
+}
+
+func (e *AclEndpoint) Construct(service *AclService, op api.Operation) {
+	e.service = service
+	e.EndpointBase.Construct(op)
+}
+
+type AclService struct {
+	api_server.ServiceBase
+	Acl acl.AclController
+
+	RolesResource        api.Resource
+	RoleResource         api.Resource
+	RulesResource        api.Resource
+	RuleResource         api.Resource
+	ResourceTagsResource api.Resource
+	ResourceTagResource  api.Resource
+}
+
+func NewAclService(aclController acl.AclController) *AclService {
+
+	s := &AclService{}
+	s.ErrorsExtenderBase.Init(acl.ErrorDescriptions, acl.ErrorHttpCodes)
+	s.Acl = aclController
+
+	s.Init("acl")
+
+	_, s.RolesResource, s.RoleResource = api.PrepareCollectionAndNameResource("role")
+	s.AddChild(s.RolesResource)
+	_, s.RulesResource, s.RuleResource = api.PrepareCollectionAndNameResource("rule")
+	s.AddChild(s.RulesResource)
+	_, s.ResourceTagsResource, s.ResourceTagResource = api.PrepareCollectionAndNameResource("resource_tag")
+	s.AddChild(s.ResourceTagsResource)
+
+	listRoles := ListRoles(s)
+	s.RolesResource.AddOperations(AddRole(s), listRoles)
+	s.RoleResource.AddOperation(DeleteRole(s))
+
+	listRules := ListRules(s)
+	s.RulesResource.AddOperations(SetRule(s), listRules)
+	s.RuleResource.AddOperation(DeleteRule(s))
+
+	listResourceTags := ListResourceTags(s)
+	s.ResourceTagsResource.AddOperations(AddResourceTag(s), listResourceTags)
+	s.ResourceTagResource.AddOperation(DeleteResourceTag(s))
+
+	rolesTableConfig := &api_server.DynamicTableConfig{Model: &acl.AclRole{}, Operation: listRoles}
+	rulesTableConfig := &api_server.DynamicTableConfig{Model: &acl.AclRule{}, Operation: listRules}
+	resourceTagsTableConfig := &api_server.DynamicTableConfig{Model: &acl.AclResourceTag{}, Operation: listResourceTags}
+	s.AddDynamicTables(rolesTableConfig, rulesTableConfig, resourceTagsTableConfig)
+
+	return s
+}
@@ -0,0 +1,47 @@
+package acl_service
+
+import (
+	"github.com/evgeniums/go-utils/pkg/access_control/acl"
+	"github.com/evgeniums/go-utils/pkg/access_control/acl/acl_api"
+	"github.com/evgeniums/go-utils/pkg/api/api_server"
+)
+
+type AddResourceTagEndpoint struct {
+	AclEndpoint
+}
+
+func (e *AddResourceTagEndpoint) HandleRequest(request api_server.Request) error {
+
+	// setup
+	c := request.TraceInMethod("acl.AddResourceTag")
+	defer request.TraceOutMethod()
+
+	// parse command
+	cmd := &acl.AclResourceTagData{}
+	err := request.ParseValidate(cmd)
+	if err != nil {
+		c.SetMessage("failed to parse/validate command")
+		return err
+	}
+
+	// add resource tag
+	tag, err := e.service.Acl.AddResourceTag(request, cmd)
+	if err != nil {
+		c.SetMessage("failed to add resource tag")
+		return c.SetError(err)
+	}
+
+	// set response
+	resp := &acl_api.ResourceTagResponse{}
+	resp.AclResourceTag = tag
+	request.Response().SetMessage(resp)
+
+	// done
+	return nil
+}
+
+func AddResourceTag(s *AclService) *AddResourceTagEndpoint {
+	e := &AddResourceTagEndpoint{}
+	e.Construct(s, acl_api.AddResourceTag())
+	return e
+}
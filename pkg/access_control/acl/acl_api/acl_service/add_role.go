@@ -0,0 +1,47 @@
+package acl_service
+
+import (
+	"github.com/evgeniums/go-utils/pkg/access_control/acl"
+	"github.com/evgeniums/go-utils/pkg/access_control/acl/acl_api"
+	"github.com/evgeniums/go-utils/pkg/api/api_server"
+)
+
+type AddRoleEndpoint struct {
+	AclEndpoint
+}
+
+func (e *AddRoleEndpoint) HandleRequest(request api_server.Request) error {
+
+	// setup
+	c := request.TraceInMethod("acl.AddRole")
+	defer request.TraceOutMethod()
+
+	// parse command
+	cmd := acl.NewRole()
+	err := request.ParseValidate(cmd)
+	if err != nil {
+		c.SetMessage("failed to parse/validate command")
+		return err
+	}
+
+	// add role
+	role, err := e.service.Acl.AddRole(request, cmd)
+	if err != nil {
+		c.SetMessage("failed to add role")
+		return c.SetError(err)
+	}
+
+	// set response
+	resp := &acl_api.RoleResponse{}
+	resp.AclRole = role
+	request.Response().SetMessage(resp)
+
+	// done
+	return nil
+}
+
+func AddRole(s *AclService) *AddRoleEndpoint {
+	e := &AddRoleEndpoint{}
+	e.Construct(s, acl_api.AddRole())
+	return e
+}
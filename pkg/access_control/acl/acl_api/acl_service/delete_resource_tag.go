@@ -0,0 +1,33 @@
+package acl_service
+
+import (
+	"github.com/evgeniums/go-utils/pkg/access_control/acl/acl_api"
+	"github.com/evgeniums/go-utils/pkg/api/api_server"
+)
+
+type DeleteResourceTagEndpoint struct {
+	AclEndpoint
+}
+
+func (e *DeleteResourceTagEndpoint) HandleRequest(request api_server.Request) error {
+
+	// setup
+	c := request.TraceInMethod("acl.DeleteResourceTag")
+	defer request.TraceOutMethod()
+
+	// delete resource tag
+	err := e.service.Acl.DeleteResourceTag(request, request.GetResourceId("resource_tag"))
+	if err != nil {
+		c.SetMessage("failed to delete resource tag")
+		return c.SetError(err)
+	}
+
+	// done
+	return nil
+}
+
+func DeleteResourceTag(s *AclService) *DeleteResourceTagEndpoint {
+	e := &DeleteResourceTagEndpoint{}
+	e.Construct(s, acl_api.DeleteResourceTag())
+	return e
+}
@@ -0,0 +1,33 @@
+package acl_service
+
+import (
+	"github.com/evgeniums/go-utils/pkg/access_control/acl/acl_api"
+	"github.com/evgeniums/go-utils/pkg/api/api_server"
+)
+
+type DeleteRoleEndpoint struct {
+	AclEndpoint
+}
+
+func (e *DeleteRoleEndpoint) HandleRequest(request api_server.Request) error {
+
+	// setup
+	c := request.TraceInMethod("acl.DeleteRole")
+	defer request.TraceOutMethod()
+
+	// delete role
+	err := e.service.Acl.DeleteRole(request, request.GetResourceId("role"))
+	if err != nil {
+		c.SetMessage("failed to delete role")
+		return c.SetError(err)
+	}
+
+	// done
+	return nil
+}
+
+func DeleteRole(s *AclService) *DeleteRoleEndpoint {
+	e := &DeleteRoleEndpoint{}
+	e.Construct(s, acl_api.DeleteRole())
+	return e
+}
@@ -0,0 +1,33 @@
+package acl_service
+
+import (
+	"github.com/evgeniums/go-utils/pkg/access_control/acl/acl_api"
+	"github.com/evgeniums/go-utils/pkg/api/api_server"
+)
+
+type DeleteRuleEndpoint struct {
+	AclEndpoint
+}
+
+func (e *DeleteRuleEndpoint) HandleRequest(request api_server.Request) error {
+
+	// setup
+	c := request.TraceInMethod("acl.DeleteRule")
+	defer request.TraceOutMethod()
+
+	// delete rule
+	err := e.service.Acl.DeleteRule(request, request.GetResourceId("rule"))
+	if err != nil {
+		c.SetMessage("failed to delete rule")
+		return c.SetError(err)
+	}
+
+	// done
+	return nil
+}
+
+func DeleteRule(s *AclService) *DeleteRuleEndpoint {
+	e := &DeleteRuleEndpoint{}
+	e.Construct(s, acl_api.DeleteRule())
+	return e
+}
@@ -0,0 +1,45 @@
+package acl_service
+
+import (
+	"github.com/evgeniums/go-utils/pkg/access_control/acl"
+	"github.com/evgeniums/go-utils/pkg/access_control/acl/acl_api"
+	"github.com/evgeniums/go-utils/pkg/api/api_server"
+)
+
+type ListResourceTagsEndpoint struct {
+	AclEndpoint
+}
+
+func (e *ListResourceTagsEndpoint) HandleRequest(request api_server.Request) error {
+
+	// setup
+	c := request.TraceInMethod("acl.ListResourceTags")
+	defer request.TraceOutMethod()
+
+	// parse query
+	queryName := request.Endpoint().Resource().ServicePathPrototype()
+	filter, err := api_server.ParseDbQuery(request, &acl.AclResourceTag{}, queryName)
+	if err != nil {
+		return c.SetError(err)
+	}
+
+	// get resource tags
+	resp := &acl_api.ListResourceTagsResponse{}
+	resp.Items, resp.Count, err = e.service.Acl.GetResourceTags(request, filter)
+	if err != nil {
+		return c.SetError(err)
+	}
+
+	// set response message
+	resp.SetCursors(filter)
+	api_server.SetResponseList(request, resp)
+
+	// done
+	return nil
+}
+
+func ListResourceTags(s *AclService) *ListResourceTagsEndpoint {
+	e := &ListResourceTagsEndpoint{}
+	e.Construct(s, acl_api.ListResourceTags())
+	return e
+}
@@ -0,0 +1,45 @@
+package acl_service
+
+import (
+	"github.com/evgeniums/go-utils/pkg/access_control/acl"
+	"github.com/evgeniums/go-utils/pkg/access_control/acl/acl_api"
+	"github.com/evgeniums/go-utils/pkg/api/api_server"
+)
+
+type ListRolesEndpoint struct {
+	AclEndpoint
+}
+
+func (e *ListRolesEndpoint) HandleRequest(request api_server.Request) error {
+
+	// setup
+	c := request.TraceInMethod("acl.ListRoles")
+	defer request.TraceOutMethod()
+
+	// parse query
+	queryName := request.Endpoint().Resource().ServicePathPrototype()
+	filter, err := api_server.ParseDbQuery(request, &acl.AclRole{}, queryName)
+	if err != nil {
+		return c.SetError(err)
+	}
+
+	// get roles
+	resp := &acl_api.ListRolesResponse{}
+	resp.Items, resp.Count, err = e.service.Acl.GetRoles(request, filter)
+	if err != nil {
+		return c.SetError(err)
+	}
+
+	// set response message
+	resp.SetCursors(filter)
+	api_server.SetResponseList(request, resp)
+
+	// done
+	return nil
+}
+
+func ListRoles(s *AclService) *ListRolesEndpoint {
+	e := &ListRolesEndpoint{}
+	e.Construct(s, acl_api.ListRoles())
+	return e
+}
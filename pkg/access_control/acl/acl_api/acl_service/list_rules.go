@@ -0,0 +1,45 @@
+package acl_service
+
+import (
+	"github.com/evgeniums/go-utils/pkg/access_control/acl"
+	"github.com/evgeniums/go-utils/pkg/access_control/acl/acl_api"
+	"github.com/evgeniums/go-utils/pkg/api/api_server"
+)
+
+type ListRulesEndpoint struct {
+	AclEndpoint
+}
+
+func (e *ListRulesEndpoint) HandleRequest(request api_server.Request) error {
+
+	// setup
+	c := request.TraceInMethod("acl.ListRules")
+	defer request.TraceOutMethod()
+
+	// parse query
+	queryName := request.Endpoint().Resource().ServicePathPrototype()
+	filter, err := api_server.ParseDbQuery(request, &acl.AclRule{}, queryName)
+	if err != nil {
+		return c.SetError(err)
+	}
+
+	// get rules
+	resp := &acl_api.ListRulesResponse{}
+	resp.Items, resp.Count, err = e.service.Acl.GetRules(request, filter)
+	if err != nil {
+		return c.SetError(err)
+	}
+
+	// set response message
+	resp.SetCursors(filter)
+	api_server.SetResponseList(request, resp)
+
+	// done
+	return nil
+}
+
+func ListRules(s *AclService) *ListRulesEndpoint {
+	e := &ListRulesEndpoint{}
+	e.Construct(s, acl_api.ListRules())
+	return e
+}
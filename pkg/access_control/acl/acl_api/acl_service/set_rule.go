@@ -0,0 +1,47 @@
+package acl_service
+
+import (
+	"github.com/evgeniums/go-utils/pkg/access_control/acl"
+	"github.com/evgeniums/go-utils/pkg/access_control/acl/acl_api"
+	"github.com/evgeniums/go-utils/pkg/api/api_server"
+)
+
+type SetRuleEndpoint struct {
+	AclEndpoint
+}
+
+func (e *SetRuleEndpoint) HandleRequest(request api_server.Request) error {
+
+	// setup
+	c := request.TraceInMethod("acl.SetRule")
+	defer request.TraceOutMethod()
+
+	// parse command
+	cmd := &acl.AclRuleData{}
+	err := request.ParseValidate(cmd)
+	if err != nil {
+		c.SetMessage("failed to parse/validate command")
+		return err
+	}
+
+	// set rule
+	rule, err := e.service.Acl.SetRule(request, cmd)
+	if err != nil {
+		c.SetMessage("failed to set rule")
+		return c.SetError(err)
+	}
+
+	// set response
+	resp := &acl_api.RuleResponse{}
+	resp.AclRule = rule
+	request.Response().SetMessage(resp)
+
+	// done
+	return nil
+}
+
+func SetRule(s *AclService) *SetRuleEndpoint {
+	e := &SetRuleEndpoint{}
+	e.Construct(s, acl_api.SetRule())
+	return e
+}
@@ -0,0 +1,47 @@
+package acl
+
+import (
+	"fmt"
+
+	"github.com/evgeniums/go-utils/pkg/logger"
+	"github.com/evgeniums/go-utils/pkg/multitenancy"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+)
+
+const RuleCacheKey = "acl_rule"
+const TagsCacheKey = "acl_tags"
+
+type CachedRule struct {
+	Found bool        `json:"found"`
+	Rule  AclRuleData `json:"rule"`
+}
+
+type CachedTags struct {
+	Tags []string `json:"tags"`
+}
+
+func contextTenancy(ctx op_context.Context) string {
+	tenancyCtx, ok := ctx.(multitenancy.TenancyContext)
+	if !ok {
+		return ""
+	}
+	return multitenancy.ContextTenancy(tenancyCtx)
+}
+
+func ruleCacheKey(ctx op_context.Context, resourcePath string, tag string, role string) string {
+	return fmt.Sprintf("%s/%s/%s/%s/%s", RuleCacheKey, contextTenancy(ctx), role, tag, resourcePath)
+}
+
+func tagsCacheKey(ctx op_context.Context, resourcePath string) string {
+	return fmt.Sprintf("%s/%s/%s", TagsCacheKey, contextTenancy(ctx), resourcePath)
+}
+
+func unsetCache(ctx op_context.Context, key string) {
+	if ctx.Cache() == nil {
+		return
+	}
+	err := ctx.Cache().Unset(key)
+	if err != nil {
+		ctx.Logger().Error("failed to unset ACL cache", err, logger.Fields{"key": key})
+	}
+}
@@ -0,0 +1,46 @@
+package acl_console
+
+import (
+	"github.com/evgeniums/go-utils/pkg/access_control/acl"
+	"github.com/evgeniums/go-utils/pkg/console_tool"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+)
+
+type AclCommands struct {
+	console_tool.Commands[*AclCommands]
+	GetAclController func() acl.AclController
+}
+
+func NewAclCommands(aclController func() acl.AclController) *AclCommands {
+	p := &AclCommands{}
+	p.Construct(p, "acl", "Manage access control rules")
+	p.GetAclController = aclController
+	p.LoadHandlers()
+	return p
+}
+
+func (p *AclCommands) LoadHandlers() {
+	p.AddHandlers(AddRole,
+		DeleteRole,
+		ListRoles,
+		SetRule,
+		DeleteRule,
+		ListRules,
+		AddResourceTag,
+		DeleteResourceTag,
+		ListResourceTags)
+}
+
+type Handler = console_tool.Handler[*AclCommands]
+
+type HandlerBase struct {
+	console_tool.HandlerBase[*AclCommands]
+}
+
+func (b *HandlerBase) Context(data interface{}) (op_context.Context, acl.AclController, error) {
+	ctx, err := b.HandlerBase.Context(data)
+	if err != nil {
+		return ctx, nil, err
+	}
+	return ctx, b.Group.GetAclController(), nil
+}
@@ -0,0 +1,41 @@
+package acl_console
+
+import (
+	"fmt"
+
+	"github.com/evgeniums/go-utils/pkg/access_control/acl"
+	"github.com/evgeniums/go-utils/pkg/utils"
+)
+
+const AddResourceTagCmd string = "add_resource_tag"
+const AddResourceTagDescription string = "Add tag to resource"
+
+func AddResourceTag() Handler {
+	a := &AddResourceTagHandler{}
+	a.Init(AddResourceTagCmd, AddResourceTagDescription)
+	return a
+}
+
+type AddResourceTagHandler struct {
+	HandlerBase
+	acl.AclResourceTagData
+}
+
+func (a *AddResourceTagHandler) Data() interface{} {
+	return &a.AclResourceTagData
+}
+
+func (a *AddResourceTagHandler) Execute(args []string) error {
+
+	ctx, controller, err := a.Context(a.Data())
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+
+	tag, err := controller.AddResourceTag(ctx, &a.AclResourceTagData)
+	if err == nil {
+		fmt.Printf("Added resource tag:\n%s\n", utils.DumpPrettyJson(tag))
+	}
+	return err
+}
@@ -0,0 +1,50 @@
+package acl_console
+
+import (
+	"fmt"
+
+	"github.com/evgeniums/go-utils/pkg/access_control/acl"
+	"github.com/evgeniums/go-utils/pkg/utils"
+)
+
+const AddRoleCmd string = "add_role"
+const AddRoleDescription string = "Add role"
+
+func AddRole() Handler {
+	a := &AddRoleHandler{}
+	a.Init(AddRoleCmd, AddRoleDescription)
+	return a
+}
+
+type AddRoleData struct {
+	Name        string `long:"name" description:"Name of the role, must be unique" required:"true"`
+	Description string `long:"description" description:"Role description"`
+}
+
+type AddRoleHandler struct {
+	HandlerBase
+	AddRoleData
+}
+
+func (a *AddRoleHandler) Data() interface{} {
+	return &a.AddRoleData
+}
+
+func (a *AddRoleHandler) Execute(args []string) error {
+
+	ctx, controller, err := a.Context(a.Data())
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+
+	r := acl.NewRole()
+	r.SetName(a.Name)
+	r.SetDescription(a.Description)
+
+	role, err := controller.AddRole(ctx, r)
+	if err == nil {
+		fmt.Printf("Added role:\n%s\n", utils.DumpPrettyJson(role))
+	}
+	return err
+}
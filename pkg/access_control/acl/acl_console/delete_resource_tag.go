@@ -0,0 +1,34 @@
+package acl_console
+
+const DeleteResourceTagCmd string = "delete_resource_tag"
+const DeleteResourceTagDescription string = "Delete resource tag"
+
+func DeleteResourceTag() Handler {
+	a := &DeleteResourceTagHandler{}
+	a.Init(DeleteResourceTagCmd, DeleteResourceTagDescription)
+	return a
+}
+
+type DeleteResourceTagData struct {
+	Id string `long:"id" description:"ID of the resource tag" required:"true"`
+}
+
+type DeleteResourceTagHandler struct {
+	HandlerBase
+	DeleteResourceTagData
+}
+
+func (d *DeleteResourceTagHandler) Data() interface{} {
+	return &d.DeleteResourceTagData
+}
+
+func (d *DeleteResourceTagHandler) Execute(args []string) error {
+
+	ctx, controller, err := d.Context(d.Data())
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+
+	return controller.DeleteResourceTag(ctx, d.Id)
+}
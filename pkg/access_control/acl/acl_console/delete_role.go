@@ -0,0 +1,34 @@
+package acl_console
+
+const DeleteRoleCmd string = "delete_role"
+const DeleteRoleDescription string = "Delete role"
+
+func DeleteRole() Handler {
+	a := &DeleteRoleHandler{}
+	a.Init(DeleteRoleCmd, DeleteRoleDescription)
+	return a
+}
+
+type DeleteRoleData struct {
+	Name string `long:"name" description:"Name of the role" required:"true"`
+}
+
+type DeleteRoleHandler struct {
+	HandlerBase
+	DeleteRoleData
+}
+
+func (d *DeleteRoleHandler) Data() interface{} {
+	return &d.DeleteRoleData
+}
+
+func (d *DeleteRoleHandler) Execute(args []string) error {
+
+	ctx, controller, err := d.Context(d.Data())
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+
+	return controller.DeleteRole(ctx, d.Name, true)
+}
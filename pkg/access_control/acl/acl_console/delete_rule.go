@@ -0,0 +1,34 @@
+package acl_console
+
+const DeleteRuleCmd string = "delete_rule"
+const DeleteRuleDescription string = "Delete access rule"
+
+func DeleteRule() Handler {
+	a := &DeleteRuleHandler{}
+	a.Init(DeleteRuleCmd, DeleteRuleDescription)
+	return a
+}
+
+type DeleteRuleData struct {
+	Id string `long:"id" description:"ID of the rule" required:"true"`
+}
+
+type DeleteRuleHandler struct {
+	HandlerBase
+	DeleteRuleData
+}
+
+func (d *DeleteRuleHandler) Data() interface{} {
+	return &d.DeleteRuleData
+}
+
+func (d *DeleteRuleHandler) Execute(args []string) error {
+
+	ctx, controller, err := d.Context(d.Data())
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+
+	return controller.DeleteRule(ctx, d.Id)
+}
@@ -0,0 +1,34 @@
+package acl_console
+
+import (
+	"fmt"
+
+	"github.com/evgeniums/go-utils/pkg/utils"
+)
+
+const ListResourceTagsCmd string = "list_resource_tags"
+const ListResourceTagsDescription string = "List resource tags"
+
+func ListResourceTags() Handler {
+	a := &ListResourceTagsHandler{}
+	a.Init(ListResourceTagsCmd, ListResourceTagsDescription)
+	return a
+}
+
+type ListResourceTagsHandler struct {
+	HandlerBase
+}
+
+func (a *ListResourceTagsHandler) Execute(args []string) error {
+
+	ctx, controller, err := a.Context(a.Data())
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+	items, _, err := controller.GetResourceTags(ctx, nil)
+	if err == nil {
+		fmt.Printf("Resource tags:\n\n%s\n\n", utils.DumpPrettyJson(items))
+	}
+	return err
+}
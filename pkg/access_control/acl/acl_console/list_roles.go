@@ -0,0 +1,34 @@
+package acl_console
+
+import (
+	"fmt"
+
+	"github.com/evgeniums/go-utils/pkg/utils"
+)
+
+const ListRolesCmd string = "list_roles"
+const ListRolesDescription string = "List roles"
+
+func ListRoles() Handler {
+	a := &ListRolesHandler{}
+	a.Init(ListRolesCmd, ListRolesDescription)
+	return a
+}
+
+type ListRolesHandler struct {
+	HandlerBase
+}
+
+func (a *ListRolesHandler) Execute(args []string) error {
+
+	ctx, controller, err := a.Context(a.Data())
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+	items, _, err := controller.GetRoles(ctx, nil)
+	if err == nil {
+		fmt.Printf("Roles:\n\n%s\n\n", utils.DumpPrettyJson(items))
+	}
+	return err
+}
@@ -0,0 +1,34 @@
+package acl_console
+
+import (
+	"fmt"
+
+	"github.com/evgeniums/go-utils/pkg/utils"
+)
+
+const ListRulesCmd string = "list_rules"
+const ListRulesDescription string = "List access rules"
+
+func ListRules() Handler {
+	a := &ListRulesHandler{}
+	a.Init(ListRulesCmd, ListRulesDescription)
+	return a
+}
+
+type ListRulesHandler struct {
+	HandlerBase
+}
+
+func (a *ListRulesHandler) Execute(args []string) error {
+
+	ctx, controller, err := a.Context(a.Data())
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+	items, _, err := controller.GetRules(ctx, nil)
+	if err == nil {
+		fmt.Printf("Rules:\n\n%s\n\n", utils.DumpPrettyJson(items))
+	}
+	return err
+}
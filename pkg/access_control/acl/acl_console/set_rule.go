@@ -0,0 +1,60 @@
+package acl_console
+
+import (
+	"fmt"
+
+	"github.com/evgeniums/go-utils/pkg/access_control"
+	"github.com/evgeniums/go-utils/pkg/access_control/acl"
+	"github.com/evgeniums/go-utils/pkg/utils"
+)
+
+const SetRuleCmd string = "set_rule"
+const SetRuleDescription string = "Add or update access rule"
+
+func SetRule() Handler {
+	a := &SetRuleHandler{}
+	a.Init(SetRuleCmd, SetRuleDescription)
+	return a
+}
+
+type SetRuleData struct {
+	Resource string   `long:"resource" description:"Resource path" required:"true"`
+	Tag      string   `long:"tag" description:"Resource tag, if empty then rule is applied to resource regardless of tags"`
+	Role     string   `long:"role" description:"Name of the role" required:"true"`
+	Access   []string `long:"access" description:"Allowed access types: none, read, create, update_replace, update_partial, update, delete, all or HTTP methods, can be repeated or comma separated" required:"true"`
+}
+
+type SetRuleHandler struct {
+	HandlerBase
+	SetRuleData
+}
+
+func (a *SetRuleHandler) Data() interface{} {
+	return &a.SetRuleData
+}
+
+func (a *SetRuleHandler) Execute(args []string) error {
+
+	ctx, controller, err := a.Context(a.Data())
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+
+	access, err := access_control.ParseAccess(a.Access...)
+	if err != nil {
+		return err
+	}
+
+	r := &acl.AclRuleData{}
+	r.RESOURCE = a.Resource
+	r.TAG = a.Tag
+	r.ROLE = a.Role
+	r.ACCESS = uint32(access)
+
+	rule, err := controller.SetRule(ctx, r)
+	if err == nil {
+		fmt.Printf("Access rule:\n%s\n", utils.DumpPrettyJson(rule))
+	}
+	return err
+}
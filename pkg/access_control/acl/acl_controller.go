@@ -0,0 +1,328 @@
+package acl
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/evgeniums/go-utils/pkg/crud"
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/logger"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+	"github.com/evgeniums/go-utils/pkg/utils"
+)
+
+const ErrorCodeRoleNotFound = "acl_role_not_found"
+const ErrorCodeRoleNameConflict = "acl_role_name_conflict"
+const ErrorCodeRoleRulesExist = "acl_role_rules_exist"
+const ErrorCodeRuleNotFound = "acl_rule_not_found"
+const ErrorCodeResourceTagNotFound = "acl_resource_tag_not_found"
+const ErrorCodeResourceTagConflict = "acl_resource_tag_conflict"
+
+var ErrorDescriptions = map[string]string{
+	ErrorCodeRoleNotFound:        "Role not found",
+	ErrorCodeRoleNameConflict:    "Role with such name already exists, choose another name",
+	ErrorCodeRoleRulesExist:      "Can't delete role with access rules. First, delete all rules of the role",
+	ErrorCodeRuleNotFound:        "Access rule not found",
+	ErrorCodeResourceTagNotFound: "Resource tag not found",
+	ErrorCodeResourceTagConflict: "Resource already has such tag",
+}
+
+var ErrorHttpCodes = map[string]int{
+	ErrorCodeRoleNotFound:        http.StatusNotFound,
+	ErrorCodeRuleNotFound:        http.StatusNotFound,
+	ErrorCodeResourceTagNotFound: http.StatusNotFound,
+}
+
+type AclController interface {
+	AddRole(ctx op_context.Context, role *AclRole) (*AclRole, error)
+	FindRole(ctx op_context.Context, id string, idIsName ...bool) (*AclRole, error)
+	DeleteRole(ctx op_context.Context, id string, idIsName ...bool) error
+	GetRoles(ctx op_context.Context, filter *db.Filter) ([]*AclRole, int64, error)
+
+	SetRule(ctx op_context.Context, rule *AclRuleData) (*AclRule, error)
+	DeleteRule(ctx op_context.Context, id string) error
+	GetRules(ctx op_context.Context, filter *db.Filter) ([]*AclRule, int64, error)
+
+	AddResourceTag(ctx op_context.Context, tag *AclResourceTagData) (*AclResourceTag, error)
+	DeleteResourceTag(ctx op_context.Context, id string) error
+	GetResourceTags(ctx op_context.Context, filter *db.Filter) ([]*AclResourceTag, int64, error)
+}
+
+func NewAclController(crud crud.CRUD) *AclControllerBase {
+	c := &AclControllerBase{}
+	c.CRUD = crud
+	return c
+}
+
+type AclControllerBase struct {
+	CRUD crud.CRUD
+}
+
+func fieldName(idIsName ...bool) string {
+	fieldName := "id"
+	if utils.OptionalArg(false, idIsName...) {
+		fieldName = "name"
+	}
+	return fieldName
+}
+
+func (a *AclControllerBase) OpLog(ctx op_context.Context, operation string, oplog *OpLogAcl) {
+	oplog.SetOperation(operation)
+	ctx.Oplog(oplog)
+}
+
+func (a *AclControllerBase) AddRole(ctx op_context.Context, role *AclRole) (*AclRole, error) {
+
+	c := ctx.TraceInMethod("AclController.AddRole", logger.Fields{"name": role.Name()})
+	defer ctx.TraceOutMethod()
+
+	// check if role name is unique
+	filter := db.NewFilter()
+	filter.AddField("name", role.Name())
+	exists, err := a.CRUD.Exists(ctx, filter, &AclRole{})
+	if err != nil {
+		c.SetMessage("failed to check existence of role with desired name")
+		return nil, c.SetError(err)
+	}
+	if exists {
+		ctx.SetGenericErrorCode(ErrorCodeRoleNameConflict)
+		return nil, c.SetError(errors.New("role with desired name exists"))
+	}
+
+	// create role
+	role.InitObject()
+	err = a.CRUD.Create(ctx, role)
+	if err != nil {
+		return nil, c.SetError(err)
+	}
+
+	// save oplog
+	a.OpLog(ctx, "add_role", &OpLogAcl{Role: role.Name()})
+
+	// done
+	return role, nil
+}
+
+func (a *AclControllerBase) FindRole(ctx op_context.Context, id string, idIsName ...bool) (*AclRole, error) {
+	field := fieldName(idIsName...)
+	role, err := crud.FindByField(a.CRUD, ctx, "AclController.FindRole", field, id, &AclRole{})
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		ctx.SetGenericErrorCode(ErrorCodeRoleNotFound)
+		return nil, nil
+	}
+	return role, nil
+}
+
+func (a *AclControllerBase) DeleteRole(ctx op_context.Context, id string, idIsName ...bool) error {
+
+	c := ctx.TraceInMethod("AclController.DeleteRole", logger.Fields{"role": id})
+	defer ctx.TraceOutMethod()
+
+	// find role
+	role, err := a.FindRole(ctx, id, idIsName...)
+	if err != nil {
+		return c.SetError(err)
+	}
+	if role == nil {
+		ctx.SetGenericErrorCode(ErrorCodeRoleNotFound)
+		return c.SetError(errors.New("role not found"))
+	}
+
+	// check if role has rules
+	filter := db.NewFilter()
+	filter.AddField("role", role.Name())
+	exists, err := a.CRUD.Exists(ctx, filter, &AclRule{})
+	if err != nil {
+		c.SetMessage("failed to check rules")
+		return c.SetError(err)
+	}
+	if exists {
+		ctx.SetGenericErrorCode(ErrorCodeRoleRulesExist)
+		return c.SetError(errors.New("can not delete role with rules, delete rules first"))
+	}
+
+	// delete role
+	err = a.CRUD.Delete(ctx, role)
+	if err != nil {
+		return c.SetError(err)
+	}
+
+	// save oplog
+	a.OpLog(ctx, "delete_role", &OpLogAcl{Role: role.Name()})
+
+	// done
+	return nil
+}
+
+func (a *AclControllerBase) GetRoles(ctx op_context.Context, filter *db.Filter) ([]*AclRole, int64, error) {
+	var roles []*AclRole
+	count, err := crud.List(a.CRUD, ctx, "AclController.GetRoles", filter, &roles)
+	if err != nil {
+		return nil, 0, err
+	}
+	return roles, count, nil
+}
+
+func (a *AclControllerBase) SetRule(ctx op_context.Context, rule *AclRuleData) (*AclRule, error) {
+
+	c := ctx.TraceInMethod("AclController.SetRule", logger.Fields{"resource": rule.RESOURCE, "tag": rule.TAG, "role": rule.ROLE, "access": rule.ACCESS})
+	defer ctx.TraceOutMethod()
+
+	// check if role exists
+	role, err := a.FindRole(ctx, rule.ROLE, true)
+	if err != nil {
+		return nil, c.SetError(err)
+	}
+	if role == nil {
+		return nil, c.SetError(errors.New("role not found"))
+	}
+
+	// find existing rule
+	fields := db.Fields{"resource": rule.RESOURCE, "tag": rule.TAG, "role": rule.ROLE}
+	obj, err := crud.Find(a.CRUD, ctx, "AclController.FindRule", fields, &AclRule{})
+	if err != nil {
+		return nil, c.SetError(err)
+	}
+
+	// create or update rule
+	if obj == nil {
+		obj = &AclRule{AclRuleData: *rule}
+		obj.InitObject()
+		err = a.CRUD.Create(ctx, obj)
+	} else {
+		err = a.CRUD.Update(ctx, obj, db.Fields{"access": rule.ACCESS})
+		obj.ACCESS = rule.ACCESS
+	}
+	if err != nil {
+		return nil, c.SetError(err)
+	}
+
+	// invalidate cache
+	unsetCache(ctx, ruleCacheKey(ctx, obj.RESOURCE, obj.TAG, obj.ROLE))
+
+	// save oplog
+	a.OpLog(ctx, "set_rule", &OpLogAcl{Role: obj.ROLE, Resource: obj.RESOURCE, Tag: obj.TAG, Access: obj.ACCESS})
+
+	// done
+	return obj, nil
+}
+
+func (a *AclControllerBase) DeleteRule(ctx op_context.Context, id string) error {
+
+	c := ctx.TraceInMethod("AclController.DeleteRule", logger.Fields{"rule": id})
+	defer ctx.TraceOutMethod()
+
+	// find rule
+	rule, err := crud.FindByField(a.CRUD, ctx, "AclController.FindRule", "id", id, &AclRule{})
+	if err != nil {
+		return c.SetError(err)
+	}
+	if rule == nil {
+		ctx.SetGenericErrorCode(ErrorCodeRuleNotFound)
+		return c.SetError(errors.New("rule not found"))
+	}
+
+	// delete rule
+	err = a.CRUD.Delete(ctx, rule)
+	if err != nil {
+		return c.SetError(err)
+	}
+
+	// invalidate cache
+	unsetCache(ctx, ruleCacheKey(ctx, rule.RESOURCE, rule.TAG, rule.ROLE))
+
+	// save oplog
+	a.OpLog(ctx, "delete_rule", &OpLogAcl{Role: rule.ROLE, Resource: rule.RESOURCE, Tag: rule.TAG, Access: rule.ACCESS})
+
+	// done
+	return nil
+}
+
+func (a *AclControllerBase) GetRules(ctx op_context.Context, filter *db.Filter) ([]*AclRule, int64, error) {
+	var rules []*AclRule
+	count, err := crud.List(a.CRUD, ctx, "AclController.GetRules", filter, &rules)
+	if err != nil {
+		return nil, 0, err
+	}
+	return rules, count, nil
+}
+
+func (a *AclControllerBase) AddResourceTag(ctx op_context.Context, tag *AclResourceTagData) (*AclResourceTag, error) {
+
+	c := ctx.TraceInMethod("AclController.AddResourceTag", logger.Fields{"resource": tag.RESOURCE, "tag": tag.TAG})
+	defer ctx.TraceOutMethod()
+
+	// check if tag is unique
+	filter := db.NewFilter()
+	filter.AddField("resource", tag.RESOURCE)
+	filter.AddField("tag", tag.TAG)
+	exists, err := a.CRUD.Exists(ctx, filter, &AclResourceTag{})
+	if err != nil {
+		c.SetMessage("failed to check existence of resource tag")
+		return nil, c.SetError(err)
+	}
+	if exists {
+		ctx.SetGenericErrorCode(ErrorCodeResourceTagConflict)
+		return nil, c.SetError(errors.New("resource tag exists"))
+	}
+
+	// create tag
+	obj := &AclResourceTag{AclResourceTagData: *tag}
+	obj.InitObject()
+	err = a.CRUD.Create(ctx, obj)
+	if err != nil {
+		return nil, c.SetError(err)
+	}
+
+	// invalidate cache
+	unsetCache(ctx, tagsCacheKey(ctx, obj.RESOURCE))
+
+	// save oplog
+	a.OpLog(ctx, "add_resource_tag", &OpLogAcl{Resource: obj.RESOURCE, Tag: obj.TAG})
+
+	// done
+	return obj, nil
+}
+
+func (a *AclControllerBase) DeleteResourceTag(ctx op_context.Context, id string) error {
+
+	c := ctx.TraceInMethod("AclController.DeleteResourceTag", logger.Fields{"resource_tag": id})
+	defer ctx.TraceOutMethod()
+
+	// find tag
+	tag, err := crud.FindByField(a.CRUD, ctx, "AclController.FindResourceTag", "id", id, &AclResourceTag{})
+	if err != nil {
+		return c.SetError(err)
+	}
+	if tag == nil {
+		ctx.SetGenericErrorCode(ErrorCodeResourceTagNotFound)
+		return c.SetError(errors.New("resource tag not found"))
+	}
+
+	// delete tag
+	err = a.CRUD.Delete(ctx, tag)
+	if err != nil {
+		return c.SetError(err)
+	}
+
+	// invalidate cache
+	unsetCache(ctx, tagsCacheKey(ctx, tag.RESOURCE))
+
+	// save oplog
+	a.OpLog(ctx, "delete_resource_tag", &OpLogAcl{Resource: tag.RESOURCE, Tag: tag.TAG})
+
+	// done
+	return nil
+}
+
+func (a *AclControllerBase) GetResourceTags(ctx op_context.Context, filter *db.Filter) ([]*AclResourceTag, int64, error) {
+	var tags []*AclResourceTag
+	count, err := crud.List(a.CRUD, ctx, "AclController.GetResourceTags", filter, &tags)
+	if err != nil {
+		return nil, 0, err
+	}
+	return tags, count, nil
+}
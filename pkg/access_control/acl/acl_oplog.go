@@ -0,0 +1,11 @@
+package acl
+
+import "github.com/evgeniums/go-utils/pkg/oplog"
+
+type OpLogAcl struct {
+	oplog.OplogBase
+	Role     string `gorm:"index" json:"role"`
+	Resource string `gorm:"index" json:"resource"`
+	Tag      string `gorm:"index" json:"tag"`
+	Access   uint32 `json:"access"`
+}
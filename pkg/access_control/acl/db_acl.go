@@ -0,0 +1,123 @@
+package acl
+
+import (
+	"github.com/evgeniums/go-utils/pkg/access_control"
+	"github.com/evgeniums/go-utils/pkg/crud"
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/logger"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+	"github.com/evgeniums/go-utils/pkg/utils"
+)
+
+const DefaultCacheTtlSeconds = 300
+
+// ACL and resource manager using rules and resource tags stored in database.
+type DbAcl struct {
+	CRUD            crud.CRUD
+	CacheTtlSeconds int
+}
+
+func NewDbAcl(cruds crud.CRUD, cacheTtlSeconds ...int) *DbAcl {
+	a := &DbAcl{}
+	a.CRUD = cruds
+	a.CacheTtlSeconds = utils.OptionalArg(DefaultCacheTtlSeconds, cacheTtlSeconds...)
+	return a
+}
+
+func (a *DbAcl) FindRule(ctx op_context.Context, resourcePath string, tag string, role access_control.Role) (access_control.Rule, error) {
+
+	c := ctx.TraceInMethod("DbAcl.FindRule", logger.Fields{"resource": resourcePath, "tag": tag, "role": role.Name()})
+	defer ctx.TraceOutMethod()
+
+	// try to find rule in cache
+	cacheKey := ruleCacheKey(ctx, resourcePath, tag, role.Name())
+	cachedRule := &CachedRule{}
+	if ctx.Cache() != nil {
+		found, err := ctx.Cache().Get(cacheKey, cachedRule)
+		if err != nil {
+			c.Logger().Error("failed to get rule from cache", err)
+		} else if found {
+			if !cachedRule.Found {
+				return nil, nil
+			}
+			return &AclRule{AclRuleData: cachedRule.Rule}, nil
+		}
+	}
+
+	// find rule in database
+	rule, err := crud.Find(a.CRUD, ctx, "DbAcl.FindRule", db.Fields{"resource": resourcePath, "tag": tag, "role": role.Name()}, &AclRule{})
+	if err != nil {
+		return nil, c.SetError(err)
+	}
+
+	// save rule in cache
+	if ctx.Cache() != nil {
+		if rule != nil {
+			cachedRule.Found = true
+			cachedRule.Rule = rule.AclRuleData
+		}
+		err = ctx.Cache().Set(cacheKey, cachedRule, a.CacheTtlSeconds)
+		if err != nil {
+			c.Logger().Error("failed to save rule in cache", err)
+		}
+	}
+
+	// done
+	if rule == nil {
+		return nil, nil
+	}
+	return rule, nil
+}
+
+func (a *DbAcl) FindResource(ctx op_context.Context, path string) (access_control.Resource, error) {
+	return access_control.NewResource(path), nil
+}
+
+func (a *DbAcl) ResourceTags(ctx op_context.Context, path string) ([]string, error) {
+
+	c := ctx.TraceInMethod("DbAcl.ResourceTags", logger.Fields{"resource": path})
+	defer ctx.TraceOutMethod()
+
+	// try to find tags in cache
+	cacheKey := tagsCacheKey(ctx, path)
+	cachedTags := &CachedTags{}
+	if ctx.Cache() != nil {
+		found, err := ctx.Cache().Get(cacheKey, cachedTags)
+		if err != nil {
+			c.Logger().Error("failed to get resource tags from cache", err)
+		} else if found {
+			return cachedTags.Tags, nil
+		}
+	}
+
+	// load tags from database
+	filter := db.NewFilter()
+	filter.AddField("resource", path)
+	filter.SortField = "tag"
+	var resourceTags []*AclResourceTag
+	_, err := crud.List(a.CRUD, ctx, "DbAcl.ResourceTags", filter, &resourceTags)
+	if err != nil {
+		return nil, c.SetError(err)
+	}
+	cachedTags.Tags = make([]string, len(resourceTags))
+	for i, resourceTag := range resourceTags {
+		cachedTags.Tags[i] = resourceTag.TAG
+	}
+
+	// save tags in cache
+	if ctx.Cache() != nil {
+		err = ctx.Cache().Set(cacheKey, cachedTags, a.CacheTtlSeconds)
+		if err != nil {
+			c.Logger().Error("failed to save resource tags in cache", err)
+		}
+	}
+
+	// done
+	return cachedTags.Tags, nil
+}
+
+// Create access controller using ACL stored in database.
+func NewAccessControl(cruds crud.CRUD, defaultAccess ...access_control.Access) *access_control.AccessControlBase {
+	a := NewDbAcl(cruds)
+	return access_control.NewAccessControl(a, a, defaultAccess...)
+}
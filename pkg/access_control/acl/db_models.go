@@ -0,0 +1,9 @@
+package acl
+
+func DbModels() []interface{} {
+	return []interface{}{&AclRole{}, &AclRule{}, &AclResourceTag{}, &OpLogAcl{}}
+}
+
+func QueryDbModels() []interface{} {
+	return []interface{}{&AclRole{}, &AclRule{}, &AclResourceTag{}, &OpLogAcl{}}
+}
@@ -3,6 +3,7 @@ package access_control
 import (
 	"github.com/evgeniums/go-utils/pkg/common"
 	"github.com/evgeniums/go-utils/pkg/op_context"
+	"github.com/evgeniums/go-utils/pkg/utils"
 )
 
 type Resource interface {
@@ -18,4 +19,23 @@ type ResourceManager interface {
 
 type ResourceBase struct {
 	common.WithNameAndPathBase
+	ownerAccess AccessBase
+}
+
+func NewResource(path string, name ...string) *ResourceBase {
+	r := &ResourceBase{}
+	r.Init(path, utils.OptionalArg("", name...))
+	return r
+}
+
+func (r *ResourceBase) IsOwner(subject Subject) bool {
+	return false
+}
+
+func (r *ResourceBase) OwnerAccess() Access {
+	return &r.ownerAccess
+}
+
+func (r *ResourceBase) SetOwnerAccess(accessType AccessType) {
+	r.ownerAccess = NewAccess(uint32(accessType))
 }
@@ -9,3 +9,17 @@ type Role interface {
 type RoleBase struct {
 	common.WithNameBase
 }
+
+func NewRole(name string) *RoleBase {
+	r := &RoleBase{}
+	r.Init(name)
+	return r
+}
+
+func RolesFromNames(names ...string) []Role {
+	roles := make([]Role, len(names))
+	for i, name := range names {
+		roles[i] = NewRole(name)
+	}
+	return roles
+}
@@ -3,3 +3,21 @@ package access_control
 type Subject interface {
 	Roles() []Role
 }
+
+type SubjectBase struct {
+	roles []Role
+}
+
+func NewSubject(roles ...string) *SubjectBase {
+	s := &SubjectBase{}
+	s.roles = RolesFromNames(roles...)
+	return s
+}
+
+func (s *SubjectBase) Roles() []Role {
+	return s.roles
+}
+
+func (s *SubjectBase) SetRoles(roles []Role) {
+	s.roles = roles
+}
@@ -24,6 +24,14 @@ func NewAdmin() *Admin {
 	return a
 }
 
+func (a *Admin) RoleNames() []string {
+	names := make([]string, len(a.Roles))
+	for i, role := range a.Roles {
+		names[i] = role.Name
+	}
+	return names
+}
+
 type AdminSession struct {
 	auth_session.SessionBase
 }
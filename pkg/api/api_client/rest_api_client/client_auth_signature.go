@@ -17,8 +17,9 @@ import (
 )
 
 type ClientAuthSignature struct {
-	Signer          crypt_utils.ESigner
-	EndpoindsConfig auth.EndpointsAuthConfig
+	Signer           crypt_utils.ESigner
+	EndpoindsConfig  auth.EndpointsAuthConfig
+	ReplayProtection bool
 }
 
 func (a *ClientAuthSignature) HandleResponse(resp Response) {
@@ -44,30 +45,42 @@ func (a *ClientAuthSignature) MakeHeaders(ctx op_context.Context, operation api.
 		return nil, c.SetError(err)
 	}
 
+	// add timestamp and nonce
+	extraData := []string{access_control.Access2HttpMethod(operation.AccessType()), path}
+	h := map[string]string{}
+	if a.ReplayProtection {
+		timestamp, nonce := auth.MakeTimestampNonce()
+		extraData = append(extraData, timestamp, nonce)
+		h["x-auth-timestamp"] = timestamp
+		h["x-auth-nonce"] = nonce
+	}
+
 	// sign request
-	sig, err := a.Signer.SignB64(content, access_control.Access2HttpMethod(operation.AccessType()), path)
+	sig, err := a.Signer.SignB64(content, extraData...)
 	if err != nil {
 		c.SetMessage("failed to sign request")
 		return nil, c.SetError(err)
 	}
 
 	// put signature to header
-	h := map[string]string{"x-auth-signature": sig}
+	h["x-auth-signature"] = sig
 
 	// done
 	return h, nil
 }
 
 type ClientAuthSignatureBaseConfig struct {
+	ALGORITHM            string `validate:"required,oneof=rsa_h256_signature ed25519_signature ecdsa_p256_h256_signature" default:"rsa_h256_signature"`
 	PRIVATE_KEY_FILE     string `validate:"required"`
 	PRIVATE_KEY_PASSWORD string `mask:"true"`
+	REPLAY_PROTECTION    bool   `default:"true"`
 }
 
 type ClientAuthSignatureBase struct {
 	ClientAuthSignatureBaseConfig
 	ClientAuthSignature
 
-	rsaSigner       *crypt_utils.RsaSigner
+	keySigner       crypt_utils.ESignerWithKey
 	endpointsConfig *auth.EndpointsAuthConfigBase
 }
 
@@ -85,11 +98,20 @@ func (a *ClientAuthSignatureBase) Init(cfg config.Config, log logger.Logger, vld
 		return log.PushFatalStack("failed to load configuration of client auth signature", err)
 	}
 
+	a.ReplayProtection = a.REPLAY_PROTECTION
+
 	// load key
-	if a.rsaSigner != nil {
-		err = a.rsaSigner.LoadKeyFromFile(a.PRIVATE_KEY_FILE, a.PRIVATE_KEY_PASSWORD)
+	if a.keySigner != nil {
+		if a.ALGORITHM != crypt_utils.RSA_H256_SIGNATURE {
+			a.keySigner, err = crypt_utils.NewSigner(a.ALGORITHM)
+			if err != nil {
+				return log.PushFatalStack("failed to create signer of client auth signature", err)
+			}
+			a.Signer = a.keySigner
+		}
+		err = a.keySigner.LoadKeyFromFile(a.PRIVATE_KEY_FILE, a.PRIVATE_KEY_PASSWORD)
 		if err != nil {
-			return log.PushFatalStack("failed to load private key for RSA signer of client auth signature", err)
+			return log.PushFatalStack("failed to load private key for signer of client auth signature", err, logger.Fields{"algorithm": a.ALGORITHM})
 		}
 	}
 
@@ -108,8 +130,8 @@ func NewClientAuthSignature() *ClientAuthSignatureBase {
 
 	c := &ClientAuthSignatureBase{}
 
-	c.rsaSigner = crypt_utils.NewRsaSigner()
-	c.Signer = c.rsaSigner
+	c.keySigner = crypt_utils.NewRsaSigner()
+	c.Signer = c.keySigner
 	c.endpointsConfig = auth.NewEndpointsAuthConfigBase()
 	c.EndpoindsConfig = c.endpointsConfig
 
@@ -3,6 +3,7 @@ package api_server
 import (
 	"github.com/evgeniums/go-utils/pkg/access_control"
 	"github.com/evgeniums/go-utils/pkg/api"
+	"github.com/evgeniums/go-utils/pkg/auth"
 	"github.com/evgeniums/go-utils/pkg/generic_error"
 	"github.com/evgeniums/go-utils/pkg/utils"
 )
@@ -17,6 +18,12 @@ type Endpoint interface {
 
 	// Precheck request before some authorization methods
 	PrecheckRequestBeforeAuth(request Request, smsMessage *string, skipSms *bool) error
+
+	// Check if access control must be skipped for this endpoint.
+	SkipAccessControl() bool
+
+	// Check if request is allowed to access endpoint. Override it in endpoint to implement custom access control.
+	Authorize(request Request, accessControl access_control.AccessControl, accessType access_control.AccessType) (bool, error)
 }
 
 type EndpointHandler = func(request Request)
@@ -25,6 +32,7 @@ type EndpointHandler = func(request Request)
 type EndpointBase struct {
 	api.Operation
 	generic_error.ErrorsExtenderBase
+	skipAccessControl bool
 }
 
 func (e *EndpointBase) Construct(op api.Operation) {
@@ -39,6 +47,25 @@ func (e *EndpointBase) PrecheckRequestBeforeAuth(request Request, smsMessage *st
 	return nil
 }
 
+func (e *EndpointBase) SkipAccessControl() bool {
+	return e.skipAccessControl
+}
+
+func (e *EndpointBase) SetSkipAccessControl(val bool) {
+	e.skipAccessControl = val
+}
+
+func (e *EndpointBase) Authorize(request Request, accessControl access_control.AccessControl, accessType access_control.AccessType) (bool, error) {
+	return AuthorizeRequest(request, accessControl, accessType)
+}
+
+// Check access of request to endpoint's resource using roles of authorized user.
+func AuthorizeRequest(request Request, accessControl access_control.AccessControl, accessType access_control.AccessType) (bool, error) {
+	resource := access_control.NewResource(request.Endpoint().Resource().FullPathPrototype())
+	subject := access_control.NewSubject(auth.AuthUserRoles(request)...)
+	return accessControl.CheckAccess(request, resource, subject, accessType)
+}
+
 type ResourceEndpointI interface {
 	api.Resource
 	Endpoint
@@ -53,6 +53,11 @@ func FullRequestServicePath(r Request) string {
 }
 
 func ParseDbQuery(request Request, model interface{}, queryName string, cmd ...api.Query) (*db.Filter, error) {
+	return ParseDbQueryWithValidator(request, model, queryName, nil, cmd...)
+}
+
+// Filter validator can be used to set validation rules of fields and to allow search operators for some fields.
+func ParseDbQueryWithValidator(request Request, model interface{}, queryName string, vld *db.FilterValidator, cmd ...api.Query) (*db.Filter, error) {
 
 	var q api.Query
 	if len(cmd) == 0 {
@@ -72,7 +77,14 @@ func ParseDbQuery(request Request, model interface{}, queryName string, cmd ...a
 		return nil, nil
 	}
 
-	filter, err := db.ParseQuery(request.Db(), q.Query(), model, queryName, db.EmptyFilterValidator(request.App().Validator()))
+	filterValidator := db.EmptyFilterValidator(request.App().Validator())
+	if vld != nil {
+		*filterValidator = *vld
+		if filterValidator.Validator == nil {
+			filterValidator.Validator = request.App().Validator()
+		}
+	}
+	filter, err := db.ParseQuery(request.Db(), q.Query(), model, queryName, filterValidator)
 	if err != nil {
 		vErr, ok := err.(*validator.ValidationError)
 		if ok {
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/evgeniums/go-utils/pkg/access_control"
@@ -53,6 +54,9 @@ type ServerConfig struct {
 	DEFAULT_RESPONSE_JSON string
 
 	FORM_SINGLE_FILE_FIELD string `default:"file"`
+
+	// Path prototypes of resources accessible without access control, e.g. login or public endpoints. Nested resources are also public.
+	PUBLIC_RESOURCES []string
 }
 
 type AuthParameterGetter = func(r *Request, key string) string
@@ -80,6 +84,10 @@ type Server struct {
 
 	dynamicTables api_server.DynamicTables
 
+	accessControlMutex sync.RWMutex
+	accessControl      access_control.AccessControl
+	publicResources    map[string]bool
+
 	propagateContextId bool
 	propagateAuthUser  bool
 
@@ -114,6 +122,7 @@ func NewServer() *Server {
 	}
 
 	s.TENANCY_PARAMETER = TenancyParameter
+	s.publicResources = make(map[string]bool)
 
 	return s
 }
@@ -166,6 +175,52 @@ func (s *Server) DynamicTables() api_server.DynamicTables {
 	return s.dynamicTables
 }
 
+func (s *Server) SetAccessControl(accessControl access_control.AccessControl) {
+	s.accessControlMutex.Lock()
+	defer s.accessControlMutex.Unlock()
+	s.accessControl = accessControl
+}
+
+func (s *Server) AccessControl() access_control.AccessControl {
+	s.accessControlMutex.RLock()
+	defer s.accessControlMutex.RUnlock()
+	return s.accessControl
+}
+
+func (s *Server) AddPublicResources(pathPrototypes ...string) {
+	s.accessControlMutex.Lock()
+	defer s.accessControlMutex.Unlock()
+	for _, path := range pathPrototypes {
+		path = strings.TrimSuffix(path, "/")
+		if path == "" {
+			// root path makes all resources public
+			path = "/"
+		}
+		s.publicResources[path] = true
+	}
+}
+
+// Check if resource with path prototype or one of its parents is public.
+func (s *Server) isPublicResource(pathPrototype string) bool {
+	s.accessControlMutex.RLock()
+	defer s.accessControlMutex.RUnlock()
+	if len(s.publicResources) == 0 {
+		return false
+	}
+	path := pathPrototype
+	for path != "" {
+		if s.publicResources[path] {
+			return true
+		}
+		idx := strings.LastIndex(path, "/")
+		if idx < 0 {
+			break
+		}
+		path = path[:idx]
+	}
+	return s.publicResources["/"]
+}
+
 func (s *Server) TenancyManager() multitenancy.Multitenancy {
 	return s.tenancies
 }
@@ -300,6 +355,7 @@ func (s *Server) Init(ctx app_context.Context, auth auth.Auth, tenancyManager mu
 	if err != nil {
 		return ctx.Logger().PushFatalStack("failed to load server configuration", err, logger.Fields{"name": s.Name()})
 	}
+	s.AddPublicResources(s.PUBLIC_RESOURCES...)
 
 	// load CSRF configuration
 	csrfKey := object_config.Key(utils.OptionalArg(defaultPath, configPath...), "csrf")
@@ -475,16 +531,25 @@ func requestHandler(s *Server, ep api_server.Endpoint) gin.HandlerFunc {
 		origin.SetUserType(s.OPLOG_USER_TYPE)
 		request.SetOrigin(origin)
 
-		// TODO process access control
-		if err == nil {
-
-		}
-
 		// set tenancy
 		if tenancy != nil && !s.AUTH_FROM_TENANCY_DB {
 			request.SetTenancy(tenancy)
 		}
 
+		// process access control
+		accessControl := s.AccessControl()
+		if err == nil && accessControl != nil && !ep.SkipAccessControl() && !s.isPublicResource(ep.Resource().FullPathPrototype()) {
+			var allowed bool
+			allowed, err = ep.Authorize(request, accessControl, access_control.HttpMethod2Access(ginCtx.Request.Method))
+			if err != nil {
+				c.SetMessage("failed to check access")
+				request.SetGenericErrorCode(generic_error.ErrorCodeInternalServerError)
+			} else if !allowed {
+				err = errors.New("access denied")
+				request.SetGenericErrorCode(generic_error.ErrorCodeForbidden)
+			}
+		}
+
 		// call endpoint's request handler
 		if err == nil {
 			err = ep.HandleRequest(request)
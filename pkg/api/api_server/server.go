@@ -3,6 +3,7 @@ package api_server
 import (
 	"fmt"
 
+	"github.com/evgeniums/go-utils/pkg/access_control"
 	"github.com/evgeniums/go-utils/pkg/auth"
 	"github.com/evgeniums/go-utils/pkg/background_worker"
 	"github.com/evgeniums/go-utils/pkg/common"
@@ -42,6 +43,15 @@ type Server interface {
 
 	// Get pool service used for server configuration
 	ConfigPoolService() pool.PoolService
+
+	// Set access controller used to authorize requests after authentication. If access controller is nil then authorization is disabled.
+	SetAccessControl(accessControl access_control.AccessControl)
+
+	// Get access controller.
+	AccessControl() access_control.AccessControl
+
+	// Add path prototypes of resources that are accessible without access control.
+	AddPublicResources(pathPrototypes ...string)
 }
 
 func AddServiceToServer(s Server, service Service) {
@@ -3,7 +3,9 @@ package pool_misrocervice_client
 import (
 	"context"
 	"fmt"
+	"sync"
 
+	"github.com/evgeniums/go-utils/pkg/api"
 	"github.com/evgeniums/go-utils/pkg/api/api_client"
 	"github.com/evgeniums/go-utils/pkg/app_context"
 	"github.com/evgeniums/go-utils/pkg/config/object_config"
@@ -30,6 +32,10 @@ type PoolMicroserviceClient struct {
 	PoolServiceClient
 
 	overridePoolName string
+	poolId           string
+
+	// client is reinitialized when pool is reloaded, so requests hold read lock
+	mutex sync.RWMutex
 }
 
 func NewPoolMicroserviceClient(defaultRole string, client ...PoolServiceClient) *PoolMicroserviceClient {
@@ -101,31 +107,82 @@ func (p *PoolMicroserviceClient) Init(app app_with_pools.AppWithPools, configPat
 	p.SetPropagateAuthUser(true)
 	p.SetPropagateContextId(true)
 
+	// reconnect when service is changed in the pool
+	p.poolId = poool.GetID()
+	app.Pools().AddReloadHandler(p.ReloadPool)
+
 	// done
 	return nil
 }
 
+// Reinit client if service with configured role was changed in reloaded pool.
+func (p *PoolMicroserviceClient) ReloadPool(ctx op_context.Context, poolId string, oldPool pool.Pool, newPool pool.Pool) error {
+
+	if poolId != p.poolId {
+		return nil
+	}
+
+	service := pool.FindPoolService(newPool, p.POOL_SERVICE_ROLE)
+	if service == nil {
+		// keep using old service until pool gets service with the role
+		ctx.Logger().Warn("pool has no service for microservice api client", logger.Fields{"pool_id": poolId, "role": p.POOL_SERVICE_ROLE})
+		return nil
+	}
+	if !pool.ServiceChanged(pool.FindPoolService(oldPool, p.POOL_SERVICE_ROLE), service) {
+		return nil
+	}
+
+	return p.setService(ctx, service, true)
+}
+
 func (p *PoolMicroserviceClient) SetService(ctx op_context.Context, service *pool.PoolServiceBinding) error {
+	return p.setService(ctx, service, false)
+}
+
+func (p *PoolMicroserviceClient) setService(ctx op_context.Context, service *pool.PoolServiceBinding, propagate bool) error {
 
 	c := ctx.TraceInMethod("PoolMicroserviceClient.SetService")
 	defer ctx.TraceOutMethod()
 
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
 	// init client form service data
-	err := p.InitForPoolService(p.HttpClient(), service, AppUserAgent(ctx.App()))
+	err := p.PoolServiceClient.InitForPoolService(p.HttpClient(), service, AppUserAgent(ctx.App()))
 	if err != nil {
 		c.SetMessage("failed to init microservice api client with pool service configuration")
 		return c.SetError(err)
 	}
+	if propagate {
+		p.PoolServiceClient.SetPropagateAuthUser(true)
+		p.PoolServiceClient.SetPropagateContextId(true)
+	}
 
 	// done
 	return nil
 }
 
+func (p *PoolMicroserviceClient) Exec(ctx op_context.Context, operation api.Operation, cmd interface{}, response interface{}, tenancyPath ...string) error {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.PoolServiceClient.Exec(ctx, operation, cmd, response, tenancyPath...)
+}
+
+func (p *PoolMicroserviceClient) Transport() interface{} {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.PoolServiceClient.Transport()
+}
+
 func (p *PoolMicroserviceClient) SetPropagateAuthUser(val bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
 	p.PoolServiceClient.SetPropagateAuthUser(val)
 }
 
 func (p *PoolMicroserviceClient) SetPropagateContextId(val bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
 	p.PoolServiceClient.SetPropagateContextId(val)
 }
 
@@ -2,6 +2,7 @@ package api
 
 import (
 	"github.com/evgeniums/go-utils/pkg/common"
+	"github.com/evgeniums/go-utils/pkg/db"
 )
 
 const (
@@ -31,6 +32,27 @@ type ResponseCount struct {
 	Count int64 `json:"count,omitempty"`
 }
 
+type ResponseCursors struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// Set keyset cursors from filter after query.
+func (r *ResponseCursors) SetCursors(filter *db.Filter) {
+	if filter != nil {
+		r.NextCursor = filter.NextCursor
+		r.PrevCursor = filter.PrevCursor
+	}
+}
+
+// Set keyset cursors received in response to filter so that it can be used to request next or previous page.
+func (r *ResponseCursors) UpdateFilter(filter *db.Filter) {
+	if filter != nil {
+		filter.NextCursor = r.NextCursor
+		filter.PrevCursor = r.PrevCursor
+	}
+}
+
 type ResponseExists struct {
 	ResponseStub
 	Exists bool `json:"exists"`
@@ -46,6 +68,7 @@ type ResponseListI interface {
 
 type ResponseList[T common.WithID] struct {
 	ResponseCount
+	ResponseCursors
 	Items []T `json:"items"`
 
 	ResponseBase
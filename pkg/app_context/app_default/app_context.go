@@ -12,11 +12,13 @@ import (
 	"github.com/evgeniums/go-utils/pkg/app_context"
 	"github.com/evgeniums/go-utils/pkg/cache"
 	"github.com/evgeniums/go-utils/pkg/cache/inmem_cache"
+	"github.com/evgeniums/go-utils/pkg/cache/layered_cache"
 	"github.com/evgeniums/go-utils/pkg/cache/redis_cache"
 	"github.com/evgeniums/go-utils/pkg/config"
 	"github.com/evgeniums/go-utils/pkg/config/config_viper"
 	"github.com/evgeniums/go-utils/pkg/config/object_config"
 	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/db/db_factory"
 	"github.com/evgeniums/go-utils/pkg/db/db_gorm"
 	"github.com/evgeniums/go-utils/pkg/logger"
 	"github.com/evgeniums/go-utils/pkg/logger/logger_logrus"
@@ -45,11 +47,12 @@ type Context struct {
 	logger.WithLoggerBase
 	config.WithCfgBase
 
-	db           *db_gorm.GormDB
+	db           db_factory.Database
 	validator    *validator_playground.PlaygroundValdator
 	cache        cache.Cache
 	inmemCache   *inmem_cache.InmemCache[string]
 	redisCache   *redis_cache.RedisCache
+	layeredCache *layered_cache.LayeredCache
 	logrusLogger *logger_logrus.LogrusLogger
 
 	contextConfig
@@ -70,6 +73,16 @@ func (c *Context) Cache() cache.Cache {
 	return c.cache
 }
 
+// Layered cache if application cache is configured with in-memory L1 in front of Redis, otherwise nil.
+func (c *Context) LayeredCache() *layered_cache.LayeredCache {
+	return c.layeredCache
+}
+
+// In-memory cache if it is used as application cache, otherwise nil.
+func (c *Context) InmemCache() *inmem_cache.InmemCache[string] {
+	return c.inmemCache
+}
+
 func (c *Context) Validator() validator.Validator {
 	return c.validator
 }
@@ -205,9 +218,23 @@ func (c *Context) InitWithArgs(configFile string, args []string, configType ...s
 				return log.PushFatalStack("failed to init redis cache", err)
 			}
 			c.cache = cache.New(c.redisCache)
+			if c.Cfg().IsSet(layered_cache.LayeredCacheConfigPath) {
+				log.Info("using in-memory L1 cache in front of Redis cache")
+				c.layeredCache = layered_cache.New(c.redisCache)
+				err = c.layeredCache.Init(c.Cfg(), c.Logger(), c.Validator())
+				if err != nil {
+					return log.PushFatalStack("failed to init layered cache", err)
+				}
+				c.cache = cache.New(c.layeredCache)
+				c.layeredCache.Start()
+			}
 		} else {
 			log.Info("using in-memory cache as application cache")
 			c.inmemCache = inmem_cache.New[string]()
+			err = c.inmemCache.Init(c.Cfg(), c.Logger(), c.Validator())
+			if err != nil {
+				return log.PushFatalStack("failed to init in-memory cache", err)
+			}
 			c.cache = cache.New(c.inmemCache)
 			c.inmemCache.Start()
 		}
@@ -229,6 +256,9 @@ func (c *Context) Close() {
 	if c.inmemCache != nil {
 		c.inmemCache.Stop()
 	}
+	if c.layeredCache != nil {
+		c.layeredCache.Stop()
+	}
 	if c.redisCache != nil {
 		c.redisCache.Stop()
 	}
@@ -258,7 +288,8 @@ func (c *Context) InitDB(configPath string, gormDbConnector ...*db_gorm.DbConnec
 	if c.db != nil {
 		return nil
 	}
-	d := db_gorm.New(gormDbConnector...)
+	provider := c.Cfg().GetString(object_config.Key(configPath, "db_provider"))
+	d := db_factory.New(provider, gormDbConnector...)
 	c.db = d
 	return d.Init(c, c.Cfg(), c.validator, configPath)
 }
@@ -8,16 +8,22 @@ const (
 	ErrorCodeUnauthorized          string = "unauthorized"
 	ErrorCodeInvalidAuthSchema     string = "invalid_auth_schema"
 	ErrorCodeUnsupportedAuthMethod string = "unknown_auth_method"
+	ErrorCodeInvalidTimestamp      string = "invalid_request_timestamp"
+	ErrorCodeReplayedRequest       string = "replayed_request"
 )
 
 var ErrorDescriptions = map[string]string{
 	ErrorCodeUnauthorized:          "Request is not authorized.",
 	ErrorCodeInvalidAuthSchema:     "Invalid authorization schema.",
 	ErrorCodeUnsupportedAuthMethod: "Unsupported authorization method.",
+	ErrorCodeInvalidTimestamp:      "Request timestamp is missing or out of allowed window.",
+	ErrorCodeReplayedRequest:       "Request was already processed.",
 }
 
 var ErrorHttpCodes = map[string]int{
 	ErrorCodeUnauthorized:          http.StatusUnauthorized,
 	ErrorCodeInvalidAuthSchema:     http.StatusInternalServerError, // because this is error of server configuration
 	ErrorCodeUnsupportedAuthMethod: http.StatusUnauthorized,
+	ErrorCodeInvalidTimestamp:      http.StatusUnauthorized,
+	ErrorCodeReplayedRequest:       http.StatusUnauthorized,
 }
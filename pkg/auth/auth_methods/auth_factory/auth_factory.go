@@ -9,6 +9,7 @@ import (
 	"github.com/evgeniums/go-utils/pkg/auth/auth_methods/auth_signature"
 	"github.com/evgeniums/go-utils/pkg/auth/auth_methods/auth_sms"
 	"github.com/evgeniums/go-utils/pkg/auth/auth_methods/auth_token"
+	"github.com/evgeniums/go-utils/pkg/auth/auth_methods/auth_totp"
 	"github.com/evgeniums/go-utils/pkg/auth/auth_session"
 	"github.com/evgeniums/go-utils/pkg/signature"
 	"github.com/evgeniums/go-utils/pkg/sms"
@@ -37,6 +38,8 @@ func (f *DefaultAuthFactory) Create(protocol string) (auth.AuthHandler, error) {
 		return &auth_hmac.AuthHmac{}, nil
 	case auth_sms.SmsProtocol:
 		return auth_sms.New(f.SmsManager), nil
+	case auth_totp.TotpProtocol:
+		return auth_totp.New(f.Users), nil
 	case auth_signature.SignatureProtocol:
 		return auth_signature.New(f.SignatureManager), nil
 	case auth.NoAuthProtocol:
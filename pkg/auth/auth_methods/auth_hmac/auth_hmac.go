@@ -21,6 +21,7 @@ type UserWithHmacSecret interface {
 }
 
 type AuthHmacConfig struct {
+	auth.ReplayProtectionConfig
 }
 
 type AuthHmac struct {
@@ -63,6 +64,7 @@ func (a *AuthHmac) ErrorProtocolCodes() map[string]int {
 // Call this handler after discovering user (ctx.AuthUser() must be not nil).
 // HMAC secret must be set for the user.
 // HMAC string is calculated as BASE64(HMAC_SHA256(RequestMethod,RequestPath,RequestContent)), where BASE64 is calculated with padding.
+// If timestamp and nonce are set in request then HMAC is calculated as BASE64(HMAC_SHA256(RequestMethod,RequestPath,RequestContent,Timestamp,Nonce)).
 func (a *AuthHmac) Handle(ctx auth.AuthContext) (bool, error) {
 
 	// setup
@@ -102,15 +104,35 @@ func (a *AuthHmac) Handle(ctx auth.AuthContext) (bool, error) {
 		return true, err
 	}
 
+	// check timestamp and nonce
+	extraData, err := a.CheckReplay(ctx, a.Protocol())
+	if err != nil {
+		return true, err
+	}
+
 	// check hmac
 	hmac := crypt_utils.NewHmac(secret)
-	hmac.Calc([]byte(ctx.GetRequestMethod()), []byte(ctx.GetRequestPath()), ctx.GetRequestContent())
+	hmac.Calc(HmacData(ctx.GetRequestMethod(), ctx.GetRequestPath(), ctx.GetRequestContent(), extraData...)...)
 	err = hmac.CheckStr(requestHmac)
 	if err != nil {
 		ctx.SetGenericErrorCode(ErrorCodeInvalidHmac)
 		return true, err
 	}
 
+	// keep nonce
+	err = a.KeepNonce(ctx, a.Protocol(), extraData)
+	if err != nil {
+		return true, err
+	}
+
 	// done
 	return true, nil
 }
+
+func HmacData(method string, path string, content []byte, extraData ...string) [][]byte {
+	data := [][]byte{[]byte(method), []byte(path), content}
+	for _, extra := range extraData {
+		data = append(data, []byte(extra))
+	}
+	return data
+}
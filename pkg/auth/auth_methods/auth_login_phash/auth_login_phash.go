@@ -32,7 +32,7 @@ type LoginDelay struct {
 type User interface {
 	PasswordHash() string
 	PasswordSalt() string
-	SetPassword(password string)
+	SetPassword(password string, hashers ...*PasswordHashers) error
 	CheckPasswordHash(phash string) bool
 }
 
@@ -49,32 +49,73 @@ func (u *UserBase) PasswordSalt() string {
 	return u.PASSWORD_SALT
 }
 
-func (u *UserBase) SetPassword(password string) {
+func (u *UserBase) SetPassword(password string, hashers ...*PasswordHashers) error {
 	u.PASSWORD_SALT = crypt_utils.GenerateString()
-	u.PASSWORD_HASH = Phash(password, u.PASSWORD_SALT)
+	return u.setPasswordHash(utils.OptionalArg(DefaultPasswordHashers, hashers...), Phash(password, u.PASSWORD_SALT))
+}
+
+func (u *UserBase) setPasswordHash(hashers *PasswordHashers, phash string) error {
+	hash, err := hashers.Hash(phash)
+	if err != nil {
+		return err
+	}
+	u.PASSWORD_HASH = hash
+	return nil
 }
 
 func (u *UserBase) CheckPasswordHash(phash string) bool {
-	return crypt_utils.HashEqual(u.PASSWORD_HASH, phash)
+	return DefaultPasswordHashers.Verify(u.PASSWORD_HASH, phash)
+}
+
+func (u *UserBase) PasswordHashNeedsUpgrade(hashers ...*PasswordHashers) bool {
+	return utils.OptionalArg(DefaultPasswordHashers, hashers...).NeedsRehash(u.PASSWORD_HASH)
+}
+
+func (u *UserBase) UpgradePasswordHash(phash string, hashers ...*PasswordHashers) error {
+	return u.setPasswordHash(utils.OptionalArg(DefaultPasswordHashers, hashers...), phash)
+}
+
+// User manager that hashes new passwords with hashers of login handler.
+type WithPasswordHashers interface {
+	SetPasswordHashers(hashers *PasswordHashers)
+}
+
+// User whose password hash can be upgraded to the default hasher after successful login.
+type UserWithPasswordUpgrade interface {
+	PasswordHashNeedsUpgrade(hashers ...*PasswordHashers) bool
+	UpgradePasswordHash(phash string, hashers ...*PasswordHashers) error
+}
+
+// Optional interface of AuthUserManager to save upgraded password hash.
+type PasswordHashUpdater interface {
+	UpdatePasswordHash(ctx op_context.Context, user auth.User) error
 }
 
 type LoginHandlerConfig struct {
-	THROTTLE_DELAY_SECONDS int `default:"2" validate:"gt=0"`
+	THROTTLE_DELAY_SECONDS int    `default:"2" validate:"gt=0"`
+	PASSWORD_HASHER        string `default:"argon2id" validate:"oneof=argon2id scrypt bcrypt"`
 }
 
 // Auth handler for login processing. The AuthTokenHandler MUST ALWAYS follow this handler in session scheme with AND conjunction.
 type LoginHandler struct {
 	LoginHandlerConfig
 	auth.AuthHandlerBase
-	users auth_session.WithAuthUserManager
+	users   auth_session.WithAuthUserManager
+	hashers *PasswordHashers
 }
 
 func New(users auth_session.WithAuthUserManager) *LoginHandler {
 	l := &LoginHandler{}
 	l.users = users
+	l.hashers = DefaultPasswordHashers
 	return l
 }
 
+// Password hashers of login handler, default hasher is set in configuration of the handler.
+func (l *LoginHandler) PasswordHashers() *PasswordHashers {
+	return l.hashers
+}
+
 func (l *LoginHandler) Config() interface{} {
 	return &l.LoginHandlerConfig
 }
@@ -89,6 +130,21 @@ func (l *LoginHandler) Init(cfg config.Config, log logger.Logger, vld validator.
 		return log.PushFatalStack("failed to load configuration of auth login_phash handler", err)
 	}
 
+	hasher := DefaultPasswordHashers.Find(l.PASSWORD_HASHER)
+	if hasher == nil {
+		return log.PushFatalStack("unknown password hasher", nil, logger.Fields{"password_hasher": l.PASSWORD_HASHER})
+	}
+	l.hashers = DefaultPasswordHashers.Clone()
+	l.hashers.SetDefault(hasher)
+
+	// new passwords of users must be hashed with the same hashers
+	if l.users != nil {
+		withHashers, ok := l.users.AuthUserManager().(WithPasswordHashers)
+		if ok {
+			withHashers.SetPasswordHashers(l.hashers)
+		}
+	}
+
 	return nil
 }
 
@@ -220,13 +276,16 @@ func (l *LoginHandler) Handle(ctx auth.AuthContext) (bool, error) {
 	if phash != "" {
 
 		// check password hash
-		if !phashUser.CheckPasswordHash(phash) {
+		if !l.hashers.Verify(phashUser.PasswordHash(), phash) {
 			err = errors.New("invalid password hash")
 			ctx.SetGenericErrorCode(ErrorCodeLoginFailed)
 			l.setDelay(ctx, c, delayCacheKey, delayItem)
 			return true, err
 		}
 
+		// upgrade password hash if it was made with outdated scheme
+		l.upgradePasswordHash(ctx, c, dbUser, phash)
+
 		// set context user
 		ctx.SetAuthUser(dbUser)
 
@@ -257,6 +316,28 @@ func (l *LoginHandler) setDelay(ctx op_context.Context, c op_context.CallContext
 	}
 }
 
+func (l *LoginHandler) upgradePasswordHash(ctx auth.AuthContext, c op_context.CallContext, dbUser auth.User, phash string) {
+
+	upgradeUser, ok := dbUser.(UserWithPasswordUpgrade)
+	if !ok || !upgradeUser.PasswordHashNeedsUpgrade(l.hashers) {
+		return
+	}
+	updater, ok := l.users.AuthUserManager().(PasswordHashUpdater)
+	if !ok {
+		return
+	}
+
+	err := upgradeUser.UpgradePasswordHash(phash, l.hashers)
+	if err != nil {
+		c.Logger().Error("failed to upgrade password hash", err)
+		return
+	}
+	err = updater.UpdatePasswordHash(ctx, dbUser)
+	if err != nil {
+		c.Logger().Error("failed to save upgraded password hash", err)
+	}
+}
+
 func Phash(password string, salt string) string {
 	h := crypt_utils.NewHash()
 	return h.CalcStrStr(salt, password)
@@ -0,0 +1,332 @@
+package auth_login_phash
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/evgeniums/go-utils/pkg/crypt_utils"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+const HasherLegacy = "legacy"
+const HasherArgon2id = "argon2id"
+const HasherScrypt = "scrypt"
+const HasherBcrypt = "bcrypt"
+
+// PasswordHasher hashes secrets that are stored in database.
+// Hash string must be self-describing, i.e. contain the algorithm and its parameters.
+type PasswordHasher interface {
+	Name() string
+	Hash(secret string) (string, error)
+	Verify(hash string, secret string) bool
+	NeedsRehash(hash string) bool
+}
+
+var ErrInvalidHashFormat = errors.New("invalid hash format")
+
+// HasherName detects name of hasher from hash string.
+func HasherName(hash string) string {
+	if !strings.HasPrefix(hash, "$") {
+		return HasherLegacy
+	}
+	if strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$") {
+		return HasherBcrypt
+	}
+	parts := strings.SplitN(hash[1:], "$", 2)
+	return parts[0]
+}
+
+func encodeB64(data []byte) string {
+	return base64.RawStdEncoding.EncodeToString(data)
+}
+
+func decodeB64(data string) ([]byte, error) {
+	return base64.RawStdEncoding.DecodeString(data)
+}
+
+//-------------------------------------------------------------------------------
+
+// LegacyHasher keeps salted SHA-256 hash of password as is. Use it only to verify old hashes.
+type LegacyHasher struct {
+}
+
+func (l *LegacyHasher) Name() string {
+	return HasherLegacy
+}
+
+func (l *LegacyHasher) Hash(secret string) (string, error) {
+	return secret, nil
+}
+
+func (l *LegacyHasher) Verify(hash string, secret string) bool {
+	return crypt_utils.HashEqual(hash, secret)
+}
+
+func (l *LegacyHasher) NeedsRehash(hash string) bool {
+	return HasherName(hash) != HasherLegacy
+}
+
+//-------------------------------------------------------------------------------
+
+type Argon2idHasher struct {
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+	SaltLen int
+	KeyLen  int
+}
+
+func NewArgon2idHasher() *Argon2idHasher {
+	return &Argon2idHasher{Memory: 19 * 1024, Time: 2, Threads: 1, SaltLen: 16, KeyLen: 32}
+}
+
+func (a *Argon2idHasher) Name() string {
+	return HasherArgon2id
+}
+
+func (a *Argon2idHasher) Hash(secret string) (string, error) {
+	salt, err := crypt_utils.GenerateCryptoRand(a.SaltLen)
+	if err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(secret), salt, a.Time, a.Memory, a.Threads, uint32(a.KeyLen))
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", HasherArgon2id, argon2.Version, a.Memory, a.Time, a.Threads, encodeB64(salt), encodeB64(key)), nil
+}
+
+func (a *Argon2idHasher) parse(hash string) (params *Argon2idHasher, salt []byte, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != HasherArgon2id {
+		return nil, nil, nil, ErrInvalidHashFormat
+	}
+	var version int
+	_, err = fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return nil, nil, nil, ErrInvalidHashFormat
+	}
+	params = &Argon2idHasher{}
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads)
+	if err != nil {
+		return nil, nil, nil, ErrInvalidHashFormat
+	}
+	salt, err = decodeB64(parts[4])
+	if err != nil {
+		return nil, nil, nil, ErrInvalidHashFormat
+	}
+	key, err = decodeB64(parts[5])
+	if err != nil || len(key) == 0 {
+		return nil, nil, nil, ErrInvalidHashFormat
+	}
+	params.SaltLen = len(salt)
+	params.KeyLen = len(key)
+	return params, salt, key, nil
+}
+
+func (a *Argon2idHasher) Verify(hash string, secret string) bool {
+	params, salt, key, err := a.parse(hash)
+	if err != nil {
+		return false
+	}
+	otherKey := argon2.IDKey([]byte(secret), salt, params.Time, params.Memory, params.Threads, uint32(params.KeyLen))
+	return subtle.ConstantTimeCompare(key, otherKey) == 1
+}
+
+func (a *Argon2idHasher) NeedsRehash(hash string) bool {
+	params, _, _, err := a.parse(hash)
+	if err != nil {
+		return true
+	}
+	return *params != *a
+}
+
+//-------------------------------------------------------------------------------
+
+type ScryptHasher struct {
+	LogN    uint8
+	R       int
+	P       int
+	SaltLen int
+	KeyLen  int
+}
+
+func NewScryptHasher() *ScryptHasher {
+	return &ScryptHasher{LogN: 15, R: 8, P: 1, SaltLen: 16, KeyLen: 32}
+}
+
+func (s *ScryptHasher) Name() string {
+	return HasherScrypt
+}
+
+func (s *ScryptHasher) Hash(secret string) (string, error) {
+	salt, err := crypt_utils.GenerateCryptoRand(s.SaltLen)
+	if err != nil {
+		return "", err
+	}
+	key, err := scrypt.Key([]byte(secret), salt, 1<<s.LogN, s.R, s.P, s.KeyLen)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("$%s$ln=%d,r=%d,p=%d$%s$%s", HasherScrypt, s.LogN, s.R, s.P, encodeB64(salt), encodeB64(key)), nil
+}
+
+func (s *ScryptHasher) parse(hash string) (params *ScryptHasher, salt []byte, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 5 || parts[1] != HasherScrypt {
+		return nil, nil, nil, ErrInvalidHashFormat
+	}
+	params = &ScryptHasher{}
+	_, err = fmt.Sscanf(parts[2], "ln=%d,r=%d,p=%d", &params.LogN, &params.R, &params.P)
+	if err != nil || params.LogN == 0 || params.LogN > 31 {
+		return nil, nil, nil, ErrInvalidHashFormat
+	}
+	salt, err = decodeB64(parts[3])
+	if err != nil {
+		return nil, nil, nil, ErrInvalidHashFormat
+	}
+	key, err = decodeB64(parts[4])
+	if err != nil || len(key) == 0 {
+		return nil, nil, nil, ErrInvalidHashFormat
+	}
+	params.SaltLen = len(salt)
+	params.KeyLen = len(key)
+	return params, salt, key, nil
+}
+
+func (s *ScryptHasher) Verify(hash string, secret string) bool {
+	params, salt, key, err := s.parse(hash)
+	if err != nil {
+		return false
+	}
+	otherKey, err := scrypt.Key([]byte(secret), salt, 1<<params.LogN, params.R, params.P, params.KeyLen)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(key, otherKey) == 1
+}
+
+func (s *ScryptHasher) NeedsRehash(hash string) bool {
+	params, _, _, err := s.parse(hash)
+	if err != nil {
+		return true
+	}
+	return *params != *s
+}
+
+//-------------------------------------------------------------------------------
+
+type BcryptHasher struct {
+	Cost int
+}
+
+func NewBcryptHasher() *BcryptHasher {
+	return &BcryptHasher{Cost: bcrypt.DefaultCost}
+}
+
+func (b *BcryptHasher) Name() string {
+	return HasherBcrypt
+}
+
+func (b *BcryptHasher) Hash(secret string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), b.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (b *BcryptHasher) Verify(hash string, secret string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(secret)) == nil
+}
+
+func (b *BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return true
+	}
+	return cost != b.Cost
+}
+
+//-------------------------------------------------------------------------------
+
+// PasswordHashers is a registry of password hashers. New hashes are always made with default hasher,
+// hashes made with other registered hashers can be only verified.
+type PasswordHashers struct {
+	mutex         sync.RWMutex
+	defaultHasher PasswordHasher
+	hashers       map[string]PasswordHasher
+}
+
+func NewPasswordHashers(defaultHasher PasswordHasher, others ...PasswordHasher) *PasswordHashers {
+	p := &PasswordHashers{hashers: make(map[string]PasswordHasher)}
+	for _, hasher := range others {
+		p.hashers[hasher.Name()] = hasher
+	}
+	p.SetDefault(defaultHasher)
+	return p
+}
+
+// Make a copy of registry, so that default hasher can be changed without affecting the origin.
+func (p *PasswordHashers) Clone() *PasswordHashers {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	c := &PasswordHashers{defaultHasher: p.defaultHasher, hashers: make(map[string]PasswordHasher)}
+	for name, hasher := range p.hashers {
+		c.hashers[name] = hasher
+	}
+	return c
+}
+
+func (p *PasswordHashers) SetDefault(hasher PasswordHasher) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.defaultHasher = hasher
+	p.hashers[hasher.Name()] = hasher
+}
+
+func (p *PasswordHashers) Register(hasher PasswordHasher) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.hashers[hasher.Name()] = hasher
+}
+
+func (p *PasswordHashers) Default() PasswordHasher {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.defaultHasher
+}
+
+func (p *PasswordHashers) Find(name string) PasswordHasher {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.hashers[name]
+}
+
+func (p *PasswordHashers) Hash(secret string) (string, error) {
+	return p.Default().Hash(secret)
+}
+
+func (p *PasswordHashers) Verify(hash string, secret string) bool {
+	if hash == "" {
+		return false
+	}
+	hasher := p.Find(HasherName(hash))
+	if hasher == nil {
+		return false
+	}
+	return hasher.Verify(hash, secret)
+}
+
+func (p *PasswordHashers) NeedsRehash(hash string) bool {
+	hasher := p.Default()
+	if HasherName(hash) != hasher.Name() {
+		return true
+	}
+	return hasher.NeedsRehash(hash)
+}
+
+var DefaultPasswordHashers = NewPasswordHashers(NewArgon2idHasher(), &LegacyHasher{}, NewScryptHasher(), NewBcryptHasher())
@@ -15,6 +15,7 @@ const SignatureProtocol = "signature"
 const SignatureParameter = "signature"
 
 type AuthSignatureConfig struct {
+	auth.ReplayProtectionConfig
 }
 
 type AuthSignature struct {
@@ -60,6 +61,7 @@ func (a *AuthSignature) ErrorProtocolCodes() map[string]int {
 // Call this handler after discovering user (ctx.AuthUser() must be not nil).
 // Public key of user must be set for the user.
 // signature is calculated as sig(sha256(RequestContent,RequestMethod,RequestPath))
+// If timestamp and nonce are set in request then signature is calculated as sig(sha256(RequestContent,RequestMethod,RequestPath,Timestamp,Nonce))
 func (a *AuthSignature) Handle(ctx auth.AuthContext) (bool, error) {
 
 	// setup
@@ -80,8 +82,20 @@ func (a *AuthSignature) Handle(ctx auth.AuthContext) (bool, error) {
 		return false, nil
 	}
 
+	// check timestamp and nonce
+	extraData, err := a.CheckReplay(ctx, a.Protocol())
+	if err != nil {
+		return true, err
+	}
+
 	// verify signature
-	err = a.signatureManager.Verify(ctx, requestSignature, ctx.GetRequestContent(), ctx.GetRequestMethod(), ctx.GetRequestPath())
+	err = a.signatureManager.Verify(ctx, requestSignature, ctx.GetRequestContent(), append([]string{ctx.GetRequestMethod(), ctx.GetRequestPath()}, extraData...)...)
+	if err != nil {
+		return true, err
+	}
+
+	// keep nonce
+	err = a.KeepNonce(ctx, a.Protocol(), extraData)
 	if err != nil {
 		return true, err
 	}
@@ -0,0 +1,537 @@
+package auth_totp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/evgeniums/go-utils/pkg/auth"
+	"github.com/evgeniums/go-utils/pkg/auth/auth_session"
+	"github.com/evgeniums/go-utils/pkg/config"
+	"github.com/evgeniums/go-utils/pkg/config/object_config"
+	"github.com/evgeniums/go-utils/pkg/crypt_utils"
+	"github.com/evgeniums/go-utils/pkg/generic_error"
+	"github.com/evgeniums/go-utils/pkg/logger"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+	"github.com/evgeniums/go-utils/pkg/utils"
+	"github.com/evgeniums/go-utils/pkg/validator"
+)
+
+const TotpProtocol = "totp"
+
+const CodeName = "totp-code"
+const RecoveryCodeName = "totp-recovery-code"
+const EnrollmentTokenName = "totp-enroll-token"
+const SecretName = "totp-secret"
+const UriName = "totp-uri"
+const RecoveryCodesName = "totp-recovery-codes"
+
+const SecretSaltSize = 16
+
+const TotpLastCounterCacheKey = "totp-last"
+const TotpTriesCacheKey = "totp-tries"
+
+type User interface {
+	TotpSecret() string
+	SetTotpSecret(secret string)
+	TotpRecoveryCodes() []string
+	SetTotpRecoveryCodes(codes []string)
+}
+
+type UserTotpBase struct {
+	TOTP_SECRET         string `json:"-"`
+	TOTP_RECOVERY_CODES string `json:"-"`
+}
+
+func (u *UserTotpBase) TotpSecret() string {
+	return u.TOTP_SECRET
+}
+
+func (u *UserTotpBase) SetTotpSecret(secret string) {
+	u.TOTP_SECRET = secret
+}
+
+func (u *UserTotpBase) TotpRecoveryCodes() []string {
+	if u.TOTP_RECOVERY_CODES == "" {
+		return nil
+	}
+	return strings.Split(u.TOTP_RECOVERY_CODES, ",")
+}
+
+func (u *UserTotpBase) SetTotpRecoveryCodes(codes []string) {
+	u.TOTP_RECOVERY_CODES = strings.Join(codes, ",")
+}
+
+// Optional interface of AuthUserManager to save TOTP secret and recovery codes of user.
+type TotpUpdater interface {
+	UpdateTotp(ctx op_context.Context, user auth.User) error
+}
+
+type EnrollmentToken struct {
+	auth.ExpireToken
+	UserId string `json:"user_id"`
+	Secret string `json:"secret"`
+}
+
+type TotpLastCounter struct {
+	Counter uint64 `json:"counter"`
+}
+
+type TotpTries struct {
+	Tries int `json:"tries"`
+}
+
+type AuthTotpConfig struct {
+	SECRET                       string `validate:"required" mask:"true"`
+	ISSUER                       string
+	ALGORITHM                    string `default:"SHA1" validate:"oneof=SHA1 SHA256 SHA512"`
+	DIGITS                       int    `default:"6" validate:"oneof=6 8"`
+	PERIOD_SECONDS               int    `default:"30" validate:"gt=0"`
+	SKEW                         int    `default:"1" validate:"gte=0,lte=10"`
+	SECRET_SIZE                  int    `default:"20" validate:"gte=10,lte=64"`
+	ENROLLMENT_TOKEN_TTL_SECONDS int    `default:"600" validate:"gt=0"`
+	RECOVERY_CODES_COUNT         int    `default:"10" validate:"gte=0,lte=100"`
+	MAX_TRIES                    int    `default:"5" validate:"gt=1"`
+	TRIES_TTL_SECONDS            int    `default:"300" validate:"gt=0"`
+	OPTIONAL                     bool
+}
+
+type AuthTotp struct {
+	auth.AuthHandlerBase
+	AuthTotpConfig
+	Encryption auth.AuthParameterEncryption
+	users      auth_session.WithAuthUserManager
+	keyRing    *crypt_utils.KeyRing
+}
+
+func (a *AuthTotp) Config() interface{} {
+	return &a.AuthTotpConfig
+}
+
+func New(users auth_session.WithAuthUserManager) *AuthTotp {
+	a := &AuthTotp{}
+	a.users = users
+	return a
+}
+
+func (a *AuthTotp) Init(cfg config.Config, log logger.Logger, vld validator.Validator, configPath ...string) error {
+
+	a.AuthHandlerBase.Init(TotpProtocol)
+
+	path := utils.OptionalArg("auth.methods.totp", configPath...)
+
+	err := object_config.LoadLogValidate(cfg, log, vld, a, path)
+	if err != nil {
+		return log.PushFatalStack("failed to load configuration of auth TOTP handler", err)
+	}
+
+	encryption := &auth.AuthParameterEncryptionBase{}
+	err = encryption.Init(cfg, log, vld, path)
+	if err != nil {
+		return log.PushFatalStack("failed to load configuration of TOTP encryption", err)
+	}
+	a.Encryption = encryption
+
+	a.keyRing, err = crypt_utils.LoadKeyRing(cfg, log, vld, path, a.SECRET, "")
+	if err != nil {
+		return log.PushFatalStack("failed to load key ring of TOTP secrets", err)
+	}
+
+	return nil
+}
+
+func (a *AuthTotp) Params() TotpParams {
+	return TotpParams{Algorithm: a.ALGORITHM, Digits: a.DIGITS, Period: a.PERIOD_SECONDS, Skew: a.SKEW}
+}
+
+const ErrorCodeTotpRequired = "totp_required"
+const ErrorCodeEnrollmentRequired = "totp_enrollment_required"
+const ErrorCodeInvalidCode = "totp_code_invalid"
+const ErrorCodeInvalidRecoveryCode = "totp_recovery_code_invalid"
+const ErrorCodeInvalidToken = "totp_token_invalid"
+const ErrorCodeTokenExpired = "totp_token_expired"
+const ErrorCodeTooManyTries = "totp_too_many_tries"
+
+func (a *AuthTotp) ErrorDescriptions() map[string]string {
+	m := map[string]string{
+		ErrorCodeTotpRequired:        "Request must be confirmed with one-time password from authenticator app.",
+		ErrorCodeEnrollmentRequired:  "Authenticator app must be enrolled, confirm enrollment with one-time password.",
+		ErrorCodeInvalidCode:         "Invalid one-time password.",
+		ErrorCodeInvalidRecoveryCode: "Invalid recovery code.",
+		ErrorCodeInvalidToken:        "Invalid TOTP enrollment token.",
+		ErrorCodeTokenExpired:        "TOTP enrollment token expired.",
+		ErrorCodeTooManyTries:        "Too many code tries.",
+	}
+	return m
+}
+
+func (a *AuthTotp) ErrorProtocolCodes() map[string]int {
+	m := map[string]int{
+		ErrorCodeTotpRequired:        http.StatusUnauthorized,
+		ErrorCodeEnrollmentRequired:  http.StatusUnauthorized,
+		ErrorCodeInvalidCode:         http.StatusUnauthorized,
+		ErrorCodeInvalidRecoveryCode: http.StatusUnauthorized,
+		ErrorCodeInvalidToken:        http.StatusUnauthorized,
+		ErrorCodeTokenExpired:        http.StatusUnauthorized,
+		ErrorCodeTooManyTries:        http.StatusUnauthorized,
+	}
+	return m
+}
+
+func (a *AuthTotp) Handle(ctx auth.AuthContext) (bool, error) {
+
+	// setup
+	c := ctx.TraceInMethod("AuthTotp.Handle")
+	var err error
+	onExit := func() {
+		if err != nil {
+			c.SetError(err)
+		}
+		ctx.TraceOutMethod()
+	}
+	defer onExit()
+
+	// check if user authenticated
+	if ctx.AuthUser() == nil {
+		err = errors.New("unknown user")
+		ctx.SetGenericErrorCode(auth.ErrorCodeUnauthorized)
+		return true, err
+	}
+
+	// user must be of User interface
+	user, ok := ctx.AuthUser().(User)
+	if !ok {
+		err = errors.New("user must be of auth_totp.User interface")
+		ctx.SetGenericErrorCode(generic_error.ErrorCodeInternalServerError)
+		return true, err
+	}
+
+	// process enrollment if user has no TOTP secret yet
+	if user.TotpSecret() == "" {
+		if a.OPTIONAL {
+			return true, nil
+		}
+		err = a.enroll(ctx, c, user)
+		return true, err
+	}
+
+	// check recovery code
+	recoveryCode := ctx.GetAuthParameter(a.Protocol(), RecoveryCodeName)
+	if recoveryCode != "" {
+		err = a.checkRecoveryCode(ctx, c, user, recoveryCode)
+		return true, err
+	}
+
+	// get code from request
+	code := ctx.GetAuthParameter(a.Protocol(), CodeName)
+	if code == "" {
+		err = errors.New("TOTP code not found")
+		ctx.SetGenericErrorCode(ErrorCodeTotpRequired)
+		return true, err
+	}
+
+	// decrypt secret
+	secret, err := a.DecryptSecret(ctx.AuthUser().GetID(), user.TotpSecret())
+	if err != nil {
+		c.SetMessage("failed to decrypt TOTP secret")
+		ctx.SetGenericErrorCode(generic_error.ErrorCodeInternalServerError)
+		return true, err
+	}
+
+	// check code
+	err = a.checkCode(ctx, c, secret, code)
+	return true, err
+}
+
+func (a *AuthTotp) enroll(ctx auth.AuthContext, c op_context.CallContext, user User) error {
+
+	userId := ctx.AuthUser().GetID()
+	code := ctx.GetAuthParameter(a.Protocol(), CodeName)
+
+	// extract enrollment token from request
+	token := &EnrollmentToken{}
+	exists, err := a.Encryption.GetAuthParameter(ctx, a.Protocol(), EnrollmentTokenName, token)
+	if err != nil {
+		c.SetMessage("failed to get encrypted enrollment token")
+		ctx.SetGenericErrorCode(ErrorCodeInvalidToken)
+		return err
+	}
+
+	// start enrollment if code or token not set in request
+	if !exists || code == "" {
+
+		// generate secret
+		token.Secret, err = GenerateSecret(a.SECRET_SIZE)
+		if err != nil {
+			c.SetMessage("failed to generate TOTP secret")
+			ctx.SetGenericErrorCode(generic_error.ErrorCodeInternalServerError)
+			return err
+		}
+		token.UserId = userId
+		token.SetTTL(a.ENROLLMENT_TOKEN_TTL_SECONDS)
+
+		// put token, secret and URI to response
+		err = a.Encryption.SetAuthParameter(ctx, a.Protocol(), EnrollmentTokenName, token)
+		if err != nil {
+			c.SetMessage("failed to put enrollment token to response")
+			ctx.SetGenericErrorCode(generic_error.ErrorCodeInternalServerError)
+			return err
+		}
+		ctx.SetAuthParameter(a.Protocol(), SecretName, token.Secret)
+		ctx.SetAuthParameter(a.Protocol(), UriName, OtpauthUri(a.ISSUER, ctx.AuthUser().Login(), token.Secret, a.Params()))
+
+		// done
+		ctx.SetGenericErrorCode(ErrorCodeEnrollmentRequired)
+		return errors.New("TOTP enrollment required")
+	}
+
+	// check token
+	if token.UserId != userId {
+		ctx.SetGenericErrorCode(ErrorCodeInvalidToken)
+		return errors.New("enrollment token of other user")
+	}
+	if token.Expired() {
+		ctx.SetGenericErrorCode(ErrorCodeTokenExpired)
+		return errors.New("enrollment token expired")
+	}
+
+	// check code
+	err = a.checkCode(ctx, c, token.Secret, code)
+	if err != nil {
+		return err
+	}
+
+	// keep encrypted secret and recovery codes in user
+	encryptedSecret, err := a.EncryptSecret(userId, token.Secret)
+	if err != nil {
+		c.SetMessage("failed to encrypt TOTP secret")
+		ctx.SetGenericErrorCode(generic_error.ErrorCodeInternalServerError)
+		return err
+	}
+	recoveryCodes, recoveryHashes, err := a.GenerateRecoveryCodes()
+	if err != nil {
+		c.SetMessage("failed to generate recovery codes")
+		ctx.SetGenericErrorCode(generic_error.ErrorCodeInternalServerError)
+		return err
+	}
+	user.SetTotpSecret(encryptedSecret)
+	user.SetTotpRecoveryCodes(recoveryHashes)
+	err = a.updateUser(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	// put recovery codes to response
+	if len(recoveryCodes) != 0 {
+		ctx.SetAuthParameter(a.Protocol(), RecoveryCodesName, strings.Join(recoveryCodes, ","))
+	}
+
+	// done
+	return nil
+}
+
+func (a *AuthTotp) checkCode(ctx auth.AuthContext, c op_context.CallContext, secret string, code string) error {
+
+	userId := ctx.AuthUser().GetID()
+
+	// check tries
+	triesCacheKey := a.cacheKey(TotpTriesCacheKey, userId)
+	err := a.checkTries(ctx, c, triesCacheKey)
+	if err != nil {
+		return err
+	}
+
+	// validate code
+	ok, counter, err := ValidateTotpCode(secret, code, time.Now(), a.Params())
+	if err != nil {
+		c.SetMessage("failed to validate TOTP code")
+		ctx.SetGenericErrorCode(generic_error.ErrorCodeInternalServerError)
+		return err
+	}
+	if !ok {
+		a.incTries(ctx, c, triesCacheKey)
+		ctx.SetGenericErrorCode(ErrorCodeInvalidCode)
+		return errors.New("invalid TOTP code")
+	}
+
+	// check if code was already used
+	lastCacheKey := a.cacheKey(TotpLastCounterCacheKey, userId)
+	last := &TotpLastCounter{}
+	found, err := ctx.Cache().Get(lastCacheKey, last)
+	if err != nil {
+		c.SetMessage("failed to get last TOTP counter from cache")
+		ctx.SetGenericErrorCode(generic_error.ErrorCodeInternalServerError)
+		return err
+	}
+	if found && counter <= last.Counter {
+		a.incTries(ctx, c, triesCacheKey)
+		ctx.SetGenericErrorCode(ErrorCodeInvalidCode)
+		return errors.New("TOTP code already used")
+	}
+
+	// keep last used counter
+	last.Counter = counter
+	err = ctx.Cache().Set(lastCacheKey, last, a.PERIOD_SECONDS*(2*a.SKEW+2))
+	if err != nil {
+		c.SetMessage("failed to save last TOTP counter in cache")
+		ctx.SetGenericErrorCode(generic_error.ErrorCodeInternalServerError)
+		return err
+	}
+	ctx.Cache().Unset(triesCacheKey)
+
+	// done
+	return nil
+}
+
+func (a *AuthTotp) checkRecoveryCode(ctx auth.AuthContext, c op_context.CallContext, user User, code string) error {
+
+	// check tries
+	triesCacheKey := a.cacheKey(TotpTriesCacheKey, ctx.AuthUser().GetID())
+	err := a.checkTries(ctx, c, triesCacheKey)
+	if err != nil {
+		return err
+	}
+
+	// find recovery code
+	hash := a.recoveryCodeHash(code)
+	hashes := user.TotpRecoveryCodes()
+	index := -1
+	for i, h := range hashes {
+		if crypt_utils.HashEqual(h, hash) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		a.incTries(ctx, c, triesCacheKey)
+		ctx.SetGenericErrorCode(ErrorCodeInvalidRecoveryCode)
+		return errors.New("invalid recovery code")
+	}
+
+	// recovery code can be used only once
+	user.SetTotpRecoveryCodes(append(hashes[:index], hashes[index+1:]...))
+	err = a.updateUser(ctx, c)
+	if err != nil {
+		return err
+	}
+	ctx.Cache().Unset(triesCacheKey)
+
+	// done
+	return nil
+}
+
+func (a *AuthTotp) checkTries(ctx auth.AuthContext, c op_context.CallContext, triesCacheKey string) error {
+	tries := &TotpTries{}
+	_, err := ctx.Cache().Get(triesCacheKey, tries)
+	if err != nil {
+		c.SetMessage("failed to get TOTP tries from cache")
+		ctx.SetGenericErrorCode(generic_error.ErrorCodeInternalServerError)
+		return err
+	}
+	if tries.Tries >= a.MAX_TRIES {
+		ctx.SetGenericErrorCode(ErrorCodeTooManyTries)
+		return errors.New("too many tries")
+	}
+	return nil
+}
+
+func (a *AuthTotp) incTries(ctx auth.AuthContext, c op_context.CallContext, triesCacheKey string) {
+	tries := &TotpTries{}
+	_, err := ctx.Cache().Get(triesCacheKey, tries)
+	if err != nil {
+		c.Logger().Error("failed to get TOTP tries from cache", err)
+		return
+	}
+	tries.Tries++
+	err = ctx.Cache().Set(triesCacheKey, tries, a.TRIES_TTL_SECONDS)
+	if err != nil {
+		c.Logger().Error("failed to save TOTP tries in cache", err)
+	}
+}
+
+func (a *AuthTotp) updateUser(ctx auth.AuthContext, c op_context.CallContext) error {
+	updater, ok := a.users.AuthUserManager().(TotpUpdater)
+	if !ok {
+		ctx.SetGenericErrorCode(generic_error.ErrorCodeInternalServerError)
+		return errors.New("auth user manager must be of auth_totp.TotpUpdater interface")
+	}
+	err := updater.UpdateTotp(ctx, ctx.AuthUser())
+	if err != nil {
+		c.SetMessage("failed to update TOTP of user")
+		ctx.SetGenericErrorCode(generic_error.ErrorCodeInternalServerError)
+		return err
+	}
+	return nil
+}
+
+func (a *AuthTotp) cacheKey(prefix string, userId string) string {
+	return fmt.Sprintf("%s/%s", prefix, userId)
+}
+
+// Encrypt TOTP secret bound to user ID.
+func (a *AuthTotp) EncryptSecret(userId string, secret string) (string, error) {
+
+	salt, err := crypt_utils.GenerateCryptoRand(SecretSaltSize)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := a.keyRing.EncryptWithSalt(salt, []byte(secret), []byte(userId))
+	if err != nil {
+		return "", err
+	}
+
+	coding := utils.Base64StringCoding{}
+	return coding.Encode(append(ciphertext, salt...)), nil
+}
+
+// Decrypt TOTP secret bound to user ID.
+func (a *AuthTotp) DecryptSecret(userId string, encryptedSecret string) (string, error) {
+
+	coding := utils.Base64StringCoding{}
+	ciphertext, err := coding.Decode(encryptedSecret)
+	if err != nil {
+		return "", err
+	}
+	if len(ciphertext) < SecretSaltSize {
+		return "", errors.New("ciphertext too short for salt")
+	}
+	salt := ciphertext[len(ciphertext)-SecretSaltSize:]
+	ciphertext = ciphertext[:len(ciphertext)-SecretSaltSize]
+
+	secret, err := a.keyRing.DecryptWithSalt(salt, ciphertext, []byte(userId))
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+// Generate recovery codes. Returns plain codes for user and their hashes to keep in database.
+func (a *AuthTotp) GenerateRecoveryCodes() ([]string, []string, error) {
+
+	codes := make([]string, 0, a.RECOVERY_CODES_COUNT)
+	hashes := make([]string, 0, a.RECOVERY_CODES_COUNT)
+	for i := 0; i < a.RECOVERY_CODES_COUNT; i++ {
+		data, err := crypt_utils.GenerateCryptoRand(5)
+		if err != nil {
+			return nil, nil, err
+		}
+		str := strings.ToLower(b32.EncodeToString(data))
+		code := fmt.Sprintf("%s-%s", str[:4], str[4:])
+		codes = append(codes, code)
+		hashes = append(hashes, a.recoveryCodeHash(code))
+	}
+
+	return codes, hashes, nil
+}
+
+func (a *AuthTotp) recoveryCodeHash(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	h := crypt_utils.NewHmac(a.SECRET)
+	return h.CalcStrStr(normalized)
+}
+
+func (a *AuthTotp) SetAuthManager(manager auth.AuthManager) {
+	manager.Schemas().AddHandler(a)
+}
@@ -0,0 +1,139 @@
+package auth_totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/evgeniums/go-utils/pkg/crypt_utils"
+)
+
+const AlgorithmSHA1 = "SHA1"
+const AlgorithmSHA256 = "SHA256"
+const AlgorithmSHA512 = "SHA512"
+
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Parameters of RFC 6238 one-time passwords.
+type TotpParams struct {
+	Algorithm string
+	Digits    int
+	Period    int
+	Skew      int
+}
+
+func DefaultTotpParams() TotpParams {
+	return TotpParams{Algorithm: AlgorithmSHA1, Digits: 6, Period: 30, Skew: 1}
+}
+
+func (p *TotpParams) digest() func() hash.Hash {
+	switch p.Algorithm {
+	case AlgorithmSHA256:
+		return sha256.New
+	case AlgorithmSHA512:
+		return sha512.New
+	}
+	return sha1.New
+}
+
+// Generate random TOTP secret encoded in base32.
+func GenerateSecret(size int) (string, error) {
+	secret, err := crypt_utils.GenerateCryptoRand(size)
+	if err != nil {
+		return "", err
+	}
+	return b32.EncodeToString(secret), nil
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	return b32.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+}
+
+// Calculate HOTP code for counter as defined in RFC 4226.
+func HotpCode(secret []byte, counter uint64, params TotpParams) string {
+
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	mac := hmac.New(params.digest(), secret)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < params.Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", params.Digits, value%mod)
+}
+
+// Get TOTP counter for given time.
+func TotpCounter(t time.Time, params TotpParams) uint64 {
+	return uint64(t.Unix()) / uint64(params.Period)
+}
+
+// Calculate TOTP code for given time.
+func TotpCode(secret string, t time.Time, params TotpParams) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return HotpCode(key, TotpCounter(t, params), params), nil
+}
+
+// Validate TOTP code within allowed skew. Returns matched counter that can be used for replay protection.
+func ValidateTotpCode(secret string, code string, t time.Time, params TotpParams) (bool, uint64, error) {
+
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return false, 0, err
+	}
+	if len(code) != params.Digits {
+		return false, 0, nil
+	}
+
+	counter := TotpCounter(t, params)
+	for i := -params.Skew; i <= params.Skew; i++ {
+		if i < 0 && counter < uint64(-i) {
+			continue
+		}
+		c := uint64(int64(counter) + int64(i))
+		if subtle.ConstantTimeCompare([]byte(HotpCode(key, c, params)), []byte(code)) == 1 {
+			return true, c, nil
+		}
+	}
+
+	return false, 0, nil
+}
+
+// Make otpauth URI for authenticator apps.
+func OtpauthUri(issuer string, account string, secret string, params TotpParams) string {
+
+	label := account
+	if issuer != "" {
+		label = fmt.Sprintf("%s:%s", issuer, account)
+	}
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	if issuer != "" {
+		query.Set("issuer", issuer)
+	}
+	query.Set("algorithm", params.Algorithm)
+	query.Set("digits", fmt.Sprintf("%d", params.Digits))
+	query.Set("period", fmt.Sprintf("%d", params.Period))
+
+	u := url.URL{Scheme: "otpauth", Host: "totp", Path: "/" + label, RawQuery: query.Encode()}
+	return u.String()
+}
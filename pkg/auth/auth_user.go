@@ -51,6 +51,23 @@ func NewAuthUser(id string, login string, display string, blocked ...bool) *User
 	return &UserBase{UserId: id, UserLogin: userLogin, UserDisplay: userDisplay, UserBlocked: utils.OptionalArg(false, blocked...)}
 }
 
+// Interface of user having roles used for access control.
+type UserWithRoles interface {
+	RoleNames() []string
+}
+
+// Get roles of authorized user.
+func AuthUserRoles(ctx WithAuthUser) []string {
+	if ctx.AuthUser() == nil {
+		return []string{}
+	}
+	u, ok := ctx.AuthUser().(UserWithRoles)
+	if !ok {
+		return []string{}
+	}
+	return u.RoleNames()
+}
+
 type WithUser interface {
 	SetUser(user User)
 	GetUserId() string
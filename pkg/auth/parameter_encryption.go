@@ -30,6 +30,8 @@ type AuthParameterEncryptionBase struct {
 	AuthParameterEncryptionBaseConfig
 	Serializer   message.Serializer
 	StringCoding utils.StringCoding
+
+	keyRing *crypt_utils.KeyRing
 }
 
 func (a *AuthParameterEncryptionBase) Config() interface{} {
@@ -40,19 +42,24 @@ func (a *AuthParameterEncryptionBase) Init(cfg config.Config, log logger.Logger,
 	a.Serializer = &message_json.JsonSerializer{}
 	a.StringCoding = &utils.Base64StringCoding{}
 
-	err := object_config.LoadLogValidate(cfg, log, vld, a, "auth.params_encryption", configPath...)
+	path := utils.OptionalArg("auth.params_encryption", configPath...)
+	err := object_config.LoadLogValidate(cfg, log, vld, a, path)
 	if err != nil {
 		return log.PushFatalStack("failed to load configuration of auth parameters encryption", err)
 	}
-	return nil
-}
 
-func (a *AuthParameterEncryptionBase) createCipher(salt []byte) (*crypt_utils.AEAD, error) {
 	pbkdfCfg := crypt_utils.DefaultPbkdfConfig()
 	pbkdfCfg.Iter = int(a.PBKDF2_ITERATIONS)
-	aeadCfg := crypt_utils.DefaultAEADConfig(pbkdfCfg)
-	cipher, err := crypt_utils.NewAEAD(a.SECRET, salt, aeadCfg)
-	return cipher, err
+	a.keyRing, err = crypt_utils.LoadKeyRing(cfg, log, vld, path, a.SECRET, "", crypt_utils.DefaultAEADConfig(pbkdfCfg))
+	if err != nil {
+		return log.PushFatalStack("failed to load key ring of auth parameters encryption", err)
+	}
+
+	return nil
+}
+
+func (a *AuthParameterEncryptionBase) KeyRing() *crypt_utils.KeyRing {
+	return a.keyRing
 }
 
 func (a *AuthParameterEncryptionBase) SetAuthParameter(ctx AuthContext, authMethodProtocol string, name string, obj interface{}, directKeyName ...bool) error {
@@ -116,15 +123,8 @@ func (a *AuthParameterEncryptionBase) GetAuthParameter(ctx AuthContext, authMeth
 	salt := ciphertext[len(ciphertext)-a.SALT_SIZE:]
 	ciphertext = ciphertext[:len(ciphertext)-len(salt)]
 
-	// create cipher
-	cipher, err := a.createCipher(salt)
-	if err != nil {
-		c.SetMessage("failed to create AEAD cipher")
-		return true, err
-	}
-
 	// decrypt data
-	plaintext, err := cipher.Decrypt(ciphertext)
+	plaintext, err := a.keyRing.DecryptWithSalt(salt, ciphertext)
 	if err != nil {
 		c.SetMessage("failed to decrypt ciphertext")
 		return true, err
@@ -168,15 +168,8 @@ func (a *AuthParameterEncryptionBase) Encrypt(ctx op_context.Context, obj interf
 		return "", err
 	}
 
-	// create cipher
-	cipher, err := a.createCipher(salt)
-	if err != nil {
-		c.SetMessage("failed to create AEAD cipher")
-		return "", err
-	}
-
 	// encrypt data
-	ciphertext, err := cipher.Encrypt(plaintext)
+	ciphertext, err := a.keyRing.EncryptWithSalt(salt, plaintext)
 	if err != nil {
 		c.SetMessage("failed to encrypt data")
 		return "", err
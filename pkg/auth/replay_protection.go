@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/evgeniums/go-utils/pkg/crypt_utils"
+	"github.com/evgeniums/go-utils/pkg/generic_error"
+)
+
+const TimestampParameter = "timestamp"
+const NonceParameter = "nonce"
+
+const NonceCacheKey = "auth-nonce"
+
+// Configuration of replay protection of signed requests.
+//
+// Server accepts requests without timestamp and nonce unless REQUIRE_NONCE is set, so clients can be upgraded before the server.
+// Rollout: first upgrade all clients, they send timestamp and nonce by default (REPLAY_PROTECTION of client),
+// then set REQUIRE_NONCE on the server to reject requests without timestamp and nonce.
+type ReplayProtectionConfig struct {
+	REQUIRE_NONCE      bool
+	CLOCK_SKEW_SECONDS int `default:"300" validate:"gt=0"`
+}
+
+type RequestNonce struct {
+	Timestamp int64 `json:"timestamp"`
+}
+
+// Check optional timestamp and nonce of request.
+// If timestamp and nonce are present then they are returned as extra data that must be included into signed material.
+func (r *ReplayProtectionConfig) CheckReplay(ctx AuthContext, authMethodProtocol string) ([]string, error) {
+
+	timestamp := ctx.GetAuthParameter(authMethodProtocol, TimestampParameter)
+	nonce := ctx.GetAuthParameter(authMethodProtocol, NonceParameter)
+
+	// check if timestamp and nonce are set
+	if timestamp == "" && nonce == "" {
+		if r.REQUIRE_NONCE {
+			ctx.SetGenericErrorCode(ErrorCodeInvalidTimestamp)
+			return nil, errors.New("timestamp and nonce required")
+		}
+		return nil, nil
+	}
+	if timestamp == "" || nonce == "" {
+		ctx.SetGenericErrorCode(ErrorCodeInvalidTimestamp)
+		return nil, errors.New("both timestamp and nonce must be set")
+	}
+
+	// check timestamp
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		ctx.SetGenericErrorCode(ErrorCodeInvalidTimestamp)
+		return nil, fmt.Errorf("invalid timestamp format: %s", err)
+	}
+	diff := time.Now().Unix() - ts
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > int64(r.CLOCK_SKEW_SECONDS) {
+		ctx.SetGenericErrorCode(ErrorCodeInvalidTimestamp)
+		return nil, errors.New("timestamp out of window")
+	}
+
+	return []string{timestamp, nonce}, nil
+}
+
+// Remember nonce of request. Call it only after request's signature was verified.
+func (r *ReplayProtectionConfig) KeepNonce(ctx AuthContext, authMethodProtocol string, extraData []string) error {
+
+	if len(extraData) != 2 {
+		return nil
+	}
+
+	userId := ""
+	if ctx.AuthUser() != nil {
+		userId = ctx.AuthUser().GetID()
+	}
+	key := fmt.Sprintf("%s/%s/%s/%s", NonceCacheKey, authMethodProtocol, userId, crypt_utils.H256Hex([]byte(extraData[1])))
+
+	// check and keep nonce atomically, so that concurrent requests with the same nonce can not pass
+	ts, _ := strconv.ParseInt(extraData[0], 10, 64)
+	set, err := ctx.Cache().SetNX(key, &RequestNonce{Timestamp: ts}, 2*r.CLOCK_SKEW_SECONDS)
+	if err != nil {
+		ctx.SetGenericErrorCode(generic_error.ErrorCodeInternalServerError)
+		return err
+	}
+	if !set {
+		ctx.SetGenericErrorCode(ErrorCodeReplayedRequest)
+		return errors.New("nonce already used")
+	}
+
+	return nil
+}
+
+// Make timestamp and nonce for signing request on client side.
+func MakeTimestampNonce() (string, string) {
+	return strconv.FormatInt(time.Now().Unix(), 10), crypt_utils.GenerateString()
+}
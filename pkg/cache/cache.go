@@ -9,6 +9,7 @@ import (
 type Cache interface {
 	Set(key string, value interface{}, ttlSeconds ...int) error
 	Get(key string, value interface{}) (bool, error)
+	SetNX(key string, value interface{}, ttlSeconds ...int) (bool, error)
 	Unset(key string) error
 	GetUnset(key string, value interface{}) (bool, error)
 	Touch(key string) error
@@ -19,6 +20,7 @@ type Cache interface {
 type GenericCache[T any] interface {
 	Set(key string, value T, ttlSeconds ...int) error
 	Get(key string, value *T) (bool, error)
+	SetNX(key string, value T, ttlSeconds ...int) (bool, error)
 	Unset(key string) error
 	GetUnset(key string, value *T) (bool, error)
 	Touch(key string) error
@@ -53,6 +55,18 @@ func (c *SerializedObjectCache) Set(key string, value interface{}, ttlSeconds ..
 	return c.impl.Set(key, str, ttlSeconds...)
 }
 
+// Set value only if key is not set yet. Returns true if value was set.
+func (c *SerializedObjectCache) SetNX(key string, value interface{}, ttlSeconds ...int) (bool, error) {
+
+	b, err := c.Serializer.SerializeMessage(value)
+	if err != nil {
+		return false, err
+	}
+	str := c.StringCoding.Encode(b)
+
+	return c.impl.SetNX(key, str, ttlSeconds...)
+}
+
 func (c *SerializedObjectCache) Get(key string, obj interface{}) (bool, error) {
 
 	var val string
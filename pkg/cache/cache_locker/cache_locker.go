@@ -0,0 +1,42 @@
+package cache_locker
+
+import (
+	"fmt"
+
+	"github.com/evgeniums/go-utils/pkg/app_context"
+	"github.com/evgeniums/go-utils/pkg/cache"
+	"github.com/evgeniums/go-utils/pkg/cache/db_locker"
+	"github.com/evgeniums/go-utils/pkg/cache/inmem_cache"
+	"github.com/evgeniums/go-utils/pkg/cache/redis_cache"
+	"github.com/evgeniums/go-utils/pkg/db"
+)
+
+const (
+	LockerRedis string = "redis"
+	LockerInmem string = "inmem"
+	LockerDb    string = "db"
+)
+
+// Create locker of given type. Database is used only by database locker, by default database of application is used.
+func New(app app_context.Context, lockerType string, database ...db.DB) (cache.Locker, error) {
+
+	switch lockerType {
+	case LockerInmem:
+		return inmem_cache.NewLocker(), nil
+	case LockerDb:
+		dbHandlers := app.Db()
+		if len(database) != 0 && database[0] != nil {
+			dbHandlers = database[0]
+		}
+		return db_locker.NewLocker(app, dbHandlers), nil
+	case LockerRedis, "":
+		redisCache := redis_cache.NewCache()
+		err := redisCache.Init(app.Cfg(), app.Logger(), app.Validator(), redis_cache.RedisCacheConfigPath)
+		if err != nil {
+			return nil, err
+		}
+		return redis_cache.NewLocker(redisCache), nil
+	}
+
+	return nil, fmt.Errorf("unknown locker type %s", lockerType)
+}
@@ -0,0 +1,116 @@
+package db_locker
+
+import (
+	"time"
+
+	"github.com/evgeniums/go-utils/pkg/cache"
+	"github.com/evgeniums/go-utils/pkg/common"
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/logger"
+	"github.com/evgeniums/go-utils/pkg/utils"
+)
+
+// DbLock is a record in lock table.
+type DbLock struct {
+	common.ObjectBase
+	LockKey   string    `gorm:"uniqueIndex"`
+	Token     string    `gorm:"index"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+func DbModels() []interface{} {
+	return []interface{}{&DbLock{}}
+}
+
+// DbLocker keeps locks in lock table of database, so that locks can be shared by all nodes using the same database.
+type DbLocker struct {
+	ctx logger.WithLogger
+	db  db.DBHandlers
+}
+
+type DbLockHandle struct {
+	locker      *DbLocker
+	key         string
+	token       string
+	notObtained bool
+}
+
+func NewLocker(ctx logger.WithLogger, database db.DBHandlers) *DbLocker {
+	return &DbLocker{ctx: ctx, db: database}
+}
+
+func (l *DbLocker) Lock(key string, ttl time.Duration) (cache.Lock, error) {
+
+	lock := &DbLockHandle{locker: l, key: key, token: utils.GenerateID()}
+	now := time.Now()
+
+	// try to create new lock
+	record := &DbLock{}
+	record.InitObject()
+	record.LockKey = key
+	record.Token = lock.token
+	record.ExpiresAt = now.Add(ttl)
+	duplicate, err := l.db.CreateDup(l.ctx, record)
+	if err == nil {
+		return lock, nil
+	}
+	if !duplicate {
+		return nil, err
+	}
+
+	// take over expired lock
+	obtained := false
+	err = l.db.Transaction(func(tx db.Transaction) error {
+		existing := &DbLock{}
+		found, err := tx.FindForUpdate(l.ctx, db.Fields{"lock_key": key}, existing)
+		if err != nil {
+			return err
+		}
+		if !found || existing.ExpiresAt.After(now) {
+			return nil
+		}
+		err = db.Update(tx, l.ctx, existing, db.Fields{"token": lock.token, "expires_at": now.Add(ttl)})
+		if err != nil {
+			return err
+		}
+		obtained = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lock.notObtained = !obtained
+	return lock, nil
+}
+
+func (l *DbLockHandle) NotObtained() bool {
+	return l.notObtained
+}
+
+func (l *DbLockHandle) Release() error {
+	if l.notObtained {
+		return nil
+	}
+	return l.locker.db.DeleteByFields(l.locker.ctx, db.Fields{"lock_key": l.key, "token": l.token}, &DbLock{})
+}
+
+func (l *DbLockHandle) Renew(ttl time.Duration) error {
+
+	if l.notObtained {
+		return cache.ErrLockNotHeld
+	}
+
+	return l.locker.db.Transaction(func(tx db.Transaction) error {
+		existing := &DbLock{}
+		found, err := tx.FindForUpdate(l.locker.ctx, db.Fields{"lock_key": l.key, "token": l.token}, existing)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if !found || !existing.ExpiresAt.After(now) {
+			return cache.ErrLockNotHeld
+		}
+		return db.Update(tx, l.locker.ctx, existing, db.Fields{"expires_at": now.Add(ttl)})
+	})
+}
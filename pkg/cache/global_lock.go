@@ -1,14 +1,18 @@
 package cache
 
 import (
+	"errors"
 	"time"
 
 	"github.com/evgeniums/go-utils/pkg/utils"
 )
 
+var ErrLockNotHeld = errors.New("lock not held")
+
 type Lock interface {
 	NotObtained() bool
 	Release() error
+	Renew(ttl time.Duration) error
 }
 
 type Locker interface {
@@ -28,3 +32,37 @@ func LockObject(locker Locker, keyPrefix string, objectId string, ttl int) (Lock
 
 	return lock, nil
 }
+
+// Periodically renew lock until returned stop function is called.
+func KeepLock(lock Lock, ttl time.Duration, interval time.Duration, onError ...func(err error)) func() {
+
+	if interval <= 0 {
+		return func() {}
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				err := lock.Renew(ttl)
+				if err != nil {
+					if len(onError) != 0 {
+						onError[0](err)
+					}
+					if err == ErrLockNotHeld {
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+	}
+}
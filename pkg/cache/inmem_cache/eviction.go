@@ -0,0 +1,100 @@
+package inmem_cache
+
+import "container/list"
+
+type evictionPolicy[T any] interface {
+	add(entry *inmemEntry[T])
+	access(entry *inmemEntry[T])
+	remove(entry *inmemEntry[T])
+	victim() *inmemEntry[T]
+}
+
+func newEvictionPolicy[T any](policy string) evictionPolicy[T] {
+	if policy == EvictionLFU {
+		return newLfuPolicy[T]()
+	}
+	return newLruPolicy[T]()
+}
+
+// Least recently used entries are evicted first.
+type lruPolicy[T any] struct {
+	entries *list.List
+}
+
+func newLruPolicy[T any]() *lruPolicy[T] {
+	return &lruPolicy[T]{entries: list.New()}
+}
+
+func (p *lruPolicy[T]) add(entry *inmemEntry[T]) {
+	entry.elem = p.entries.PushFront(entry)
+}
+
+func (p *lruPolicy[T]) access(entry *inmemEntry[T]) {
+	p.entries.MoveToFront(entry.elem)
+}
+
+func (p *lruPolicy[T]) remove(entry *inmemEntry[T]) {
+	p.entries.Remove(entry.elem)
+}
+
+func (p *lruPolicy[T]) victim() *inmemEntry[T] {
+	return p.entries.Back().Value.(*inmemEntry[T])
+}
+
+// Least frequently used entries are evicted first, least recently used among entries with the same frequency.
+type lfuPolicy[T any] struct {
+	buckets map[uint64]*list.List
+	minFreq uint64
+}
+
+func newLfuPolicy[T any]() *lfuPolicy[T] {
+	return &lfuPolicy[T]{buckets: make(map[uint64]*list.List)}
+}
+
+func (p *lfuPolicy[T]) push(entry *inmemEntry[T]) {
+	bucket, ok := p.buckets[entry.freq]
+	if !ok {
+		bucket = list.New()
+		p.buckets[entry.freq] = bucket
+	}
+	entry.elem = bucket.PushFront(entry)
+}
+
+func (p *lfuPolicy[T]) remove(entry *inmemEntry[T]) {
+	bucket := p.buckets[entry.freq]
+	bucket.Remove(entry.elem)
+	if bucket.Len() == 0 {
+		delete(p.buckets, entry.freq)
+	}
+}
+
+func (p *lfuPolicy[T]) add(entry *inmemEntry[T]) {
+	entry.freq = 1
+	p.push(entry)
+	p.minFreq = 1
+}
+
+func (p *lfuPolicy[T]) access(entry *inmemEntry[T]) {
+	p.remove(entry)
+	if entry.freq == p.minFreq && p.buckets[entry.freq] == nil {
+		p.minFreq++
+	}
+	entry.freq++
+	p.push(entry)
+}
+
+func (p *lfuPolicy[T]) victim() *inmemEntry[T] {
+	bucket, ok := p.buckets[p.minFreq]
+	if !ok {
+		// minimal frequency is outdated after removal of entries
+		first := true
+		for freq := range p.buckets {
+			if first || freq < p.minFreq {
+				p.minFreq = freq
+				first = false
+			}
+		}
+		bucket = p.buckets[p.minFreq]
+	}
+	return bucket.Back().Value.(*inmemEntry[T])
+}
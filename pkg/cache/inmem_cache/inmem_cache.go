@@ -1,94 +1,392 @@
 package inmem_cache
 
 import (
-	"sync/atomic"
+	"container/list"
+	"errors"
+	"reflect"
+	"sync"
 	"time"
 
-	"github.com/jellydator/ttlcache/v3"
+	"github.com/evgeniums/go-utils/pkg/config"
+	"github.com/evgeniums/go-utils/pkg/config/object_config"
+	"github.com/evgeniums/go-utils/pkg/logger"
+	"github.com/evgeniums/go-utils/pkg/utils"
+	"github.com/evgeniums/go-utils/pkg/validator"
 )
 
+const InmemCacheConfigPath = "inmem_cache"
+
+const (
+	EvictionLRU string = "lru"
+	EvictionLFU string = "lfu"
+)
+
+// Approximate memory used by entry besides key and value.
+const entryOverhead int64 = 96
+
+const (
+	DefaultMaxEntries int   = 100000
+	DefaultMaxBytes   int64 = 64 * 1024 * 1024
+)
+
+var ErrEntryTooLarge = errors.New("cache entry is larger than maximum size of cache")
+
+// Zero MAX_ENTRIES or MAX_BYTES disables corresponding limit.
+type InmemCacheConfig struct {
+	MAX_ENTRIES              int    `default:"100000" validate:"gte=0"`
+	MAX_BYTES                int64  `default:"67108864" validate:"gte=0"`
+	EVICTION_POLICY          string `default:"lru" validate:"oneof=lru lfu"`
+	CLEANUP_INTERVAL_SECONDS int    `default:"1" validate:"gt=0"`
+}
+
+type InmemCacheStats struct {
+	Hits        uint64
+	Misses      uint64
+	Evictions   uint64
+	Expirations uint64
+	Entries     int
+	Bytes       int64
+}
+
+type inmemEntry[T any] struct {
+	key       string
+	value     T
+	ttl       time.Duration
+	expiresAt time.Time
+	size      int64
+	freq      uint64
+	elem      *list.Element
+}
+
+func (e *inmemEntry[T]) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// InmemCache is an in-memory cache with optional limits of number of entries and approximate size in bytes.
+// When a limit is exceeded entries are evicted according to LRU or LFU policy. Expired entries are removed by background cleanup
+// started with Start() or when they are accessed.
 type InmemCache[T any] struct {
-	cache *ttlcache.Cache[string, T]
+	InmemCacheConfig
 
-	running atomic.Bool
+	mutex   sync.Mutex
+	entries map[string]*inmemEntry[T]
+	policy  evictionPolicy[T]
+	sizer   func(key string, value T) int64
+	stats   InmemCacheStats
+
+	running bool
+	stop    chan struct{}
 }
 
-func New[T any]() *InmemCache[T] {
+// Create in-memory cache. If configuration is not set then default limits are used.
+func New[T any](config ...InmemCacheConfig) *InmemCache[T] {
 	c := &InmemCache[T]{}
-	c.cache = ttlcache.New(ttlcache.WithDisableTouchOnHit[string, T]())
+	c.InmemCacheConfig = utils.OptionalArg(InmemCacheConfig{MAX_ENTRIES: DefaultMaxEntries, MAX_BYTES: DefaultMaxBytes}, config...)
+	if c.EVICTION_POLICY == "" {
+		c.EVICTION_POLICY = EvictionLRU
+	}
+	if c.CLEANUP_INTERVAL_SECONDS == 0 {
+		c.CLEANUP_INTERVAL_SECONDS = 1
+	}
+	c.sizer = defaultSize[T]
+	c.reset()
 	return c
 }
 
+func (c *InmemCache[T]) Config() interface{} {
+	return &c.InmemCacheConfig
+}
+
+func (c *InmemCache[T]) Init(cfg config.Config, log logger.Logger, vld validator.Validator, configPath ...string) error {
+
+	err := object_config.LoadLogValidate(cfg, log, vld, c, InmemCacheConfigPath, configPath...)
+	if err != nil {
+		return log.PushFatalStack("failed to load configuration of in-memory cache", err)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entries := utils.AllMapValues(c.entries)
+	c.policy = newEvictionPolicy[T](c.EVICTION_POLICY)
+	for _, entry := range entries {
+		c.policy.add(entry)
+	}
+	c.evict(0, 0)
+
+	return nil
+}
+
+// Set function for calculating approximate size of entry in bytes.
+func (c *InmemCache[T]) SetSizer(sizer func(key string, value T) int64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.sizer = sizer
+}
+
+func (c *InmemCache[T]) Stats() InmemCacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	stats := c.stats
+	stats.Entries = len(c.entries)
+	return stats
+}
+
+func (c *InmemCache[T]) reset() {
+	c.entries = make(map[string]*inmemEntry[T])
+	c.policy = newEvictionPolicy[T](c.EVICTION_POLICY)
+	c.stats.Bytes = 0
+}
+
+func (c *InmemCache[T]) remove(entry *inmemEntry[T]) {
+	c.policy.remove(entry)
+	delete(c.entries, entry.key)
+	c.stats.Bytes -= entry.size
+}
+
+func (c *InmemCache[T]) overLimits(extraEntries int, extraBytes int64) bool {
+	if c.MAX_ENTRIES > 0 && len(c.entries)+extraEntries > c.MAX_ENTRIES {
+		return true
+	}
+	if c.MAX_BYTES > 0 && c.stats.Bytes+extraBytes > c.MAX_BYTES {
+		return true
+	}
+	return false
+}
+
+// Evict entries until there is room for extra entries and bytes.
+func (c *InmemCache[T]) evict(extraEntries int, extraBytes int64) {
+	for len(c.entries) > 0 && c.overLimits(extraEntries, extraBytes) {
+		c.remove(c.policy.victim())
+		c.stats.Evictions++
+	}
+}
+
+// Find valid entry, expired entry is removed.
+func (c *InmemCache[T]) find(key string) *inmemEntry[T] {
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	if entry.expired(time.Now()) {
+		c.remove(entry)
+		c.stats.Expirations++
+		return nil
+	}
+	return entry
+}
+
 func (c *InmemCache[T]) Set(key string, value T, ttlSeconds ...int) error {
 
-	ttl := ttlcache.NoTTL
+	var ttl time.Duration
 	if len(ttlSeconds) > 0 {
 		ttl = time.Second * time.Duration(ttlSeconds[0])
 	}
 
-	c.cache.Set(key, value, ttl)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 
+	return c.set(key, value, ttl)
+}
+
+func (c *InmemCache[T]) SetNX(key string, value T, ttlSeconds ...int) (bool, error) {
+
+	var ttl time.Duration
+	if len(ttlSeconds) > 0 {
+		ttl = time.Second * time.Duration(ttlSeconds[0])
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.find(key) != nil {
+		return false, nil
+	}
+	err := c.set(key, value, ttl)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *InmemCache[T]) set(key string, value T, ttl time.Duration) error {
+
+	size := c.sizer(key, value)
+	entry, ok := c.entries[key]
+	if c.MAX_BYTES > 0 && size > c.MAX_BYTES {
+		// previous value must not stay in cache after failed update
+		if ok {
+			c.remove(entry)
+		}
+		return ErrEntryTooLarge
+	}
+	if ok {
+		c.stats.Bytes -= entry.size
+		c.policy.access(entry)
+	} else {
+		// make room before adding, otherwise new entry would be the first candidate for LFU eviction
+		c.evict(1, size)
+		entry = &inmemEntry[T]{key: key}
+		c.entries[key] = entry
+		c.policy.add(entry)
+	}
+	entry.value = value
+	entry.ttl = ttl
+	entry.expiresAt = time.Time{}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	entry.size = size
+	c.stats.Bytes += entry.size
+
+	c.evict(0, 0)
 	return nil
 }
 
 func (c *InmemCache[T]) Get(key string, value *T) (bool, error) {
 
-	item := c.cache.Get(key)
-	if item == nil || item.IsExpired() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry := c.find(key)
+	if entry == nil {
+		c.stats.Misses++
 		return false, nil
 	}
+	c.stats.Hits++
+	c.policy.access(entry)
 
-	*value = item.Value()
+	*value = entry.value
 	return true, nil
 }
 
 func (c *InmemCache[T]) GetUnset(key string, value *T) (bool, error) {
 
-	item, found := c.cache.GetAndDelete(key)
-	if !found || item == nil || item.IsExpired() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry := c.find(key)
+	if entry == nil {
+		c.stats.Misses++
 		return false, nil
 	}
+	c.stats.Hits++
+	c.remove(entry)
 
-	*value = item.Value()
+	*value = entry.value
 	return true, nil
 }
 
 func (c *InmemCache[T]) Unset(key string) error {
 
-	c.cache.Delete(key)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.entries[key]
+	if ok {
+		c.remove(entry)
+	}
 
 	return nil
 }
 
 func (c *InmemCache[T]) Clear() error {
 
-	c.cache.DeleteAll()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.reset()
 
 	return nil
 }
 
 func (c *InmemCache[T]) Touch(key string) error {
 
-	c.cache.Touch(key)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry := c.find(key)
+	if entry != nil && entry.ttl > 0 {
+		entry.expiresAt = time.Now().Add(entry.ttl)
+	}
 
 	return nil
 }
 
-func (c *InmemCache[T]) Start() {
-	c.running.Store(true)
-	go c.cache.Start()
+func (c *InmemCache[T]) Keys() ([]string, error) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	keys := make([]string, 0, len(c.entries))
+	for key, entry := range c.entries {
+		if !entry.expired(now) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
 }
 
-func (c *InmemCache[T]) Stop() {
-	if c.running.Load() {
-		c.cache.Stop()
+// Remove expired entries.
+func (c *InmemCache[T]) DeleteExpired() {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	for _, entry := range c.entries {
+		if entry.expired(now) {
+			c.remove(entry)
+			c.stats.Expirations++
+		}
 	}
-	c.running.Store(false)
 }
 
-func (c *InmemCache[T]) Keys() ([]string, error) {
+func (c *InmemCache[T]) Start() {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.running {
+		return
+	}
+	c.running = true
+	c.stop = make(chan struct{})
 
-	keys := c.cache.Keys()
+	go func(stop chan struct{}, interval time.Duration) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				c.DeleteExpired()
+			}
+		}
+	}(c.stop, time.Duration(c.CLEANUP_INTERVAL_SECONDS)*time.Second)
+}
 
-	return keys, nil
+func (c *InmemCache[T]) Stop() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.running {
+		close(c.stop)
+	}
+	c.running = false
+}
+
+func defaultSize[T any](key string, value T) int64 {
+	size := entryOverhead + int64(len(key))
+	switch v := any(value).(type) {
+	case string:
+		size += int64(len(v))
+	case []byte:
+		size += int64(len(v))
+	default:
+		t := reflect.TypeOf(value)
+		if t != nil {
+			size += int64(t.Size())
+		}
+	}
+	return size
 }
@@ -0,0 +1,98 @@
+package inmem_cache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/evgeniums/go-utils/pkg/cache"
+	"github.com/evgeniums/go-utils/pkg/utils"
+)
+
+type inmemLockEntry struct {
+	token     string
+	expiresAt time.Time
+}
+
+// InmemLocker is an in-process locker that can be used in single-node deployments.
+type InmemLocker struct {
+	mutex sync.Mutex
+	locks map[string]*inmemLockEntry
+}
+
+type InmemLock struct {
+	locker      *InmemLocker
+	key         string
+	token       string
+	notObtained bool
+}
+
+func NewLocker() *InmemLocker {
+	l := &InmemLocker{}
+	l.locks = make(map[string]*inmemLockEntry)
+	return l
+}
+
+func (l *InmemLocker) Lock(key string, ttl time.Duration) (cache.Lock, error) {
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	lock := &InmemLock{locker: l, key: key}
+
+	now := time.Now()
+	entry, ok := l.locks[key]
+	if ok && entry.expiresAt.After(now) {
+		lock.notObtained = true
+		return lock, nil
+	}
+
+	lock.token = utils.GenerateID()
+	l.locks[key] = &inmemLockEntry{token: lock.token, expiresAt: now.Add(ttl)}
+
+	// remove expired locks
+	for k, e := range l.locks {
+		if !e.expiresAt.After(now) {
+			delete(l.locks, k)
+		}
+	}
+
+	return lock, nil
+}
+
+func (l *InmemLock) NotObtained() bool {
+	return l.notObtained
+}
+
+func (l *InmemLock) Release() error {
+
+	if l.notObtained {
+		return nil
+	}
+
+	l.locker.mutex.Lock()
+	defer l.locker.mutex.Unlock()
+
+	entry, ok := l.locker.locks[l.key]
+	if ok && entry.token == l.token {
+		delete(l.locker.locks, l.key)
+	}
+	return nil
+}
+
+func (l *InmemLock) Renew(ttl time.Duration) error {
+
+	if l.notObtained {
+		return cache.ErrLockNotHeld
+	}
+
+	l.locker.mutex.Lock()
+	defer l.locker.mutex.Unlock()
+
+	now := time.Now()
+	entry, ok := l.locker.locks[l.key]
+	if !ok || entry.token != l.token || !entry.expiresAt.After(now) {
+		return cache.ErrLockNotHeld
+	}
+	entry.expiresAt = now.Add(ttl)
+	return nil
+}
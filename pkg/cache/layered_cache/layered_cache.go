@@ -0,0 +1,356 @@
+package layered_cache
+
+import (
+	"hash/fnv"
+	"strings"
+	"sync"
+
+	"github.com/evgeniums/go-utils/pkg/cache"
+	"github.com/evgeniums/go-utils/pkg/cache/inmem_cache"
+	"github.com/evgeniums/go-utils/pkg/config"
+	"github.com/evgeniums/go-utils/pkg/config/object_config"
+	"github.com/evgeniums/go-utils/pkg/logger"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+	"github.com/evgeniums/go-utils/pkg/pubsub/pubsub_subscriber"
+	"github.com/evgeniums/go-utils/pkg/utils"
+	"github.com/evgeniums/go-utils/pkg/validator"
+)
+
+const LayeredCacheConfigPath = "layered_cache"
+
+type LayeredCacheConfig struct {
+	L1_TTL_SECONDS   int    `default:"30" validate:"gt=0"`
+	TOPIC            string `default:"cache_invalidation" validate:"required"`
+	L2_ONLY_PREFIXES []string
+}
+
+// Replay nonces and TOTP counters are only written and checked with atomic operations, they are never read from L1 of other instances.
+var DefaultL2OnlyPrefixes = []string{"auth-nonce/", "totp-last/", "totp-tries/"}
+
+type InvalidationMessage struct {
+	Instance string   `json:"instance"`
+	Keys     []string `json:"keys,omitempty"`
+	Clear    bool     `json:"clear,omitempty"`
+}
+
+func NewInvalidationMessage() *InvalidationMessage {
+	return &InvalidationMessage{}
+}
+
+// Subset of pool_pubsub.PoolPubsub used for invalidation of L1 entries.
+type SelfPoolPubsub interface {
+	PublishSelfPool(topicName string, msg interface{}) error
+	SubscribeSelfPool(ctx op_context.Context, topic pubsub_subscriber.Topic) (string, error)
+	UnsubscribeSelfPool(topicName string)
+}
+
+// LayeredCache is a string cache with bounded in-memory L1 in front of shared L2, e.g. Redis.
+// Limits and eviction policy of L1 are configured in "l1" section of layered cache configuration.
+//
+// Reads are served from L1 when possible. Writes go to L2 and to local L1, then invalidation message is published to self pool,
+// so that other instances evict their L1 copies. Entries stay in L1 at most L1_TTL_SECONDS, which limits staleness
+// if invalidation message is lost or pubsub is not enabled. Pubsub provider must deliver messages to each instance,
+// so redis_streams provider must not be used in queue delivery mode.
+//
+// Keys with one of L2_ONLY_PREFIXES are neither kept in L1 nor published for invalidation, by default DefaultL2OnlyPrefixes are used.
+type LayeredCache struct {
+	LayeredCacheConfig
+
+	l1 *inmem_cache.InmemCache[string]
+	l2 cache.StringCache
+
+	// generations of keys are changed on each change of L1, so that values read from L2 before invalidation are not put to L1
+	generations [generationStripes]keyGeneration
+
+	instance string
+
+	mutex  sync.RWMutex
+	pubsub SelfPoolPubsub
+	log    logger.Logger
+}
+
+const generationStripes = 256
+
+type keyGeneration struct {
+	mutex      sync.Mutex
+	generation uint64
+}
+
+func New(l2 cache.StringCache) *LayeredCache {
+	l := &LayeredCache{l2: l2}
+	l.instance = utils.GenerateID()
+	l.L1_TTL_SECONDS = 30
+	l.TOPIC = "cache_invalidation"
+	l.L2_ONLY_PREFIXES = DefaultL2OnlyPrefixes
+	l.l1 = inmem_cache.New[string](inmem_cache.InmemCacheConfig{MAX_ENTRIES: 10000})
+	return l
+}
+
+func (l *LayeredCache) Config() interface{} {
+	return &l.LayeredCacheConfig
+}
+
+func (l *LayeredCache) Init(cfg config.Config, log logger.Logger, vld validator.Validator, configPath ...string) error {
+
+	path := utils.OptionalArg(LayeredCacheConfigPath, configPath...)
+	err := object_config.LoadLogValidate(cfg, log, vld, l, path)
+	if err != nil {
+		return log.PushFatalStack("failed to load configuration of layered cache", err)
+	}
+	l.log = log
+
+	err = l.l1.Init(cfg, log, vld, object_config.Key(path, "l1"))
+	if err != nil {
+		return log.PushFatalStack("failed to init L1 of layered cache", err)
+	}
+
+	return nil
+}
+
+// Subscribe to invalidation messages from other instances and start publishing own invalidations to self pool.
+func (l *LayeredCache) EnableInvalidation(ctx op_context.Context, pubsub SelfPoolPubsub) error {
+
+	c := ctx.TraceInMethod("LayeredCache.EnableInvalidation", logger.Fields{"topic": l.TOPIC})
+	defer ctx.TraceOutMethod()
+
+	topic := pubsub_subscriber.New(l.TOPIC, NewInvalidationMessage)
+	handler := &invalidationHandler{cache: l}
+	handler.Init("layered_cache")
+	topic.Subscribe(handler)
+	_, err := pubsub.SubscribeSelfPool(ctx, topic)
+	if err != nil {
+		return c.SetError(err)
+	}
+
+	l.mutex.Lock()
+	l.pubsub = pubsub
+	l.mutex.Unlock()
+
+	return nil
+}
+
+func (l *LayeredCache) publish(msg *InvalidationMessage) {
+
+	l.mutex.RLock()
+	pubsub := l.pubsub
+	l.mutex.RUnlock()
+	if pubsub == nil {
+		return
+	}
+
+	msg.Instance = l.instance
+	err := pubsub.PublishSelfPool(l.TOPIC, msg)
+	if err != nil && l.log != nil {
+		l.log.Error("failed to publish cache invalidation", err, logger.Fields{"topic": l.TOPIC, "keys": msg.Keys, "clear": msg.Clear})
+	}
+}
+
+// Check if key must bypass L1.
+func (l *LayeredCache) l2Only(key string) bool {
+	for _, prefix := range l.L2_ONLY_PREFIXES {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *LayeredCache) l1Ttl(ttlSeconds ...int) int {
+	ttl := utils.OptionalArg(0, ttlSeconds...)
+	if ttl <= 0 || ttl > l.L1_TTL_SECONDS {
+		return l.L1_TTL_SECONDS
+	}
+	return ttl
+}
+
+// Generation of key. Keys are hashed to a fixed number of stripes, so keys of the same stripe share generation.
+func (l *LayeredCache) generation(key string) *keyGeneration {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return &l.generations[h.Sum32()%generationStripes]
+}
+
+// Set or unset L1 entry and change generation of key.
+func (l *LayeredCache) updateL1(key string, value *string, ttlSeconds int) {
+	g := l.generation(key)
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.generation++
+	if value != nil {
+		l.l1.Set(key, *value, ttlSeconds)
+	} else {
+		l.l1.Unset(key)
+	}
+}
+
+func (l *LayeredCache) clearL1() {
+	for i := range l.generations {
+		l.generations[i].mutex.Lock()
+		l.generations[i].generation++
+	}
+	l.l1.Clear()
+	for i := range l.generations {
+		l.generations[i].mutex.Unlock()
+	}
+}
+
+func (l *LayeredCache) Set(key string, value string, ttlSeconds ...int) error {
+
+	if l.l2Only(key) {
+		return l.l2.Set(key, value, ttlSeconds...)
+	}
+
+	err := l.l2.Set(key, value, ttlSeconds...)
+	if err != nil {
+		l.updateL1(key, nil, 0)
+		return err
+	}
+
+	l.updateL1(key, &value, l.l1Ttl(ttlSeconds...))
+	l.publish(&InvalidationMessage{Keys: []string{key}})
+	return nil
+}
+
+func (l *LayeredCache) SetNX(key string, value string, ttlSeconds ...int) (bool, error) {
+
+	if l.l2Only(key) {
+		return l.l2.SetNX(key, value, ttlSeconds...)
+	}
+
+	set, err := l.l2.SetNX(key, value, ttlSeconds...)
+	if err != nil || !set {
+		return set, err
+	}
+
+	l.updateL1(key, &value, l.l1Ttl(ttlSeconds...))
+	l.publish(&InvalidationMessage{Keys: []string{key}})
+	return true, nil
+}
+
+func (l *LayeredCache) Get(key string, value *string) (bool, error) {
+
+	if l.l2Only(key) {
+		return l.l2.Get(key, value)
+	}
+
+	found, _ := l.l1.Get(key, value)
+	if found {
+		return true, nil
+	}
+
+	// value is not put to L1 if the key was invalidated while reading L2
+	g := l.generation(key)
+	g.mutex.Lock()
+	generation := g.generation
+	g.mutex.Unlock()
+
+	found, err := l.l2.Get(key, value)
+	if err != nil || !found {
+		return found, err
+	}
+
+	g.mutex.Lock()
+	if g.generation == generation {
+		l.l1.Set(key, *value, l.L1_TTL_SECONDS)
+	}
+	g.mutex.Unlock()
+	return true, nil
+}
+
+func (l *LayeredCache) GetUnset(key string, value *string) (bool, error) {
+
+	if l.l2Only(key) {
+		return l.l2.GetUnset(key, value)
+	}
+
+	l.updateL1(key, nil, 0)
+	found, err := l.l2.GetUnset(key, value)
+	if err != nil {
+		return false, err
+	}
+	if found {
+		l.publish(&InvalidationMessage{Keys: []string{key}})
+	}
+	return found, nil
+}
+
+func (l *LayeredCache) Unset(key string) error {
+
+	if l.l2Only(key) {
+		return l.l2.Unset(key)
+	}
+
+	l.updateL1(key, nil, 0)
+	err := l.l2.Unset(key)
+	if err != nil {
+		return err
+	}
+	l.publish(&InvalidationMessage{Keys: []string{key}})
+	return nil
+}
+
+func (l *LayeredCache) Clear() error {
+
+	l.clearL1()
+	err := l.l2.Clear()
+	if err != nil {
+		return err
+	}
+	l.publish(&InvalidationMessage{Clear: true})
+	return nil
+}
+
+func (l *LayeredCache) Touch(key string) error {
+	l.l1.Touch(key)
+	return l.l2.Touch(key)
+}
+
+func (l *LayeredCache) Keys() ([]string, error) {
+	return l.l2.Keys()
+}
+
+// Evict entries from L1 only.
+func (l *LayeredCache) Evict(keys ...string) {
+	for _, key := range keys {
+		l.updateL1(key, nil, 0)
+	}
+}
+
+func (l *LayeredCache) Start() {
+	l.l1.Start()
+}
+
+func (l *LayeredCache) Stop() {
+	l.l1.Stop()
+	l.mutex.Lock()
+	if l.pubsub != nil {
+		l.pubsub.UnsubscribeSelfPool(l.TOPIC)
+		l.pubsub = nil
+	}
+	l.mutex.Unlock()
+}
+
+type invalidationHandler struct {
+	pubsub_subscriber.SubscriberClientBase
+	cache *LayeredCache
+}
+
+func (h *invalidationHandler) Handle(ctx op_context.Context, msg *InvalidationMessage) error {
+
+	// own invalidations are already applied
+	if msg.Instance == h.cache.instance {
+		return nil
+	}
+
+	if msg.Clear {
+		h.cache.clearL1()
+		return nil
+	}
+	h.cache.Evict(msg.Keys...)
+	return nil
+}
+
+// Statistics of L1.
+func (l *LayeredCache) L1Stats() inmem_cache.InmemCacheStats {
+	return l.l1.Stats()
+}
@@ -0,0 +1,223 @@
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/evgeniums/go-utils/pkg/utils"
+)
+
+const tagKeyPrefix = "cache_tag:"
+
+func TenancyTag(tenancyId string) string {
+	return utils.ConcatStrings("tenancy:", tenancyId)
+}
+
+func UserTag(userId string) string {
+	return utils.ConcatStrings("user:", userId)
+}
+
+type loadingEntry[T any] struct {
+	Value      T                 `json:"value"`
+	FreshUntil int64             `json:"fresh_until,omitempty"`
+	Tags       map[string]string `json:"tags,omitempty"`
+}
+
+type loadingCall struct {
+	wg    sync.WaitGroup
+	value interface{}
+	err   error
+}
+
+// LoadingCache implements cache-aside pattern on top of Cache.
+//
+// Concurrent loads of the same key within the process are deduplicated, so that only one loader is invoked and the others wait for its result.
+// If StaleSeconds is set then entries are kept in cache for that long after their TTL expires. Stale entry is returned immediately
+// while it is reloaded in background.
+//
+// Entries can be tagged. Invalidation of a tag drops all entries loaded with that tag. Tags are implemented with versions stored in cache,
+// so invalidation works the same way for in-memory and Redis backends.
+type LoadingCache struct {
+	cache        Cache
+	StaleSeconds int
+
+	mutex sync.Mutex
+	calls map[string]*loadingCall
+}
+
+func NewLoadingCache(cache Cache, staleSeconds ...int) *LoadingCache {
+	c := &LoadingCache{cache: cache}
+	c.StaleSeconds = utils.OptionalArg(0, staleSeconds...)
+	c.calls = make(map[string]*loadingCall)
+	return c
+}
+
+func (c *LoadingCache) Cache() Cache {
+	return c.cache
+}
+
+// Drop cached entry.
+func (c *LoadingCache) Invalidate(key string) error {
+	return c.cache.Unset(key)
+}
+
+// Drop all entries loaded with any of the tags.
+func (c *LoadingCache) InvalidateTags(tags ...string) error {
+	for _, tag := range tags {
+		err := c.cache.Set(tagKey(tag), utils.GenerateID())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func tagKey(tag string) string {
+	return utils.ConcatStrings(tagKeyPrefix, tag)
+}
+
+// Get versions of tags. Version of tag that is not in cache yet is initialized with random value.
+func (c *LoadingCache) tagVersions(tags []string) (map[string]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	versions := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		var version string
+		found, err := c.cache.Get(tagKey(tag), &version)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			version = utils.GenerateID()
+			ok, err := c.cache.SetNX(tagKey(tag), version)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				// version was initialized concurrently
+				found, err = c.cache.Get(tagKey(tag), &version)
+				if err != nil {
+					return nil, err
+				}
+				if !found {
+					version = ""
+				}
+			}
+		}
+		versions[tag] = version
+	}
+	return versions, nil
+}
+
+// Check if versions of tags were not changed. Missing tag is treated as invalidated, e.g. if it was evicted from cache.
+func (c *LoadingCache) tagsValid(versions map[string]string) (bool, error) {
+	for tag, version := range versions {
+		var current string
+		found, err := c.cache.Get(tagKey(tag), &current)
+		if err != nil {
+			return false, err
+		}
+		if !found || version == "" || current != version {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Invoke function once for all concurrent callers with the same key.
+func (c *LoadingCache) singleFlight(key string, fn func() (interface{}, error)) (interface{}, error) {
+
+	c.mutex.Lock()
+	call, ok := c.calls[key]
+	if ok {
+		c.mutex.Unlock()
+		call.wg.Wait()
+		return call.value, call.err
+	}
+	call = &loadingCall{}
+	call.wg.Add(1)
+	c.calls[key] = call
+	c.mutex.Unlock()
+
+	defer func() {
+		c.mutex.Lock()
+		delete(c.calls, key)
+		c.mutex.Unlock()
+		call.wg.Done()
+	}()
+
+	call.value, call.err = fn()
+	return call.value, call.err
+}
+
+func (c *LoadingCache) isLoading(key string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	_, ok := c.calls[key]
+	return ok
+}
+
+func load[T any](c *LoadingCache, key string, loader func() (T, error), ttlSeconds int, tags []string) (T, error) {
+
+	result, err := c.singleFlight(key, func() (interface{}, error) {
+
+		// versions must be read before loading, so that invalidation during loading is not lost
+		versions, err := c.tagVersions(tags)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := loader()
+		if err != nil {
+			return nil, err
+		}
+
+		entry := &loadingEntry[T]{Value: value, Tags: versions}
+		if ttlSeconds > 0 {
+			entry.FreshUntil = time.Now().Add(time.Duration(ttlSeconds) * time.Second).UnixNano()
+			err = c.cache.Set(key, entry, ttlSeconds+c.StaleSeconds)
+		} else {
+			err = c.cache.Set(key, entry)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return value, nil
+	})
+
+	if err != nil {
+		var empty T
+		return empty, err
+	}
+	value, _ := result.(T)
+	return value, nil
+}
+
+// GetOrLoad returns value from cache or loads it with loader and puts to cache with given TTL.
+// Zero TTL means that value never expires. Entry is tagged with optional tags.
+func GetOrLoad[T any](c *LoadingCache, key string, loader func() (T, error), ttlSeconds int, tags ...string) (T, error) {
+
+	entry := &loadingEntry[T]{}
+	found, err := c.cache.Get(key, entry)
+	if err == nil && found {
+		valid, err := c.tagsValid(entry.Tags)
+		if err == nil && valid && len(entry.Tags) == len(tags) {
+
+			if entry.FreshUntil == 0 || time.Now().UnixNano() < entry.FreshUntil {
+				return entry.Value, nil
+			}
+
+			// return stale value and reload it in background
+			if c.StaleSeconds > 0 {
+				if !c.isLoading(key) {
+					go load(c, key, loader, ttlSeconds, tags)
+				}
+				return entry.Value, nil
+			}
+		}
+	}
+
+	return load(c, key, loader, ttlSeconds, tags)
+}
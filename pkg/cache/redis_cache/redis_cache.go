@@ -24,9 +24,9 @@ func (r *RedisCache) Set(key string, value string, ttlSeconds ...int) error {
 
 	if len(ttlSeconds) > 0 {
 		ttl := time.Second * time.Duration(ttlSeconds[0])
-		r.NativeHandler().SetEx(r.Context(), key, value, ttl).Err()
+		err = r.NativeHandler().SetEx(r.Context(), key, value, ttl).Err()
 	} else {
-		r.NativeHandler().Set(r.Context(), key, value, 0).Err()
+		err = r.NativeHandler().Set(r.Context(), key, value, 0).Err()
 	}
 
 	if err != nil {
@@ -36,6 +36,16 @@ func (r *RedisCache) Set(key string, value string, ttlSeconds ...int) error {
 	return nil
 }
 
+func (r *RedisCache) SetNX(key string, value string, ttlSeconds ...int) (bool, error) {
+
+	var ttl time.Duration
+	if len(ttlSeconds) > 0 {
+		ttl = time.Second * time.Duration(ttlSeconds[0])
+	}
+
+	return r.NativeHandler().SetNX(r.Context(), key, value, ttl).Result()
+}
+
 func (r *RedisCache) Get(key string, value *string) (bool, error) {
 
 	var err error
@@ -29,6 +29,20 @@ func (r *RedisLock) Release() error {
 	return nil
 }
 
+func (r *RedisLock) Renew(ttl time.Duration) error {
+	if r.lock == nil {
+		return cache.ErrLockNotHeld
+	}
+	err := r.lock.Refresh(r.locker.Context(), ttl, nil)
+	if err != nil {
+		if err == redislock.ErrNotObtained {
+			return cache.ErrLockNotHeld
+		}
+		return err
+	}
+	return nil
+}
+
 func (r *RedisLock) NotObtained() bool {
 	return r.notObtained
 }
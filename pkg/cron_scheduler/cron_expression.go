@@ -0,0 +1,247 @@
+package cron_scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type cronField struct {
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	minuteField = cronField{min: 0, max: 59}
+	hourField   = cronField{min: 0, max: 23}
+	domField    = cronField{min: 1, max: 31}
+	monthField  = cronField{min: 1, max: 12, names: map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}}
+	dowField = cronField{min: 0, max: 7, names: map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}}
+)
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// CronExpression is a parsed standard 5-field cron expression: minute, hour, day of month, month and day of week.
+// Lists, ranges, steps, names of months and days of week, as well as macros like @daily are supported.
+type CronExpression struct {
+	expr string
+
+	minutes uint64
+	hours   uint64
+	dom     uint64
+	months  uint64
+	dow     uint64
+
+	domRestricted bool
+	dowRestricted bool
+}
+
+func ParseCronExpression(expr string) (*CronExpression, error) {
+
+	e := &CronExpression{expr: expr}
+
+	str := strings.TrimSpace(expr)
+	if macro, ok := cronMacros[strings.ToLower(str)]; ok {
+		str = macro
+	}
+
+	fields := strings.Fields(str)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields", expr)
+	}
+
+	var err error
+	if e.minutes, err = minuteField.parse(fields[0]); err != nil {
+		return nil, fmt.Errorf("invalid minutes in cron expression %q: %s", expr, err)
+	}
+	if e.hours, err = hourField.parse(fields[1]); err != nil {
+		return nil, fmt.Errorf("invalid hours in cron expression %q: %s", expr, err)
+	}
+	if e.dom, err = domField.parse(fields[2]); err != nil {
+		return nil, fmt.Errorf("invalid day of month in cron expression %q: %s", expr, err)
+	}
+	if e.months, err = monthField.parse(fields[3]); err != nil {
+		return nil, fmt.Errorf("invalid month in cron expression %q: %s", expr, err)
+	}
+	if e.dow, err = dowField.parse(fields[4]); err != nil {
+		return nil, fmt.Errorf("invalid day of week in cron expression %q: %s", expr, err)
+	}
+
+	// 7 is an alias of sunday
+	if e.dow&(1<<7) != 0 {
+		e.dow |= 1
+		e.dow &^= 1 << 7
+	}
+
+	e.domRestricted = fields[2] != "*" && fields[2] != "?"
+	e.dowRestricted = fields[4] != "*" && fields[4] != "?"
+
+	return e, nil
+}
+
+func (e *CronExpression) String() string {
+	return e.expr
+}
+
+func (f *cronField) value(str string) (int, error) {
+	if f.names != nil {
+		if v, ok := f.names[strings.ToUpper(str)]; ok {
+			return v, nil
+		}
+	}
+	v, err := strconv.Atoi(str)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", str)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %d out of range [%d,%d]", v, f.min, f.max)
+	}
+	return v, nil
+}
+
+func (f *cronField) parse(str string) (uint64, error) {
+
+	var bits uint64
+	for _, part := range strings.Split(str, ",") {
+
+		step := 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[idx+1:])
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			part = part[:idx]
+		}
+
+		from, to := f.min, f.max
+		if part != "*" && part != "?" {
+			if idx := strings.Index(part, "-"); idx >= 0 {
+				var err error
+				if from, err = f.value(part[:idx]); err != nil {
+					return 0, err
+				}
+				if to, err = f.value(part[idx+1:]); err != nil {
+					return 0, err
+				}
+				if from > to {
+					return 0, fmt.Errorf("invalid range %q", part)
+				}
+			} else {
+				var err error
+				if from, err = f.value(part); err != nil {
+					return 0, err
+				}
+				if step == 1 {
+					to = from
+				}
+			}
+		}
+
+		for v := from; v <= to; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+func has(bits uint64, v int) bool {
+	return bits&(1<<uint(v)) != 0
+}
+
+func (e *CronExpression) matchDay(t time.Time) bool {
+	domMatch := has(e.dom, t.Day())
+	dowMatch := has(e.dow, int(t.Weekday()))
+	if e.domRestricted && e.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// Next returns the first time strictly after t matching the expression, in location of t.
+// Expression is matched against wall clock of the location. Wall clock times skipped by transition to daylight saving time
+// are matched at the first minute after the transition, so that such jobs are delayed rather than skipped.
+// Wall clock times repeated by transition from daylight saving time are matched at each occurrence.
+// Zero time is returned if there is no matching time within five years.
+func (e *CronExpression) Next(t time.Time) time.Time {
+
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+
+		if !has(e.months, int(t.Month())) {
+			t = startOfDay(t.Year(), t.Month()+1, 1, loc)
+			continue
+		}
+
+		if !e.matchDay(t) {
+			t = startOfDay(t.Year(), t.Month(), t.Day()+1, loc)
+			continue
+		}
+
+		if e.matchGap(t) {
+			return t
+		}
+
+		if !has(e.hours, t.Hour()) {
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+			continue
+		}
+
+		if !has(e.minutes, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+// Check if t is the first minute after transition to daylight saving time and any of skipped wall clock times matches hours and minutes.
+func (e *CronExpression) matchGap(t time.Time) bool {
+
+	_, before := t.Add(-time.Minute).Zone()
+	_, after := t.Zone()
+	if after <= before {
+		return false
+	}
+
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	for skipped := wall.Add(-time.Duration(after-before) * time.Second); skipped.Before(wall); skipped = skipped.Add(time.Minute) {
+		if has(e.hours, skipped.Hour()) && has(e.minutes, skipped.Minute()) {
+			return true
+		}
+	}
+	return false
+}
+
+// First instant of the day in location. If midnight is skipped by transition to daylight saving time then
+// the first minute after the transition is returned.
+func startOfDay(year int, month time.Month, day int, loc *time.Location) time.Time {
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	t := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	for t.Day() != date.Day() {
+		t = t.Add(time.Minute)
+	}
+	return t
+}
@@ -0,0 +1,561 @@
+package cron_scheduler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/evgeniums/go-utils/pkg/app_context"
+	"github.com/evgeniums/go-utils/pkg/background_worker"
+	"github.com/evgeniums/go-utils/pkg/cache"
+	"github.com/evgeniums/go-utils/pkg/cache/cache_locker"
+	"github.com/evgeniums/go-utils/pkg/cache/db_locker"
+	"github.com/evgeniums/go-utils/pkg/common"
+	"github.com/evgeniums/go-utils/pkg/config"
+	"github.com/evgeniums/go-utils/pkg/config/object_config"
+	"github.com/evgeniums/go-utils/pkg/crud"
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/logger"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+	"github.com/evgeniums/go-utils/pkg/op_context/default_op_context"
+	"github.com/evgeniums/go-utils/pkg/utils"
+	"github.com/evgeniums/go-utils/pkg/validator"
+)
+
+const ErrorCodeCronJobNotFound = "cron_job_not_found"
+const ErrorCodeCronJobBusy = "cron_job_busy"
+
+var ErrorDescriptions = map[string]string{
+	ErrorCodeCronJobNotFound: "Cron job not found",
+	ErrorCodeCronJobBusy:     "Cron job is already running",
+}
+
+var ErrorHttpCodes = map[string]int{
+	ErrorCodeCronJobNotFound: http.StatusNotFound,
+	ErrorCodeCronJobBusy:     http.StatusConflict,
+}
+
+// Policies of handling runs missed while scheduler was not running.
+const (
+	// Skip missed runs unless the latest missed run is late for less than misfire threshold.
+	MissedRunsSkip string = "skip"
+	// Run job once for all missed runs.
+	MissedRunsOnce string = "once"
+	// Run job for each missed run up to MAX_CATCH_UP_RUNS.
+	MissedRunsAll string = "all"
+)
+
+// Handler of cron job. The time the run was scheduled for is passed as scheduledTime.
+type CronJobHandler func(ctx op_context.Context, scheduledTime time.Time) error
+
+type CronJobState struct {
+	common.ObjectBase
+	Name            string    `gorm:"uniqueIndex" json:"name"`
+	Schedule        string    `json:"schedule"`
+	Timezone        string    `json:"timezone"`
+	Paused          bool      `gorm:"index" json:"paused"`
+	NextRunAt       time.Time `gorm:"index" json:"next_run_at"`
+	LastRunAt       time.Time `json:"last_run_at"`
+	LastScheduledAt time.Time `json:"last_scheduled_at"`
+	LastDurationMs  int64     `json:"last_duration_ms"`
+	LastError       string    `json:"last_error"`
+	RunCount        int       `json:"run_count"`
+	FailCount       int       `json:"fail_count"`
+}
+
+// Models of cron scheduler including lock table used by database locker.
+func DbModels() []interface{} {
+	return append([]interface{}{&CronJobState{}}, db_locker.DbModels()...)
+}
+
+type CronJobConfig struct {
+	SCHEDULE                  string `validate:"required"`
+	TIMEZONE                  string `default:"UTC"`
+	MISSED_RUNS               string `default:"skip" validate:"oneof=skip once all"`
+	MISFIRE_THRESHOLD_SECONDS int    `default:"60" validate:"gte=0"`
+	MAX_CATCH_UP_RUNS         int    `default:"100" validate:"gte=1"`
+	DISABLED                  bool
+}
+
+type CronJob struct {
+	CronJobConfig
+	name       string
+	handler    CronJobHandler
+	expression *CronExpression
+	location   *time.Location
+}
+
+func (j *CronJob) Config() interface{} {
+	return &j.CronJobConfig
+}
+
+func (j *CronJob) Name() string {
+	return j.name
+}
+
+func (j *CronJob) Expression() *CronExpression {
+	return j.expression
+}
+
+func (j *CronJob) Location() *time.Location {
+	return j.location
+}
+
+func (j *CronJob) Init(cfg config.Config, log logger.Logger, vld validator.Validator, configPath string) error {
+
+	err := object_config.LoadLogValidate(cfg, log, vld, j, configPath)
+	if err != nil {
+		return err
+	}
+
+	j.expression, err = ParseCronExpression(j.SCHEDULE)
+	if err != nil {
+		return err
+	}
+
+	j.location, err = time.LoadLocation(j.TIMEZONE)
+	if err != nil {
+		return fmt.Errorf("invalid timezone %q: %s", j.TIMEZONE, err)
+	}
+
+	return nil
+}
+
+// Next run of job after given time.
+func (j *CronJob) Next(t time.Time) time.Time {
+	return j.expression.Next(t.In(j.location))
+}
+
+// Runs that must be executed at given time according to missed runs policy.
+func (j *CronJob) dueRuns(nextRunAt time.Time, now time.Time) []time.Time {
+
+	runs := make([]time.Time, 0)
+	if nextRunAt.IsZero() || nextRunAt.After(now) {
+		return runs
+	}
+
+	if j.MISSED_RUNS == MissedRunsAll {
+		for t := nextRunAt; !t.IsZero() && !t.After(now) && len(runs) < j.MAX_CATCH_UP_RUNS; t = j.Next(t) {
+			runs = append(runs, t)
+		}
+		return runs
+	}
+
+	// find the latest missed run
+	latest := nextRunAt
+	for t := j.Next(latest); !t.IsZero() && !t.After(now); t = j.Next(t) {
+		latest = t
+	}
+
+	if j.MISSED_RUNS == MissedRunsSkip && now.Sub(latest) > time.Duration(j.MISFIRE_THRESHOLD_SECONDS)*time.Second {
+		return runs
+	}
+
+	return append(runs, latest)
+}
+
+type CronSchedulerConfig struct {
+	PERIOD           int    `default:"10"`
+	LOCK_TTL_SECONDS int    `default:"300" validate:"gt=0"`
+	LOCKER           string `default:"redis" validate:"oneof=redis inmem db"`
+}
+
+type CronScheduler struct {
+	CronSchedulerConfig
+	app_context.WithAppBase
+	crud.WithCRUDBase
+	background_worker.JobRunnerBase
+
+	name    string
+	jobs    map[string]*CronJob
+	locker  cache.Locker
+	running atomic.Bool
+}
+
+func NewCronScheduler(name string, cruds ...crud.CRUD) *CronScheduler {
+	s := &CronScheduler{name: name}
+	s.WithCRUDBase.Construct(cruds...)
+	s.jobs = make(map[string]*CronJob)
+	return s
+}
+
+func (s *CronScheduler) Config() interface{} {
+	return &s.CronSchedulerConfig
+}
+
+// Register job. Schedule can be overriden in configuration section jobs.<name>. Must be called before Init.
+func (s *CronScheduler) RegisterJob(name string, schedule string, handler CronJobHandler) {
+	job := &CronJob{name: name, handler: handler}
+	job.SCHEDULE = schedule
+	s.jobs[name] = job
+}
+
+// Set locker of jobs. Must be called before Init, otherwise locker is created according to configuration.
+func (s *CronScheduler) SetLocker(locker cache.Locker) {
+	s.locker = locker
+}
+
+func (s *CronScheduler) Locker() cache.Locker {
+	return s.locker
+}
+
+func (s *CronScheduler) Job(name string) (*CronJob, bool) {
+	job, ok := s.jobs[name]
+	return job, ok
+}
+
+// Names of registered jobs in alphabetical order.
+func (s *CronScheduler) JobNames() []string {
+	names := utils.AllMapKeys(s.jobs)
+	sort.Strings(names)
+	return names
+}
+
+func (s *CronScheduler) Init(app app_context.Context, configPath ...string) error {
+
+	s.WithAppBase.Init(app)
+
+	path := utils.OptionalArg("cron_scheduler", configPath...)
+	err := object_config.LoadLogValidateApp(app, s, path)
+	if err != nil {
+		return app.Logger().PushFatalStack("failed to load configuration of CronScheduler", err)
+	}
+
+	// load jobs
+	for _, name := range s.JobNames() {
+		job := s.jobs[name]
+		err = job.Init(app.Cfg(), app.Logger(), app.Validator(), object_config.Key(object_config.Key(path, "jobs"), name))
+		if err != nil {
+			return app.Logger().PushFatalStack("failed to init cron job", err, logger.Fields{"cron_job": name})
+		}
+	}
+
+	// init locker if it was not injected
+	if s.locker == nil {
+		s.locker, err = cache_locker.New(app, s.LOCKER)
+		if err != nil {
+			return app.Logger().PushFatalStack("failed to init locker for CronScheduler", err)
+		}
+	}
+
+	// sync states of jobs in database
+	ctx := default_op_context.BackgroundOpContext(app, s.name)
+	defer ctx.Close()
+	for _, name := range s.JobNames() {
+		err = s.syncState(ctx, s.jobs[name])
+		if err != nil {
+			return app.Logger().PushFatalStack("failed to sync state of cron job", err, logger.Fields{"cron_job": name})
+		}
+	}
+
+	// done
+	return nil
+}
+
+func (s *CronScheduler) StopJob() {
+}
+
+func (s *CronScheduler) RunJob() {
+	s.ProcessJobs()
+}
+
+// Create state of job in database or update it if schedule of job was changed.
+func (s *CronScheduler) syncState(ctx op_context.Context, job *CronJob) error {
+
+	c := ctx.TraceInMethod("CronScheduler.syncState", logger.Fields{"cron_job": job.name})
+	defer ctx.TraceOutMethod()
+
+	now := time.Now()
+	state := &CronJobState{}
+	found, err := s.CRUD().Read(ctx, db.Fields{"name": job.name}, state)
+	if err != nil {
+		c.SetMessage("failed to read state of cron job")
+		return c.SetError(err)
+	}
+
+	if !found {
+		state.InitObject()
+		state.Name = job.name
+		state.Schedule = job.SCHEDULE
+		state.Timezone = job.TIMEZONE
+		state.NextRunAt = job.Next(now)
+		_, err = s.CRUD().CreateDup(ctx, state, true)
+		if err != nil {
+			c.SetMessage("failed to create state of cron job")
+			return c.SetError(err)
+		}
+		return nil
+	}
+
+	if state.Schedule != job.SCHEDULE || state.Timezone != job.TIMEZONE || state.NextRunAt.IsZero() {
+		err = s.CRUD().Update(ctx, state, db.Fields{"schedule": job.SCHEDULE, "timezone": job.TIMEZONE, "next_run_at": job.Next(now)})
+		if err != nil {
+			c.SetMessage("failed to update state of cron job")
+			return c.SetError(err)
+		}
+	}
+
+	return nil
+}
+
+// Run all jobs that are due.
+func (s *CronScheduler) ProcessJobs() {
+
+	if !s.running.CompareAndSwap(false, true) {
+		return
+	}
+	defer s.running.Store(false)
+
+	ctx := default_op_context.BackgroundOpContext(s.App(), s.name)
+	ctx.SetWriteCloseLog(false)
+	defer ctx.Close()
+
+	now := time.Now()
+	var states []*CronJobState
+	filter := db.NewFilter()
+	filter.AddField("paused", false)
+	filter.AddInterval("next_run_at", nil, now)
+	_, err := s.CRUD().List(ctx, filter, &states)
+	if err != nil {
+		ctx.Logger().Error("failed to list due cron jobs", err)
+		return
+	}
+
+	for _, state := range states {
+		if s.Stopper() != nil && s.Stopper().IsStopped() {
+			break
+		}
+		job, ok := s.jobs[state.Name]
+		if !ok || job.DISABLED {
+			continue
+		}
+		s.runDueJob(ctx, job)
+	}
+}
+
+func (s *CronScheduler) lockJob(job *CronJob) (cache.Lock, error) {
+	return cache.LockObject(s.locker, "cron_job", job.name, s.LOCK_TTL_SECONDS)
+}
+
+// Run job under lock if it is still due after lock is obtained.
+func (s *CronScheduler) runDueJob(ctx op_context.Context, job *CronJob) {
+
+	c := ctx.TraceInMethod("CronScheduler.runDueJob", logger.Fields{"cron_job": job.name})
+	defer ctx.TraceOutMethod()
+
+	lock, err := s.lockJob(job)
+	if err != nil {
+		c.SetMessage("failed to lock cron job")
+		c.SetError(err)
+		return
+	}
+	if lock == nil {
+		// job is running on another instance
+		return
+	}
+	defer lock.Release()
+	lockTtl := time.Second * time.Duration(s.LOCK_TTL_SECONDS)
+	stopRenewal := cache.KeepLock(lock, lockTtl, lockTtl/2, func(err error) {
+		c.Logger().Error("failed to renew cron job lock", err)
+	})
+	defer stopRenewal()
+
+	// re-read state under lock
+	state := &CronJobState{}
+	found, err := s.CRUD().Read(ctx, db.Fields{"name": job.name}, state)
+	if err != nil {
+		c.SetMessage("failed to read state of cron job")
+		c.SetError(err)
+		return
+	}
+	if !found || state.Paused {
+		return
+	}
+
+	now := time.Now()
+	runs := job.dueRuns(state.NextRunAt, now)
+	if len(runs) == 0 && !state.NextRunAt.After(now) {
+		c.Logger().Warn("skipping missed cron job runs", logger.Fields{"missed_run_at": state.NextRunAt})
+	}
+
+	fields := db.Fields{}
+	executed := 0
+	for _, scheduledTime := range runs {
+		if s.Stopper() != nil && s.Stopper().IsStopped() {
+			break
+		}
+		s.execute(ctx, job, state, scheduledTime, fields)
+		executed++
+	}
+	if executed < len(runs) {
+		// runs were interrupted, remaining runs will be executed after restart
+		if executed == 0 {
+			return
+		}
+		fields["next_run_at"] = job.Next(runs[executed-1])
+	} else {
+		fields["next_run_at"] = job.Next(time.Now())
+	}
+
+	err = s.CRUD().Update(ctx, state, fields)
+	if err != nil {
+		c.SetMessage("failed to save state of cron job")
+		c.SetError(err)
+	}
+}
+
+// Execute handler of job and fill fields of job state to update.
+func (s *CronScheduler) execute(ctx op_context.Context, job *CronJob, state *CronJobState, scheduledTime time.Time, fields db.Fields) error {
+
+	c := ctx.TraceInMethod("CronScheduler.execute", logger.Fields{"scheduled_time": scheduledTime})
+	defer ctx.TraceOutMethod()
+
+	started := time.Now()
+	var err error
+	if job.handler == nil {
+		err = errors.New("handler of cron job is not set")
+	} else {
+		err = job.handler(ctx, scheduledTime)
+	}
+
+	state.RunCount++
+	state.LastRunAt = started
+	state.LastScheduledAt = scheduledTime
+	state.LastDurationMs = time.Since(started).Milliseconds()
+	state.LastError = ""
+	if err != nil {
+		state.FailCount++
+		state.LastError = err.Error()
+		c.SetMessage("cron job failed")
+		c.SetError(err)
+	}
+
+	fields["run_count"] = state.RunCount
+	fields["fail_count"] = state.FailCount
+	fields["last_run_at"] = state.LastRunAt
+	fields["last_scheduled_at"] = state.LastScheduledAt
+	fields["last_duration_ms"] = state.LastDurationMs
+	fields["last_error"] = state.LastError
+
+	return err
+}
+
+func (s *CronScheduler) findJob(ctx op_context.Context, name string) (*CronJob, error) {
+	job, ok := s.jobs[name]
+	if !ok {
+		ctx.SetGenericErrorCode(ErrorCodeCronJobNotFound)
+		return nil, errors.New("cron job not found")
+	}
+	return job, nil
+}
+
+// Run job immediately regardless of its schedule and pause. Next scheduled run is not changed.
+func (s *CronScheduler) Trigger(ctx op_context.Context, name string) error {
+
+	c := ctx.TraceInMethod("CronScheduler.Trigger", logger.Fields{"cron_job": name})
+	defer ctx.TraceOutMethod()
+
+	job, err := s.findJob(ctx, name)
+	if err != nil {
+		return c.SetError(err)
+	}
+
+	lock, err := s.lockJob(job)
+	if err != nil {
+		c.SetMessage("failed to lock cron job")
+		return c.SetError(err)
+	}
+	if lock == nil {
+		ctx.SetGenericErrorCode(ErrorCodeCronJobBusy)
+		return c.SetErrorStr("cron job is already running")
+	}
+	defer lock.Release()
+	lockTtl := time.Second * time.Duration(s.LOCK_TTL_SECONDS)
+	stopRenewal := cache.KeepLock(lock, lockTtl, lockTtl/2, func(err error) {
+		c.Logger().Error("failed to renew cron job lock", err)
+	})
+	defer stopRenewal()
+
+	state := &CronJobState{}
+	found, err := s.CRUD().Read(ctx, db.Fields{"name": name}, state)
+	if err != nil {
+		c.SetMessage("failed to read state of cron job")
+		return c.SetError(err)
+	}
+	if !found {
+		ctx.SetGenericErrorCode(ErrorCodeCronJobNotFound)
+		return c.SetErrorStr("state of cron job not found")
+	}
+
+	fields := db.Fields{}
+	runErr := s.execute(ctx, job, state, time.Now(), fields)
+	err = s.CRUD().Update(ctx, state, fields)
+	if err != nil {
+		c.SetMessage("failed to save state of cron job")
+		return c.SetError(err)
+	}
+
+	return runErr
+}
+
+// Pause or resume job. When job is resumed its next run is calculated from current time.
+func (s *CronScheduler) SetPaused(ctx op_context.Context, name string, paused bool) error {
+
+	c := ctx.TraceInMethod("CronScheduler.SetPaused", logger.Fields{"cron_job": name, "paused": paused})
+	defer ctx.TraceOutMethod()
+
+	job, err := s.findJob(ctx, name)
+	if err != nil {
+		return c.SetError(err)
+	}
+
+	state := &CronJobState{}
+	found, err := s.CRUD().Read(ctx, db.Fields{"name": name}, state)
+	if err != nil {
+		c.SetMessage("failed to read state of cron job")
+		return c.SetError(err)
+	}
+	if !found {
+		ctx.SetGenericErrorCode(ErrorCodeCronJobNotFound)
+		return c.SetErrorStr("state of cron job not found")
+	}
+
+	fields := db.Fields{"paused": paused}
+	if !paused {
+		fields["next_run_at"] = job.Next(time.Now())
+	}
+	err = s.CRUD().Update(ctx, state, fields)
+	if err != nil {
+		c.SetMessage("failed to update state of cron job")
+		return c.SetError(err)
+	}
+
+	return nil
+}
+
+func (s *CronScheduler) Pause(ctx op_context.Context, name string) error {
+	return s.SetPaused(ctx, name, true)
+}
+
+func (s *CronScheduler) Resume(ctx op_context.Context, name string) error {
+	return s.SetPaused(ctx, name, false)
+}
+
+// List states of jobs.
+func (s *CronScheduler) ListJobs(ctx op_context.Context, filter *db.Filter) ([]*CronJobState, int64, error) {
+
+	c := ctx.TraceInMethod("CronScheduler.ListJobs")
+	defer ctx.TraceOutMethod()
+
+	var states []*CronJobState
+	count, err := s.CRUD().List(ctx, filter, &states)
+	if err != nil {
+		c.SetMessage("failed to list cron jobs")
+		return nil, 0, c.SetError(err)
+	}
+
+	return states, count, nil
+}
@@ -0,0 +1,56 @@
+package cron_scheduler_console
+
+import (
+	"github.com/evgeniums/go-utils/pkg/app_context"
+	"github.com/evgeniums/go-utils/pkg/console_tool"
+	"github.com/evgeniums/go-utils/pkg/cron_scheduler"
+	"github.com/evgeniums/go-utils/pkg/multitenancy"
+)
+
+type CronSchedulerBuilder func(app app_context.Context) (*cron_scheduler.CronScheduler, error)
+
+type CronSchedulerCommands struct {
+	console_tool.Commands[*CronSchedulerCommands]
+
+	MakeController CronSchedulerBuilder
+}
+
+func NewCronSchedulerCommands(name string, description string, makeController CronSchedulerBuilder) *CronSchedulerCommands {
+	c := &CronSchedulerCommands{}
+	c.Construct(c, name, description)
+	c.MakeController = makeController
+	c.LoadHandlers()
+	return c
+}
+
+func (c *CronSchedulerCommands) LoadHandlers() {
+	c.AddHandlers(
+		List,
+		Trigger,
+		Pause,
+		Resume,
+	)
+}
+
+type HandlerBase struct {
+	console_tool.HandlerBase[*CronSchedulerCommands]
+}
+
+func (b *HandlerBase) Context(data interface{}) (multitenancy.TenancyContext, *cron_scheduler.CronScheduler, error) {
+
+	ctx, err := b.HandlerBase.Context(data)
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	ctrl, err := b.Group.MakeController(ctx.App())
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	return ctx, ctrl, nil
+}
+
+type JobNameData struct {
+	Job string `long:"job" description:"Name of cron job" required:"true" validate:"required"`
+}
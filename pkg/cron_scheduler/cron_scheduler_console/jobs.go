@@ -0,0 +1,146 @@
+package cron_scheduler_console
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/evgeniums/go-utils/pkg/console_tool"
+	"github.com/evgeniums/go-utils/pkg/cron_scheduler"
+	"github.com/evgeniums/go-utils/pkg/db"
+)
+
+const ListCmd string = "list"
+const ListDescription string = "List cron jobs"
+
+func List() console_tool.Handler[*CronSchedulerCommands] {
+	a := &ListHandler{}
+	a.Init(ListCmd, ListDescription)
+	return a
+}
+
+type ListHandler struct {
+	HandlerBase
+	console_tool.QueryData
+}
+
+func (a *ListHandler) Data() interface{} {
+	return &a.QueryData
+}
+
+func (a *ListHandler) Execute(args []string) error {
+
+	ctx, controller, err := a.Context(a.Data())
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+
+	filter, err := db.ParseQuery(ctx.Db(), a.Query, &cron_scheduler.CronJobState{}, "")
+	if err != nil {
+		return fmt.Errorf("failed to parse query: %s", err)
+	}
+
+	jobs, count, err := controller.ListJobs(ctx, filter)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(jobs, "", "   ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize result: %s", err)
+	}
+	fmt.Printf("********************\n\n%s\n\nCount %d\n\n********************\n\n", string(b), count)
+	return nil
+}
+
+//-------------------------------------------------
+
+const TriggerCmd string = "trigger"
+const TriggerDescription string = "Run cron job immediately"
+
+func Trigger() console_tool.Handler[*CronSchedulerCommands] {
+	a := &TriggerHandler{}
+	a.Init(TriggerCmd, TriggerDescription)
+	return a
+}
+
+type TriggerHandler struct {
+	HandlerBase
+	JobNameData
+}
+
+func (a *TriggerHandler) Data() interface{} {
+	return &a.JobNameData
+}
+
+func (a *TriggerHandler) Execute(args []string) error {
+
+	ctx, controller, err := a.Context(a.Data())
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+
+	return controller.Trigger(ctx, a.Job)
+}
+
+//-------------------------------------------------
+
+const PauseCmd string = "pause"
+const PauseDescription string = "Pause cron job"
+
+func Pause() console_tool.Handler[*CronSchedulerCommands] {
+	a := &PauseHandler{}
+	a.Init(PauseCmd, PauseDescription)
+	return a
+}
+
+type PauseHandler struct {
+	HandlerBase
+	JobNameData
+}
+
+func (a *PauseHandler) Data() interface{} {
+	return &a.JobNameData
+}
+
+func (a *PauseHandler) Execute(args []string) error {
+
+	ctx, controller, err := a.Context(a.Data())
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+
+	return controller.Pause(ctx, a.Job)
+}
+
+//-------------------------------------------------
+
+const ResumeCmd string = "resume"
+const ResumeDescription string = "Resume paused cron job"
+
+func Resume() console_tool.Handler[*CronSchedulerCommands] {
+	a := &ResumeHandler{}
+	a.Init(ResumeCmd, ResumeDescription)
+	return a
+}
+
+type ResumeHandler struct {
+	HandlerBase
+	JobNameData
+}
+
+func (a *ResumeHandler) Data() interface{} {
+	return &a.JobNameData
+}
+
+func (a *ResumeHandler) Execute(args []string) error {
+
+	ctx, controller, err := a.Context(a.Data())
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+
+	return controller.Resume(ctx, a.Job)
+}
@@ -0,0 +1,73 @@
+package crypt_utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"os"
+
+	"github.com/evgeniums/go-utils/pkg/utils"
+)
+
+// EcdsaSigner signs SHA-256 digest of data and extra data with ECDSA P-256 private key. Signatures are ASN.1 DER encoded.
+type EcdsaSigner struct {
+	utils.WithStringCoderBase
+	key *ecdsa.PrivateKey
+}
+
+func NewEcdsaSigner(encoder ...utils.StringCoding) *EcdsaSigner {
+	s := &EcdsaSigner{}
+	s.WithStringCoderBase.Construct(encoder...)
+	return s
+}
+
+func (s *EcdsaSigner) LoadKeyFromFile(filePath string, password string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return errors.New("no ECDSA private key found")
+	}
+	return s.LoadKey(data, password)
+}
+
+func (s *EcdsaSigner) LoadKey(data []byte, password string) error {
+
+	parsedKey, err := parsePemPrivateKey(data, password, "EC PRIVATE KEY", "PRIVATE KEY")
+	if err != nil {
+		return err
+	}
+
+	key, ok := parsedKey.(*ecdsa.PrivateKey)
+	if !ok || key.Curve != elliptic.P256() {
+		return errors.New("unable to parse ECDSA P-256 private key")
+	}
+	s.key = key
+
+	return nil
+}
+
+func (s *EcdsaSigner) SetKey(key *ecdsa.PrivateKey) {
+	s.key = key
+}
+
+func (s *EcdsaSigner) Sign(data []byte, extraData ...string) ([]byte, error) {
+	if s.key == nil {
+		return nil, errors.New("ECDSA private key not loaded")
+	}
+	hashed := H256(data, extraData...)
+	return ecdsa.SignASN1(rand.Reader, s.key, hashed)
+}
+
+func (s *EcdsaSigner) SignB64(data []byte, extraData ...string) (string, error) {
+
+	signature, err := s.Sign(data, extraData...)
+	if err != nil {
+		return "", err
+	}
+
+	return utils.Base64Encode(signature), nil
+}
+
+func (s *EcdsaSigner) Key() *ecdsa.PrivateKey {
+	return s.key
+}
@@ -0,0 +1,58 @@
+package crypt_utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+	"os"
+
+	"github.com/evgeniums/go-utils/pkg/utils"
+)
+
+const ECDSA_P256_H256_SIGNATURE = "ecdsa_p256_h256_signature"
+
+type EcdsaVerifier struct {
+	utils.WithStringCoderBase
+	key *ecdsa.PublicKey
+}
+
+func NewEcdsaVerifier(encoder ...utils.StringCoding) *EcdsaVerifier {
+	v := &EcdsaVerifier{}
+	v.WithStringCoderBase.Construct(encoder...)
+	return v
+}
+
+func (v *EcdsaVerifier) LoadKeyFromFile(filePath string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return errors.New("no ECDSA public key found")
+	}
+	return v.LoadKey(data)
+}
+
+func (v *EcdsaVerifier) LoadKey(data []byte) error {
+
+	parsedKey, err := parsePemPublicKey(data)
+	if err != nil {
+		return err
+	}
+
+	key, ok := parsedKey.(*ecdsa.PublicKey)
+	if !ok || key.Curve != elliptic.P256() {
+		return errors.New("unable to parse ECDSA P-256 public key")
+	}
+	v.key = key
+
+	return nil
+}
+
+func (v *EcdsaVerifier) Verify(data []byte, signature []byte, extraData ...string) error {
+	if v.key == nil {
+		return errors.New("ECDSA public key not loaded")
+	}
+	hashed := H256(data, extraData...)
+	if !ecdsa.VerifyASN1(v.key, hashed, signature) {
+		return errors.New("invalid ECDSA signature")
+	}
+	return nil
+}
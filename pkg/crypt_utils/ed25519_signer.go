@@ -0,0 +1,96 @@
+package crypt_utils
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/evgeniums/go-utils/pkg/utils"
+)
+
+// Ed25519Signer signs data followed by extra data with pure Ed25519, so that signatures can be verified with standard EdDSA implementations.
+type Ed25519Signer struct {
+	utils.WithStringCoderBase
+	key ed25519.PrivateKey
+}
+
+func NewEd25519Signer(encoder ...utils.StringCoding) *Ed25519Signer {
+	s := &Ed25519Signer{}
+	s.WithStringCoderBase.Construct(encoder...)
+	return s
+}
+
+func (s *Ed25519Signer) LoadKeyFromFile(filePath string, password string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return errors.New("no Ed25519 private key found")
+	}
+	return s.LoadKey(data, password)
+}
+
+// Load private key either in PEM format or as base64 encoded raw seed or private key.
+func (s *Ed25519Signer) LoadKey(data []byte, password string) error {
+
+	raw, err := utils.Base64Decode(strings.TrimSpace(string(data)))
+	if err == nil {
+		switch len(raw) {
+		case ed25519.SeedSize:
+			s.key = ed25519.NewKeyFromSeed(raw)
+			return nil
+		case ed25519.PrivateKeySize:
+			s.key = ed25519.PrivateKey(raw)
+			return nil
+		}
+	}
+
+	parsedKey, err := parsePemPrivateKey(data, password, "PRIVATE KEY")
+	if err != nil {
+		return err
+	}
+
+	var ok bool
+	s.key, ok = parsedKey.(ed25519.PrivateKey)
+	if !ok {
+		return errors.New("unable to parse Ed25519 private key")
+	}
+
+	return nil
+}
+
+func (s *Ed25519Signer) SetKey(key ed25519.PrivateKey) {
+	s.key = key
+}
+
+func (s *Ed25519Signer) Sign(data []byte, extraData ...string) ([]byte, error) {
+	if s.key == nil {
+		return nil, errors.New("Ed25519 private key not loaded")
+	}
+	return ed25519.Sign(s.key, ed25519Message(data, extraData...)), nil
+}
+
+func (s *Ed25519Signer) SignB64(data []byte, extraData ...string) (string, error) {
+
+	signature, err := s.Sign(data, extraData...)
+	if err != nil {
+		return "", err
+	}
+
+	return utils.Base64Encode(signature), nil
+}
+
+// Message of Ed25519 signature is concatenation of data and extra data.
+func ed25519Message(data []byte, extraData ...string) []byte {
+	if len(extraData) == 0 {
+		return data
+	}
+	msg := append([]byte{}, data...)
+	for _, extra := range extraData {
+		msg = append(msg, extra...)
+	}
+	return msg
+}
+
+func (s *Ed25519Signer) Key() ed25519.PrivateKey {
+	return s.key
+}
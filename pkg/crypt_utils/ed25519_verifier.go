@@ -0,0 +1,63 @@
+package crypt_utils
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/evgeniums/go-utils/pkg/utils"
+)
+
+const ED25519_SIGNATURE = "ed25519_signature"
+
+type Ed25519Verifier struct {
+	utils.WithStringCoderBase
+	key ed25519.PublicKey
+}
+
+func NewEd25519Verifier(encoder ...utils.StringCoding) *Ed25519Verifier {
+	v := &Ed25519Verifier{}
+	v.WithStringCoderBase.Construct(encoder...)
+	return v
+}
+
+func (v *Ed25519Verifier) LoadKeyFromFile(filePath string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return errors.New("no Ed25519 public key found")
+	}
+	return v.LoadKey(data)
+}
+
+// Load public key either in PEM format or as compact base64 encoded raw 32 bytes key.
+func (v *Ed25519Verifier) LoadKey(data []byte) error {
+
+	raw, err := utils.Base64Decode(strings.TrimSpace(string(data)))
+	if err == nil && len(raw) == ed25519.PublicKeySize {
+		v.key = ed25519.PublicKey(raw)
+		return nil
+	}
+
+	parsedKey, err := parsePemPublicKey(data)
+	if err != nil {
+		return err
+	}
+
+	var ok bool
+	if v.key, ok = parsedKey.(ed25519.PublicKey); !ok {
+		return errors.New("unable to parse Ed25519 public key")
+	}
+
+	return nil
+}
+
+func (v *Ed25519Verifier) Verify(data []byte, signature []byte, extraData ...string) error {
+	if len(v.key) != ed25519.PublicKeySize {
+		return errors.New("Ed25519 public key not loaded")
+	}
+	if !ed25519.Verify(v.key, ed25519Message(data, extraData...), signature) {
+		return errors.New("invalid Ed25519 signature")
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package encryption_console
+
+import (
+	"github.com/evgeniums/go-utils/pkg/app_context"
+	"github.com/evgeniums/go-utils/pkg/config/object_config"
+	"github.com/evgeniums/go-utils/pkg/console_tool"
+	"github.com/evgeniums/go-utils/pkg/crypt_utils"
+)
+
+type KeyRingBuilder func(app app_context.Context) (*crypt_utils.KeyRing, error)
+
+type EncryptionCommands struct {
+	console_tool.Commands[*EncryptionCommands]
+	SmsKeyRing       KeyRingBuilder
+	SignatureKeyRing KeyRingBuilder
+}
+
+func NewEncryptionCommands() *EncryptionCommands {
+	p := &EncryptionCommands{}
+	p.Construct(p, "encryption", "Manage encryption of stored data")
+	p.SmsKeyRing = KeyRingFromConfig("sms")
+	p.SignatureKeyRing = KeyRingFromConfig("signature")
+	p.LoadHandlers()
+	return p
+}
+
+func (p *EncryptionCommands) LoadHandlers() {
+	p.AddHandlers(Reencrypt)
+}
+
+// Make key ring builder that loads key ring from application configuration using legacy SECRET and SALT in the same path.
+func KeyRingFromConfig(configPath string) KeyRingBuilder {
+	return func(app app_context.Context) (*crypt_utils.KeyRing, error) {
+		cfg := app.Cfg()
+		secret := cfg.GetString(object_config.Key(configPath, "secret"))
+		salt := cfg.GetString(object_config.Key(configPath, "salt"))
+		return crypt_utils.LoadKeyRing(cfg, app.Logger(), app.Validator(), configPath, secret, salt)
+	}
+}
+
+type Handler = console_tool.Handler[*EncryptionCommands]
+
+type HandlerBase struct {
+	console_tool.HandlerBase[*EncryptionCommands]
+}
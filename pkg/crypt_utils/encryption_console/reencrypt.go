@@ -0,0 +1,81 @@
+package encryption_console
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/evgeniums/go-utils/pkg/console_tool"
+	"github.com/evgeniums/go-utils/pkg/crypt_utils"
+	"github.com/evgeniums/go-utils/pkg/crypt_utils/key_ring_db"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+	"github.com/evgeniums/go-utils/pkg/signature"
+	"github.com/evgeniums/go-utils/pkg/sms"
+)
+
+const ReencryptCmd string = "reencrypt"
+const ReencryptDescription string = "Re-encrypt stored SMS messages and signed messages with active key"
+
+func Reencrypt() console_tool.Handler[*EncryptionCommands] {
+	a := &ReencryptHandler{}
+	a.Init(ReencryptCmd, ReencryptDescription)
+	return a
+}
+
+type ReencryptData struct {
+	Sms        bool `long:"sms" description:"Re-encrypt SMS messages"`
+	Signatures bool `long:"signatures" description:"Re-encrypt signed messages"`
+	BatchSize  int  `long:"batch" description:"Number of records processed in one batch" default:"100" validate:"gt=0"`
+}
+
+type ReencryptHandler struct {
+	HandlerBase
+	ReencryptData
+}
+
+func (a *ReencryptHandler) Data() interface{} {
+	return &a.ReencryptData
+}
+
+func (a *ReencryptHandler) Execute(args []string) error {
+
+	ctx, err := a.Context(a.Data())
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+
+	all := !a.Sms && !a.Signatures
+	if all || a.Sms {
+		err = a.reencrypt(ctx, "SMS messages", a.Group.SmsKeyRing, sms.ReencryptMessages)
+		if err != nil {
+			return err
+		}
+	}
+	if all || a.Signatures {
+		err = a.reencrypt(ctx, "signed messages", a.Group.SignatureKeyRing, signature.ReencryptMessages)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *ReencryptHandler) reencrypt(ctx op_context.Context, name string, builder KeyRingBuilder,
+	handler func(ctx op_context.Context, keyRing *crypt_utils.KeyRing, batchSize ...int) (*key_ring_db.ReencryptStats, error)) error {
+
+	keyRing, err := builder(ctx.App())
+	if err != nil {
+		return fmt.Errorf("failed to load key ring for %s: %s", name, err)
+	}
+	if keyRing.IsEmpty() {
+		return errors.New("key ring for " + name + " is empty")
+	}
+
+	stats, err := handler(ctx, keyRing, a.BatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to re-encrypt %s: %s", name, err)
+	}
+	fmt.Printf("Re-encrypted %s with key %s: total %d, re-encrypted %d, failed %d\n", name, keyRing.ActiveKeyId(), stats.Total, stats.Reencrypted, stats.Failed)
+	return nil
+}
@@ -0,0 +1,272 @@
+package crypt_utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/evgeniums/go-utils/pkg/utils"
+)
+
+const KeyRingDefaultKeyId = "default"
+
+// Ciphertexts made with key ring are prefixed with keyRingMagic, length of key ID and key ID.
+var keyRingMagic = []byte{'K', 'R', 0x01}
+
+var ErrUnknownKey = errors.New("unknown key")
+
+type keyRingKey struct {
+	secret string
+	cipher *AEAD
+}
+
+// KeyRing holds a set of AEAD keys. New data is encrypted with the active key, old data can be decrypted with any configured key.
+type KeyRing struct {
+	mutex    sync.RWMutex
+	config   AEADConfig
+	activeId string
+	keys     map[string]*keyRingKey
+	ids      []string
+}
+
+func NewKeyRing(config ...AEADConfig) *KeyRing {
+	k := &KeyRing{}
+	k.keys = make(map[string]*keyRingKey)
+	if len(config) == 1 {
+		k.config = config[0]
+	} else {
+		k.config = DefaultAEADConfig(DefaultPbkdfConfig())
+	}
+	return k
+}
+
+// Add key to key ring. If salt is not empty then key can be used for Encrypt/Decrypt, otherwise only for EncryptWithSalt/DecryptWithSalt.
+// The first added key becomes active.
+func (k *KeyRing) AddKey(id string, secret string, salt []byte) error {
+
+	if id == "" || len(id) > 255 {
+		return errors.New("invalid key ID")
+	}
+
+	key := &keyRingKey{secret: secret}
+	if len(salt) != 0 {
+		var err error
+		key.cipher, err = NewAEAD(secret, salt, k.config)
+		if err != nil {
+			return err
+		}
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	if _, exists := k.keys[id]; exists {
+		return fmt.Errorf("duplicate key %s", id)
+	}
+	k.keys[id] = key
+	k.ids = append(k.ids, id)
+	if k.activeId == "" {
+		k.activeId = id
+	}
+	return nil
+}
+
+func (k *KeyRing) SetActiveKey(id string) error {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	if _, exists := k.keys[id]; !exists {
+		return ErrUnknownKey
+	}
+	k.activeId = id
+	return nil
+}
+
+func (k *KeyRing) ActiveKeyId() string {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	return k.activeId
+}
+
+func (k *KeyRing) KeyIds() []string {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	return append([]string{}, k.ids...)
+}
+
+func (k *KeyRing) IsEmpty() bool {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	return len(k.ids) == 0
+}
+
+func (k *KeyRing) activeKey() (string, *keyRingKey, error) {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	key, ok := k.keys[k.activeId]
+	if !ok {
+		return "", nil, errors.New("active key is not set")
+	}
+	return k.activeId, key, nil
+}
+
+func (k *KeyRing) orderedKeys(firstId string) []*keyRingKey {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	keys := make([]*keyRingKey, 0, len(k.ids))
+	first, ok := k.keys[firstId]
+	if ok {
+		keys = append(keys, first)
+	}
+	for _, id := range k.ids {
+		if id != firstId {
+			keys = append(keys, k.keys[id])
+		}
+	}
+	return keys
+}
+
+func (k *KeyRing) prefix(id string) []byte {
+	b := make([]byte, 0, len(keyRingMagic)+1+len(id))
+	b = append(b, keyRingMagic...)
+	b = append(b, byte(len(id)))
+	b = append(b, []byte(id)...)
+	return b
+}
+
+// Split ciphertext to key ID and payload. Key ID is empty for ciphertexts made without key ring.
+func SplitKeyRingCiphertext(ciphertext []byte) (string, []byte) {
+	if !bytes.HasPrefix(ciphertext, keyRingMagic) || len(ciphertext) < len(keyRingMagic)+1 {
+		return "", ciphertext
+	}
+	idLen := int(ciphertext[len(keyRingMagic)])
+	start := len(keyRingMagic) + 1
+	if len(ciphertext) < start+idLen {
+		return "", ciphertext
+	}
+	return string(ciphertext[start : start+idLen]), ciphertext[start+idLen:]
+}
+
+// Check if data looks like ciphertext made with key ring.
+func IsKeyRingCiphertext(data []byte) bool {
+	id, _ := SplitKeyRingCiphertext(data)
+	return id != ""
+}
+
+func (k *KeyRing) KeyIdOf(ciphertext []byte) string {
+	id, _ := SplitKeyRingCiphertext(ciphertext)
+	return id
+}
+
+func (k *KeyRing) Encrypt(plaintext []byte, additionalData ...[]byte) ([]byte, error) {
+	id, key, err := k.activeKey()
+	if err != nil {
+		return nil, err
+	}
+	if key.cipher == nil {
+		return nil, errors.New("key does not have fixed salt")
+	}
+	ciphertext, err := key.cipher.Encrypt(plaintext, additionalData...)
+	if err != nil {
+		return nil, err
+	}
+	return append(k.prefix(id), ciphertext...), nil
+}
+
+func (k *KeyRing) Decrypt(ciphertext []byte, additionalData ...[]byte) ([]byte, error) {
+	return k.decrypt(ciphertext, func(key *keyRingKey, payload []byte) ([]byte, error) {
+		if key.cipher == nil {
+			return nil, errors.New("key does not have fixed salt")
+		}
+		return key.cipher.Decrypt(payload, additionalData...)
+	})
+}
+
+func (k *KeyRing) EncryptWithSalt(salt []byte, plaintext []byte, additionalData ...[]byte) ([]byte, error) {
+	id, key, err := k.activeKey()
+	if err != nil {
+		return nil, err
+	}
+	cipher, err := NewAEAD(key.secret, salt, k.config)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := cipher.Encrypt(plaintext, additionalData...)
+	if err != nil {
+		return nil, err
+	}
+	return append(k.prefix(id), ciphertext...), nil
+}
+
+func (k *KeyRing) DecryptWithSalt(salt []byte, ciphertext []byte, additionalData ...[]byte) ([]byte, error) {
+	return k.decrypt(ciphertext, func(key *keyRingKey, payload []byte) ([]byte, error) {
+		cipher, err := NewAEAD(key.secret, salt, k.config)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.Decrypt(payload, additionalData...)
+	})
+}
+
+func (k *KeyRing) decrypt(ciphertext []byte, decryptFn func(key *keyRingKey, payload []byte) ([]byte, error)) ([]byte, error) {
+
+	// try key from ciphertext first
+	id, payload := SplitKeyRingCiphertext(ciphertext)
+	if id != "" {
+		k.mutex.RLock()
+		key, ok := k.keys[id]
+		k.mutex.RUnlock()
+		if ok {
+			plaintext, err := decryptFn(key, payload)
+			if err == nil {
+				return plaintext, nil
+			}
+		}
+	}
+
+	// try all keys with ciphertext as is, e.g. when ciphertext was made without key ring
+	var err error
+	for _, key := range k.orderedKeys(k.ActiveKeyId()) {
+		var plaintext []byte
+		plaintext, err = decryptFn(key, ciphertext)
+		if err == nil {
+			return plaintext, nil
+		}
+	}
+	if err == nil {
+		err = ErrUnknownKey
+	}
+	return nil, err
+}
+
+func (k *KeyRing) DecryptB64(ciphertext string, additionalData ...[]byte) ([]byte, error) {
+	coding := utils.Base64StringCoding{}
+	data, err := coding.Decode(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	return k.Decrypt(data, additionalData...)
+}
+
+// Re-encrypt base64 encoded ciphertext with active key. Returns false if ciphertext is already encrypted with active key.
+func (k *KeyRing) ReencryptB64(ciphertext string) (string, bool, error) {
+
+	coding := utils.Base64StringCoding{}
+	data, err := coding.Decode(ciphertext)
+	if err != nil {
+		return "", false, err
+	}
+	if k.KeyIdOf(data) == k.ActiveKeyId() {
+		return ciphertext, false, nil
+	}
+
+	plaintext, err := k.Decrypt(data)
+	if err != nil {
+		return "", false, err
+	}
+	newCiphertext, err := k.Encrypt(plaintext)
+	if err != nil {
+		return "", false, err
+	}
+
+	return coding.Encode(newCiphertext), true, nil
+}
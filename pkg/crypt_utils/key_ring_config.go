@@ -0,0 +1,68 @@
+package crypt_utils
+
+import (
+	"github.com/evgeniums/go-utils/pkg/config"
+	"github.com/evgeniums/go-utils/pkg/config/object_config"
+	"github.com/evgeniums/go-utils/pkg/logger"
+	"github.com/evgeniums/go-utils/pkg/validator"
+)
+
+type KeyRingKeyConfig struct {
+	ID     string `validate:"required"`
+	SECRET string `validate:"required" mask:"true"`
+	SALT   string `mask:"true"`
+}
+
+func (k *KeyRingKeyConfig) Config() interface{} {
+	return k
+}
+
+func (k *KeyRingKeyConfig) Init(cfg config.Config, log logger.Logger, vld validator.Validator, configPath ...string) error {
+	err := object_config.LoadLogValidate(cfg, log, vld, k, "key", configPath...)
+	if err != nil {
+		return log.PushFatalStack("failed to load configuration of key ring key", err)
+	}
+	return nil
+}
+
+// Load key ring from configuration.
+// Legacy secret and salt, if not empty, are added with KeyRingDefaultKeyId, other keys are loaded from "keys" list in config path.
+// Keys without own salt use legacy salt.
+// Active key is set with "active_key" in config path, by default the first key is active.
+func LoadKeyRing(cfg config.Config, log logger.Logger, vld validator.Validator, configPath string, legacySecret string, legacySalt string, aeadConfig ...AEADConfig) (*KeyRing, error) {
+
+	fields := logger.Fields{"config_path": configPath}
+	keyRing := NewKeyRing(aeadConfig...)
+
+	if legacySecret != "" {
+		err := keyRing.AddKey(KeyRingDefaultKeyId, legacySecret, []byte(legacySalt))
+		if err != nil {
+			return nil, log.PushFatalStack("failed to add default key to key ring", err, fields)
+		}
+	}
+
+	keys, err := object_config.LoadLogValidateSubobjectsList(cfg, log, vld, object_config.Key(configPath, "keys"), func() *KeyRingKeyConfig { return &KeyRingKeyConfig{} })
+	if err != nil {
+		return nil, log.PushFatalStack("failed to load keys of key ring", err, fields)
+	}
+	for _, key := range keys {
+		salt := key.SALT
+		if salt == "" {
+			salt = legacySalt
+		}
+		err = keyRing.AddKey(key.ID, key.SECRET, []byte(salt))
+		if err != nil {
+			return nil, log.PushFatalStack("failed to add key to key ring", err, logger.AppendFieldsNew(fields, logger.Fields{"key": key.ID}))
+		}
+	}
+
+	activeKey := cfg.GetString(object_config.Key(configPath, "active_key"))
+	if activeKey != "" {
+		err = keyRing.SetActiveKey(activeKey)
+		if err != nil {
+			return nil, log.PushFatalStack("invalid active key of key ring", err, logger.AppendFieldsNew(fields, logger.Fields{"active_key": activeKey}))
+		}
+	}
+
+	return keyRing, nil
+}
@@ -0,0 +1,77 @@
+package key_ring_db
+
+import (
+	"github.com/evgeniums/go-utils/pkg/common"
+	"github.com/evgeniums/go-utils/pkg/crypt_utils"
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/logger"
+)
+
+const DefaultReencryptBatchSize = 100
+
+type ReencryptStats struct {
+	Total       int
+	Reencrypted int
+	Failed      int
+}
+
+// Re-encrypt base64 encoded field of database objects with active key of key ring.
+// Objects that can not be re-encrypted are logged and skipped.
+func ReencryptObjects[T common.Object](ctx logger.WithLogger, handlers db.DBHandlers, keyRing *crypt_utils.KeyRing, fieldName string, field func(obj T) *string, batchSize ...int) (*ReencryptStats, error) {
+	return ReencryptFilteredObjects(ctx, handlers, keyRing, db.NewFilter(), fieldName, field, batchSize...)
+}
+
+// Re-encrypt base64 encoded field of database objects selected by filter. Sorting and paging of the filter are overridden.
+func ReencryptFilteredObjects[T common.Object](ctx logger.WithLogger, handlers db.DBHandlers, keyRing *crypt_utils.KeyRing, filter *db.Filter, fieldName string, field func(obj T) *string, batchSize ...int) (*ReencryptStats, error) {
+
+	stats := &ReencryptStats{}
+	limit := DefaultReencryptBatchSize
+	if len(batchSize) != 0 && batchSize[0] > 0 {
+		limit = batchSize[0]
+	}
+
+	filter.SetSorting("id")
+	filter.Limit = limit
+	filter.Keyset = true
+	for {
+		var objects []T
+		_, err := handlers.FindWithFilter(ctx, filter, &objects)
+		if err != nil {
+			return stats, err
+		}
+
+		for _, obj := range objects {
+			value := field(obj)
+			if *value == "" {
+				continue
+			}
+			stats.Total++
+
+			ciphertext, changed, err := keyRing.ReencryptB64(*value)
+			if err != nil {
+				stats.Failed++
+				ctx.Logger().Error("failed to re-encrypt object", err, logger.Fields{"id": obj.GetID()})
+				continue
+			}
+			if !changed {
+				continue
+			}
+
+			err = db.Update(handlers, ctx, obj, db.Fields{fieldName: ciphertext})
+			if err != nil {
+				stats.Failed++
+				ctx.Logger().Error("failed to save re-encrypted object", err, logger.Fields{"id": obj.GetID()})
+				continue
+			}
+			*value = ciphertext
+			stats.Reencrypted++
+		}
+
+		if filter.NextCursor == "" {
+			break
+		}
+		filter.SetCursor(filter.NextCursor)
+	}
+
+	return stats, nil
+}
@@ -1,6 +1,16 @@
 package crypt_utils
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
 	"github.com/evgeniums/go-utils/pkg/utils"
 )
 
@@ -10,6 +20,12 @@ type ESigner interface {
 	SignB64(data []byte, extraData ...string) (string, error)
 }
 
+type ESignerWithKey interface {
+	ESigner
+	LoadKey(data []byte, password string) error
+	LoadKeyFromFile(filePath string, password string) error
+}
+
 type EVerifier interface {
 	utils.WithStringCoder
 	Verify(data []byte, signature []byte, extraData ...string) error
@@ -18,7 +34,7 @@ type EVerifier interface {
 }
 
 func Sign(signer ESigner, data []byte, extraData ...string) (string, error) {
-	signature, err := signer.Sign(data)
+	signature, err := signer.Sign(data, extraData...)
 	if err != nil {
 		return "", err
 	}
@@ -32,3 +48,116 @@ func VerifySignature(verifier EVerifier, data []byte, signature string, extraDat
 	}
 	return verifier.Verify(data, sig, extraData...)
 }
+
+func SignatureAlgorithms() []string {
+	return []string{RSA_H256_SIGNATURE, ED25519_SIGNATURE, ECDSA_P256_H256_SIGNATURE}
+}
+
+func NewSigner(algorithm string, encoder ...utils.StringCoding) (ESignerWithKey, error) {
+	switch algorithm {
+	case RSA_H256_SIGNATURE:
+		return NewRsaSigner(encoder...), nil
+	case ED25519_SIGNATURE:
+		return NewEd25519Signer(encoder...), nil
+	case ECDSA_P256_H256_SIGNATURE:
+		return NewEcdsaSigner(encoder...), nil
+	}
+	return nil, fmt.Errorf("unsupported signature algorithm %s", algorithm)
+}
+
+func NewVerifier(algorithm string, encoder ...utils.StringCoding) (EVerifier, error) {
+	switch algorithm {
+	case RSA_H256_SIGNATURE:
+		return NewRsaVerifier(encoder...), nil
+	case ED25519_SIGNATURE:
+		return NewEd25519Verifier(encoder...), nil
+	case ECDSA_P256_H256_SIGNATURE:
+		return NewEcdsaVerifier(encoder...), nil
+	}
+	return nil, fmt.Errorf("unsupported signature algorithm %s", algorithm)
+}
+
+// Detect signature algorithm from public key in PEM format or from compact base64 encoded Ed25519 key.
+func PubKeyAlgorithm(data []byte) (string, error) {
+
+	raw, err := utils.Base64Decode(strings.TrimSpace(string(data)))
+	if err == nil && len(raw) == ed25519.PublicKeySize {
+		return ED25519_SIGNATURE, nil
+	}
+
+	parsedKey, err := parsePemPublicKey(data)
+	if err != nil {
+		return "", err
+	}
+
+	switch key := parsedKey.(type) {
+	case *rsa.PublicKey:
+		return RSA_H256_SIGNATURE, nil
+	case ed25519.PublicKey:
+		return ED25519_SIGNATURE, nil
+	case *ecdsa.PublicKey:
+		if key.Curve == elliptic.P256() {
+			return ECDSA_P256_H256_SIGNATURE, nil
+		}
+	}
+
+	return "", errors.New("unsupported type of public key")
+}
+
+func parsePemPublicKey(data []byte) (interface{}, error) {
+
+	pubPem, _ := pem.Decode(data)
+	if pubPem == nil {
+		return nil, errors.New("public key not in pem format")
+	}
+	if pubPem.Type != "RSA PUBLIC KEY" && pubPem.Type != "PUBLIC KEY" {
+		return nil, errors.New("public key is of the wrong type")
+	}
+
+	parsedKey, err := x509.ParsePKIXPublicKey(pubPem.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse public key: %s", err)
+	}
+	return parsedKey, nil
+}
+
+func parsePemPrivateKey(data []byte, password string, pemTypes ...string) (interface{}, error) {
+
+	privPem, _ := pem.Decode(data)
+	if privPem == nil {
+		return nil, errors.New("private key not in pem format")
+	}
+	validType := false
+	for _, pemType := range pemTypes {
+		if privPem.Type == pemType {
+			validType = true
+			break
+		}
+	}
+	if !validType {
+		return nil, errors.New("private key is of the wrong type")
+	}
+
+	privPemBytes := privPem.Bytes
+	if password != "" {
+		var err error
+		privPemBytes, err = x509.DecryptPEMBlock(privPem, []byte(password))
+		if err != nil {
+			return nil, errors.New("unable to decrypt passphrase")
+		}
+	}
+
+	if privPem.Type == "EC PRIVATE KEY" {
+		key, err := x509.ParseECPrivateKey(privPemBytes)
+		if err != nil {
+			return nil, errors.New("unable to parse EC private key")
+		}
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(privPemBytes)
+	if err != nil {
+		return nil, errors.New("unable to parse private key")
+	}
+	return key, nil
+}
@@ -1,7 +1,9 @@
 package customer
 
 import (
+	"github.com/evgeniums/go-utils/pkg/auth"
 	"github.com/evgeniums/go-utils/pkg/auth/auth_session"
+	"github.com/evgeniums/go-utils/pkg/op_context"
 	"github.com/evgeniums/go-utils/pkg/user"
 )
 
@@ -33,6 +35,14 @@ func (m *CustomersBase) AuthUserManager() auth_session.AuthUserManager {
 	return m
 }
 
+func (m *CustomersBase) UpdatePasswordHash(ctx op_context.Context, authUser auth.User) error {
+	return user.UpdatePasswordHash(m.AuthUserFinder, ctx, authUser)
+}
+
+func (m *CustomersBase) UpdateTotp(ctx op_context.Context, authUser auth.User) error {
+	return user.UpdateTotp(m.AuthUserFinder, ctx, authUser)
+}
+
 type ManagerConfig struct {
 	CustomerController CustomerController
 	SessionController  auth_session.SessionController
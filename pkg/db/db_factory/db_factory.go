@@ -0,0 +1,33 @@
+package db_factory
+
+import (
+	"github.com/evgeniums/go-utils/pkg/config"
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/db/db_gorm"
+	"github.com/evgeniums/go-utils/pkg/db/db_mongo"
+	"github.com/evgeniums/go-utils/pkg/logger"
+	"github.com/evgeniums/go-utils/pkg/validator"
+)
+
+type Database interface {
+	db.DB
+	Init(ctx logger.WithLogger, cfg config.Config, vld validator.Validator, configPath ...string) error
+}
+
+// Create database for provider, all providers except MongoDB are served by gorm.
+func New(provider string, gormDbConnector ...*db_gorm.DbConnector) Database {
+	if provider == db_mongo.Provider {
+		return db_mongo.New()
+	}
+	return db_gorm.New(gormDbConnector...)
+}
+
+// Create database for provider using sample database as a prototype if the sample is of the same kind.
+// If provider is empty then sample database is cloned.
+func Clone(sample db.DB, provider string) db.DB {
+	_, sampleMongo := sample.(*db_mongo.MongoDB)
+	if provider == "" || sampleMongo == (provider == db_mongo.Provider) {
+		return sample.Clone()
+	}
+	return New(provider)
+}
@@ -0,0 +1,81 @@
+package db_gorm
+
+import (
+	"errors"
+
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/logger"
+	"github.com/evgeniums/go-utils/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// ProvidersDbConnector selects connector of database provider set in configuration.
+func ProvidersDbConnector(connectors map[string]*DbConnector) *DbConnector {
+
+	find := func(provider string) (*DbConnector, error) {
+		connector, ok := connectors[provider]
+		if !ok {
+			return nil, errors.New("unknown database provider")
+		}
+		return connector, nil
+	}
+
+	c := &DbConnector{}
+	c.DialectorOpener = func(provider string, dsn string) (gorm.Dialector, error) {
+		connector, err := find(provider)
+		if err != nil {
+			return nil, err
+		}
+		return connector.DialectorOpener(provider, dsn)
+	}
+	c.DsnBuilder = func(config *db.DBConfig) (string, error) {
+		connector, err := find(config.DB_PROVIDER)
+		if err != nil {
+			return "", err
+		}
+		return connector.DsnBuilder(config)
+	}
+	c.CheckDuplicateKeyError = func(provider string, result *gorm.DB) (bool, error) {
+		connector, err := find(provider)
+		if err != nil {
+			return false, err
+		}
+		return connector.CheckDuplicateKeyError(provider, result)
+	}
+	c.PartitionedMonthMigrator = func(provider string, ctx logger.WithLogger, db *gorm.DB, models ...interface{}) error {
+		connector, err := find(provider)
+		if err != nil {
+			return err
+		}
+		return connector.PartitionedMonthMigrator(provider, ctx, db, models...)
+	}
+	c.PartitionedMonthDeleter = func(provider string, ctx logger.WithLogger, db *gorm.DB, table string, months []utils.Month) error {
+		connector, err := find(provider)
+		if err != nil {
+			return err
+		}
+		return connector.PartitionedMonthDeleter(provider, ctx, db, table, months)
+	}
+	c.PartitionedMonthDetacher = func(provider string, ctx logger.WithLogger, db *gorm.DB, table string, months []utils.Month) error {
+		connector, err := find(provider)
+		if err != nil {
+			return err
+		}
+		return connector.PartitionedMonthDetacher(provider, ctx, db, table, months)
+	}
+	c.DbCreator = func(provider string, db *gorm.DB, dbName string) error {
+		connector, err := find(provider)
+		if err != nil {
+			return err
+		}
+		return connector.DbCreator(provider, db, dbName)
+	}
+	return c
+}
+
+func SqlDbConnector() *DbConnector {
+	return ProvidersDbConnector(map[string]*DbConnector{
+		"postgres": PostgresDbConnector(),
+		"sqlite":   SqliteDbConnector(),
+	})
+}
@@ -3,6 +3,8 @@ package db_gorm
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 	"sync"
 
 	"github.com/evgeniums/go-utils/pkg/db"
@@ -12,13 +14,15 @@ import (
 	"gorm.io/gorm/schema"
 )
 
+var fullTextLanguage = regexp.MustCompile(`^[a-z_]+$`)
+
 type FilterParser struct {
 	Manager     *FilterManager
 	Destination *ModelDescriptor
 	Validator   *db.FilterValidator
 }
 
-func convertValue(field *schema.Field, value string) (interface{}, error) {
+func ConvertValue(field *schema.Field, value string) (interface{}, error) {
 
 	switch field.DataType {
 	case schema.String:
@@ -58,14 +62,14 @@ func convertValue(field *schema.Field, value string) (interface{}, error) {
 	return nil, errors.New("unsupported field type")
 }
 
-func (f *FilterParser) ParseValidateField(name string, value string, onlyName ...bool) (string, interface{}, error) {
-
-	fieldName := func(dscr *FieldDescriptor) string {
-		if f.Validator != nil && f.Validator.PlainFieldNames {
-			return dscr.DbField
-		}
-		return dscr.FullDbName
+func (f *FilterParser) fieldName(dscr *FieldDescriptor) string {
+	if f.Validator != nil && f.Validator.PlainFieldNames {
+		return dscr.DbField
 	}
+	return dscr.FullDbName
+}
+
+func (f *FilterParser) ParseValidateField(name string, value string, onlyName ...bool) (string, interface{}, error) {
 
 	// find field by json name
 	field, err := f.Destination.FindJsonField(name)
@@ -80,11 +84,11 @@ func (f *FilterParser) ParseValidateField(name string, value string, onlyName ..
 
 	// break if only field name validation required
 	if utils.OptionalArg(false, onlyName...) {
-		return fieldName(field), value, nil
+		return f.fieldName(field), value, nil
 	}
 
 	// convert string value to desired type
-	result, err := convertValue(field.Schema, value)
+	result, err := ConvertValue(field.Schema, value)
 	if err != nil {
 		return "", nil, &validator.ValidationError{Message: "Invalid field value", Field: name}
 	}
@@ -109,7 +113,41 @@ func (f *FilterParser) ParseValidateField(name string, value string, onlyName ..
 	}
 
 	// done
-	return fieldName(field), result, nil
+	return f.fieldName(field), result, nil
+}
+
+func (f *FilterParser) ParseValidateSearchField(name string, operator string, value string) (string, error) {
+
+	// find field by json name
+	field, err := f.Destination.FindJsonField(name)
+	if err != nil {
+		return "", &validator.ValidationError{Message: "Invalid field name", Field: name}
+	}
+
+	// allow only string fields
+	if field.Schema.DataType != schema.String {
+		return "", &validator.ValidationError{Message: "Search can be used only for string fields", Field: name}
+	}
+
+	// allow only whitelisted fields
+	if f.Validator == nil || f.Validator.Rules == nil {
+		return "", &validator.ValidationError{Message: "Search is not allowed for this field", Field: name}
+	}
+	rules, ok := f.Validator.Rules[db.SearchRuleKey(name, operator)]
+	if !ok {
+		return "", &validator.ValidationError{Message: "Search is not allowed for this field", Field: name}
+	}
+
+	// validate value
+	if rules != "" && f.Validator.Validator != nil {
+		err = f.Validator.Validator.ValidateValue(value, rules)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// done
+	return f.fieldName(field), nil
 }
 
 func (f *FilterParser) Parse(query *db.Query) (*db.Filter, error) {
@@ -128,6 +166,14 @@ func (f *FilterParser) Parse(query *db.Query) (*db.Filter, error) {
 		}
 	}
 
+	// keyset cursor
+	if query.Cursor != "" {
+		_, err = db.DecodeKeysetCursor(query.Cursor)
+		if err != nil {
+			return nil, &validator.ValidationError{Message: "Invalid cursor", Field: "cursor"}
+		}
+	}
+
 	// sort field
 	if query.SortField != "" {
 		field, _, err := f.ParseValidateField(query.SortField, "", true)
@@ -270,10 +316,128 @@ func (f *FilterParser) Parse(query *db.Query) (*db.Filter, error) {
 		filter.OrFields[i] = &db.OrFields{Value: value, Fields: fields}
 	}
 
+	// fill patterns
+	if len(query.Like) > 0 {
+		filter.Like = make(map[string]*db.Pattern)
+	}
+	for key, pattern := range query.Like {
+		field, err := f.ParseValidateSearchField(key, db.SEARCH_LIKE, pattern.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		filter.Like[field] = &db.Pattern{Pattern: pattern.Pattern, CaseInsensitive: pattern.CaseInsensitive}
+	}
+	if len(query.Prefix) > 0 {
+		filter.Prefix = make(map[string]*db.Pattern)
+	}
+	for key, prefix := range query.Prefix {
+		field, err := f.ParseValidateSearchField(key, db.SEARCH_PREFIX, prefix.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		filter.Prefix[field] = &db.Pattern{Pattern: prefix.Pattern, CaseInsensitive: prefix.CaseInsensitive}
+	}
+
+	// fill full text search
+	if len(query.FullText) > 0 {
+		filter.FullText = make(map[string]*db.FullTextSearch)
+	}
+	for key, search := range query.FullText {
+		field, err := f.ParseValidateSearchField(key, db.SEARCH_FULL_TEXT, search.Query)
+		if err != nil {
+			return nil, err
+		}
+		if search.Language != "" && !fullTextLanguage.MatchString(search.Language) {
+			return nil, &validator.ValidationError{Message: "Invalid language of full text search", Field: key}
+		}
+		filter.FullText[field] = &db.FullTextSearch{Query: search.Query, Language: search.Language}
+	}
+
 	// done
 	return filter, nil
 }
 
+// Qualify field name with table of destination model. Names that are already qualified or not found in destination model are kept as is.
+func (f *FilterParser) QualifiedName(name string) string {
+	if strings.Contains(name, ".") {
+		return name
+	}
+	field, err := f.Destination.FindJsonField(name)
+	if err != nil {
+		return name
+	}
+	return field.FullDbName
+}
+
+func (f *FilterParser) qualifyFields(fields db.Fields) db.Fields {
+	if fields == nil {
+		return nil
+	}
+	result := make(db.Fields, len(fields))
+	for name, value := range fields {
+		result[f.QualifiedName(name)] = value
+	}
+	return result
+}
+
+func qualifyMap[T any](f *FilterParser, m map[string]T) map[string]T {
+	if m == nil {
+		return nil
+	}
+	result := make(map[string]T, len(m))
+	for name, value := range m {
+		result[f.QualifiedName(name)] = value
+	}
+	return result
+}
+
+// Make copy of filter with field names qualified by tables of destination model.
+// Used in join queries so that the same field names can be used as in queries of single model.
+func (f *FilterParser) QualifyFilter(filter *db.Filter) *db.Filter {
+
+	result := *filter
+	result.SortField = f.QualifiedName(filter.SortField)
+	if filter.IsKeyset() && filter.SortField == "" {
+		// keyset pages are ordered by ID that must be qualified in joins
+		result.SortField = f.QualifiedName("id")
+	}
+	result.Fields = f.qualifyFields(filter.Fields)
+	result.FieldsIn = qualifyMap(f, filter.FieldsIn)
+	result.FieldsNotIn = qualifyMap(f, filter.FieldsNotIn)
+	result.Intervals = qualifyMap(f, filter.Intervals)
+	result.Like = qualifyMap(f, filter.Like)
+	result.Prefix = qualifyMap(f, filter.Prefix)
+	result.FullText = qualifyMap(f, filter.FullText)
+
+	if filter.PresetFields != nil {
+		result.PresetFields = make([]db.Fields, len(filter.PresetFields))
+		for i, fields := range filter.PresetFields {
+			result.PresetFields[i] = f.qualifyFields(fields)
+		}
+	}
+	if filter.BetweenFields != nil {
+		result.BetweenFields = make([]*db.BetweenFields, len(filter.BetweenFields))
+		for i, between := range filter.BetweenFields {
+			b := *between
+			b.FromField = f.QualifiedName(between.FromField)
+			b.ToField = f.QualifiedName(between.ToField)
+			result.BetweenFields[i] = &b
+		}
+	}
+	if filter.OrFields != nil {
+		result.OrFields = make([]*db.OrFields, len(filter.OrFields))
+		for i, orFields := range filter.OrFields {
+			o := &db.OrFields{Value: orFields.Value, Fields: make([]string, len(orFields.Fields))}
+			for j, name := range orFields.Fields {
+				o.Fields[j] = f.QualifiedName(name)
+			}
+			result.OrFields[i] = o
+		}
+	}
+
+	return &result
+}
+
 type FilterManager struct {
 	mutex      sync.Mutex
 	modelStore *ModelStore
@@ -289,6 +453,47 @@ func NewFilterManager(modelStore ...*ModelStore) *FilterManager {
 
 func (f *FilterManager) PrepareFilterParser(model interface{}, name string, validator ...*db.FilterValidator) (db.FilterParser, error) {
 
+	parser, err := f.DestinationParser(model, validator...)
+	if err != nil {
+		return nil, err
+	}
+
+	// save parser in cache
+	f.mutex.Lock()
+	f.parsers[name] = parser
+	f.mutex.Unlock()
+
+	// done
+	return parser, nil
+}
+
+// Find descriptor of join destination with parsed fields, destination model is registered if it was not registered yet.
+func (f *FilterManager) DestinationDescriptor(destination interface{}) (*ModelDescriptor, error) {
+
+	destinationSchema, err := schema.Parse(destination, f.modelStore.schemaCache, f.modelStore.schemaNamer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse destination model: %s", err)
+	}
+
+	destinationDescriptor := f.modelStore.FindDescriptor(destinationSchema.Table)
+	if destinationDescriptor == nil {
+		f.modelStore.RegisterModel(destination)
+		destinationDescriptor = f.modelStore.FindDescriptor(destinationSchema.Table)
+	}
+
+	if destinationDescriptor.FieldsJson == nil {
+		err = f.modelStore.ParseModelFields(destinationDescriptor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse destination model fields: %s", err)
+		}
+	}
+
+	return destinationDescriptor, nil
+}
+
+// Make parser for model without keeping it in cache.
+func (f *FilterManager) DestinationParser(model interface{}, validator ...*db.FilterValidator) (*FilterParser, error) {
+
 	parser := &FilterParser{}
 	parser.Manager = f
 
@@ -312,11 +517,6 @@ func (f *FilterManager) PrepareFilterParser(model interface{}, name string, vali
 	// keep validator
 	parser.Validator = utils.OptionalArg(nil, validator...)
 
-	// save parser in cache
-	f.mutex.Lock()
-	f.parsers[name] = parser
-	f.mutex.Unlock()
-
 	// done
 	return parser, nil
 }
@@ -61,7 +61,7 @@ func (g *GormDB) Config() interface{} {
 	return &g.gormDBConfig
 }
 
-var DefaultDbConnector = PostgresDbConnector
+var DefaultDbConnector = SqlDbConnector
 
 func New(dbConnector ...*DbConnector) *GormDB {
 	g := &GormDB{}
@@ -1,6 +1,7 @@
 package db_gorm
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/evgeniums/go-utils/pkg/db"
@@ -36,12 +37,30 @@ type JoinQueryConstructor struct {
 	groupFields map[string]bool
 }
 
-// TODO make join type configurable LEFT/LEFT OUTER/RIGHT/RIGHT OUTER
+// Name of table in query, alias is used if set.
+func (jt *JoinTable) Name(f *FilterManager) (string, error) {
+	if jt.Alias() != "" {
+		return jt.Alias(), nil
+	}
+	s, err := jt.Schema(f)
+	if err != nil {
+		return "", err
+	}
+	return s.Table, nil
+}
+
 func constructJoins(g *gorm.DB, f *FilterManager, q *JoinQueryConstructor) (*gorm.DB, error) {
 	db := g
 
+	aliases := make(map[string]bool)
 	for _, pair := range q.pairs {
-		leftSchema, err := pair.left.Schema(f)
+		if !pair.Type().Valid() {
+			return nil, fmt.Errorf("invalid join type %s", pair.Type())
+		}
+		if pair.left.Model() == nil && !aliases[pair.left.Alias()] {
+			return nil, fmt.Errorf("unknown alias %s", pair.left.Alias())
+		}
+		left, err := pair.left.Name(f)
 		if err != nil {
 			return nil, err
 		}
@@ -49,7 +68,14 @@ func constructJoins(g *gorm.DB, f *FilterManager, q *JoinQueryConstructor) (*gor
 		if err != nil {
 			return nil, err
 		}
-		join := fmt.Sprintf("LEFT OUTER JOIN \"%s\" ON \"%s\".\"%s\"=\"%s\".\"%s\"", rightSchema.Table, leftSchema.Table, pair.LeftField(), rightSchema.Table, pair.RightField())
+		right := rightSchema.Table
+		table := fmt.Sprintf("\"%s\"", right)
+		if pair.right.Alias() != "" {
+			right = pair.right.Alias()
+			table = fmt.Sprintf("\"%s\" AS \"%s\"", rightSchema.Table, right)
+			aliases[right] = true
+		}
+		join := fmt.Sprintf("%s JOIN %s ON \"%s\".\"%s\"=\"%s\".\"%s\"", pair.Type(), table, left, pair.LeftField(), right, pair.RightField())
 		db = db.Joins(join)
 	}
 
@@ -60,27 +86,20 @@ func PrepareJoin(f *FilterManager, g *gorm.DB, constructor *JoinQueryConstructor
 
 	q := constructor
 
+	if len(q.pairs) == 0 {
+		return nil, errors.New("join query must have at least one join")
+	}
 	mainModel := q.pairs[0].left.Model()
+	if mainModel == nil {
+		return nil, errors.New("first join must start with model")
+	}
 	db := g.Model(mainModel)
 
-	destinationSchema, err := schema.Parse(constructor.Destination(), f.modelStore.schemaCache, f.modelStore.schemaNamer)
+	destinationDescriptor, err := f.DestinationDescriptor(constructor.Destination())
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse destination model: %s", err)
-	}
-
-	destinationDescriptor := f.modelStore.FindDescriptor(destinationSchema.Table)
-	if destinationDescriptor == nil {
-		f.modelStore.RegisterModel(constructor.Destination())
-		destinationDescriptor = f.modelStore.FindDescriptor(destinationSchema.Table)
+		return nil, err
 	}
-
 	selects := make([]string, 0, len(destinationDescriptor.FieldsJson))
-	if destinationDescriptor.FieldsJson == nil {
-		err = f.modelStore.ParseModelFields(destinationDescriptor)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse destination model fields: %s", err)
-		}
-	}
 
 	sums := len(constructor.sumFields) > 0
 	groups := len(constructor.groupFields) > 0 || sums
@@ -123,15 +142,24 @@ func PrepareJoin(f *FilterManager, g *gorm.DB, constructor *JoinQueryConstructor
 type JoinQuery struct {
 	db              *GormDB
 	preparedSession *gorm.DB
+	parser          *FilterParser
 }
 
+// Plain names of filter fields are replaced with names qualified by tables of joined models.
 func (j *JoinQuery) Join(ctx logger.WithLogger, filter *Filter, dest interface{}) (int64, error) {
-	// TODO process filter fields to match nested field names
 	session := j.preparedSession.Session(&gorm.Session{})
 	if j.db != nil && j.db.ENABLE_DEBUG {
 		session = session.Debug()
 	}
-	return find(session, filter, j.db.paginator, dest)
+	if filter == nil || j.parser == nil {
+		return find(session, filter, j.db.paginator, dest)
+	}
+
+	qualified := j.parser.QualifyFilter(filter)
+	count, err := find(session, qualified, j.db.paginator, dest)
+	filter.NextCursor = qualified.NextCursor
+	filter.PrevCursor = qualified.PrevCursor
+	return count, err
 }
 
 type Joiner struct {
@@ -186,10 +214,9 @@ func (j *Joiner) Destination(destination interface{}) (db.JoinQuery, error) {
 	}
 
 	q := &JoinQuery{preparedSession: preparedSession, db: j.db}
-	models := make(map[string]interface{})
-	for _, pair := range j.constructor.pairs {
-		models[pair.left.schema.Table] = pair.left.Model
-		models[pair.right.schema.Table] = pair.right.Model
+	q.parser, err = j.db.filterManager.DestinationParser(destination)
+	if err != nil {
+		return nil, err
 	}
 
 	return q, nil
@@ -206,6 +233,17 @@ func (j *Joiner) Join(model interface{}, field string) db.JoinBegin {
 	return j
 }
 
+func (j *Joiner) JoinAlias(alias string, field string) db.JoinBegin {
+	if j.constructor == nil {
+		j.constructor = newJoinQueryConstuctor()
+	}
+	j.pair = &JoinPair{}
+	j.pair.left = &JoinTable{}
+	j.pair.left.JoinTableData.Alias = alias
+	j.pair.JoinPairData.LeftField = field
+	return j
+}
+
 func (j *Joiner) On(model interface{}, field string) db.JoinEnd {
 	if j.constructor == nil || j.pair == nil {
 		panic("can not call ON without calling Join first")
@@ -217,6 +255,22 @@ func (j *Joiner) On(model interface{}, field string) db.JoinEnd {
 	return j
 }
 
+func (j *Joiner) Type(joinType db.JoinType) db.JoinEnd {
+	if j.pair == nil {
+		panic("can not set join type without calling Join first")
+	}
+	j.pair.JoinPairData.Type = joinType
+	return j
+}
+
+func (j *Joiner) As(alias string) db.JoinEnd {
+	if j.pair == nil {
+		panic("can not set alias without calling Join first")
+	}
+	j.pair.right.JoinTableData.Alias = alias
+	return j
+}
+
 func (g *GormDB) Joiner() db.Joiner {
 	return newJoiner(g)
 }
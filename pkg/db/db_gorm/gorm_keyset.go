@@ -0,0 +1,180 @@
+package db_gorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/evgeniums/go-utils/pkg/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+var keysetSchemaCache = &sync.Map{}
+var keysetSchemaNamer = &schema.NamingStrategy{}
+
+func keysetIdField(sortField string) string {
+	parts := strings.Split(sortField, ".")
+	if len(parts) == 2 {
+		return fmt.Sprintf("%s.id", parts[0])
+	}
+	return "id"
+}
+
+func schemaFieldName(name string) string {
+	parts := strings.Split(name, ".")
+	return parts[len(parts)-1]
+}
+
+func keysetValue(g *gorm.DB, sortField string, value string) interface{} {
+	if g.Statement.Model == nil || g.Statement.Parse(g.Statement.Model) != nil {
+		return value
+	}
+	field := g.Statement.Schema.LookUpField(schemaFieldName(sortField))
+	if field == nil {
+		return value
+	}
+	val, err := ConvertValue(field, value)
+	if err != nil {
+		return value
+	}
+	return val
+}
+
+// Rows are ordered by sort field and ID, rows following the cursor are selected.
+// For backward cursor the order is reversed and the rows are reversed back after query.
+func keysetPaginate(g *gorm.DB, filter *Filter) *gorm.DB {
+
+	h := g
+
+	var cursor *db.KeysetCursor
+	if filter.Cursor != "" {
+		var err error
+		cursor, err = db.DecodeKeysetCursor(filter.Cursor)
+		if err != nil {
+			h.AddError(err)
+			return h
+		}
+	}
+
+	asc := filter.SortDirection != db.SORT_DESC
+	if cursor != nil && cursor.Backward {
+		asc = !asc
+	}
+	direction := db.SORT_ASC
+	op := ">"
+	if !asc {
+		direction = db.SORT_DESC
+		op = "<"
+	}
+
+	idField := quoteField(keysetIdField(filter.SortField))
+	sortField := ""
+	if filter.SortField != "" && schemaFieldName(filter.SortField) != "id" {
+		sortField = quoteField(filter.SortField)
+	}
+
+	if cursor != nil {
+		if sortField != "" {
+			value := keysetValue(h, filter.SortField, cursor.Value)
+			h = h.Where(fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s ?))", sortField, op, sortField, idField, op), value, value, cursor.ID)
+		} else {
+			h = h.Where(fmt.Sprintf("%s %s ?", idField, op), cursor.ID)
+		}
+	}
+
+	if sortField != "" {
+		h = h.Order(fmt.Sprintf("%s %s", sortField, direction))
+	}
+	h = h.Order(fmt.Sprintf("%s %s", idField, direction))
+
+	return h
+}
+
+func reverseSlice(v reflect.Value) {
+	swap := reflect.Swapper(v.Interface())
+	for i, j := 0, v.Len()-1; i < j; i, j = i+1, j-1 {
+		swap(i, j)
+	}
+}
+
+// Rows are selected with one extra row to detect if there is a next page, that row is trimmed here.
+// Number of rows in the page is returned.
+func (p *Paginator) SetKeysetCursors(filter *Filter, dest interface{}) (int64, error) {
+	return KeysetCursors(filter, dest, p.Limit(filter))
+}
+
+func KeysetCursors(filter *Filter, dest interface{}, limit int) (int64, error) {
+
+	filter.NextCursor = ""
+	filter.PrevCursor = ""
+
+	v := reflect.Indirect(reflect.ValueOf(dest))
+	if v.Kind() != reflect.Slice {
+		return 0, nil
+	}
+
+	backward := false
+	if filter.Cursor != "" {
+		cursor, err := db.DecodeKeysetCursor(filter.Cursor)
+		if err != nil {
+			return 0, err
+		}
+		backward = cursor.Backward
+	}
+
+	more := limit > 0 && v.Len() > limit
+	if more {
+		v.Set(v.Slice(0, limit))
+	}
+	if backward {
+		reverseSlice(v)
+	}
+
+	n := v.Len()
+	if n == 0 {
+		return 0, nil
+	}
+
+	s, err := schema.Parse(dest, keysetSchemaCache, keysetSchemaNamer)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse schema of keyset destination: %s", err)
+	}
+	idField := s.LookUpField("id")
+	if idField == nil {
+		return 0, errors.New("keyset pagination requires ID field")
+	}
+	var sortField *schema.Field
+	if filter.SortField != "" {
+		sortField = s.LookUpField(schemaFieldName(filter.SortField))
+	}
+
+	makeCursor := func(index int, backward bool) string {
+		item := reflect.Indirect(v.Index(index))
+		id, _ := idField.ValueOf(context.Background(), item)
+		var value interface{}
+		if sortField != nil {
+			value, _ = sortField.ValueOf(context.Background(), item)
+		}
+		return db.EncodeKeysetCursor(value, fmt.Sprintf("%v", id), backward)
+	}
+
+	if backward {
+		if more {
+			filter.PrevCursor = makeCursor(0, true)
+		}
+		filter.NextCursor = makeCursor(n-1, false)
+	} else {
+		if more {
+			filter.NextCursor = makeCursor(n-1, false)
+		}
+		if filter.Cursor != "" {
+			filter.PrevCursor = makeCursor(0, true)
+		}
+	}
+
+	return int64(n), nil
+}
@@ -94,17 +94,18 @@ func compareOp(isOpen bool, comparator string) string {
 
 func prepareInterval(db *gorm.DB, name string, interval *Interval) *gorm.DB {
 	h := db
+	name = quoteField(name)
 
 	if interval.From != nil && interval.To != nil {
 		if interval.From == interval.To {
-			h = h.Where(fmt.Sprintf("\"%v\" = ?", name), interval.From)
+			h = h.Where(fmt.Sprintf("%v = ?", name), interval.From)
 		} else {
-			h = h.Where(fmt.Sprintf("\"%v\" %s ? AND \"%v\" %s ? ", name, compareOp(interval.FromOpen, ">"), name, compareOp(interval.ToOpen, "<")), interval.From, interval.To)
+			h = h.Where(fmt.Sprintf("%v %s ? AND %v %s ? ", name, compareOp(interval.FromOpen, ">"), name, compareOp(interval.ToOpen, "<")), interval.From, interval.To)
 		}
 	} else if interval.From != nil {
-		h = h.Where(fmt.Sprintf("\"%v\" %s ? ", name, compareOp(interval.FromOpen, ">")), interval.From)
+		h = h.Where(fmt.Sprintf("%v %s ? ", name, compareOp(interval.FromOpen, ">")), interval.From)
 	} else if interval.To != nil {
-		h = h.Where(fmt.Sprintf("\"%v\" %s ? ", name, compareOp(interval.ToOpen, "<")), interval.To)
+		h = h.Where(fmt.Sprintf("%v %s ? ", name, compareOp(interval.ToOpen, "<")), interval.To)
 	}
 	return h
 }
@@ -121,11 +122,11 @@ func prepareFilter(db *gorm.DB, filter *Filter) *gorm.DB {
 	}
 
 	for field, values := range filter.FieldsIn {
-		h = h.Where(fmt.Sprintf("\"%v\" IN ? ", field), values)
+		h = h.Where(fmt.Sprintf("%v IN ? ", quoteField(field)), values)
 	}
 
 	for field, values := range filter.FieldsNotIn {
-		h = h.Where(fmt.Sprintf("\"%v\" NOT IN ? ", field), values)
+		h = h.Where(fmt.Sprintf("%v NOT IN ? ", quoteField(field)), values)
 	}
 
 	for name, interval := range filter.Intervals {
@@ -133,7 +134,7 @@ func prepareFilter(db *gorm.DB, filter *Filter) *gorm.DB {
 	}
 
 	for _, between := range filter.BetweenFields {
-		h = h.Where(fmt.Sprintf("? %s \"%v\" AND ? %s \"%v\"", compareOp(between.FromOpen, ">"), between.FromField, compareOp(between.ToOpen, ">"), between.ToField), between.Value, between.Value)
+		h = h.Where(fmt.Sprintf("? %s %v AND ? %s %v", compareOp(between.FromOpen, ">"), quoteField(between.FromField), compareOp(between.ToOpen, ">"), quoteField(between.ToField)), between.Value, between.Value)
 	}
 
 	for _, orFields := range filter.OrFields {
@@ -149,6 +150,8 @@ func prepareFilter(db *gorm.DB, filter *Filter) *gorm.DB {
 		}
 	}
 
+	h = prepareSearch(h, filter)
+
 	return h
 }
 
@@ -165,13 +168,9 @@ func SetFilter(g *gorm.DB, filter *Filter, paginator *Paginator, docs interface{
 
 	h = prepareFilter(h, filter)
 
-	if filter.SortField != "" && (filter.SortDirection == db.SORT_ASC || filter.SortDirection == db.SORT_DESC) {
-		parts := strings.Split(filter.SortField, ".")
-		if len(parts) == 2 {
-			h = h.Order(fmt.Sprintf("\"%s\".\"%s\" %s", parts[0], parts[1], filter.SortDirection))
-		} else {
-			h = h.Order(fmt.Sprintf("\"%s\" %s", filter.SortField, filter.SortDirection))
-		}
+	// with keyset pagination sorting is set by paginator
+	if !filter.IsKeyset() && filter.SortField != "" && (filter.SortDirection == db.SORT_ASC || filter.SortDirection == db.SORT_DESC) {
+		h = h.Order(fmt.Sprintf("%s %s", quoteField(filter.SortField), filter.SortDirection))
 	}
 
 	if paginator != nil {
@@ -185,22 +184,48 @@ type Paginator struct {
 	MaxLimit int
 }
 
+func quoteField(name string) string {
+	parts := strings.Split(name, ".")
+	if len(parts) == 2 {
+		return fmt.Sprintf("\"%s\".\"%s\"", parts[0], parts[1])
+	}
+	return fmt.Sprintf("\"%s\"", name)
+}
+
+func (p *Paginator) Limit(filter *Filter) int {
+	if filter.Limit > 0 {
+		if !filter.NoLimit && filter.Limit > p.MaxLimit && p.MaxLimit > 0 {
+			return p.MaxLimit
+		}
+		return filter.Limit
+	} else if !filter.NoLimit && p.MaxLimit > 0 {
+		return p.MaxLimit
+	}
+	return 0
+}
+
 func (p *Paginator) Paginate(g *gorm.DB, filter *Filter, paginate ...bool) *gorm.DB {
-	h := g
 	if utils.OptionalArg(true, paginate...) {
-		if filter.Offset > 0 {
-			h = h.Offset(filter.Offset)
-		}
+		return p.paginate(g, filter, false)
+	}
+	return g
+}
 
-		if filter.Limit > 0 {
-			limit := filter.Limit
-			if !filter.NoLimit && limit > p.MaxLimit && p.MaxLimit > 0 {
-				limit = p.MaxLimit
-			}
-			h = h.Limit(limit)
-		} else if !filter.NoLimit && p.MaxLimit > 0 {
-			h = h.Limit(p.MaxLimit)
+// With lookahead one extra row is selected in keyset mode to detect if there is a next page.
+func (p *Paginator) paginate(g *gorm.DB, filter *Filter, lookahead bool) *gorm.DB {
+	h := g
+	if filter.IsKeyset() {
+		h = keysetPaginate(h, filter)
+	} else if filter.Offset > 0 {
+		h = h.Offset(filter.Offset)
+	}
+
+	limit := p.Limit(filter)
+	if limit > 0 {
+		if lookahead && filter.IsKeyset() {
+			limit++
 		}
+		h = h.Limit(limit)
 	}
 	return h
 }
@@ -211,14 +236,16 @@ func find(g *gorm.DB, filter *Filter, paginator *Paginator, dest interface{}) (i
 
 	h := g
 	if filter != nil {
-		h = SetFilter(g, filter, paginator, nil, !filter.Count)
+		h = SetFilter(g, filter, paginator, nil, false)
 		if filter.Count {
 			counter := h.Session(&gorm.Session{})
 			result := counter.Count(&count)
 			if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
 				return 0, result.Error
 			}
-			h = paginator.Paginate(h, filter)
+		}
+		if paginator != nil {
+			h = paginator.paginate(h, filter, true)
 		}
 	}
 
@@ -230,6 +257,16 @@ func find(g *gorm.DB, filter *Filter, paginator *Paginator, dest interface{}) (i
 		count = result.RowsAffected
 	}
 
+	if filter != nil && filter.IsKeyset() && paginator != nil {
+		n, err := paginator.SetKeysetCursors(filter, dest)
+		if err != nil {
+			return 0, err
+		}
+		if !filter.Count {
+			count = n
+		}
+	}
+
 	return count, nil
 }
 
@@ -0,0 +1,63 @@
+package db_gorm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/evgeniums/go-utils/pkg/utils"
+	"gorm.io/gorm"
+)
+
+const DefaultFullTextLanguage = "simple"
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func EscapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
+
+func isPostgres(g *gorm.DB) bool {
+	return g.Dialector != nil && g.Dialector.Name() == "postgres"
+}
+
+func likeCondition(g *gorm.DB, name string, caseInsensitive bool) string {
+	field := quoteField(name)
+	if !caseInsensitive {
+		return fmt.Sprintf(`%s LIKE ? ESCAPE '\'`, field)
+	}
+	if isPostgres(g) {
+		return fmt.Sprintf(`%s ILIKE ? ESCAPE '\'`, field)
+	}
+	return fmt.Sprintf(`LOWER(%s) LIKE LOWER(?) ESCAPE '\'`, field)
+}
+
+// Full text search uses tsvector in postgres.
+// Other databases fall back to case insensitive matching of each word of the query.
+func prepareSearch(g *gorm.DB, filter *Filter) *gorm.DB {
+
+	h := g
+
+	for name, pattern := range filter.Like {
+		h = h.Where(likeCondition(h, name, pattern.CaseInsensitive), pattern.Pattern)
+	}
+
+	for name, prefix := range filter.Prefix {
+		h = h.Where(likeCondition(h, name, prefix.CaseInsensitive), utils.ConcatStrings(EscapeLike(prefix.Pattern), "%"))
+	}
+
+	for name, search := range filter.FullText {
+		if isPostgres(h) {
+			language := search.Language
+			if language == "" {
+				language = DefaultFullTextLanguage
+			}
+			h = h.Where(fmt.Sprintf("to_tsvector(?::regconfig, %s) @@ plainto_tsquery(?::regconfig, ?)", quoteField(name)), language, language, search.Query)
+		} else {
+			for _, word := range strings.Fields(search.Query) {
+				h = h.Where(likeCondition(h, name, true), utils.ConcatStrings("%", EscapeLike(word), "%"))
+			}
+		}
+	}
+
+	return h
+}
@@ -0,0 +1,165 @@
+package db_gorm
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/logger"
+	"github.com/evgeniums/go-utils/pkg/utils"
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func SqliteOpener(provider string, dsn string) (gorm.Dialector, error) {
+
+	if provider != "sqlite" {
+		return nil, errors.New("unknown database provider")
+	}
+
+	return sqlite.Open(dsn), nil
+}
+
+// DB_NAME is a path to database file, if DB_HOST is set then it is used as a folder of database file.
+func SqliteDsnBuilder(config *db.DBConfig) (string, error) {
+
+	if config.DB_NAME == "" {
+		return "", errors.New("database name must be specified")
+	}
+
+	dsn := config.DB_NAME
+	if config.DB_HOST != "" {
+		dsn = filepath.Join(config.DB_HOST, config.DB_NAME)
+	}
+	if config.DB_EXTRA_CONFIG != "" {
+		dsn = utils.ConcatStrings(dsn, "?", config.DB_EXTRA_CONFIG)
+	}
+
+	return dsn, nil
+}
+
+// Sqlite databases are created on opening.
+func SqliteDbCreator(provider string, db *gorm.DB, dbName string) error {
+
+	if provider != "sqlite" {
+		return errors.New("unknown database provider")
+	}
+
+	return nil
+}
+
+func SqliteCheckDuplicateKeyError(provider string, result *gorm.DB) (bool, error) {
+
+	if provider != "sqlite" {
+		return false, errors.New("unknown database provider")
+	}
+
+	if err, ok := result.Error.(sqlite3.Error); ok {
+		if err.ExtendedCode == sqlite3.ErrConstraintUnique || err.ExtendedCode == sqlite3.ErrConstraintPrimaryKey {
+			return true, errors.New("record already exists")
+		}
+	}
+
+	return false, result.Error
+}
+
+// Sqlite does not support partitions, so records of all months are kept in the main table.
+// Detached months are moved to separate per-month tables named the same way as partitions in postgres.
+func SqlitePartitionedMonthMigrator(provider string, ctx logger.WithLogger, db *gorm.DB, models ...interface{}) error {
+
+	if provider != "sqlite" {
+		return errors.New("unknown database provider")
+	}
+
+	if len(models) == 0 {
+		return nil
+	}
+
+	err := db.AutoMigrate(models...)
+	if err != nil {
+		return ctx.Logger().PushFatalStack("failed to migrate partitioned database models", err)
+	}
+
+	return nil
+}
+
+func SqlitePartitionedMonthDetach(provider string, ctx logger.WithLogger, db *gorm.DB, table string, months []utils.Month) error {
+
+	if provider != "sqlite" {
+		return errors.New("unknown database provider")
+	}
+
+	for _, month := range months {
+
+		partition := partitionTableName(table, month)
+		fields := logger.Fields{"month": month, "table": table, "partition": partition}
+		ctx.Logger().Info("Detaching partition", fields)
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+
+			if !tx.Migrator().HasTable(partition) {
+				result := tx.Exec(fmt.Sprintf("CREATE TABLE \"%s\" AS SELECT * FROM \"%s\" WHERE 0;", partition, table))
+				if result.Error != nil {
+					return result.Error
+				}
+			}
+
+			result := tx.Exec(fmt.Sprintf("INSERT INTO \"%s\" SELECT * FROM \"%s\" WHERE month = ?;", partition, table), month)
+			if result.Error != nil {
+				return result.Error
+			}
+
+			result = tx.Exec(fmt.Sprintf("DELETE FROM \"%s\" WHERE month = ?;", table), month)
+			return result.Error
+		})
+		if err != nil {
+			return ctx.Logger().PushFatalStack("failed to detach partition", err, fields)
+		}
+	}
+
+	return nil
+}
+
+func SqlitePartitionedMonthDelete(provider string, ctx logger.WithLogger, db *gorm.DB, table string, months []utils.Month) error {
+
+	if provider != "sqlite" {
+		return errors.New("unknown database provider")
+	}
+
+	for _, month := range months {
+
+		partition := partitionTableName(table, month)
+		fields := logger.Fields{"month": month, "table": table, "partition": partition}
+		ctx.Logger().Info("Deleting partition", fields)
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+
+			result := tx.Exec(fmt.Sprintf("DELETE FROM \"%s\" WHERE month = ?;", table), month)
+			if result.Error != nil {
+				return result.Error
+			}
+
+			result = tx.Exec(fmt.Sprintf("DROP TABLE IF EXISTS \"%s\";", partition))
+			return result.Error
+		})
+		if err != nil {
+			return ctx.Logger().PushFatalStack("failed to delete partition", err, fields)
+		}
+	}
+
+	return nil
+}
+
+func SqliteDbConnector() *DbConnector {
+	c := &DbConnector{}
+	c.DialectorOpener = SqliteOpener
+	c.DsnBuilder = SqliteDsnBuilder
+	c.CheckDuplicateKeyError = SqliteCheckDuplicateKeyError
+	c.PartitionedMonthMigrator = SqlitePartitionedMonthMigrator
+	c.PartitionedMonthDeleter = SqlitePartitionedMonthDelete
+	c.PartitionedMonthDetacher = SqlitePartitionedMonthDetach
+	c.DbCreator = SqliteDbCreator
+	return c
+}
@@ -0,0 +1,52 @@
+package db_mongo
+
+import (
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"gorm.io/gorm/schema"
+)
+
+var columnNamer = &schema.NamingStrategy{}
+
+// Struct fields are mapped to document fields the same way gorm maps them to table columns,
+// so that the same models and field names in filters can be used with both databases.
+func parseStructTags(field reflect.StructField) (bsoncodec.StructTags, error) {
+
+	tags := bsoncodec.StructTags{}
+
+	settings := schema.ParseTagSetting(field.Tag.Get("gorm"), ";")
+	if value, ok := settings["-"]; ok && (value == "-" || strings.ToLower(value) == "all") {
+		tags.Skip = true
+		return tags, nil
+	}
+
+	_, embedded := settings["EMBEDDED"]
+	if (field.Anonymous || embedded) && field.Type.Kind() == reflect.Struct {
+		tags.Inline = true
+		return tags, nil
+	}
+
+	tags.Name = settings["COLUMN"]
+	if tags.Name == "" {
+		tags.Name = columnNamer.ColumnName("", field.Name)
+	}
+
+	return tags, nil
+}
+
+func NewRegistry() (*bsoncodec.Registry, error) {
+
+	codec, err := bsoncodec.NewStructCodec(bsoncodec.StructTagParserFunc(parseStructTags))
+	if err != nil {
+		return nil, err
+	}
+
+	registry := bson.NewRegistry()
+	registry.RegisterKindEncoder(reflect.Struct, codec)
+	registry.RegisterKindDecoder(reflect.Struct, codec)
+
+	return registry, nil
+}
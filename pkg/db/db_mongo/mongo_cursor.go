@@ -0,0 +1,42 @@
+package db_mongo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/evgeniums/go-utils/pkg/logger"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MongoCursor struct {
+	cursor *mongo.Cursor
+	ctx    context.Context
+}
+
+func (c *MongoCursor) Close(ctx logger.WithLogger) error {
+	err := c.cursor.Close(c.ctx)
+	if err != nil {
+		err = fmt.Errorf("failed to close cursor")
+		ctx.Logger().Error("MongoDB.Cursor", err)
+	}
+	return err
+}
+
+func (c *MongoCursor) Scan(ctx logger.WithLogger, obj interface{}) error {
+	err := c.cursor.Decode(obj)
+	if err != nil {
+		err = fmt.Errorf("failed to decode document to object %v", ObjectTypeName(obj))
+		ctx.Logger().Error("MongoDB.Cursor", err)
+	}
+	return err
+}
+
+func (c *MongoCursor) Next(ctx logger.WithLogger) (bool, error) {
+	next := c.cursor.Next(c.ctx)
+	err := c.cursor.Err()
+	if err != nil {
+		err = fmt.Errorf("failed to read next document")
+		ctx.Logger().Error("MongoDB.Cursor", err)
+	}
+	return next, err
+}
@@ -0,0 +1,527 @@
+package db_mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/evgeniums/go-utils/pkg/common"
+	"github.com/evgeniums/go-utils/pkg/config"
+	"github.com/evgeniums/go-utils/pkg/config/object_config"
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/db/db_gorm"
+	"github.com/evgeniums/go-utils/pkg/logger"
+	"github.com/evgeniums/go-utils/pkg/utils"
+	"github.com/evgeniums/go-utils/pkg/validator"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const Provider = "mongodb"
+
+const DefaultConnectTimeout = 30 * time.Second
+
+type baseDBConfig struct {
+	ENABLE_DEBUG     bool
+	VERBOSE_ERRORS   bool
+	MAX_FILTER_LIMIT int           `validate:"gte=0" vmessage:"Invalid max filter limit" default:"100"`
+	CONNECT_TIMEOUT  time.Duration `default:"30s"`
+}
+
+type mongoDBConfig struct {
+	db.DBConfig
+	baseDBConfig
+}
+
+type DbState struct {
+	mongoDBConfig
+
+	client        *mongo.Client
+	filterManager *db_gorm.FilterManager
+	paginator     *Paginator
+	joinQueries   *db.JoinQueries
+
+	id string
+}
+
+type MongoDB struct {
+	db  *mongo.Database
+	ctx context.Context
+
+	DbState
+}
+
+func (m *MongoDB) ID() string {
+	return m.id
+}
+
+func (m *MongoDB) Config() interface{} {
+	return &m.mongoDBConfig
+}
+
+func New() *MongoDB {
+	m := &MongoDB{}
+
+	m.id = utils.GenerateID()
+	m.ctx = context.TODO()
+
+	m.filterManager = db_gorm.NewFilterManager()
+	m.paginator = &Paginator{}
+	m.joinQueries = db.NewJoinQueries()
+
+	return m
+}
+
+func DsnBuilder(config *db.DBConfig) (string, error) {
+
+	if config.DB_HOST == "" {
+		return "", errors.New("database host must be specified")
+	}
+
+	u := &url.URL{Scheme: "mongodb", Host: config.DB_HOST, Path: "/", RawQuery: config.DB_EXTRA_CONFIG}
+	if config.DB_PORT != 0 {
+		u.Host = fmt.Sprintf("%s:%d", config.DB_HOST, config.DB_PORT)
+	}
+	if config.DB_USER != "" {
+		u.User = url.UserPassword(config.DB_USER, config.DB_PASSWORD)
+	}
+
+	return u.String(), nil
+}
+
+func (m *MongoDB) ParseFilter(query *db.Query, parserName string) (*db.Filter, error) {
+	return m.filterManager.ParseFilter(query, parserName)
+}
+
+func (m *MongoDB) ParseFilterDirect(query *db.Query, model interface{}, parserName string, vld ...*db.FilterValidator) (*db.Filter, error) {
+	return m.filterManager.ParseFilterDirect(query, model, parserName, vld...)
+}
+
+func (m *MongoDB) PrepareFilterParser(model interface{}, name string, validator ...*db.FilterValidator) (db.FilterParser, error) {
+	return m.filterManager.PrepareFilterParser(model, name, validator...)
+}
+
+func (m *MongoDB) NativeHandler() interface{} {
+	return m.db
+}
+
+func (m *MongoDB) Clone() db.DB {
+	d := New()
+	d.baseDBConfig = m.baseDBConfig
+	d.paginator.MaxLimit = m.MAX_FILTER_LIMIT
+	return d
+}
+
+// Debug mode enables logging of commands in mongo driver, it takes effect on connection only.
+func (m *MongoDB) EnableDebug(value bool) {
+	m.ENABLE_DEBUG = value
+}
+
+func (m *MongoDB) EnableVerboseErrors(value bool) {
+	m.VERBOSE_ERRORS = value
+}
+
+func (m *MongoDB) Init(ctx logger.WithLogger, cfg config.Config, vld validator.Validator, configPath ...string) error {
+
+	ctx.Logger().Info("Init MongoDB")
+
+	// load configuration
+	err := object_config.LoadLogValidate(cfg, ctx.Logger(), vld, m, "db", configPath...)
+	if err != nil {
+		return ctx.Logger().PushFatalStack("failed to load MongoDB configuration", err)
+	}
+	m.paginator.MaxLimit = m.MAX_FILTER_LIMIT
+
+	// connect database
+	return m.Connect(ctx)
+}
+
+func (m *MongoDB) InitWithConfig(ctx logger.WithLogger, vld validator.Validator, cfg *db.DBConfig) error {
+
+	ctx.Logger().Info("Connect MongoDB with DBConfig")
+
+	// convert configuration
+	m.mongoDBConfig.DBConfig = *cfg
+
+	// validate configuration
+	err := vld.Validate(m.Config())
+	if err != nil {
+		return ctx.Logger().PushFatalStack("failed to validate MongoDB configuration", err)
+	}
+
+	// connect database
+	return m.Connect(ctx)
+}
+
+func (m *MongoDB) Connect(ctx logger.WithLogger) error {
+
+	var err error
+
+	// prepare options
+	var dsn string
+	if m.DB_DSN != "" {
+		dsn = m.DB_DSN
+	} else {
+		dsn, err = DsnBuilder(&m.DBConfig)
+		if err != nil {
+			return ctx.Logger().PushFatalStack("failed to build DSN to connect to database", err)
+		}
+	}
+	registry, err := NewRegistry()
+	if err != nil {
+		return ctx.Logger().PushFatalStack("failed to create BSON registry", err)
+	}
+	opts := options.Client().ApplyURI(dsn).SetRegistry(registry)
+	if m.ENABLE_DEBUG {
+		opts.SetLoggerOptions(options.Logger().SetComponentLevel(options.LogComponentCommand, options.LogLevelDebug))
+	}
+
+	// connect database
+	timeout := m.CONNECT_TIMEOUT
+	if timeout == 0 {
+		timeout = DefaultConnectTimeout
+	}
+	connectCtx, cancel := context.WithTimeout(context.TODO(), timeout)
+	defer cancel()
+	m.client, err = mongo.Connect(connectCtx, opts)
+	if err != nil {
+		return ctx.Logger().PushFatalStack("failed to connect to database", err)
+	}
+	err = m.client.Ping(connectCtx, nil)
+	if err != nil {
+		m.client.Disconnect(context.TODO())
+		m.client = nil
+		return ctx.Logger().PushFatalStack("failed to ping database", err)
+	}
+	m.db = m.client.Database(m.DB_NAME)
+
+	db.Databases().Register(m)
+
+	// done
+	return nil
+}
+
+func (m *MongoDB) Close() {
+	if m.client != nil {
+		m.client.Disconnect(context.TODO())
+	}
+	db.Databases().Unregister(m)
+}
+
+func (m *MongoDB) AutoMigrate(ctx logger.WithLogger, models []interface{}) error {
+
+	for _, model := range models {
+
+		s, err := ParseSchema(model)
+		if err != nil {
+			return ctx.Logger().PushFatalStack("failed to parse model schema", err)
+		}
+		fields := logger.Fields{"collection": s.Table}
+
+		indexes := make([]mongo.IndexModel, 0)
+		if len(s.PrimaryFieldDBNames) != 0 {
+			keys := bson.D{}
+			for _, name := range s.PrimaryFieldDBNames {
+				keys = append(keys, bson.E{Key: name, Value: 1})
+			}
+			indexes = append(indexes, mongo.IndexModel{Keys: keys, Options: options.Index().SetName(utils.ConcatStrings(s.Table, "_pkey")).SetUnique(true)})
+		}
+		for _, index := range s.ParseIndexes() {
+			keys := bson.D{}
+			for _, field := range index.Fields {
+				order := 1
+				if field.Sort == "DESC" {
+					order = -1
+				}
+				keys = append(keys, bson.E{Key: field.DBName, Value: order})
+			}
+			indexes = append(indexes, mongo.IndexModel{Keys: keys, Options: options.Index().SetName(index.Name).SetUnique(index.Class == "UNIQUE")})
+		}
+		if len(indexes) == 0 {
+			continue
+		}
+
+		_, err = m.db.Collection(s.Table).Indexes().CreateMany(m.ctx, indexes)
+		if err != nil {
+			return ctx.Logger().PushFatalStack("failed to migrate database", err, fields)
+		}
+	}
+
+	return nil
+}
+
+func (m *MongoDB) MigrateDropIndex(ctx logger.WithLogger, model interface{}, indexName string) error {
+
+	collection, err := Collection(m.db, model)
+	if err != nil {
+		return ctx.Logger().PushFatalStack("failed to drop index", err)
+	}
+
+	specs, err := collection.Indexes().ListSpecifications(m.ctx)
+	if err != nil {
+		return ctx.Logger().PushFatalStack("failed to list indexes", err)
+	}
+	for _, spec := range specs {
+		if spec.Name == indexName {
+			_, err = collection.Indexes().DropOne(m.ctx, indexName)
+			if err != nil {
+				return ctx.Logger().PushFatalStack("failed to drop index", err)
+			}
+			break
+		}
+	}
+
+	return nil
+}
+
+func partitionCollectionName(table string, month utils.Month) string {
+	return fmt.Sprintf("%s_%d", table, month)
+}
+
+// Documents of all months are kept in the same collection indexed by month field.
+func (m *MongoDB) PartitionedMonthAutoMigrate(ctx logger.WithLogger, models []interface{}) error {
+	return m.AutoMigrate(ctx, models)
+}
+
+// Documents of detached months are moved to separate collections named the same way as detached partitions in SQL databases.
+func (m *MongoDB) PartitionedMonthsDetach(ctx logger.WithLogger, table string, months []utils.Month) error {
+
+	collection := m.db.Collection(table)
+
+	for _, month := range months {
+
+		partition := partitionCollectionName(table, month)
+		fields := logger.Fields{"month": month, "table": table, "partition": partition}
+		ctx.Logger().Info("Detaching partition", fields)
+
+		pipeline := mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"month": month}}},
+			{{Key: "$out", Value: partition}},
+		}
+		cursor, err := collection.Aggregate(m.ctx, pipeline)
+		if err != nil {
+			return ctx.Logger().PushFatalStack("failed to copy documents to partition", err, fields)
+		}
+		cursor.Close(m.ctx)
+
+		_, err = collection.DeleteMany(m.ctx, bson.M{"month": month})
+		if err != nil {
+			return ctx.Logger().PushFatalStack("failed to delete detached documents", err, fields)
+		}
+	}
+
+	return nil
+}
+
+func (m *MongoDB) PartitionedMonthsDelete(ctx logger.WithLogger, table string, months []utils.Month) error {
+
+	if len(months) == 0 {
+		return nil
+	}
+
+	fields := logger.Fields{"months": months, "table": table}
+	ctx.Logger().Info("Deleting partitions", fields)
+
+	_, err := m.db.Collection(table).DeleteMany(m.ctx, bson.M{"month": bson.M{"$in": months}})
+	if err != nil {
+		return ctx.Logger().PushFatalStack("failed to delete partitions", err, fields)
+	}
+
+	return nil
+}
+
+func (m *MongoDB) FindByField(ctx logger.WithLogger, field string, value interface{}, obj interface{}, dest ...interface{}) (bool, error) {
+	found, err := FindByField(m.ctx, m.db, field, value, obj, dest...)
+	if err != nil && m.VERBOSE_ERRORS {
+		e := fmt.Errorf("failed to FindByField %v", ObjectTypeName(obj))
+		ctx.Logger().Error("MongoDB", e, logger.Fields{"field": field, "value": value, "error": err.Error()})
+	}
+	return found, err
+}
+
+func (m *MongoDB) FindByFields(ctx logger.WithLogger, fields db.Fields, obj interface{}, dest ...interface{}) (bool, error) {
+	found, err := FindByFields(m.ctx, m.db, fields, obj, dest...)
+	if err != nil && m.VERBOSE_ERRORS {
+		e := fmt.Errorf("failed to FindByFields %v", ObjectTypeName(obj))
+		ctx.Logger().Error("MongoDB", e, logger.Fields{"fields": fields, "error": err.Error()})
+	}
+	return found, err
+}
+
+// Documents can not be locked for update in MongoDB, concurrent writes of the same document are detected in transactions instead.
+func (m *MongoDB) FindForUpdate(ctx logger.WithLogger, fields db.Fields, obj interface{}) (bool, error) {
+	found, err := FindByFields(m.ctx, m.db, fields, obj)
+	if err != nil && m.VERBOSE_ERRORS {
+		e := fmt.Errorf("failed to FindForUpdate %v", ObjectTypeName(obj))
+		ctx.Logger().Error("MongoDB", e, logger.Fields{"fields": fields, "error": err.Error()})
+	}
+	return found, err
+}
+
+func (m *MongoDB) FindForShare(ctx logger.WithLogger, fields db.Fields, obj interface{}) (bool, error) {
+	found, err := FindByFields(m.ctx, m.db, fields, obj)
+	if err != nil && m.VERBOSE_ERRORS {
+		e := fmt.Errorf("failed to FindForShare %v", ObjectTypeName(obj))
+		ctx.Logger().Error("MongoDB", e, logger.Fields{"fields": fields, "error": err.Error()})
+	}
+	return found, err
+}
+
+func (m *MongoDB) AllRows(ctx logger.WithLogger, obj interface{}) (db.Cursor, error) {
+	return m.RowsWithFilter(ctx, nil, obj)
+}
+
+func (m *MongoDB) RowsWithFilter(ctx logger.WithLogger, filter *Filter, obj interface{}) (db.Cursor, error) {
+	cursor, err := RowsWithFilter(m.ctx, m.db, filter, m.paginator, obj)
+	if err != nil {
+		if m.VERBOSE_ERRORS {
+			e := fmt.Errorf("failed to RowsWithFilter %v", ObjectTypeName(obj))
+			ctx.Logger().Error("MongoDB", e, logger.FieldsWithError(err))
+		}
+		return nil, err
+	}
+	return &MongoCursor{cursor: cursor, ctx: m.ctx}, nil
+}
+
+func (m *MongoDB) Create(ctx logger.WithLogger, obj interface{}) error {
+	err := Create(m.ctx, m.db, obj)
+	if err != nil && m.VERBOSE_ERRORS {
+		e := fmt.Errorf("failed to Create %v", ObjectTypeName(obj))
+		ctx.Logger().Error("MongoDB", e, logger.FieldsWithError(err))
+	}
+	return err
+}
+
+// Note that duplicate key error aborts current transaction in MongoDB even if conflicts are ignored.
+func (m *MongoDB) CreateDup(ctx logger.WithLogger, obj interface{}, ignoreConflict ...bool) (bool, error) {
+	err := Create(m.ctx, m.db, obj)
+	if err == nil {
+		return false, nil
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		if utils.OptionalArg(false, ignoreConflict...) {
+			return true, nil
+		}
+		return true, errors.New("record already exists")
+	}
+	if m.VERBOSE_ERRORS {
+		e := fmt.Errorf("failed to Create %v", ObjectTypeName(obj))
+		ctx.Logger().Error("MongoDB", e, logger.FieldsWithError(err))
+	}
+	return false, err
+}
+
+func (m *MongoDB) DeleteByField(ctx logger.WithLogger, field string, value interface{}, model interface{}) error {
+	err := DeleteByField(m.ctx, m.db, field, value, model)
+	if err != nil && m.VERBOSE_ERRORS {
+		e := fmt.Errorf("failed to DeleteByField %v", ObjectTypeName(model))
+		ctx.Logger().Error("MongoDB", e, logger.Fields{"field": field, "value": value, "error": err.Error()})
+	}
+	return err
+}
+
+func (m *MongoDB) Delete(ctx logger.WithLogger, obj common.Object) error {
+	err := Delete(m.ctx, m.db, obj)
+	if err != nil && m.VERBOSE_ERRORS {
+		e := fmt.Errorf("failed to Delete %v", ObjectTypeName(obj))
+		ctx.Logger().Error("MongoDB", e, logger.Fields{"id": obj.GetID(), "error": err.Error()})
+	}
+	return err
+}
+
+func (m *MongoDB) DeleteByFields(ctx logger.WithLogger, fields db.Fields, obj interface{}) error {
+	err := DeleteAllByFields(m.ctx, m.db, fields, obj)
+	if err != nil && m.VERBOSE_ERRORS {
+		e := fmt.Errorf("failed to DeleteByFields %v", ObjectTypeName(obj))
+		ctx.Logger().Error("MongoDB", e, logger.Fields{"fields": fields, "error": err.Error()})
+	}
+	return err
+}
+
+// Transactions require MongoDB deployed as replica set or sharded cluster.
+// Handler can be invoked more than once if transaction is retried on transient errors.
+// Nested transactions are merged into the outer transaction.
+func (m *MongoDB) Transaction(handler db.TransactionHandler) error {
+
+	if _, ok := m.ctx.(mongo.SessionContext); ok {
+		return handler(m)
+	}
+
+	return m.client.UseSession(m.ctx, func(session mongo.SessionContext) error {
+		_, err := session.WithTransaction(session, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+			tx := &MongoDB{}
+			tx.DbState = m.DbState
+			tx.db = m.db
+			tx.ctx = sessionCtx
+			return nil, handler(tx)
+		})
+		return err
+	})
+}
+
+func (m *MongoDB) FindWithFilter(ctx logger.WithLogger, filter *Filter, obj interface{}, dest ...interface{}) (int64, error) {
+	count, err := FindWithFilter(m.ctx, m.db, filter, m.paginator, obj, dest...)
+	if err != nil && m.VERBOSE_ERRORS {
+		e := fmt.Errorf("failed to FindWithFilter %v", ObjectTypeName(obj))
+		ctx.Logger().Error("MongoDB", e, logger.FieldsWithError(err))
+	}
+	return count, err
+}
+
+func (m *MongoDB) Update(ctx logger.WithLogger, obj interface{}, filter db.Fields, newFields db.Fields) error {
+	err := UpdateFieldsMulti(m.ctx, m.db, filter, obj, newFields)
+	if err != nil && m.VERBOSE_ERRORS {
+		e := fmt.Errorf("failed to Update %v", ObjectTypeName(obj))
+		ctx.Logger().Error("MongoDB", e, logger.FieldsWithError(err))
+	}
+	return err
+}
+
+func (m *MongoDB) UpdateWithFilter(ctx logger.WithLogger, obj interface{}, filter *db.Filter, newFields db.Fields) error {
+	err := UpdateWithFilter(m.ctx, m.db, filter, obj, newFields)
+	if err != nil && m.VERBOSE_ERRORS {
+		e := fmt.Errorf("failed to UpdateWithFilter %v", ObjectTypeName(obj))
+		ctx.Logger().Error("MongoDB", e, logger.FieldsWithError(err))
+	}
+	return err
+}
+
+func (m *MongoDB) UpdateAll(ctx logger.WithLogger, obj interface{}, newFields db.Fields) error {
+	err := UpdateFieldsAll(m.ctx, m.db, obj, newFields)
+	if err != nil && m.VERBOSE_ERRORS {
+		e := fmt.Errorf("failed to UpdateAll %v", ObjectTypeName(obj))
+		ctx.Logger().Error("MongoDB", e, logger.FieldsWithError(err))
+	}
+	return err
+}
+
+func (m *MongoDB) Exists(ctx logger.WithLogger, filter *Filter, obj interface{}) (bool, error) {
+	exists, err := Exists(m.ctx, m.db, filter, obj)
+	if err != nil && m.VERBOSE_ERRORS {
+		e := fmt.Errorf("failed to Exists %v", ObjectTypeName(obj))
+		ctx.Logger().Error("MongoDB", e, logger.FieldsWithError(err))
+	}
+	return exists, err
+}
+
+// Databases are created in MongoDB implicitly on first write.
+func (m *MongoDB) CreateDatabase(ctx logger.WithLogger, dbName string) error {
+	return nil
+}
+
+// Expression must be a MongoDB aggregation expression in extended JSON with question marks as placeholders for arguments.
+func (m *MongoDB) MakeExpression(expr string, args ...interface{}) interface{} {
+	return &Expression{Expr: expr, Args: args}
+}
+
+func (m *MongoDB) Sum(ctx logger.WithLogger, groupFields []string, sumFields []string, filter *Filter, model interface{}, dest ...interface{}) (int64, error) {
+	count, err := Sum(m.ctx, m.db, m.paginator, groupFields, sumFields, filter, model, dest...)
+	if err != nil && m.VERBOSE_ERRORS {
+		e := fmt.Errorf("failed to Sum %v", ObjectTypeName(model))
+		ctx.Logger().Error("MongoDB", e, logger.FieldsWithError(err))
+	}
+	return count, err
+}
@@ -0,0 +1,121 @@
+package db_mongo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/evgeniums/go-utils/pkg/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const expressionArgKey = "__expression_arg__"
+
+// Expression is a MongoDB aggregation expression in extended JSON, question marks out of strings are placeholders for arguments.
+// Expressions can be used as values of fields in updates, e.g. MakeExpression(`{"$add": ["$counter", ?]}`, 1).
+type Expression struct {
+	Expr string
+	Args []interface{}
+}
+
+// Replace placeholders with documents that are substituted with arguments after parsing.
+func (e *Expression) withPlaceholders() (string, error) {
+
+	var b strings.Builder
+	inString := false
+	escaped := false
+	arg := 0
+
+	for _, r := range e.Expr {
+		switch {
+		case escaped:
+			escaped = false
+		case inString && r == '\\':
+			escaped = true
+		case r == '"':
+			inString = !inString
+		case !inString && r == '?':
+			if arg >= len(e.Args) {
+				return "", fmt.Errorf("not enough arguments for expression %s", e.Expr)
+			}
+			b.WriteString(fmt.Sprintf(`{"%s": %d}`, expressionArgKey, arg))
+			arg++
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	if arg != len(e.Args) {
+		return "", fmt.Errorf("too many arguments for expression %s", e.Expr)
+	}
+	return b.String(), nil
+}
+
+func (e *Expression) substitute(value interface{}) interface{} {
+	switch v := value.(type) {
+	case bson.D:
+		if len(v) == 1 && v[0].Key == expressionArgKey {
+			index, ok := v[0].Value.(int32)
+			if ok && int(index) < len(e.Args) {
+				return bson.D{{Key: "$literal", Value: e.Args[index]}}
+			}
+		}
+		for i := range v {
+			v[i].Value = e.substitute(v[i].Value)
+		}
+	case bson.A:
+		for i := range v {
+			v[i] = e.substitute(v[i])
+		}
+	}
+	return value
+}
+
+// Parse expression and substitute arguments.
+func (e *Expression) Value() (interface{}, error) {
+
+	expr, err := e.withPlaceholders()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := bson.D{}
+	err = bson.UnmarshalExtJSON([]byte(fmt.Sprintf(`{"v": %s}`, expr)), false, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %s: %s", e.Expr, err)
+	}
+
+	return e.substitute(doc[0].Value), nil
+}
+
+// Fields are set with $set operator, if there are expressions then update is made with aggregation pipeline.
+func updateDocument(fields db.Fields) (interface{}, error) {
+
+	expressions := false
+	for _, value := range fields {
+		if _, ok := value.(*Expression); ok {
+			expressions = true
+			break
+		}
+	}
+	if !expressions {
+		return bson.M{"$set": fieldsDocument(fields)}, nil
+	}
+
+	set := bson.M{}
+	for key, value := range fields {
+		expr, ok := value.(*Expression)
+		if ok {
+			val, err := expr.Value()
+			if err != nil {
+				return nil, err
+			}
+			set[fieldName(key)] = val
+		} else {
+			// values must not be interpreted as field paths or operators
+			set[fieldName(key)] = bson.M{"$literal": value}
+		}
+	}
+
+	return mongo.Pipeline{{{Key: "$set", Value: set}}}, nil
+}
@@ -0,0 +1,361 @@
+package db_mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/logger"
+	"github.com/evgeniums/go-utils/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"gorm.io/gorm/schema"
+)
+
+type JoinTable struct {
+	db.JoinTableBase
+}
+
+// Name of joined table in documents, alias is used if set.
+func (jt *JoinTable) Name() (string, error) {
+	if jt.Alias() != "" {
+		return jt.Alias(), nil
+	}
+	s, err := ParseSchema(jt.Model())
+	if err != nil {
+		return "", err
+	}
+	return s.Table, nil
+}
+
+type JoinPair struct {
+	db.JoinPairBase
+	left  *JoinTable
+	right *JoinTable
+}
+
+// Joins are made with $lookup stages of aggregation pipeline. Documents of the first collection stay at the top level
+// and each joined document is unwound into the field named after its table or alias.
+// Only inner and left outer joins are supported.
+type JoinQuery struct {
+	db          *MongoDB
+	collection  string
+	table       string
+	lookups     mongo.Pipeline
+	project     bson.D
+	paths       map[string]string
+	fields      map[string]*schema.Field
+	groupFields []string
+	sumFields   []string
+}
+
+// Path of table field in joined documents.
+func (j *JoinQuery) tablePath(table string, field string) string {
+	if table == j.table {
+		return field
+	}
+	return utils.ConcatStrings(table, ".", field)
+}
+
+// Fields can be referred either by names of destination fields or by names qualified with tables or aliases.
+func (j *JoinQuery) path(name string) string {
+	if path, ok := j.paths[name]; ok {
+		return path
+	}
+	parts := strings.SplitN(name, ".", 2)
+	if len(parts) == 2 {
+		return j.tablePath(parts[0], parts[1])
+	}
+	return name
+}
+
+// Documents are ordered by ID of destination.
+func (j *JoinQuery) keyset(filter *Filter) *keyset {
+	if filter == nil || !filter.IsKeyset() {
+		return nil
+	}
+	k := &keyset{idField: j.path("id")}
+	if filter.SortField != "" && fieldName(filter.SortField) != "id" {
+		k.sortField = j.path(filter.SortField)
+		k.valueField = j.fields[filter.SortField]
+	}
+	return k
+}
+
+func (j *JoinQuery) sums() bool {
+	return len(j.groupFields) != 0 || len(j.sumFields) != 0
+}
+
+func (j *JoinQuery) pipeline(filter *Filter, paginator *Paginator) (mongo.Pipeline, mongo.Pipeline, *query, error) {
+
+	pipeline := append(mongo.Pipeline{}, j.lookups...)
+
+	if j.sums() {
+		// filter is applied before grouping, sorting and pagination are applied to groups
+		q, err := prepareQuery(filter, paginator, j.path, nil, false)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: q.filter}})
+		pipeline = append(pipeline, groupStages(j.groupFields, j.sumFields, j.path)...)
+		counter := append(mongo.Pipeline{}, pipeline...)
+		q, err = prepareQuery(filter, paginator, fieldName, nil, false)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		pipeline = append(pipeline, q.pageStages()...)
+		return pipeline, counter, q, nil
+	}
+
+	q, err := prepareQuery(filter, paginator, j.path, j.keyset(filter), true)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	counter := append(append(mongo.Pipeline{}, pipeline...), bson.D{{Key: "$match", Value: q.filter}})
+	pipeline = append(pipeline, bson.D{{Key: "$match", Value: q.match}})
+	pipeline = append(pipeline, q.pageStages()...)
+	pipeline = append(pipeline, bson.D{{Key: "$project", Value: j.project}})
+	return pipeline, counter, q, nil
+}
+
+// Aggregation pipeline of join query for filter.
+func (j *JoinQuery) Pipeline(filter *Filter) (mongo.Pipeline, error) {
+	pipeline, _, _, err := j.pipeline(filter, j.db.paginator)
+	return pipeline, err
+}
+
+func (j *JoinQuery) find(ctx context.Context, d *mongo.Database, paginator *Paginator, filter *Filter, dest interface{}) (int64, error) {
+
+	var count int64
+
+	pipeline, counter, q, err := j.pipeline(filter, paginator)
+	if err != nil {
+		return 0, err
+	}
+	collection := d.Collection(j.collection)
+
+	if filter != nil && filter.Count {
+		count, err = countDocuments(ctx, collection, counter)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	n, err := decodeAll(ctx, cursor, dest)
+	if err != nil {
+		return 0, err
+	}
+	if n > count {
+		count = n
+	}
+
+	if q.keyset {
+		// cursors are made of destination fields
+		keysetFilter := *filter
+		if field, ok := j.fields[filter.SortField]; ok {
+			keysetFilter.SortField = field.DBName
+		}
+		n, err = q.setKeysetCursors(&keysetFilter, dest)
+		if err != nil {
+			return 0, err
+		}
+		filter.NextCursor = keysetFilter.NextCursor
+		filter.PrevCursor = keysetFilter.PrevCursor
+		if !filter.Count {
+			count = n
+		}
+	}
+
+	return count, nil
+}
+
+func (j *JoinQuery) Join(ctx logger.WithLogger, filter *Filter, dest interface{}) (int64, error) {
+	return j.find(j.db.ctx, j.db.db, j.db.paginator, filter, dest)
+}
+
+type Joiner struct {
+	db          *MongoDB
+	pairs       []*JoinPair
+	pair        *JoinPair
+	groupFields []string
+	sumFields   []string
+}
+
+func (j *Joiner) Join(model interface{}, field string) db.JoinBegin {
+	j.pair = &JoinPair{}
+	j.pair.left = &JoinTable{}
+	j.pair.left.JoinTableData.Model = model
+	j.pair.JoinPairData.LeftField = field
+	return j
+}
+
+func (j *Joiner) JoinAlias(alias string, field string) db.JoinBegin {
+	j.pair = &JoinPair{}
+	j.pair.left = &JoinTable{}
+	j.pair.left.JoinTableData.Alias = alias
+	j.pair.JoinPairData.LeftField = field
+	return j
+}
+
+func (j *Joiner) On(model interface{}, field string) db.JoinEnd {
+	if j.pair == nil {
+		panic("can not call ON without calling Join first")
+	}
+	j.pair.right = &JoinTable{}
+	j.pair.right.JoinTableData.Model = model
+	j.pair.JoinPairData.RightField = field
+	j.pairs = append(j.pairs, j.pair)
+	return j
+}
+
+func (j *Joiner) Type(joinType db.JoinType) db.JoinEnd {
+	if j.pair == nil {
+		panic("can not set join type without calling Join first")
+	}
+	j.pair.JoinPairData.Type = joinType
+	return j
+}
+
+func (j *Joiner) As(alias string) db.JoinEnd {
+	if j.pair == nil {
+		panic("can not set alias without calling Join first")
+	}
+	j.pair.right.JoinTableData.Alias = alias
+	return j
+}
+
+func (j *Joiner) Sum(groupFields []string, sumFields []string) db.JoinEnd {
+	j.groupFields = append(j.groupFields, groupFields...)
+	j.sumFields = append(j.sumFields, sumFields...)
+	return j
+}
+
+func (j *Joiner) lookups(q *JoinQuery) error {
+
+	tables := map[string]bool{q.table: true}
+
+	for _, pair := range j.pairs {
+
+		joinType := pair.Type()
+		if !joinType.Valid() {
+			return fmt.Errorf("invalid join type %s", joinType)
+		}
+		if joinType != db.JoinInner && joinType != db.JoinLeft {
+			return fmt.Errorf("join type %s is not supported by MongoDB", joinType)
+		}
+
+		left, err := pair.left.Name()
+		if err != nil {
+			return err
+		}
+		if !tables[left] {
+			if pair.left.Model() == nil {
+				return fmt.Errorf("unknown alias %s", left)
+			}
+			return fmt.Errorf("table %s must be joined before", left)
+		}
+
+		rightSchema, err := ParseSchema(pair.right.Model())
+		if err != nil {
+			return err
+		}
+		right, _ := pair.right.Name()
+		tables[right] = true
+
+		lookup := bson.D{
+			{Key: "from", Value: rightSchema.Table},
+			{Key: "localField", Value: q.tablePath(left, pair.LeftField())},
+			{Key: "foreignField", Value: pair.RightField()},
+			{Key: "as", Value: right},
+		}
+		unwind := bson.D{
+			{Key: "path", Value: utils.ConcatStrings("$", right)},
+			{Key: "preserveNullAndEmptyArrays", Value: joinType == db.JoinLeft},
+		}
+		q.lookups = append(q.lookups, bson.D{{Key: "$lookup", Value: lookup}}, bson.D{{Key: "$unwind", Value: unwind}})
+	}
+
+	return nil
+}
+
+func (j *Joiner) Destination(destination interface{}) (db.JoinQuery, error) {
+
+	if len(j.pairs) == 0 {
+		return nil, errors.New("join query must have at least one join")
+	}
+	mainModel := j.pairs[0].left.Model()
+	if mainModel == nil {
+		return nil, errors.New("first join must start with model")
+	}
+	mainSchema, err := ParseSchema(mainModel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse model schema: %s", err)
+	}
+
+	q := &JoinQuery{db: j.db, collection: mainSchema.Table, table: mainSchema.Table, groupFields: j.groupFields, sumFields: j.sumFields}
+	q.paths = make(map[string]string)
+	q.fields = make(map[string]*schema.Field)
+
+	err = j.lookups(q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct joins: %s", err)
+	}
+
+	// destination fields are projected from source fields
+	descriptor, err := j.db.filterManager.DestinationDescriptor(destination)
+	if err != nil {
+		return nil, err
+	}
+	projections := make(map[string]string)
+	for _, field := range descriptor.FieldsJson {
+		path := q.tablePath(field.DbTable, field.DbField)
+		for _, name := range []string{field.Json, field.FullDbName, field.Schema.DBName} {
+			if name == "" {
+				continue
+			}
+			q.paths[name] = path
+			q.fields[name] = field.Schema
+		}
+		projections[field.Schema.DBName] = path
+	}
+	names := utils.AllMapKeys(projections)
+	sort.Strings(names)
+	q.project = bson.D{{Key: "_id", Value: 0}}
+	for _, name := range names {
+		q.project = append(q.project, bson.E{Key: name, Value: utils.ConcatStrings("$", projections[name])})
+	}
+
+	return q, nil
+}
+
+func (m *MongoDB) Joiner() db.Joiner {
+	return &Joiner{db: m}
+}
+
+// Join queries are cached, so they are invoked with database handler of caller to run within the caller's transaction.
+func (m *MongoDB) Join(ctx logger.WithLogger, joinConfig *db.JoinQueryConfig, filter *Filter, dest interface{}) (int64, error) {
+
+	var count int64
+	q, err := m.joinQueries.FindOrCreate(joinConfig)
+	if err == nil {
+		joinQuery, ok := q.(*JoinQuery)
+		if ok {
+			count, err = joinQuery.find(m.ctx, m.db, m.paginator, filter, dest)
+		} else {
+			count, err = q.Join(ctx, filter, dest)
+		}
+	}
+
+	if err != nil && m.VERBOSE_ERRORS {
+		e := fmt.Errorf("failed to Join %v", joinConfig.Name)
+		ctx.Logger().Error("MongoDB", e, logger.FieldsWithError(err))
+	}
+	return count, err
+}
@@ -0,0 +1,90 @@
+package db_mongo
+
+import (
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/db/db_gorm"
+	"go.mongodb.org/mongo-driver/bson"
+	"gorm.io/gorm/schema"
+)
+
+// Paths of fields used in keyset pagination, sort field is empty if documents are ordered only by ID.
+type keyset struct {
+	sortField  string
+	idField    string
+	valueField *schema.Field
+}
+
+func collectionKeyset(filter *Filter, model interface{}) *keyset {
+
+	if filter == nil || !filter.IsKeyset() {
+		return nil
+	}
+
+	k := &keyset{idField: "id"}
+	name := fieldName(filter.SortField)
+	if filter.SortField != "" && name != "id" {
+		k.sortField = name
+		s, err := ParseSchema(model)
+		if err == nil {
+			k.valueField = s.LookUpField(name)
+		}
+	}
+	return k
+}
+
+func (k *keyset) value(value string) interface{} {
+	if k.valueField == nil {
+		return value
+	}
+	val, err := db_gorm.ConvertValue(k.valueField, value)
+	if err != nil {
+		return value
+	}
+	return val
+}
+
+// Documents are ordered by sort field and ID, documents following the cursor are selected.
+// For backward cursor the order is reversed and the documents are reversed back after query.
+func (k *keyset) prepare(filter *Filter) (bson.M, bson.D, error) {
+
+	var cursor *db.KeysetCursor
+	if filter.Cursor != "" {
+		var err error
+		cursor, err = db.DecodeKeysetCursor(filter.Cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	asc := filter.SortDirection != db.SORT_DESC
+	if cursor != nil && cursor.Backward {
+		asc = !asc
+	}
+	order := 1
+	op := "$gt"
+	if !asc {
+		order = -1
+		op = "$lt"
+	}
+
+	var condition bson.M
+	if cursor != nil {
+		if k.sortField != "" {
+			value := k.value(cursor.Value)
+			condition = bson.M{"$or": bson.A{
+				bson.M{k.sortField: bson.M{op: value}},
+				bson.M{k.sortField: value, k.idField: bson.M{op: cursor.ID}},
+			}}
+		} else {
+			condition = bson.M{k.idField: bson.M{op: cursor.ID}}
+		}
+	}
+
+	sort := bson.D{}
+	if k.sortField != "" {
+		sort = append(sort, bson.E{Key: k.sortField, Value: order})
+	}
+	sort = append(sort, bson.E{Key: k.idField, Value: order})
+
+	return condition, sort, nil
+}
@@ -0,0 +1,610 @@
+package db_mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/evgeniums/go-utils/pkg/common"
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/db/db_gorm"
+	"github.com/evgeniums/go-utils/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gorm.io/gorm/schema"
+)
+
+type Interval = db.Interval
+type Filter = db.Filter
+
+var ObjectTypeName = utils.ObjectTypeName
+
+var schemaCache = &sync.Map{}
+
+func ParseSchema(model interface{}) (*schema.Schema, error) {
+	return schema.Parse(model, schemaCache, columnNamer)
+}
+
+// Collection of a model is named the same way as a gorm table of that model.
+func Collection(d *mongo.Database, model interface{}) (*mongo.Collection, error) {
+	s, err := ParseSchema(model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse model schema: %s", err)
+	}
+	return d.Collection(s.Table), nil
+}
+
+// Field namer converts field name used in filter to path of the field in document.
+type fieldNamer func(name string) string
+
+// Field names can be prefixed with table names as in SQL queries, drop the prefixes because documents are flat.
+func fieldName(name string) string {
+	parts := strings.Split(name, ".")
+	return parts[len(parts)-1]
+}
+
+func fieldsDocument(fields db.Fields, names ...fieldNamer) bson.M {
+	path := utils.OptionalArg[fieldNamer](fieldName, names...)
+	doc := bson.M{}
+	for key, value := range fields {
+		doc[path(key)] = value
+	}
+	return doc
+}
+
+func compareOp(isOpen bool, comparator string) string {
+	if isOpen {
+		return comparator
+	}
+	return utils.ConcatStrings(comparator, "e")
+}
+
+func prepareInterval(name string, interval *Interval) bson.M {
+
+	if interval.From != nil && interval.To != nil {
+		if interval.From == interval.To {
+			return bson.M{name: interval.From}
+		}
+		return bson.M{name: bson.M{compareOp(interval.FromOpen, "$gt"): interval.From, compareOp(interval.ToOpen, "$lt"): interval.To}}
+	} else if interval.From != nil {
+		return bson.M{name: bson.M{compareOp(interval.FromOpen, "$gt"): interval.From}}
+	} else if interval.To != nil {
+		return bson.M{name: bson.M{compareOp(interval.ToOpen, "$lt"): interval.To}}
+	}
+
+	return nil
+}
+
+func likeToRegex(pattern string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	escaped := false
+	for _, r := range pattern {
+		if escaped {
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+			continue
+		}
+		switch r {
+		case '\\':
+			escaped = true
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+func regexCondition(name string, regex string, caseInsensitive bool) bson.M {
+	options := ""
+	if caseInsensitive {
+		options = "i"
+	}
+	return bson.M{name: primitive.Regex{Pattern: regex, Options: options}}
+}
+
+func prepareFilter(filter *Filter) bson.M {
+	return buildFilter(filter, fieldName)
+}
+
+func buildFilter(filter *Filter, path fieldNamer) bson.M {
+
+	if filter == nil {
+		return bson.M{}
+	}
+
+	conditions := make([]bson.M, 0)
+
+	if len(filter.Fields) != 0 {
+		conditions = append(conditions, fieldsDocument(filter.Fields, path))
+	}
+
+	for _, f := range filter.PresetFields {
+		if len(f) != 0 {
+			conditions = append(conditions, fieldsDocument(f, path))
+		}
+	}
+
+	for field, values := range filter.FieldsIn {
+		conditions = append(conditions, bson.M{path(field): bson.M{"$in": values}})
+	}
+
+	for field, values := range filter.FieldsNotIn {
+		conditions = append(conditions, bson.M{path(field): bson.M{"$nin": values}})
+	}
+
+	for name, interval := range filter.Intervals {
+		condition := prepareInterval(path(name), interval)
+		if condition != nil {
+			conditions = append(conditions, condition)
+		}
+	}
+
+	for _, between := range filter.BetweenFields {
+		conditions = append(conditions,
+			bson.M{path(between.FromField): bson.M{compareOp(between.FromOpen, "$lt"): between.Value}},
+			bson.M{path(between.ToField): bson.M{compareOp(between.ToOpen, "$gt"): between.Value}},
+		)
+	}
+
+	for _, orFields := range filter.OrFields {
+		alternatives := make([]bson.M, len(orFields.Fields))
+		for i, field := range orFields.Fields {
+			alternatives[i] = bson.M{path(field): orFields.Value}
+		}
+		if len(alternatives) != 0 {
+			conditions = append(conditions, bson.M{"$or": alternatives})
+		}
+	}
+
+	for name, pattern := range filter.Like {
+		conditions = append(conditions, regexCondition(path(name), likeToRegex(pattern.Pattern), pattern.CaseInsensitive))
+	}
+
+	for name, prefix := range filter.Prefix {
+		conditions = append(conditions, regexCondition(path(name), utils.ConcatStrings("^", regexp.QuoteMeta(prefix.Pattern)), prefix.CaseInsensitive))
+	}
+
+	// full text search requires text indexes in MongoDB, so each word of the query is matched instead
+	for name, search := range filter.FullText {
+		for _, word := range strings.Fields(search.Query) {
+			conditions = append(conditions, regexCondition(path(name), regexp.QuoteMeta(word), true))
+		}
+	}
+
+	switch len(conditions) {
+	case 0:
+		return bson.M{}
+	case 1:
+		return conditions[0]
+	}
+	return bson.M{"$and": conditions}
+}
+
+func prepareSort(filter *Filter, names ...fieldNamer) bson.D {
+	if filter == nil || filter.SortField == "" {
+		return nil
+	}
+	path := utils.OptionalArg[fieldNamer](fieldName, names...)
+	switch filter.SortDirection {
+	case db.SORT_ASC:
+		return bson.D{{Key: path(filter.SortField), Value: 1}}
+	case db.SORT_DESC:
+		return bson.D{{Key: path(filter.SortField), Value: -1}}
+	}
+	return nil
+}
+
+type Paginator struct {
+	MaxLimit int
+}
+
+func (p *Paginator) Paginate(filter *Filter) (offset int64, limit int64) {
+
+	maxLimit := 0
+	if p != nil {
+		maxLimit = p.MaxLimit
+	}
+
+	if filter == nil {
+		return 0, 0
+	}
+
+	offset = int64(filter.Offset)
+	if filter.Limit > 0 {
+		limit = int64(filter.Limit)
+		if !filter.NoLimit && filter.Limit > maxLimit && maxLimit > 0 {
+			limit = int64(maxLimit)
+		}
+	} else if !filter.NoLimit && maxLimit > 0 {
+		limit = int64(maxLimit)
+	}
+	return offset, limit
+}
+
+// Query is made of conditions, sorting and pagination that can be used either in find command or in aggregation pipeline.
+type query struct {
+	filter bson.M
+	match  bson.M
+	sort   bson.D
+	skip   int64
+	limit  int64
+
+	keyset bool
+	// limit of results, with lookahead one more document is selected in keyset mode
+	pageLimit int64
+}
+
+func prepareQuery(filter *Filter, paginator *Paginator, path fieldNamer, k *keyset, lookahead bool) (*query, error) {
+
+	q := &query{}
+	q.filter = buildFilter(filter, path)
+	q.match = q.filter
+	if filter == nil {
+		return q, nil
+	}
+
+	q.skip, q.limit = paginator.Paginate(filter)
+	q.pageLimit = q.limit
+
+	if filter.IsKeyset() && k != nil {
+		q.keyset = true
+		q.skip = 0
+		condition, sort, err := k.prepare(filter)
+		if err != nil {
+			return nil, err
+		}
+		if condition != nil {
+			if len(q.filter) == 0 {
+				q.match = condition
+			} else {
+				q.match = bson.M{"$and": bson.A{q.filter, condition}}
+			}
+		}
+		q.sort = sort
+		if lookahead && q.limit > 0 {
+			q.limit++
+		}
+	} else {
+		q.sort = prepareSort(filter, path)
+	}
+
+	return q, nil
+}
+
+func (q *query) findOptions() *options.FindOptions {
+	opts := options.Find()
+	if q.sort != nil {
+		opts.SetSort(q.sort)
+	}
+	if q.skip > 0 {
+		opts.SetSkip(q.skip)
+	}
+	if q.limit > 0 {
+		opts.SetLimit(q.limit)
+	}
+	return opts
+}
+
+// Conditions and options of find command for filter.
+func FindQuery(filter *Filter, paginator *Paginator, model interface{}) (bson.M, *options.FindOptions, error) {
+	q, err := prepareQuery(filter, paginator, fieldName, collectionKeyset(filter, model), false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return q.match, q.findOptions(), nil
+}
+
+// Stages of aggregation pipeline following matching stage.
+func (q *query) pageStages() mongo.Pipeline {
+	pipeline := mongo.Pipeline{}
+	if q.sort != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$sort", Value: q.sort}})
+	}
+	if q.skip > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$skip", Value: q.skip}})
+	}
+	if q.limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: q.limit}})
+	}
+	return pipeline
+}
+
+// Set keyset cursors in filter and trim lookahead document, returns number of documents in the page.
+func (q *query) setKeysetCursors(filter *Filter, dest interface{}) (int64, error) {
+	return db_gorm.KeysetCursors(filter, dest, int(q.pageLimit))
+}
+
+func isSlice(dest interface{}) bool {
+	return reflect.Indirect(reflect.ValueOf(dest)).Kind() == reflect.Slice
+}
+
+func decodeAll(ctx context.Context, cursor *mongo.Cursor, dest interface{}) (int64, error) {
+
+	defer cursor.Close(ctx)
+
+	if isSlice(dest) {
+		err := cursor.All(ctx, dest)
+		if err != nil {
+			return 0, err
+		}
+		return int64(reflect.Indirect(reflect.ValueOf(dest)).Len()), nil
+	}
+
+	if !cursor.Next(ctx) {
+		return 0, cursor.Err()
+	}
+	err := cursor.Decode(dest)
+	if err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+func findOne(ctx context.Context, d *mongo.Database, query bson.M, doc interface{}, dest interface{}) (bool, error) {
+
+	collection, err := Collection(d, doc)
+	if err != nil {
+		return false, err
+	}
+
+	err = collection.FindOne(ctx, query).Decode(dest)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+func FindByField(ctx context.Context, d *mongo.Database, fieldName string, fieldValue interface{}, doc interface{}, dest ...interface{}) (bool, error) {
+	return FindByFields(ctx, d, db.Fields{fieldName: fieldValue}, doc, dest...)
+}
+
+func FindByFields(ctx context.Context, d *mongo.Database, fields db.Fields, doc interface{}, dest ...interface{}) (bool, error) {
+	dst := utils.OptionalArg(doc, dest...)
+	return findOne(ctx, d, fieldsDocument(fields), doc, dst)
+}
+
+func find(ctx context.Context, collection *mongo.Collection, filter *Filter, paginator *Paginator, dest interface{}) (int64, error) {
+
+	var count int64
+
+	q, err := prepareQuery(filter, paginator, fieldName, collectionKeyset(filter, dest), true)
+	if err != nil {
+		return 0, err
+	}
+	if filter != nil && filter.Count {
+		count, err = collection.CountDocuments(ctx, q.filter)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	cursor, err := collection.Find(ctx, q.match, q.findOptions())
+	if err != nil {
+		return 0, err
+	}
+	n, err := decodeAll(ctx, cursor, dest)
+	if err != nil {
+		return 0, err
+	}
+	if n > count {
+		count = n
+	}
+
+	if q.keyset {
+		n, err = q.setKeysetCursors(filter, dest)
+		if err != nil {
+			return 0, err
+		}
+		if !filter.Count {
+			count = n
+		}
+	}
+
+	return count, nil
+}
+
+func FindWithFilter(ctx context.Context, d *mongo.Database, filter *Filter, paginator *Paginator, docs interface{}, dest ...interface{}) (int64, error) {
+	collection, err := Collection(d, docs)
+	if err != nil {
+		return 0, err
+	}
+	dst := utils.OptionalArg(docs, dest...)
+	return find(ctx, collection, filter, paginator, dst)
+}
+
+func RowsWithFilter(ctx context.Context, d *mongo.Database, filter *Filter, paginator *Paginator, docs interface{}) (*mongo.Cursor, error) {
+	collection, err := Collection(d, docs)
+	if err != nil {
+		return nil, err
+	}
+	q, err := prepareQuery(filter, paginator, fieldName, collectionKeyset(filter, docs), false)
+	if err != nil {
+		return nil, err
+	}
+	return collection.Find(ctx, q.match, q.findOptions())
+}
+
+func Exists(ctx context.Context, d *mongo.Database, filter *Filter, obj interface{}) (bool, error) {
+	return findOne(ctx, d, prepareFilter(filter), obj, obj)
+}
+
+func Create(ctx context.Context, d *mongo.Database, doc interface{}) error {
+
+	collection, err := Collection(d, doc)
+	if err != nil {
+		return err
+	}
+
+	if isSlice(doc) {
+		v := reflect.Indirect(reflect.ValueOf(doc))
+		if v.Len() == 0 {
+			return nil
+		}
+		docs := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			docs[i] = v.Index(i).Interface()
+		}
+		_, err = collection.InsertMany(ctx, docs)
+		return err
+	}
+
+	_, err = collection.InsertOne(ctx, doc)
+	return err
+}
+
+func Delete(ctx context.Context, d *mongo.Database, doc common.Object) error {
+	return DeleteAllByFields(ctx, d, db.Fields{"id": doc.GetID()}, doc)
+}
+
+func DeleteByField(ctx context.Context, d *mongo.Database, field string, value interface{}, doc interface{}) error {
+	return DeleteAllByFields(ctx, d, db.Fields{field: value}, doc)
+}
+
+func DeleteAllByFields(ctx context.Context, d *mongo.Database, fields db.Fields, docs interface{}) error {
+	collection, err := Collection(d, docs)
+	if err != nil {
+		return err
+	}
+	_, err = collection.DeleteMany(ctx, fieldsDocument(fields))
+	return err
+}
+
+func updateMany(ctx context.Context, d *mongo.Database, query bson.M, doc interface{}, newFields db.Fields) error {
+	collection, err := Collection(d, doc)
+	if err != nil {
+		return err
+	}
+	update, err := updateDocument(newFields)
+	if err != nil {
+		return err
+	}
+	_, err = collection.UpdateMany(ctx, query, update)
+	return err
+}
+
+// If doc is an object with ID then only that object is updated as gorm does for models with primary keys.
+func UpdateFieldsMulti(ctx context.Context, d *mongo.Database, filter db.Fields, doc interface{}, newFields db.Fields) error {
+
+	query := fieldsDocument(filter)
+	obj, ok := doc.(common.Object)
+	if ok && obj.GetID() != "" {
+		query["id"] = obj.GetID()
+	}
+	if len(query) == 0 {
+		return errors.New("update conditions must be specified")
+	}
+
+	return updateMany(ctx, d, query, doc, newFields)
+}
+
+func UpdateFieldsAll(ctx context.Context, d *mongo.Database, doc interface{}, newFields db.Fields) error {
+	return updateMany(ctx, d, bson.M{}, doc, newFields)
+}
+
+func UpdateWithFilter(ctx context.Context, d *mongo.Database, filter *db.Filter, doc interface{}, newFields db.Fields) error {
+	return updateMany(ctx, d, prepareFilter(filter), doc, newFields)
+}
+
+type countResult struct {
+	Count int64
+}
+
+// Stages of aggregation pipeline that group documents and sum fields, path converts names of fields to paths in source documents.
+func groupStages(groupFields []string, sumFields []string, path fieldNamer) mongo.Pipeline {
+
+	groupId := bson.D{}
+	group := bson.D{}
+	project := bson.D{{Key: "_id", Value: 0}}
+	for _, groupField := range groupFields {
+		name := fieldName(groupField)
+		groupId = append(groupId, bson.E{Key: name, Value: utils.ConcatStrings("$", path(groupField))})
+		project = append(project, bson.E{Key: name, Value: utils.ConcatStrings("$_id.", name)})
+	}
+	if len(groupId) == 0 {
+		group = append(group, bson.E{Key: "_id", Value: nil})
+	} else {
+		group = append(group, bson.E{Key: "_id", Value: groupId})
+	}
+	for _, sumField := range sumFields {
+		name := fieldName(sumField)
+		group = append(group, bson.E{Key: name, Value: bson.M{"$sum": utils.ConcatStrings("$", path(sumField))}})
+		project = append(project, bson.E{Key: name, Value: 1})
+	}
+
+	return mongo.Pipeline{
+		{{Key: "$group", Value: group}},
+		{{Key: "$project", Value: project}},
+	}
+}
+
+func countDocuments(ctx context.Context, collection *mongo.Collection, pipeline mongo.Pipeline) (int64, error) {
+	counter, err := collection.Aggregate(ctx, append(pipeline, bson.D{{Key: "$count", Value: "count"}}))
+	if err != nil {
+		return 0, err
+	}
+	result := &countResult{}
+	_, err = decodeAll(ctx, counter, result)
+	if err != nil {
+		return 0, err
+	}
+	return result.Count, nil
+}
+
+func Sum(ctx context.Context, d *mongo.Database, paginator *Paginator, groupFields []string, sumFields []string, filter *Filter, model interface{}, dest ...interface{}) (int64, error) {
+
+	collection, err := Collection(d, model)
+	if err != nil {
+		return 0, err
+	}
+
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: prepareFilter(filter)}}}
+	pipeline = append(pipeline, groupStages(groupFields, sumFields, fieldName)...)
+
+	// count groups
+	var n int64
+	if filter != nil && filter.Count {
+		n, err = countDocuments(ctx, collection, pipeline)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// sort and paginate
+	q, err := prepareQuery(filter, paginator, fieldName, nil, false)
+	if err != nil {
+		return 0, err
+	}
+	pipeline = append(pipeline, q.pageStages()...)
+
+	// find
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	dst := utils.OptionalArg(model, dest...)
+	found, err := decodeAll(ctx, cursor, dst)
+	if err != nil {
+		return 0, err
+	}
+	if found > n {
+		n = found
+	}
+
+	return n, nil
+}
@@ -36,6 +36,8 @@ type FilterConfig struct {
 	Offset        int    `json:"offset,omitempty" validate:"gte=0" vmessage:"Offset can not be negative"`
 	Limit         int    `json:"limit,omitempty" validate:"gte=0" vmessage:"Limit can not be negative"`
 	Count         bool   `json:"count,omitempty"`
+	Keyset        bool   `json:"keyset,omitempty"`
+	Cursor        string `json:"cursor,omitempty"`
 }
 
 type OrFields struct {
@@ -43,6 +45,22 @@ type OrFields struct {
 	Fields []string
 }
 
+const (
+	SEARCH_LIKE      string = "like"
+	SEARCH_PREFIX    string = "prefix"
+	SEARCH_FULL_TEXT string = "full_text"
+)
+
+type Pattern struct {
+	Pattern         string
+	CaseInsensitive bool
+}
+
+type FullTextSearch struct {
+	Query    string
+	Language string
+}
+
 type Filter struct {
 	FilterConfig
 	Fields        Fields
@@ -51,10 +69,16 @@ type Filter struct {
 	Intervals     map[string]*Interval
 	BetweenFields []*BetweenFields
 	OrFields      []*OrFields
+	Like          map[string]*Pattern
+	Prefix        map[string]*Pattern
+	FullText      map[string]*FullTextSearch
 
 	PresetFields []Fields
 
 	NoLimit bool
+
+	NextCursor string
+	PrevCursor string
 }
 
 func NewFilter() *Filter {
@@ -73,6 +97,17 @@ func (f *Filter) PopPresetFields() {
 	}
 }
 
+// Keyset pagination is used either if it is explicitly enabled or if continuation cursor is set.
+// Next and previous cursors are filled in the filter after the query.
+func (f *Filter) IsKeyset() bool {
+	return f.Keyset || f.Cursor != ""
+}
+
+func (f *Filter) SetCursor(cursor string) {
+	f.Cursor = cursor
+	f.Keyset = true
+}
+
 func (f *Filter) SetSorting(field string, direction ...string) {
 	f.SortField = field
 	f.SortDirection = utils.OptionalArg(SORT_ASC, direction...)
@@ -129,6 +164,28 @@ func (f *Filter) AddOrFields(value interface{}, names ...string) {
 	f.OrFields = append(f.OrFields, item)
 }
 
+// Pattern can contain SQL wildcards % and _.
+func (f *Filter) AddLike(name string, pattern string, caseInsensitive ...bool) {
+	if f.Like == nil {
+		f.Like = make(map[string]*Pattern)
+	}
+	f.Like[name] = &Pattern{Pattern: pattern, CaseInsensitive: utils.OptionalArg(false, caseInsensitive...)}
+}
+
+func (f *Filter) AddPrefix(name string, prefix string, caseInsensitive ...bool) {
+	if f.Prefix == nil {
+		f.Prefix = make(map[string]*Pattern)
+	}
+	f.Prefix[name] = &Pattern{Pattern: prefix, CaseInsensitive: utils.OptionalArg(false, caseInsensitive...)}
+}
+
+func (f *Filter) AddFullTextSearch(name string, query string, language ...string) {
+	if f.FullText == nil {
+		f.FullText = make(map[string]*FullTextSearch)
+	}
+	f.FullText[name] = &FullTextSearch{Query: query, Language: utils.OptionalArg("", language...)}
+}
+
 func filterValueToString(value interface{}) string {
 	return fmt.Sprintf("%v", value)
 }
@@ -205,6 +262,28 @@ func (f *Filter) ToQuery() *Query {
 		q.OrFields[i] = QueryOrFields{Value: value, Fields: orFields.Fields}
 	}
 
+	// fill patterns
+	if len(f.Like) > 0 {
+		q.Like = make(map[string]QueryPattern)
+	}
+	for key, pattern := range f.Like {
+		q.Like[key] = QueryPattern{Pattern: pattern.Pattern, CaseInsensitive: pattern.CaseInsensitive}
+	}
+	if len(f.Prefix) > 0 {
+		q.Prefix = make(map[string]QueryPattern)
+	}
+	for key, prefix := range f.Prefix {
+		q.Prefix[key] = QueryPattern{Pattern: prefix.Pattern, CaseInsensitive: prefix.CaseInsensitive}
+	}
+
+	// fill full text search
+	if len(f.FullText) > 0 {
+		q.FullText = make(map[string]QueryFullTextSearch)
+	}
+	for key, search := range f.FullText {
+		q.FullText[key] = QueryFullTextSearch{Query: search.Query, Language: search.Language}
+	}
+
 	return q
 }
 
@@ -234,6 +313,16 @@ type QueryOrFields struct {
 	Fields []string `json:"fields"`
 }
 
+type QueryPattern struct {
+	Pattern         string `json:"pattern"`
+	CaseInsensitive bool   `json:"case_insensitive,omitempty"`
+}
+
+type QueryFullTextSearch struct {
+	Query    string `json:"query"`
+	Language string `json:"language,omitempty"`
+}
+
 type Query struct {
 	FilterConfig
 
@@ -243,6 +332,10 @@ type Query struct {
 	Intervals     map[string]QueryInterval `json:"intervals,omitempty"`
 	BetweenFields []QueryBetweenFields     `json:"betwees_fields,omitempty"`
 	OrFields      []QueryOrFields          `json:"or_fields,omitempty"`
+
+	Like     map[string]QueryPattern        `json:"like,omitempty"`
+	Prefix   map[string]QueryPattern        `json:"prefix,omitempty"`
+	FullText map[string]QueryFullTextSearch `json:"full_text,omitempty"`
 }
 
 type WithFilterParser interface {
@@ -285,3 +378,16 @@ func ParseQuery(db DB, query string, model interface{}, parserName string, valid
 func EmptyFilterValidator(vld validator.Validator) *FilterValidator {
 	return &FilterValidator{Validator: vld}
 }
+
+// Search operators are allowed in queries only for fields explicitly whitelisted in rules with keys "<field>:<operator>".
+// Value of the rule is used to validate pattern or search query.
+func SearchRuleKey(field string, operator string) string {
+	return utils.ConcatStrings(field, ":", operator)
+}
+
+func (f *FilterValidator) AllowSearch(field string, operator string, rules ...string) {
+	if f.Rules == nil {
+		f.Rules = make(map[string]string)
+	}
+	f.Rules[SearchRuleKey(field, operator)] = utils.OptionalArg("", rules...)
+}
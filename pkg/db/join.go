@@ -7,26 +7,49 @@ import (
 	"github.com/evgeniums/go-utils/pkg/utils"
 )
 
+type JoinType string
+
+const (
+	JoinInner JoinType = "INNER"
+	JoinLeft  JoinType = "LEFT OUTER"
+	JoinRight JoinType = "RIGHT OUTER"
+	JoinFull  JoinType = "FULL OUTER"
+)
+
+// Default join type.
+const JoinDefault = JoinLeft
+
+func (j JoinType) Valid() bool {
+	return j == JoinInner || j == JoinLeft || j == JoinRight || j == JoinFull
+}
+
 type JoinQuery interface {
 	Join(ctx logger.WithLogger, filter *Filter, dest interface{}) (int64, error)
 }
 
 type Joiner interface {
 	Join(model interface{}, field string) JoinBegin
+
+	// Join using table that was joined with alias in one of previous steps.
+	JoinAlias(alias string, field string) JoinBegin
 }
 
 type JoinBegin interface {
 	On(model interface{}, field string) JoinEnd
 }
 
+// Type and As are applied to the last join step. Aliased tables must be referred by alias in "source" tags of destination fields.
 type JoinEnd interface {
 	Joiner
+	Type(joinType JoinType) JoinEnd
+	As(alias string) JoinEnd
 	Sum(groupFields []string, sumFields []string) JoinEnd
 	Destination(dst interface{}) (JoinQuery, error)
 }
 
 type JoinTableData struct {
 	Model interface{}
+	Alias string
 }
 
 type JoinTableBase struct {
@@ -37,9 +60,14 @@ func (j *JoinTableBase) Model() interface{} {
 	return j.JoinTableData.Model
 }
 
+func (j *JoinTableBase) Alias() string {
+	return j.JoinTableData.Alias
+}
+
 type JoinPairData struct {
 	LeftField  string
 	RightField string
+	Type       JoinType
 }
 
 type JoinPairBase struct {
@@ -54,6 +82,13 @@ func (j *JoinPairBase) RightField() string {
 	return j.JoinPairData.RightField
 }
 
+func (j *JoinPairBase) Type() JoinType {
+	if j.JoinPairData.Type == "" {
+		return JoinDefault
+	}
+	return j.JoinPairData.Type
+}
+
 type JoinQueryData struct {
 	destination interface{}
 }
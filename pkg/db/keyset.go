@@ -0,0 +1,54 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Keyset cursor is an opaque continuation token made of the value of sort field and ID of the edge item of a page.
+type KeysetCursor struct {
+	Value    string `json:"v"`
+	ID       string `json:"id"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+func KeysetValueToString(value interface{}) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func EncodeKeysetCursor(value interface{}, id string, backward bool) string {
+	cursor := &KeysetCursor{ID: id, Backward: backward}
+	if value != nil {
+		cursor.Value = KeysetValueToString(value)
+	}
+	b, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeKeysetCursor(token string) (*KeysetCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("invalid cursor encoding")
+	}
+	cursor := &KeysetCursor{}
+	err = json.Unmarshal(b, cursor)
+	if err != nil {
+		return nil, errors.New("invalid cursor format")
+	}
+	if cursor.ID == "" {
+		return nil, errors.New("invalid cursor ID")
+	}
+	return cursor, nil
+}
@@ -118,7 +118,7 @@ func (a *AppWithMultitenancyBase) Close() {
 	if a.tenancyManager != nil {
 		a.tenancyManager.Close()
 	}
-	a.AppWithPoolsBase.Close()
+	a.AppWithPubsubBase.Close()
 }
 
 func BackgroundOpContext(app app_context.Context, tenancy multitenancy.Tenancy, name string) multitenancy.TenancyContext {
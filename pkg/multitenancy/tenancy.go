@@ -270,6 +270,20 @@ func (u *TenancyContextBase) Pool() pool.Pool {
 	return u.ContextBase.Pool()
 }
 
+// Fork context with the same pool and tenancy. Nil is returned if underlying context is not forkable.
+func (u *TenancyContextBase) Fork() op_context.Context {
+	base := op_context.Fork(u.Context)
+	if base == nil {
+		return nil
+	}
+	f := NewContext(base)
+	f.SetPool(u.ContextBase.Pool())
+	if u.Tenancy != nil {
+		f.SetTenancy(u.Tenancy)
+	}
+	return f
+}
+
 func NewContext(fromCtx ...op_context.Context) *TenancyContextBase {
 	c := &TenancyContextBase{}
 	c.Construct(fromCtx...)
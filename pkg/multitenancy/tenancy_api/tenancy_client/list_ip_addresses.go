@@ -33,6 +33,9 @@ func (t *TenancyClient) ListIpAddresses(ctx op_context.Context, filter *db.Filte
 		return nil, 0, err
 	}
 
+	// keep keyset cursors
+	handler.Result.UpdateFilter(filter)
+
 	// done
 	return handler.Result.Items, handler.Result.Count, nil
 }
@@ -2,12 +2,25 @@ package tenancy_service
 
 import (
 	"github.com/evgeniums/go-utils/pkg/api/api_server"
+	"github.com/evgeniums/go-utils/pkg/db"
 	"github.com/evgeniums/go-utils/pkg/multitenancy"
 	"github.com/evgeniums/go-utils/pkg/multitenancy/tenancy_api"
 )
 
 type ListEndpoint struct {
 	TenancyEndpoint
+	search *db.FilterValidator
+}
+
+// Tenancies can be searched by paths, customers, pools and descriptions.
+func searchTenancies() *db.FilterValidator {
+	vld := &db.FilterValidator{}
+	vld.AllowSearch("path", db.SEARCH_PREFIX, "max=128")
+	vld.AllowSearch("customer_login", db.SEARCH_PREFIX, "max=64")
+	vld.AllowSearch("customer_login", db.SEARCH_LIKE, "max=64")
+	vld.AllowSearch("pool_name", db.SEARCH_PREFIX, "max=64")
+	vld.AllowSearch("description", db.SEARCH_FULL_TEXT, "max=128")
+	return vld
 }
 
 func (e *ListEndpoint) HandleRequest(request api_server.Request) error {
@@ -18,7 +31,7 @@ func (e *ListEndpoint) HandleRequest(request api_server.Request) error {
 
 	// parse query
 	queryName := request.Endpoint().Resource().ServicePathPrototype()
-	filter, err := api_server.ParseDbQuery(request, &multitenancy.TenancyItem{}, queryName)
+	filter, err := api_server.ParseDbQueryWithValidator(request, &multitenancy.TenancyItem{}, queryName, e.search)
 	if err != nil {
 		return c.SetError(err)
 	}
@@ -31,6 +44,7 @@ func (e *ListEndpoint) HandleRequest(request api_server.Request) error {
 	}
 
 	// set response message
+	resp.SetCursors(filter)
 	api_server.SetResponseList(request, resp)
 
 	// done
@@ -40,5 +54,6 @@ func (e *ListEndpoint) HandleRequest(request api_server.Request) error {
 func List(s *TenancyService) *ListEndpoint {
 	e := &ListEndpoint{}
 	e.Construct(s, tenancy_api.List())
+	e.search = searchTenancies()
 	return e
 }
@@ -31,6 +31,7 @@ func (e *ListIpAddressesEndpoint) HandleRequest(request api_server.Request) erro
 	}
 
 	// set response message
+	resp.SetCursors(filter)
 	api_server.SetResponseList(request, resp)
 
 	// done
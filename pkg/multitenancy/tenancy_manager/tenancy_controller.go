@@ -39,16 +39,48 @@ func (t *TenancyController) OpLog(ctx op_context.Context, operation string, oplo
 	ctx.Oplog(oplog)
 }
 
+// Publish notification about tenancy operation directly to pool pubsub.
+//
+// Deprecated: use PostOp, which writes notification to outbox of pool pubsub if it is set.
 func (t *TenancyController) PublishOp(tenancy *multitenancy.TenancyItem, op string, poolIds ...string) {
-	if len(poolIds) != 0 {
-		t.Manager.PoolPubsub.PublishPools(multitenancy.PubsubTopicName,
-			&multitenancy.PubsubNotification{Tenancy: tenancy.GetID(), Operation: op},
-			poolIds...)
-	} else {
-		t.Manager.PoolPubsub.PublishPools(multitenancy.PubsubTopicName,
-			&multitenancy.PubsubNotification{Tenancy: tenancy.GetID(), Operation: op},
-			tenancy.PoolId())
+	if len(poolIds) == 0 {
+		poolIds = []string{tenancy.PoolId()}
 	}
+	t.Manager.PoolPubsub.PublishPools(multitenancy.PubsubTopicName, &multitenancy.PubsubNotification{Tenancy: tenancy.GetID(), Operation: op}, poolIds...)
+}
+
+// Post notification about tenancy operation. If pool pubsub has outbox then notification is written to outbox, otherwise it is published directly
+// and publishing errors are only logged.
+func (t *TenancyController) PostOp(ctx op_context.Context, tenancy *multitenancy.TenancyItem, op string, poolIds ...string) error {
+	if len(poolIds) == 0 {
+		poolIds = []string{tenancy.PoolId()}
+	}
+	msg := &multitenancy.PubsubNotification{Tenancy: tenancy.GetID(), Operation: op}
+	err := t.Manager.PoolPubsub.PostPools(ctx, multitenancy.PubsubTopicName, msg, poolIds...)
+	if err != nil && t.Manager.PoolPubsub.Outbox() == nil {
+		// the change is already saved, so failed publishing must not fail the operation
+		ctx.Logger().Error("failed to publish tenancy notification", err, logger.Fields{"tenancy": tenancy.GetID(), "operation": op})
+		return nil
+	}
+	return err
+}
+
+// Save changes of tenancy and publish notification about them.
+// If pool pubsub has outbox then changes and notification are saved in the same database transaction.
+func (t *TenancyController) SaveAndPublish(ctx op_context.Context, tenancy *multitenancy.TenancyItem, op string, save func() error, poolIds ...string) error {
+
+	handler := func() error {
+		err := save()
+		if err != nil {
+			return err
+		}
+		return t.PostOp(ctx, tenancy, op, poolIds...)
+	}
+
+	if t.Manager.PoolPubsub.Outbox() == nil || ctx.DbTransaction() != nil {
+		return handler()
+	}
+	return op_context.ExecDbTransaction(ctx, handler)
 }
 
 func (t *TenancyController) Add(ctx op_context.Context, data *multitenancy.TenancyData) (*multitenancy.TenancyItem, error) {
@@ -64,8 +96,10 @@ func (t *TenancyController) Add(ctx op_context.Context, data *multitenancy.Tenan
 		return nil, c.SetError(err)
 	}
 
-	// save tenancy in database
-	err = t.CRUD.Create(ctx, &tenancy.TenancyDb)
+	// save tenancy in database and publish notification
+	err = t.SaveAndPublish(ctx, tenancy, multitenancy.OpAdd, func() error {
+		return t.CRUD.Create(ctx, &tenancy.TenancyDb)
+	})
 	if err != nil {
 		c.SetMessage("failed to save tenancy in database")
 		return nil, c.SetError(err)
@@ -75,9 +109,6 @@ func (t *TenancyController) Add(ctx op_context.Context, data *multitenancy.Tenan
 	t.OpLog(ctx, multitenancy.OpAdd, &multitenancy.OpLogTenancy{TenancyId: tenancy.GetID(),
 		Role: tenancy.Role(), ShadowPath: tenancy.ShadowPath(), Path: tenancy.Path(), DbName: tenancy.DbName(), Pool: tenancy.PoolName, Customer: tenancy.CustomerDisplay()})
 
-	// publish notification
-	t.PublishOp(tenancy, multitenancy.OpAdd)
-
 	// done
 	return tenancy, nil
 }
@@ -92,11 +123,11 @@ func (t *TenancyController) List(ctx op_context.Context, filter *db.Filter) ([]*
 	c := ctx.TraceInMethod("TenancyController.List")
 	defer ctx.TraceOutMethod()
 
-	// construct join query
+	// construct join query, tenancies are listed even if their customer or pool is missing
 	queryBuilder := func() (db.JoinQuery, error) {
 		return ctx.Db().Joiner().
-			Join(&multitenancy.TenancyDb{}, "customer_id").On(&customer.Customer{}, "id").
-			Join(&multitenancy.TenancyDb{}, "pool_id").On(&pool.PoolBase{}, "id").
+			Join(&multitenancy.TenancyDb{}, "customer_id").On(&customer.Customer{}, "id").Type(db.JoinLeft).
+			Join(&multitenancy.TenancyDb{}, "pool_id").On(&pool.PoolBase{}, "id").Type(db.JoinLeft).
 			Destination(&multitenancy.TenancyItem{})
 	}
 
@@ -136,8 +167,10 @@ func (t *TenancyController) SetPath(ctx op_context.Context, id string, path stri
 		return err
 	}
 
-	// update field
-	err = t.CRUD.Update(ctx, &tenancy.TenancyDb, db.Fields{"path": path})
+	// update field and publish notification
+	err = t.SaveAndPublish(ctx, tenancy, multitenancy.OpSetPath, func() error {
+		return t.CRUD.Update(ctx, &tenancy.TenancyDb, db.Fields{"path": path})
+	})
 	if err != nil {
 		c.SetMessage("failed to update tenancy")
 		return c.SetError(err)
@@ -147,9 +180,6 @@ func (t *TenancyController) SetPath(ctx op_context.Context, id string, path stri
 	t.OpLog(ctx, multitenancy.OpSetPath, &multitenancy.OpLogTenancy{TenancyId: tenancy.GetID(),
 		Role: tenancy.Role(), ShadowPath: tenancy.ShadowPath(), Path: tenancy.Path(), Customer: tenancy.CustomerDisplay()})
 
-	// publish notification
-	t.PublishOp(tenancy, multitenancy.OpSetPath)
-
 	// done
 	return nil
 }
@@ -172,8 +202,10 @@ func (t *TenancyController) SetShadowPath(ctx op_context.Context, id string, pat
 		return err
 	}
 
-	// update field
-	err = t.CRUD.Update(ctx, &tenancy.TenancyDb, db.Fields{"shadow_path": path})
+	// update field and publish notification
+	err = t.SaveAndPublish(ctx, tenancy, multitenancy.OpSetShadowPath, func() error {
+		return t.CRUD.Update(ctx, &tenancy.TenancyDb, db.Fields{"shadow_path": path})
+	})
 	if err != nil {
 		c.SetMessage("failed to update tenancy")
 		return c.SetError(err)
@@ -183,9 +215,6 @@ func (t *TenancyController) SetShadowPath(ctx op_context.Context, id string, pat
 	t.OpLog(ctx, multitenancy.OpSetShadowPath, &multitenancy.OpLogTenancy{TenancyId: tenancy.GetID(),
 		Role: tenancy.Role(), ShadowPath: tenancy.ShadowPath(), Path: tenancy.Path(), Customer: tenancy.CustomerDisplay()})
 
-	// publish notification
-	t.PublishOp(tenancy, multitenancy.OpSetShadowPath)
-
 	// done
 	return nil
 }
@@ -208,8 +237,10 @@ func (t *TenancyController) SetRole(ctx op_context.Context, id string, role stri
 		return err
 	}
 
-	// update field
-	err = t.CRUD.Update(ctx, &tenancy.TenancyDb, db.Fields{"role": role})
+	// update field and publish notification
+	err = t.SaveAndPublish(ctx, tenancy, multitenancy.OpSetRole, func() error {
+		return t.CRUD.Update(ctx, &tenancy.TenancyDb, db.Fields{"role": role})
+	})
 	if err != nil {
 		c.SetMessage("failed to update tenancy")
 		return c.SetError(err)
@@ -219,9 +250,6 @@ func (t *TenancyController) SetRole(ctx op_context.Context, id string, role stri
 	t.OpLog(ctx, multitenancy.OpSetRole, &multitenancy.OpLogTenancy{TenancyId: tenancy.GetID(),
 		Role: tenancy.Role(), Customer: tenancy.CustomerDisplay()})
 
-	// publish notification
-	t.PublishOp(tenancy, multitenancy.OpSetRole)
-
 	// done
 	return nil
 }
@@ -238,8 +266,10 @@ func (t *TenancyController) Activate(ctx op_context.Context, id string, idIsDisp
 		return c.SetError(err)
 	}
 
-	// update field
-	err = t.CRUD.Update(ctx, &tenancy.TenancyDb, db.Fields{"active": true})
+	// update field and publish notification
+	err = t.SaveAndPublish(ctx, tenancy, multitenancy.OpActivate, func() error {
+		return t.CRUD.Update(ctx, &tenancy.TenancyDb, db.Fields{"active": true})
+	})
 	if err != nil {
 		c.SetMessage("failed to update tenancy")
 		return c.SetError(err)
@@ -249,9 +279,6 @@ func (t *TenancyController) Activate(ctx op_context.Context, id string, idIsDisp
 	t.OpLog(ctx, multitenancy.OpActivate, &multitenancy.OpLogTenancy{TenancyId: tenancy.GetID(),
 		Role: tenancy.Role(), Customer: tenancy.CustomerDisplay()})
 
-	// publish notification
-	t.PublishOp(tenancy, multitenancy.OpActivate)
-
 	// done
 	return nil
 }
@@ -268,8 +295,10 @@ func (t *TenancyController) Deactivate(ctx op_context.Context, id string, idIsDi
 		return c.SetError(err)
 	}
 
-	// update field
-	err = t.CRUD.Update(ctx, &tenancy.TenancyDb, db.Fields{"active": false})
+	// update field and publish notification
+	err = t.SaveAndPublish(ctx, tenancy, multitenancy.OpDeactivate, func() error {
+		return t.CRUD.Update(ctx, &tenancy.TenancyDb, db.Fields{"active": false})
+	})
 	if err != nil {
 		c.SetMessage("failed to update tenancy")
 		return c.SetError(err)
@@ -279,9 +308,6 @@ func (t *TenancyController) Deactivate(ctx op_context.Context, id string, idIsDi
 	t.OpLog(ctx, multitenancy.OpDeactivate, &multitenancy.OpLogTenancy{TenancyId: tenancy.GetID(),
 		Role: tenancy.Role(), Customer: tenancy.CustomerDisplay()})
 
-	// publish notification
-	t.PublishOp(tenancy, multitenancy.OpDeactivate)
-
 	// done
 	return nil
 }
@@ -310,8 +336,10 @@ func (t *TenancyController) SetCustomer(ctx op_context.Context, id string, custo
 		return err
 	}
 
-	// update field
-	err = t.CRUD.Update(ctx, &tenancy.TenancyDb, db.Fields{"customer_id": cust.GetID()})
+	// update field and publish notification
+	err = t.SaveAndPublish(ctx, tenancy, multitenancy.OpSetCustomer, func() error {
+		return t.CRUD.Update(ctx, &tenancy.TenancyDb, db.Fields{"customer_id": cust.GetID()})
+	})
 	if err != nil {
 		c.SetMessage("failed to update tenancy")
 		return c.SetError(err)
@@ -321,9 +349,6 @@ func (t *TenancyController) SetCustomer(ctx op_context.Context, id string, custo
 	t.OpLog(ctx, multitenancy.OpSetCustomer, &multitenancy.OpLogTenancy{TenancyId: tenancy.GetID(),
 		Role: tenancy.Role(), Customer: cust.Display()})
 
-	// publish notification
-	t.PublishOp(tenancy, multitenancy.OpSetCustomer)
-
 	// done
 	return nil
 }
@@ -386,8 +411,17 @@ func (t *TenancyController) ChangePoolOrDb(ctx op_context.Context, id string, po
 		}
 	}
 
-	// update fields
-	err = t.CRUD.Update(ctx, &tenancy.TenancyDb, db.Fields{"pool_id": p.GetID(), "dbname": dbN})
+	// update fields and publish notifications
+	err = t.SaveAndPublish(ctx, tenancy, multitenancy.OpChangePoolOrDb, func() error {
+		err := t.CRUD.Update(ctx, &tenancy.TenancyDb, db.Fields{"pool_id": p.GetID(), "dbname": dbN})
+		if err != nil {
+			return err
+		}
+		if oldPoolId != pId {
+			return t.PostOp(ctx, tenancy, multitenancy.OpDelete, oldPoolId)
+		}
+		return nil
+	})
 	if err != nil {
 		c.SetMessage("failed to update tenancy")
 		return c.SetError(err)
@@ -397,12 +431,6 @@ func (t *TenancyController) ChangePoolOrDb(ctx op_context.Context, id string, po
 	t.OpLog(ctx, multitenancy.OpChangePoolOrDb, &multitenancy.OpLogTenancy{TenancyId: tenancy.GetID(),
 		Role: tenancy.Role(), Customer: tenancy.CustomerDisplay(), Pool: p.Name(), DbName: dbN})
 
-	// publish notification
-	if oldPoolId != pId {
-		t.PublishOp(tenancy, multitenancy.OpDelete, oldPoolId)
-	}
-	t.PublishOp(tenancy, multitenancy.OpChangePoolOrDb)
-
 	// done
 	return nil
 }
@@ -434,8 +462,10 @@ func (t *TenancyController) SetDbRole(ctx op_context.Context, id string, dbRole
 		return c.SetError(err)
 	}
 
-	// update fields
-	err = t.CRUD.Update(ctx, &tenancy.TenancyDb, db.Fields{"db_role": dbRole})
+	// update fields and publish notification
+	err = t.SaveAndPublish(ctx, tenancy, multitenancy.OpSetDbRole, func() error {
+		return t.CRUD.Update(ctx, &tenancy.TenancyDb, db.Fields{"db_role": dbRole})
+	})
 	if err != nil {
 		c.SetMessage("failed to update tenancy")
 		return c.SetError(err)
@@ -445,9 +475,6 @@ func (t *TenancyController) SetDbRole(ctx op_context.Context, id string, dbRole
 	t.OpLog(ctx, multitenancy.OpSetDbRole, &multitenancy.OpLogTenancy{TenancyId: tenancy.GetID(),
 		Role: tenancy.Role(), Customer: tenancy.CustomerDisplay(), DbRole: dbRole})
 
-	// publish notification
-	t.PublishOp(tenancy, multitenancy.OpSetDbRole)
-
 	// done
 	return nil
 }
@@ -469,8 +496,10 @@ func (t *TenancyController) Delete(ctx op_context.Context, id string, withDataba
 		return c.SetError(err)
 	}
 
-	// delete tenancy
-	err = t.CRUD.Delete(ctx, &tenancy.TenancyDb)
+	// delete tenancy and publish notification
+	err = t.SaveAndPublish(ctx, tenancy, multitenancy.OpDelete, func() error {
+		return t.CRUD.Delete(ctx, &tenancy.TenancyDb)
+	})
 	if err != nil {
 		c.SetMessage("failed to delete tenancy")
 		return c.SetError(err)
@@ -480,9 +509,6 @@ func (t *TenancyController) Delete(ctx op_context.Context, id string, withDataba
 	t.OpLog(ctx, multitenancy.OpDelete, &multitenancy.OpLogTenancy{TenancyId: tenancy.GetID(),
 		Role: tenancy.Role(), Customer: tenancy.CustomerDisplay()})
 
-	// publish notification
-	t.PublishOp(tenancy, multitenancy.OpDelete)
-
 	// done
 	return nil
 }
@@ -496,9 +522,9 @@ func (t *TenancyController) ListIpAddresses(ctx op_context.Context, filter *db.F
 	// construct join query
 	queryBuilder := func() (db.JoinQuery, error) {
 		return ctx.Db().Joiner().
-			Join(&multitenancy.TenancyIpAddress{}, "tenancy_id").On(&multitenancy.TenancyDb{}, "id").
-			Join(&multitenancy.TenancyDb{}, "customer_id").On(&customer.Customer{}, "id").
-			Join(&multitenancy.TenancyDb{}, "pool_id").On(&pool.PoolBase{}, "id").
+			Join(&multitenancy.TenancyIpAddress{}, "tenancy_id").On(&multitenancy.TenancyDb{}, "id").Type(db.JoinInner).
+			Join(&multitenancy.TenancyDb{}, "customer_id").On(&customer.Customer{}, "id").Type(db.JoinLeft).
+			Join(&multitenancy.TenancyDb{}, "pool_id").On(&pool.PoolBase{}, "id").Type(db.JoinLeft).
 			Destination(&multitenancy.TenancyIpAddressItem{})
 	}
 
@@ -529,7 +555,9 @@ func (t *TenancyController) DeleteIpAddress(ctx op_context.Context, id string, i
 
 	// delete tenancy
 	fields := db.Fields{"tenancy_id": tenancy.GetID(), "ip": ipAddress, "tag": tag}
-	err = t.CRUD.DeleteByFields(ctx, fields, &multitenancy.TenancyIpAddress{})
+	err = t.SaveAndPublish(ctx, tenancy, multitenancy.OpDeleteIpAddress, func() error {
+		return t.CRUD.DeleteByFields(ctx, fields, &multitenancy.TenancyIpAddress{})
+	})
 	if err != nil {
 		c.SetMessage("failed to delete tenancy IP address")
 		return c.SetError(err)
@@ -539,9 +567,6 @@ func (t *TenancyController) DeleteIpAddress(ctx op_context.Context, id string, i
 	t.OpLog(ctx, multitenancy.OpDeleteIpAddress, &multitenancy.OpLogTenancy{TenancyId: tenancy.GetID(),
 		Role: tenancy.Role(), Customer: tenancy.CustomerDisplay(), IpAddressTag: tag})
 
-	// publish notification
-	t.PublishOp(tenancy, multitenancy.OpDeleteIpAddress)
-
 	// done
 	return nil
 }
@@ -565,7 +590,10 @@ func (t *TenancyController) AddIpAddress(ctx op_context.Context, id string, ipAd
 	obj.TenancyId = tenancy.GetID()
 	obj.Tag = tag
 	obj.Ip = ipAddress
-	_, err = t.CRUD.CreateDup(ctx, obj, true)
+	err = t.SaveAndPublish(ctx, tenancy, multitenancy.OpAddIpAddress, func() error {
+		_, err := t.CRUD.CreateDup(ctx, obj, true)
+		return err
+	})
 	if err != nil {
 		c.SetMessage("failed to add IP address")
 		return c.SetError(err)
@@ -575,9 +603,6 @@ func (t *TenancyController) AddIpAddress(ctx op_context.Context, id string, ipAd
 	t.OpLog(ctx, multitenancy.OpAddIpAddress, &multitenancy.OpLogTenancy{TenancyId: tenancy.GetID(),
 		Role: tenancy.Role(), Customer: tenancy.CustomerDisplay(), IpAddressTag: tag, IpAddress: ipAddress})
 
-	// publish notification
-	t.PublishOp(tenancy, multitenancy.OpAddIpAddress)
-
 	// done
 	return nil
 }
@@ -605,7 +630,9 @@ func (t *TenancyController) SetPathBlocked(ctx op_context.Context, id string, bl
 		tenancy.BLOCK_SHADOW_PATH = blocked
 	}
 	fields := db.Fields{"block_path": tenancy.BLOCK_PATH, "block_shadow_path": tenancy.BLOCK_SHADOW_PATH}
-	err = t.CRUD.Update(ctx, &tenancy.TenancyDb, fields)
+	err = t.SaveAndPublish(ctx, tenancy, multitenancy.OpSetPathBlocked, func() error {
+		return t.CRUD.Update(ctx, &tenancy.TenancyDb, fields)
+	})
 	if err != nil {
 		c.SetMessage("failed to update tenancy")
 		return c.SetError(err)
@@ -615,9 +642,6 @@ func (t *TenancyController) SetPathBlocked(ctx op_context.Context, id string, bl
 	t.OpLog(ctx, multitenancy.OpSetPathBlocked, &multitenancy.OpLogTenancy{TenancyId: tenancy.GetID(),
 		BlockPath: tenancy.IsBlockedPath(), BlockShadowPath: tenancy.IsBlockedShadowPath(), Customer: tenancy.CustomerDisplay()})
 
-	// publish notification
-	t.PublishOp(tenancy, multitenancy.OpSetPathBlocked)
-
 	// done
 	return nil
 }
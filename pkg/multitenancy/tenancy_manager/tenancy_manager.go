@@ -120,6 +120,11 @@ func (t *TenancyManager) Init(ctx op_context.Context, configPath ...string) erro
 
 	// subscribe to pubsub notifications
 	t.PubsubTopic.TopicBase = pubsub_subscriber.New(multitenancy.PubsubTopicName, multitenancy.NewPubsubNotification)
+	err = t.PubsubTopic.LoadConfig(cfg, log, vld, object_config.Key(utils.OptionalArg("multitenancy", configPath...), "pubsub_topic"))
+	if err != nil {
+		c.SetError(err)
+		return err
+	}
 	if selfPoolErr == nil && selfPool != nil {
 		// subscribe to notifications only from self pool
 		if selfPool.IsActive() {
@@ -146,6 +151,9 @@ func (t *TenancyManager) Init(ctx op_context.Context, configPath ...string) erro
 		return ctx.Logger().PushFatalStack("failed to load tenancies", err)
 	}
 
+	// reconnect tenancies when their pool is reloaded
+	t.Pools.AddReloadHandler(t.ReloadPoolTenancies)
+
 	// done
 	return nil
 }
@@ -231,14 +239,19 @@ func (t *TenancyManager) UnloadTenancy(id string) {
 	tenancy, ok := t.tenanciesById[id]
 	if ok {
 		multitenancy.CloseTenancyDb(tenancy)
-		delete(t.tenanciesById, id)
-		delete(t.tenanciesByPath, tenancy.Path())
-		delete(t.tenanciesByShadowPath, tenancy.ShadowPath())
-		delete(t.tenancyIpAddresses, tenancy.Path())
-		delete(t.tenancyIpAddresses, tenancy.ShadowPath())
+		t.forgetTenancy(tenancy)
 	}
 }
 
+// Remove tenancy from maps, must be called under lock.
+func (t *TenancyManager) forgetTenancy(tenancy multitenancy.Tenancy) {
+	delete(t.tenanciesById, tenancy.GetID())
+	delete(t.tenanciesByPath, tenancy.Path())
+	delete(t.tenanciesByShadowPath, tenancy.ShadowPath())
+	delete(t.tenancyIpAddresses, tenancy.Path())
+	delete(t.tenancyIpAddresses, tenancy.ShadowPath())
+}
+
 func (t *TenancyManager) LoadTenancyFromData(ctx op_context.Context, tenancyDb *multitenancy.TenancyDb) (multitenancy.Tenancy, error) {
 
 	// setup
@@ -268,8 +281,12 @@ func (t *TenancyManager) LoadTenancyFromData(ctx op_context.Context, tenancyDb *
 		c.SetMessage("failed to list tenancy IP addresses")
 	}
 
-	// keep it
+	// keep it replacing previously loaded tenancy with the same ID
 	t.mutex.Lock()
+	oldTenancy, hasOld := t.tenanciesById[tenancy.GetID()]
+	if hasOld {
+		t.forgetTenancy(oldTenancy)
+	}
 	t.tenanciesById[tenancy.GetID()] = tenancy
 	t.tenanciesByPath[tenancy.Path()] = tenancy
 	t.tenanciesByShadowPath[tenancy.ShadowPath()] = tenancy
@@ -287,6 +304,9 @@ func (t *TenancyManager) LoadTenancyFromData(ctx op_context.Context, tenancyDb *
 		t.tenancyIpAddresses[tenancy.ShadowPath()] = tags
 	}
 	t.mutex.Unlock()
+	if hasOld {
+		multitenancy.CloseTenancyDb(oldTenancy)
+	}
 
 	// done
 	return tenancy, nil
@@ -562,6 +582,46 @@ func (t *TenancyManager) LoadTenancies(ctx op_context.Context, selfPool pool.Poo
 	return nil
 }
 
+// Reload tenancies of reloaded pool so that they use new pool data and reconnect to pool services.
+// New tenancy replaces the old one only after it was loaded, so the old tenancy stays in use if reloading fails.
+// If pool was deleted then its tenancies are unloaded.
+func (t *TenancyManager) ReloadPoolTenancies(ctx op_context.Context, poolId string, oldPool pool.Pool, newPool pool.Pool) error {
+
+	// setup
+	var err error
+	c := ctx.TraceInMethod("TenancyManager.ReloadPoolTenancies", logger.Fields{"pool_id": poolId})
+	onExit := func() {
+		if err != nil {
+			c.SetError(err)
+		}
+		ctx.TraceOutMethod()
+	}
+	defer onExit()
+
+	// reload tenancies, failure of one tenancy must not prevent reloading of others
+	for _, tenancy := range t.Tenancies() {
+		if tenancy.PoolId() != poolId {
+			continue
+		}
+		if newPool == nil {
+			t.UnloadTenancy(tenancy.GetID())
+			continue
+		}
+		_, tenancyErr := t.LoadTenancy(ctx, tenancy.GetID())
+		if tenancyErr != nil {
+			c.Logger().Error("failed to reload tenancy", tenancyErr, logger.Fields{"tenancy": tenancy.GetID()})
+			err = tenancyErr
+		}
+	}
+	if err != nil {
+		c.SetMessage("failed to reload tenancies of pool")
+		return err
+	}
+
+	// done
+	return nil
+}
+
 func (t *TenancyManager) TenancyController() multitenancy.TenancyController {
 	return t.Controller
 }
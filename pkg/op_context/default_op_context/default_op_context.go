@@ -163,6 +163,27 @@ func (c *ContextBase) Init(app app_context.Context, log logger.Logger, db db.DB,
 	c.Logger().Trace("open")
 }
 
+// Fork context with the same application, databases, cache, origin and logger fields. Forked context has its own call stack, errors and oplogs.
+// Database transaction is not inherited because it can not be used concurrently.
+func (c *ContextBase) Fork() op_context.Context {
+	f := NewInitContext(c.App(), c.MainLogger(), c.MainDB())
+	f.SetName(c.name)
+	f.errorManager = c.errorManager
+	if c.origin != nil {
+		origin := NewOrigin(c.App())
+		origin.CopyOrigin(c.origin)
+		f.origin = origin
+	}
+	f.AddLoggerFields(c.LoggerFields())
+	f.callContextBuilder = c.callContextBuilder
+	f.cache = c.cache
+	f.errorAsWarn = c.errorAsWarn
+	f.oplogHandler = c.oplogHandler
+	f.overrideDb = c.overrideDb
+	f.writeCloseLog = c.writeCloseLog
+	return f
+}
+
 func (c *ContextBase) SetCallContextBuilder(builder op_context.CallContextBuilder) {
 	c.callContextBuilder = builder
 }
@@ -104,6 +104,20 @@ type Context interface {
 	Close(successMessage ...string)
 }
 
+// Context that can be forked to handle the same operation in another goroutine.
+type ForkableContext interface {
+	Fork() Context
+}
+
+// Fork context if it is forkable, otherwise nil is returned.
+func Fork(ctx Context) Context {
+	f, ok := ctx.(ForkableContext)
+	if !ok {
+		return nil
+	}
+	return f.Fork()
+}
+
 func ExecDbTransaction(ctx Context, handler func() error) error {
 
 	if ctx.DbTransaction() != nil {
@@ -36,6 +36,17 @@ func (c *ContextBase) Construct(baseCtx ...op_context.Context) {
 	}
 }
 
+// Fork context with the same pool. Nil is returned if underlying context is not forkable.
+func (c *ContextBase) Fork() op_context.Context {
+	base := op_context.Fork(c.Context)
+	if base == nil {
+		return nil
+	}
+	f := NewOpContext(base)
+	f.pool = c.pool
+	return f
+}
+
 func NewOpContext(fromCtx ...op_context.Context) *ContextBase {
 	c := &ContextBase{}
 	c.Construct(fromCtx...)
@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/db/db_factory"
 	"github.com/evgeniums/go-utils/pkg/generic_error"
 	"github.com/evgeniums/go-utils/pkg/logger"
 	"github.com/evgeniums/go-utils/pkg/op_context"
@@ -73,7 +74,7 @@ func ConnectDatabaseService(ctx op_context.Context, pool Pool, role string, dbNa
 	if createDb {
 
 		// connect to master database
-		database := ctx.App().Db().Clone()
+		database := db_factory.Clone(ctx.App().Db(), dbConfig.DB_PROVIDER)
 		err = database.InitWithConfig(ctx, ctx.App().Validator(), dbConfig)
 		if err != nil {
 			genErr := generic_error.NewFromOriginal(ErrorCodeServiceInitializationFailed, "Failed to connect to master database", err)
@@ -97,7 +98,7 @@ func ConnectDatabaseService(ctx op_context.Context, pool Pool, role string, dbNa
 
 	// create and init database connection
 	dbConfig.DB_NAME = name
-	database := ctx.App().Db().Clone()
+	database := db_factory.Clone(ctx.App().Db(), dbConfig.DB_PROVIDER)
 	err = database.InitWithConfig(ctx, ctx.App().Validator(), dbConfig)
 	if err != nil {
 		genErr := generic_error.NewFromOriginal(ErrorCodeServiceInitializationFailed, "Failed to connect to database", err)
@@ -51,6 +51,9 @@ func (p *PoolClient) GetServices(ctx op_context.Context, filter *db.Filter) ([]*
 		return nil, 0, err
 	}
 
+	// keep keyset cursors
+	handler.result.UpdateFilter(filter)
+
 	// done
 	return handler.result.Items, handler.result.Count, nil
 }
@@ -32,6 +32,7 @@ func (e *FindServiceEndpoint) HandleRequest(request api_server.Request) error {
 	// set response
 	resp := &pool_api.ServiceResponse{}
 	resp.PoolServiceBase = s.(*pool.PoolServiceBase)
+	resp.MaskSecrets()
 	request.Response().SetMessage(resp)
 
 	// done
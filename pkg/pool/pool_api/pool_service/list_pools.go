@@ -2,12 +2,14 @@ package pool_service
 
 import (
 	"github.com/evgeniums/go-utils/pkg/api/api_server"
+	"github.com/evgeniums/go-utils/pkg/db"
 	"github.com/evgeniums/go-utils/pkg/pool"
 	"github.com/evgeniums/go-utils/pkg/pool/pool_api"
 )
 
 type ListPoolsEndpoint struct {
 	PoolEndpoint
+	search *db.FilterValidator
 }
 
 func (e *ListPoolsEndpoint) HandleRequest(request api_server.Request) error {
@@ -18,7 +20,7 @@ func (e *ListPoolsEndpoint) HandleRequest(request api_server.Request) error {
 
 	// parse query
 	queryName := request.Endpoint().Resource().ServicePathPrototype()
-	filter, err := api_server.ParseDbQuery(request, &pool.PoolBase{}, queryName)
+	filter, err := api_server.ParseDbQueryWithValidator(request, &pool.PoolBase{}, queryName, e.search)
 	if err != nil {
 		return c.SetError(err)
 	}
@@ -31,6 +33,7 @@ func (e *ListPoolsEndpoint) HandleRequest(request api_server.Request) error {
 	}
 
 	// set response message
+	resp.SetCursors(filter)
 	api_server.SetResponseList(request, resp)
 
 	// done
@@ -40,5 +43,6 @@ func (e *ListPoolsEndpoint) HandleRequest(request api_server.Request) error {
 func ListPools(s *PoolService) *ListPoolsEndpoint {
 	e := &ListPoolsEndpoint{}
 	e.Construct(s, pool_api.ListPools())
+	e.search = searchNames()
 	return e
 }
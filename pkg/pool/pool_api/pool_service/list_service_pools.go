@@ -2,6 +2,7 @@ package pool_service
 
 import (
 	"github.com/evgeniums/go-utils/pkg/api/api_server"
+	"github.com/evgeniums/go-utils/pkg/pool"
 	"github.com/evgeniums/go-utils/pkg/pool/pool_api"
 )
 
@@ -25,6 +26,7 @@ func (e *ListServicePoolsEndpoint) HandleRequest(request api_server.Request) err
 	}
 
 	// set response
+	pool.MaskSecrets(resp.Items)
 	request.Response().SetMessage(resp)
 
 	// done
@@ -2,12 +2,24 @@ package pool_service
 
 import (
 	"github.com/evgeniums/go-utils/pkg/api/api_server"
+	"github.com/evgeniums/go-utils/pkg/db"
 	"github.com/evgeniums/go-utils/pkg/pool"
 	"github.com/evgeniums/go-utils/pkg/pool/pool_api"
 )
 
 type ListServicesEndpoint struct {
 	PoolEndpoint
+	search *db.FilterValidator
+}
+
+// Pools and services can be searched by names and descriptions.
+func searchNames() *db.FilterValidator {
+	vld := &db.FilterValidator{}
+	vld.AllowSearch("name", db.SEARCH_PREFIX, "max=64")
+	vld.AllowSearch("name", db.SEARCH_LIKE, "max=64")
+	vld.AllowSearch("long_name", db.SEARCH_LIKE, "max=128")
+	vld.AllowSearch("description", db.SEARCH_FULL_TEXT, "max=128")
+	return vld
 }
 
 func (e *ListServicesEndpoint) HandleRequest(request api_server.Request) error {
@@ -18,7 +30,7 @@ func (e *ListServicesEndpoint) HandleRequest(request api_server.Request) error {
 
 	// parse query
 	queryName := request.Endpoint().Resource().ServicePathPrototype()
-	filter, err := api_server.ParseDbQuery(request, &pool.PoolServiceBase{}, queryName)
+	filter, err := api_server.ParseDbQueryWithValidator(request, &pool.PoolServiceBase{}, queryName, e.search)
 	if err != nil {
 		return c.SetError(err)
 	}
@@ -31,6 +43,8 @@ func (e *ListServicesEndpoint) HandleRequest(request api_server.Request) error {
 	}
 
 	// set response message
+	pool.MaskSecrets(resp.Items)
+	resp.SetCursors(filter)
 	api_server.SetResponseList(request, resp)
 
 	// done
@@ -40,5 +54,6 @@ func (e *ListServicesEndpoint) HandleRequest(request api_server.Request) error {
 func ListServices(s *PoolService) *ListServicesEndpoint {
 	e := &ListServicesEndpoint{}
 	e.Construct(s, pool_api.ListServices())
+	e.search = searchNames()
 	return e
 }
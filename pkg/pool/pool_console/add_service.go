@@ -6,7 +6,6 @@ import (
 
 	"github.com/evgeniums/go-utils/pkg/console_tool"
 	"github.com/evgeniums/go-utils/pkg/pool"
-	"github.com/evgeniums/go-utils/pkg/utils"
 )
 
 const AddServiceCmd string = "add_service"
@@ -69,7 +68,7 @@ func (a *AddServiceHandler) Execute(args []string) error {
 		return err
 	}
 
-	fmt.Printf("Added service:\n%s\n", utils.DumpPrettyJson(addedService))
+	fmt.Printf("Added service:\n%s\n", dumpService(addedService))
 
 	// add service to pool
 	if a.Pool != "" {
@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/evgeniums/go-utils/pkg/pool"
-	"github.com/evgeniums/go-utils/pkg/utils"
 )
 
 const DisableServiceCmd string = "disable_service"
@@ -39,7 +38,7 @@ func (a *DisableServiceHandler) Execute(args []string) error {
 
 	s, err := pool.DeactivateService(controller, ctx, a.Service, true)
 	if err == nil {
-		fmt.Printf("Updated service:\n\n%s\n\n", utils.DumpPrettyJson(s))
+		fmt.Printf("Updated service:\n\n%s\n\n", dumpService(s))
 	}
 
 	return err
@@ -0,0 +1,62 @@
+package pool_console
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/evgeniums/go-utils/pkg/crypt_utils"
+	"github.com/evgeniums/go-utils/pkg/crypt_utils/key_ring_db"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+)
+
+const EncryptSecretsCmd string = "encrypt_secrets"
+const EncryptSecretsDescription string = "Encrypt plaintext secrets of services and re-encrypt secrets with active key"
+
+func EncryptSecrets() Handler {
+	a := &EncryptSecretsHandler{}
+	a.Init(EncryptSecretsCmd, EncryptSecretsDescription)
+	return a
+}
+
+type EncryptSecretsData struct {
+	BatchSize int `long:"batch" description:"Number of services processed in one batch" default:"100" validate:"gt=0"`
+}
+
+type EncryptSecretsHandler struct {
+	HandlerBase
+	EncryptSecretsData
+}
+
+func (a *EncryptSecretsHandler) Data() interface{} {
+	return &a.EncryptSecretsData
+}
+
+type secretsEncryptor interface {
+	SecretsKeyRing() *crypt_utils.KeyRing
+	EncryptSecrets(ctx op_context.Context, batchSize ...int) (*key_ring_db.ReencryptStats, error)
+}
+
+func (a *EncryptSecretsHandler) Execute(args []string) error {
+
+	ctx, controller, err := a.Context(a.Data())
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+
+	encryptor, ok := controller.(secretsEncryptor)
+	if !ok {
+		return errors.New("pool controller does not support encryption of service secrets")
+	}
+	keyRing := encryptor.SecretsKeyRing()
+	if keyRing == nil {
+		return errors.New("encryption of service secrets is disabled in configuration")
+	}
+
+	stats, err := encryptor.EncryptSecrets(ctx, a.BatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt service secrets: %s", err)
+	}
+	fmt.Printf("Encrypted service secrets with key %s: total %d, encrypted %d, failed %d\n", keyRing.ActiveKeyId(), stats.Total, stats.Reencrypted, stats.Failed)
+	return nil
+}
@@ -3,6 +3,7 @@ package pool_console
 import (
 	"fmt"
 
+	"github.com/evgeniums/go-utils/pkg/pool"
 	"github.com/evgeniums/go-utils/pkg/utils"
 )
 
@@ -37,7 +38,7 @@ func (a *ListPoolServicesHandler) Execute(args []string) error {
 	defer ctx.Close()
 	services, err := controller.GetPoolBindings(ctx, a.Pool, true)
 	if err == nil {
-		fmt.Printf("Services:\n\n%s\n\n", utils.DumpPrettyJson(services))
+		fmt.Printf("Services:\n\n%s\n\n", utils.DumpPrettyJson(pool.MaskSecrets(services)))
 	}
 	return err
 }
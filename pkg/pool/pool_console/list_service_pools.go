@@ -3,6 +3,7 @@ package pool_console
 import (
 	"fmt"
 
+	"github.com/evgeniums/go-utils/pkg/pool"
 	"github.com/evgeniums/go-utils/pkg/utils"
 )
 
@@ -37,7 +38,7 @@ func (a *ListServicePoolsHandler) Execute(args []string) error {
 	defer ctx.Close()
 	pools, err := controller.GetServiceBindings(ctx, a.Name, true)
 	if err == nil {
-		fmt.Printf("Pools:\n\n%s\n\n", utils.DumpPrettyJson(pools))
+		fmt.Printf("Pools:\n\n%s\n\n", utils.DumpPrettyJson(pool.MaskSecrets(pools)))
 	}
 	return err
 }
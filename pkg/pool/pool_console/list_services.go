@@ -3,6 +3,7 @@ package pool_console
 import (
 	"fmt"
 
+	"github.com/evgeniums/go-utils/pkg/pool"
 	"github.com/evgeniums/go-utils/pkg/utils"
 )
 
@@ -28,7 +29,7 @@ func (a *ListServicesHandler) Execute(args []string) error {
 	defer ctx.Close()
 	services, _, err := controller.GetServices(ctx, nil)
 	if err == nil {
-		fmt.Printf("Services:\n\n%s\n\n", utils.DumpPrettyJson(services))
+		fmt.Printf("Services:\n\n%s\n\n", utils.DumpPrettyJson(pool.MaskSecrets(services)))
 	}
 	return err
 }
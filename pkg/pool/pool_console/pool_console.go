@@ -1,9 +1,12 @@
 package pool_console
 
 import (
+	"github.com/evgeniums/go-utils/pkg/app_context"
 	"github.com/evgeniums/go-utils/pkg/console_tool"
 	"github.com/evgeniums/go-utils/pkg/op_context"
 	"github.com/evgeniums/go-utils/pkg/pool"
+	"github.com/evgeniums/go-utils/pkg/pool/app_with_pools"
+	"github.com/evgeniums/go-utils/pkg/utils"
 )
 
 type PoolCommands struct {
@@ -39,7 +42,8 @@ func (p *PoolCommands) LoadHandlers() {
 		EnablePool,
 		DisablePool,
 		EnableService,
-		DisableService)
+		DisableService,
+		EncryptSecrets)
 }
 
 type Handler = console_tool.Handler[*PoolCommands]
@@ -53,5 +57,37 @@ func (b *HandlerBase) Context(data interface{}) (op_context.Context, pool.PoolCo
 	if err != nil {
 		return ctx, nil, err
 	}
-	return ctx, b.Group.GetPoolController(), nil
+	controller := b.Group.GetPoolController()
+
+	// secrets of services can be decrypted only if key ring is set
+	if c, ok := controller.(pool.WithSecretsKeyRing); ok {
+		app := ctx.App()
+		keyRing, err := pool.LoadSecretsKeyRing(app.Cfg(), app.Logger(), app.Validator(), poolsConfigPath(app))
+		if err != nil {
+			return ctx, nil, err
+		}
+		if keyRing != nil {
+			c.SetSecretsKeyRing(keyRing)
+		}
+	}
+
+	return ctx, controller, nil
+}
+
+// Key ring of secrets is configured in section of pool store.
+func poolsConfigPath(app app_context.Context) string {
+	if a, ok := app.(app_with_pools.AppWithPools); ok {
+		if store, ok := a.Pools().(pool.PoolStoreWithConfigPath); ok && store.ConfigPath() != "" {
+			return store.ConfigPath()
+		}
+	}
+	return "pools"
+}
+
+// Dump service with masked secrets.
+func dumpService(service pool.PoolService) string {
+	if s, ok := service.(pool.WithMaskedSecrets); ok {
+		s.MaskSecrets()
+	}
+	return utils.DumpPrettyJson(service)
 }
@@ -2,8 +2,6 @@ package pool_console
 
 import (
 	"fmt"
-
-	"github.com/evgeniums/go-utils/pkg/utils"
 )
 
 const ShowServiceCmd string = "show_service"
@@ -38,7 +36,7 @@ func (a *ShowServiceHandler) Execute(args []string) error {
 	service, err := controller.FindService(ctx, a.Service, true)
 	if err == nil {
 		if service != nil {
-			fmt.Printf("Service:\n\n%s\n\n", utils.DumpPrettyJson(service))
+			fmt.Printf("Service:\n\n%s\n\n", dumpService(service))
 		} else {
 			fmt.Println("Service not found")
 		}
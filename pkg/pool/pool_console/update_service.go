@@ -69,6 +69,6 @@ func (a *UpdateServiceHandler) Execute(args []string) error {
 		return err
 	}
 
-	fmt.Printf("Updated service:\n\n%s\n\n", utils.DumpPrettyJson(service))
+	fmt.Printf("Updated service:\n\n%s\n\n", dumpService(service))
 	return nil
 }
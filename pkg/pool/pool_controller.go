@@ -5,7 +5,10 @@ import (
 	"net/http"
 
 	"github.com/evgeniums/go-utils/pkg/crud"
+	"github.com/evgeniums/go-utils/pkg/crypt_utils"
+	"github.com/evgeniums/go-utils/pkg/crypt_utils/key_ring_db"
 	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/generic_error"
 	"github.com/evgeniums/go-utils/pkg/logger"
 	"github.com/evgeniums/go-utils/pkg/op_context"
 	"github.com/evgeniums/go-utils/pkg/utils"
@@ -80,7 +83,96 @@ func NewPoolController(crud crud.CRUD) *PoolControllerBase {
 }
 
 type PoolControllerBase struct {
-	CRUD crud.CRUD
+	CRUD     crud.CRUD
+	notifier Notifier
+	keyRing  *crypt_utils.KeyRing
+}
+
+// Set key ring for encryption of service secrets. If key ring is not set then secrets are stored as is.
+func (m *PoolControllerBase) SetSecretsKeyRing(keyRing *crypt_utils.KeyRing) {
+	m.keyRing = keyRing
+}
+
+func (m *PoolControllerBase) SecretsKeyRing() *crypt_utils.KeyRing {
+	return m.keyRing
+}
+
+func (m *PoolControllerBase) decryptSecrets(ctx op_context.Context, secrets *SecretsBase, serviceId string) error {
+	err := secrets.decryptSecrets(m.keyRing, serviceId)
+	if err != nil {
+		ctx.SetGenericErrorCode(ErrorCodeInvalidServiceConfiguration)
+		ctx.Logger().Error("failed to decrypt service secrets", err, logger.Fields{"service_id": serviceId})
+		return err
+	}
+	return nil
+}
+
+// Set notifier of changes of pools and services. If notifier is not set then other application instances are not notified.
+func (m *PoolControllerBase) SetNotifier(notifier Notifier) {
+	m.notifier = notifier
+}
+
+// Save changes of pools or services and notify other application instances about them.
+// Notifications are returned by save function, so that they can depend on saved data.
+// If notifier writes notifications to outbox then changes and notifications are saved in the same database transaction.
+func (m *PoolControllerBase) SaveAndNotify(ctx op_context.Context, save func() ([]*PubsubNotification, error)) error {
+
+	var notifications []*PubsubNotification
+	handler := func() error {
+		var err error
+		notifications, err = save()
+		if err != nil || m.notifier == nil {
+			return err
+		}
+		for _, msg := range notifications {
+			err = m.notifier.Post(ctx, msg)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var err error
+	if m.notifier == nil || !m.notifier.Transactional() || ctx.DbTransaction() != nil {
+		err = handler()
+	} else {
+		err = op_context.ExecDbTransaction(ctx, handler)
+	}
+	if err != nil {
+		return err
+	}
+
+	// apply changes to local pool store
+	if m.notifier != nil {
+		for _, msg := range notifications {
+			m.notifier.Apply(ctx, msg)
+		}
+	}
+	return nil
+}
+
+// Notifications for each pool the service is bound to.
+func serviceNotifications(operation string, serviceId string, bindings []*PoolServiceBinding) []*PubsubNotification {
+	notifications := make([]*PubsubNotification, 0, len(bindings))
+	for _, binding := range bindings {
+		notifications = append(notifications, NewNotification(operation, binding.PoolId, serviceId))
+	}
+	return notifications
+}
+
+func (m *PoolControllerBase) serviceBindingsForNotification(ctx op_context.Context, serviceId string) []*PoolServiceBinding {
+	if m.notifier == nil {
+		return nil
+	}
+	bindings, err := m.GetServiceBindings(ctx, serviceId)
+	if err != nil {
+		// operation is already done, so failed notification must not fail it
+		ctx.Logger().Error("failed to find pools for notification", err, logger.Fields{"service_id": serviceId})
+		ctx.ClearError()
+		return nil
+	}
+	return bindings
 }
 
 func fieldName(idIsName ...bool) string {
@@ -109,7 +201,9 @@ func (m *PoolControllerBase) AddPool(ctx op_context.Context, pool Pool) (Pool, e
 
 	// create pool
 	pool.InitObject()
-	err = m.CRUD.Create(ctx, pool)
+	err = m.SaveAndNotify(ctx, func() ([]*PubsubNotification, error) {
+		return []*PubsubNotification{NewNotification(OpAddPool, pool.GetID(), "")}, m.CRUD.Create(ctx, pool)
+	})
 	if err != nil {
 		return nil, c.SetError(err)
 	}
@@ -168,7 +262,15 @@ func (m *PoolControllerBase) UpdatePool(ctx op_context.Context, id string, field
 
 	// update
 	field := fieldName(idIsName...)
-	obj, err := crud.FindUpdate(m.CRUD, ctx, "PoolController.FindUpdatePool", field, id, fields, &PoolBase{}, logger.Fields{field: id})
+	var obj *PoolBase
+	err := m.SaveAndNotify(ctx, func() ([]*PubsubNotification, error) {
+		var err error
+		obj, err = crud.FindUpdate(m.CRUD, ctx, "PoolController.FindUpdatePool", field, id, fields, &PoolBase{}, logger.Fields{field: id})
+		if err != nil || obj == nil {
+			return nil, err
+		}
+		return []*PubsubNotification{NewNotification(OpUpdatePool, obj.GetID(), "")}, nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -225,7 +327,10 @@ func (m *PoolControllerBase) DeletePool(ctx op_context.Context, id string, idIsN
 		return c.SetError(errors.New("can not delete pool with services, remove all service bindings first"))
 	}
 
-	err = crud.Delete(m.CRUD, ctx, "PoolController.DeletePool", "id", poolId, &PoolBase{}, logger.Fields{"id": id})
+	err = m.SaveAndNotify(ctx, func() ([]*PubsubNotification, error) {
+		err := crud.Delete(m.CRUD, ctx, "PoolController.DeletePool", "id", poolId, &PoolBase{}, logger.Fields{"id": id})
+		return []*PubsubNotification{NewNotification(OpDeletePool, poolId, "")}, err
+	})
 	if err != nil {
 		return err
 	}
@@ -249,6 +354,19 @@ func (m *PoolControllerBase) AddService(ctx op_context.Context, service PoolServ
 	}
 
 	service.InitObject()
+	if m.keyRing != nil {
+		s, ok := service.(interface{ secrets() *SecretsBase })
+		if !ok {
+			return nil, c.SetErrorStr("service does not support encryption of secrets")
+		}
+		secrets := *s.secrets()
+		err = s.secrets().encryptSecrets(m.keyRing, service.GetID())
+		if err != nil {
+			c.SetMessage("failed to encrypt service secrets")
+			return nil, c.SetError(err)
+		}
+		defer func() { *s.secrets() = secrets }()
+	}
 	err = m.CRUD.Create(ctx, service)
 	if err != nil {
 		return nil, err
@@ -269,6 +387,10 @@ func (m *PoolControllerBase) FindService(ctx op_context.Context, id string, idIs
 		ctx.SetGenericErrorCode(ErrorCodeServiceNotFound)
 		return nil, err
 	}
+	err = m.decryptSecrets(ctx, &service.SecretsBase, service.GetID())
+	if err != nil {
+		return nil, err
+	}
 	return service, nil
 }
 
@@ -318,9 +440,44 @@ func (m *PoolControllerBase) UpdateService(ctx op_context.Context, id string, fi
 		}
 	}
 
-	// update
+	// encrypt secrets
+	if m.keyRing != nil && (db.IsFieldSet(fields, Secret1Field) || db.IsFieldSet(fields, Secret2Field)) {
+		serviceId, err := m.ServiceId(c, ctx, id, idIsName...)
+		if err != nil {
+			return nil, err
+		}
+		if serviceId == "" {
+			return nil, c.SetError(errors.New("service not found"))
+		}
+		fields = utils.CopyMapOneLevel(fields)
+		for _, field := range []string{Secret1Field, Secret2Field} {
+			if !db.IsFieldSet(fields, field) {
+				continue
+			}
+			value, ok := fields[field].(string)
+			if !ok {
+				ctx.SetGenericErrorCode(generic_error.ErrorCodeFormat)
+				return nil, c.SetError(errors.New("invalid type of secret"))
+			}
+			fields[field], err = EncryptSecret(m.keyRing, serviceId, field, value)
+			if err != nil {
+				c.SetMessage("failed to encrypt service secret")
+				return nil, c.SetError(err)
+			}
+		}
+	}
+
+	// update and notify pools the service is bound to
 	idField := fieldName(idIsName...)
-	obj, err := crud.FindUpdate(m.CRUD, ctx, "PoolController.FindUpdateService", idField, id, fields, &PoolServiceBase{}, logger.Fields{idField: id})
+	var obj *PoolServiceBase
+	err := m.SaveAndNotify(ctx, func() ([]*PubsubNotification, error) {
+		var err error
+		obj, err = crud.FindUpdate(m.CRUD, ctx, "PoolController.FindUpdateService", idField, id, fields, &PoolServiceBase{}, logger.Fields{idField: id})
+		if err != nil || obj == nil {
+			return nil, err
+		}
+		return serviceNotifications(OpUpdateService, obj.GetID(), m.serviceBindingsForNotification(ctx, obj.GetID())), nil
+	})
 	if err != nil {
 		return nil, c.SetError(err)
 	}
@@ -406,9 +563,104 @@ func (p *PoolControllerBase) GetServices(ctx op_context.Context, filter *db.Filt
 	if err != nil {
 		return nil, 0, err
 	}
+	for _, service := range services {
+		err = p.decryptSecrets(ctx, &service.SecretsBase, service.GetID())
+		if err != nil {
+			return nil, 0, err
+		}
+	}
 	return services, count, nil
 }
 
+// Encrypt plaintext secrets of services and re-encrypt secrets encrypted with other than active key of key ring.
+// Services that can not be encrypted are logged and skipped.
+func (m *PoolControllerBase) EncryptSecrets(ctx op_context.Context, batchSize ...int) (*key_ring_db.ReencryptStats, error) {
+
+	// setup
+	stats := &key_ring_db.ReencryptStats{}
+	c := ctx.TraceInMethod("PoolController.EncryptSecrets")
+	var err error
+	onExit := func() {
+		if err != nil {
+			c.SetError(err)
+		}
+		ctx.TraceOutMethod()
+	}
+	defer onExit()
+
+	if m.keyRing == nil {
+		err = errors.New("key ring for service secrets is not set")
+		return stats, err
+	}
+	c.SetLoggerField("active_key", m.keyRing.ActiveKeyId())
+
+	limit := key_ring_db.DefaultReencryptBatchSize
+	if len(batchSize) != 0 && batchSize[0] > 0 {
+		limit = batchSize[0]
+	}
+
+	// process services in batches, keyset paging is used because rows are updated while paging
+	filter := db.NewFilter()
+	filter.SetSorting("id")
+	filter.Limit = limit
+	filter.Keyset = true
+	for {
+		var services []*PoolServiceBase
+		_, err = m.CRUD.List(ctx, filter, &services)
+		if err != nil {
+			c.SetMessage("failed to load services")
+			return stats, err
+		}
+
+		for _, service := range services {
+			fields := db.Fields{}
+			failed := false
+			for _, field := range []string{Secret1Field, Secret2Field} {
+				value := service.SECRET1
+				if field == Secret2Field {
+					value = service.SECRET2
+				}
+				if value == "" {
+					continue
+				}
+				stats.Total++
+				if !secretNeedsEncryption(m.keyRing, value) {
+					continue
+				}
+				plaintext, err := DecryptSecret(m.keyRing, service.GetID(), field, value)
+				if err == nil {
+					fields[field], err = EncryptSecret(m.keyRing, service.GetID(), field, plaintext)
+				}
+				if err != nil {
+					stats.Failed++
+					failed = true
+					c.Logger().Error("failed to encrypt service secret", err, logger.Fields{"service_id": service.GetID(), "field": field})
+				}
+			}
+			if failed || len(fields) == 0 {
+				continue
+			}
+
+			updateErr := m.CRUD.Update(ctx, service, fields)
+			if updateErr != nil {
+				stats.Failed += len(fields)
+				c.Logger().Error("failed to save encrypted service secrets", updateErr, logger.Fields{"service_id": service.GetID()})
+				ctx.ClearError()
+				continue
+			}
+			stats.Reencrypted += len(fields)
+		}
+
+		if filter.NextCursor == "" {
+			break
+		}
+		filter.SetCursor(filter.NextCursor)
+	}
+
+	// done
+	return stats, nil
+}
+
 func (m *PoolControllerBase) AddServiceToPool(ctx op_context.Context, poolId string, serviceId string, role string, idIsName ...bool) error {
 
 	field := fieldName(idIsName...)
@@ -458,7 +710,9 @@ func (m *PoolControllerBase) AddServiceToPool(ctx op_context.Context, poolId str
 	association.POOL_ID = pool.GetID()
 	association.SERVICE_ID = service.GetID()
 	association.ROLE = role
-	err = m.CRUD.Create(ctx, association)
+	err = m.SaveAndNotify(ctx, func() ([]*PubsubNotification, error) {
+		return []*PubsubNotification{NewNotification(OpUpdateBindings, pool.GetID(), service.GetID())}, m.CRUD.Create(ctx, association)
+	})
 	if err != nil {
 		c.SetMessage("failed to save association in database")
 		return c.SetError(err)
@@ -548,7 +802,9 @@ func (m *PoolControllerBase) RemoveServiceFromPool(ctx op_context.Context, id st
 		return nil
 	}
 
-	err = m.CRUD.Delete(ctx, association)
+	err = m.SaveAndNotify(ctx, func() ([]*PubsubNotification, error) {
+		return []*PubsubNotification{NewNotification(OpUpdateBindings, poolId, association.SERVICE_ID)}, m.CRUD.Delete(ctx, association)
+	})
 	if err != nil {
 		c.SetMessage("failed to delete association")
 		return err
@@ -584,7 +840,9 @@ func (m *PoolControllerBase) RemoveAllServicesFromPool(ctx op_context.Context, i
 	}
 
 	fields := db.Fields{"pool_id": poolId}
-	err = m.CRUD.DeleteByFields(ctx, fields, &PoolServiceAssociationBase{})
+	err = m.SaveAndNotify(ctx, func() ([]*PubsubNotification, error) {
+		return []*PubsubNotification{NewNotification(OpUpdateBindings, poolId, "")}, m.CRUD.DeleteByFields(ctx, fields, &PoolServiceAssociationBase{})
+	})
 	if err != nil {
 		return err
 	}
@@ -619,7 +877,10 @@ func (m *PoolControllerBase) RemoveServiceFromAllPools(ctx op_context.Context, i
 	}
 
 	fields := db.Fields{"service_id": serviceId}
-	err = m.CRUD.DeleteByFields(ctx, fields, &PoolServiceAssociationBase{})
+	err = m.SaveAndNotify(ctx, func() ([]*PubsubNotification, error) {
+		bindings := m.serviceBindingsForNotification(ctx, serviceId)
+		return serviceNotifications(OpUpdateBindings, serviceId, bindings), m.CRUD.DeleteByFields(ctx, fields, &PoolServiceAssociationBase{})
+	})
 	if err != nil {
 		return err
 	}
@@ -661,8 +922,8 @@ func (p *PoolControllerBase) GetPoolBindings(ctx op_context.Context, id string,
 	// construct join query
 	queryBuilder := func() (db.JoinQuery, error) {
 		return ctx.Db().Joiner().
-			Join(&PoolServiceAssociationBase{}, "pool_id").On(&PoolBase{}, "id").
-			Join(&PoolServiceAssociationBase{}, "service_id").On(&PoolServiceBase{}, "id").
+			Join(&PoolServiceAssociationBase{}, "pool_id").On(&PoolBase{}, "id").Type(db.JoinLeft).
+			Join(&PoolServiceAssociationBase{}, "service_id").On(&PoolServiceBase{}, "id").Type(db.JoinLeft).
 			Destination(&PoolServiceBinding{})
 	}
 
@@ -673,6 +934,12 @@ func (p *PoolControllerBase) GetPoolBindings(ctx op_context.Context, id string,
 	if err != nil {
 		return nil, err
 	}
+	for _, service := range services {
+		err = p.decryptSecrets(ctx, &service.SecretsBase, service.ServiceId)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	// done
 	return services, nil
@@ -705,8 +972,8 @@ func (p *PoolControllerBase) GetServiceBindings(ctx op_context.Context, id strin
 	// construct join query
 	queryBuilder := func() (db.JoinQuery, error) {
 		return ctx.Db().Joiner().
-			Join(&PoolServiceAssociationBase{}, "service_id").On(&PoolServiceBase{}, "id").
-			Join(&PoolServiceAssociationBase{}, "pool_id").On(&PoolBase{}, "id").
+			Join(&PoolServiceAssociationBase{}, "service_id").On(&PoolServiceBase{}, "id").Type(db.JoinLeft).
+			Join(&PoolServiceAssociationBase{}, "pool_id").On(&PoolBase{}, "id").Type(db.JoinLeft).
 			Destination(&PoolServiceBinding{})
 	}
 
@@ -717,6 +984,12 @@ func (p *PoolControllerBase) GetServiceBindings(ctx op_context.Context, id strin
 	if err != nil {
 		return nil, err
 	}
+	for _, service := range services {
+		err = p.decryptSecrets(ctx, &service.SecretsBase, service.ServiceId)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	// done
 	return services, nil
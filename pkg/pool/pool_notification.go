@@ -0,0 +1,78 @@
+package pool
+
+import (
+	"reflect"
+
+	"github.com/evgeniums/go-utils/pkg/op_context"
+	"github.com/evgeniums/go-utils/pkg/pubsub/pubsub_subscriber"
+)
+
+const (
+	OpAddPool        string = "add_pool"
+	OpUpdatePool     string = "update_pool"
+	OpDeletePool     string = "delete_pool"
+	OpUpdateService  string = "update_service"
+	OpUpdateBindings string = "update_bindings"
+)
+
+const PubsubTopicName = "pool"
+
+type PubsubNotification struct {
+	Pool      string `json:"pool"`
+	Service   string `json:"service,omitempty"`
+	Operation string `json:"operation"`
+	Instance  string `json:"instance,omitempty"`
+}
+
+func NewPubsubNotification() *PubsubNotification {
+	return &PubsubNotification{}
+}
+
+type PubsubTopic struct {
+	*pubsub_subscriber.TopicBase[*PubsubNotification]
+}
+
+func NewNotification(operation string, poolId string, serviceId string) *PubsubNotification {
+	return &PubsubNotification{Pool: poolId, Service: serviceId, Operation: operation}
+}
+
+// Notifier of other application instances about changes of pools and services. Notifications are delivered to affected pools.
+type Notifier interface {
+	// Post notification within database transaction of the change.
+	Post(ctx op_context.Context, msg *PubsubNotification) error
+
+	// Apply change to local pool store after the change was saved.
+	Apply(ctx op_context.Context, msg *PubsubNotification)
+
+	// Check if notifications are written to outbox, in that case they must be posted in the same database transaction as the change.
+	Transactional() bool
+}
+
+type WithNotifier interface {
+	SetNotifier(notifier Notifier)
+}
+
+// Handler invoked after pool was reloaded in pool store. New pool is nil if pool was deleted.
+type PoolReloadHandler = func(ctx op_context.Context, poolId string, oldPool Pool, newPool Pool) error
+
+// Check if configuration of service bound to pool was changed.
+func ServiceChanged(old *PoolServiceBinding, new *PoolServiceBinding) bool {
+	if old == nil || new == nil {
+		return old != new
+	}
+	return old.ServiceId != new.ServiceId ||
+		old.Role() != new.Role() ||
+		!reflect.DeepEqual(old.PoolServiceBaseData, new.PoolServiceBaseData)
+}
+
+// Find service with role in pool, nil is returned if pool is nil or pool has no such service.
+func FindPoolService(p Pool, role string) *PoolServiceBinding {
+	if p == nil {
+		return nil
+	}
+	service, err := p.Service(role)
+	if err != nil {
+		return nil
+	}
+	return service
+}
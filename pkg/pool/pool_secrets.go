@@ -0,0 +1,176 @@
+package pool
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/evgeniums/go-utils/pkg/config"
+	"github.com/evgeniums/go-utils/pkg/config/object_config"
+	"github.com/evgeniums/go-utils/pkg/crypt_utils"
+	"github.com/evgeniums/go-utils/pkg/logger"
+	"github.com/evgeniums/go-utils/pkg/utils"
+	"github.com/evgeniums/go-utils/pkg/validator"
+)
+
+const MaskedSecret = "********"
+
+// Encrypted secrets are stored as versioned prefix followed by base64 encoded ciphertext of key ring, other values are plaintext.
+const EncryptedSecretPrefix = "enc:v1:"
+
+const (
+	Secret1Field string = "secret1"
+	Secret2Field string = "secret2"
+)
+
+type SecretsConfig struct {
+	ENCRYPT_SECRETS bool
+	SECRET          string `mask:"true"`
+	SALT            string `mask:"true"`
+}
+
+func (s *SecretsConfig) Config() interface{} {
+	return s
+}
+
+type WithSecretsKeyRing interface {
+	SetSecretsKeyRing(keyRing *crypt_utils.KeyRing)
+}
+
+type WithMaskedSecrets interface {
+	MaskSecrets()
+}
+
+// Load key ring for encryption of service secrets from configuration of pool store. Nil key ring is returned if encryption is disabled.
+func LoadSecretsKeyRing(cfg config.Config, log logger.Logger, vld validator.Validator, configPath ...string) (*crypt_utils.KeyRing, error) {
+	path := utils.OptionalArg("pools", configPath...)
+	secretsCfg := &SecretsConfig{}
+	err := object_config.LoadLogValidate(cfg, log, vld, secretsCfg, path)
+	if err != nil {
+		return nil, log.PushFatalStack("failed to load configuration of service secrets", err)
+	}
+	return loadSecretsKeyRing(cfg, log, vld, path, secretsCfg)
+}
+
+func loadSecretsKeyRing(cfg config.Config, log logger.Logger, vld validator.Validator, path string, secretsCfg *SecretsConfig) (*crypt_utils.KeyRing, error) {
+
+	if !secretsCfg.ENCRYPT_SECRETS {
+		return nil, nil
+	}
+
+	if secretsCfg.SECRET != "" && secretsCfg.SALT == "" {
+		return nil, log.PushFatalStack("encryption salt must not be empty", nil)
+	}
+	keyRing, err := crypt_utils.LoadKeyRing(cfg, log, vld, path, secretsCfg.SECRET, secretsCfg.SALT)
+	if err != nil {
+		return nil, log.PushFatalStack("failed to init key ring for service secrets", err)
+	}
+	if keyRing.IsEmpty() {
+		return nil, log.PushFatalStack("encryption secret must not be empty", nil)
+	}
+
+	return keyRing, nil
+}
+
+// Get ciphertext of encrypted secret. Nil is returned if value has no prefix or is not base64 encoded ciphertext of key ring.
+func secretCiphertext(value string) []byte {
+	if !strings.HasPrefix(value, EncryptedSecretPrefix) {
+		return nil
+	}
+	coding := utils.Base64StringCoding{}
+	data, err := coding.Decode(strings.TrimPrefix(value, EncryptedSecretPrefix))
+	if err != nil || !crypt_utils.IsKeyRingCiphertext(data) {
+		return nil
+	}
+	return data
+}
+
+func IsEncryptedSecret(value string) bool {
+	return secretCiphertext(value) != nil
+}
+
+func secretAdditionalData(serviceId string, field string) []byte {
+	return []byte(utils.ConcatStrings(serviceId, ":", field))
+}
+
+// Encrypt secret with active key of key ring. Service ID and field name are used as additional data, so that ciphertext can not be moved to other service or field.
+func EncryptSecret(keyRing *crypt_utils.KeyRing, serviceId string, field string, value string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+	ciphertext, err := keyRing.Encrypt([]byte(value), secretAdditionalData(serviceId, field))
+	if err != nil {
+		return "", err
+	}
+	coding := utils.Base64StringCoding{}
+	return utils.ConcatStrings(EncryptedSecretPrefix, coding.Encode(ciphertext)), nil
+}
+
+// Decrypt secret. Plaintext secrets are returned as is.
+func DecryptSecret(keyRing *crypt_utils.KeyRing, serviceId string, field string, value string) (string, error) {
+	ciphertext := secretCiphertext(value)
+	if ciphertext == nil {
+		return value, nil
+	}
+	if keyRing == nil {
+		return "", errors.New("key ring for service secrets is not set")
+	}
+	plaintext, err := keyRing.Decrypt(ciphertext, secretAdditionalData(serviceId, field))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Check if secret must be encrypted or re-encrypted with active key of key ring.
+func secretNeedsEncryption(keyRing *crypt_utils.KeyRing, value string) bool {
+	if value == "" {
+		return false
+	}
+	ciphertext := secretCiphertext(value)
+	if ciphertext == nil {
+		return true
+	}
+	return keyRing.KeyIdOf(ciphertext) != keyRing.ActiveKeyId()
+}
+
+func (s *SecretsBase) secrets() *SecretsBase {
+	return s
+}
+
+// Replace non-empty secrets with mask.
+func (s *SecretsBase) MaskSecrets() {
+	if s.SECRET1 != "" {
+		s.SECRET1 = MaskedSecret
+	}
+	if s.SECRET2 != "" {
+		s.SECRET2 = MaskedSecret
+	}
+}
+
+func (s *SecretsBase) encryptSecrets(keyRing *crypt_utils.KeyRing, serviceId string) error {
+	var err error
+	s.SECRET1, err = EncryptSecret(keyRing, serviceId, Secret1Field, s.SECRET1)
+	if err != nil {
+		return err
+	}
+	s.SECRET2, err = EncryptSecret(keyRing, serviceId, Secret2Field, s.SECRET2)
+	return err
+}
+
+func (s *SecretsBase) decryptSecrets(keyRing *crypt_utils.KeyRing, serviceId string) error {
+	var err error
+	s.SECRET1, err = DecryptSecret(keyRing, serviceId, Secret1Field, s.SECRET1)
+	if err != nil {
+		return err
+	}
+	s.SECRET2, err = DecryptSecret(keyRing, serviceId, Secret2Field, s.SECRET2)
+	return err
+}
+
+// Mask secrets of services, e.g. before displaying them.
+func MaskSecrets[T WithMaskedSecrets](services []T) []T {
+	for _, service := range services {
+		service.MaskSecrets()
+	}
+	return services
+}
@@ -2,9 +2,11 @@ package pool
 
 import (
 	"errors"
+	"sync"
 
 	"github.com/evgeniums/go-utils/pkg/config/object_config"
 	"github.com/evgeniums/go-utils/pkg/crud"
+	"github.com/evgeniums/go-utils/pkg/logger"
 	"github.com/evgeniums/go-utils/pkg/op_context"
 	"github.com/evgeniums/go-utils/pkg/utils"
 )
@@ -29,18 +31,33 @@ type PoolStore interface {
 	PoolController() PoolController
 	SelfPoolService(role string) (*PoolServiceBinding, error)
 	SelfPoolServiceByName(name string) (*PoolServiceBinding, error)
+
+	ReloadPool(ctx op_context.Context, id string) error
+	AddReloadHandler(handler PoolReloadHandler)
+}
+
+// Pool store that keeps path of its configuration section.
+type PoolStoreWithConfigPath interface {
+	PoolStore
+	ConfigPath() string
 }
 
 type poolStoreConfig struct {
+	SecretsConfig
 	POOL_NAME string
 }
 
 type PoolStoreBase struct {
 	poolStoreConfig
+	mutex          sync.RWMutex
 	selfPool       Pool
 	poolsByName    map[string]Pool
 	poolsById      map[string]Pool
 	poolController PoolController
+	configPath     string
+
+	reloadMutex    sync.Mutex
+	reloadHandlers []PoolReloadHandler
 }
 
 func (p *PoolStoreBase) Config() interface{} {
@@ -68,13 +85,27 @@ func (p *PoolStoreBase) Init(ctx op_context.Context, configPath ...string) error
 	defer ctx.TraceOutMethod()
 
 	// load configuration
-	err := object_config.LoadLogValidate(ctx.App().Cfg(), ctx.Logger(), ctx.App().Validator(), p, "pools", configPath...)
+	p.configPath = utils.OptionalArg("pools", configPath...)
+	err := object_config.LoadLogValidate(ctx.App().Cfg(), ctx.Logger(), ctx.App().Validator(), p, p.configPath)
 	if err != nil {
 		msg := "failed to init PoolStore"
 		c.SetMessage(msg)
 		return ctx.Logger().PushFatalStack(msg, c.SetError(err))
 	}
 
+	// init key ring for service secrets
+	keyRing, err := loadSecretsKeyRing(ctx.App().Cfg(), ctx.Logger(), ctx.App().Validator(), p.configPath, &p.SecretsConfig)
+	if err != nil {
+		return c.SetError(err)
+	}
+	if keyRing != nil {
+		controller, ok := p.poolController.(WithSecretsKeyRing)
+		if !ok {
+			return c.SetErrorStr("pool controller does not support encryption of service secrets")
+		}
+		controller.SetSecretsKeyRing(keyRing)
+	}
+
 	loadServices := func(pool Pool) error {
 		services, err := p.poolController.GetPoolBindings(ctx, pool.GetID())
 		if err != nil {
@@ -132,6 +163,8 @@ func (p *PoolStoreBase) Init(ctx op_context.Context, configPath ...string) error
 }
 
 func (p *PoolStoreBase) SelfPool() (Pool, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
 	if p.selfPool == nil {
 		return nil, errors.New("self pool undefined")
 	}
@@ -159,6 +192,8 @@ func (p *PoolStoreBase) SelfPoolServiceByName(name string) (*PoolServiceBinding,
 }
 
 func (p *PoolStoreBase) Pool(id string) (Pool, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
 	pool, ok := p.poolsById[id]
 	if !ok {
 		return nil, errors.New("pool not found")
@@ -167,6 +202,8 @@ func (p *PoolStoreBase) Pool(id string) (Pool, error) {
 }
 
 func (p *PoolStoreBase) PoolByName(id string) (Pool, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
 	pool, ok := p.poolsByName[id]
 	if !ok {
 		return nil, errors.New("pool not found")
@@ -175,6 +212,8 @@ func (p *PoolStoreBase) PoolByName(id string) (Pool, error) {
 }
 
 func (p *PoolStoreBase) Pools() []Pool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
 	return utils.AllMapValues(p.poolsById)
 }
 
@@ -182,6 +221,90 @@ func (p *PoolStoreBase) PoolController() PoolController {
 	return p.poolController
 }
 
+func (p *PoolStoreBase) ConfigPath() string {
+	return p.configPath
+}
+
+// Add handler to be invoked after each reload of pool, e.g. to reconnect to services of the pool.
+func (p *PoolStoreBase) AddReloadHandler(handler PoolReloadHandler) {
+	p.reloadMutex.Lock()
+	defer p.reloadMutex.Unlock()
+	p.reloadHandlers = append(p.reloadHandlers, handler)
+}
+
+// Reload pool with services from database and replace it in the store, then invoke reload handlers.
+// If only self pool is kept in the store then other pools are ignored.
+// Deleted pool is removed from the store except for self pool that stays loaded until restart.
+func (p *PoolStoreBase) ReloadPool(ctx op_context.Context, id string) error {
+
+	c := ctx.TraceInMethod("PoolStore.ReloadPool", logger.Fields{"pool_id": id})
+	var err error
+	onExit := func() {
+		if err != nil {
+			c.SetError(err)
+		}
+		ctx.TraceOutMethod()
+	}
+	defer onExit()
+
+	// reloads are serialized so that handlers see pool versions in the right order
+	p.reloadMutex.Lock()
+	defer p.reloadMutex.Unlock()
+
+	selfPoolOnly := p.POOL_NAME != ""
+	p.mutex.RLock()
+	oldPool := p.poolsById[id]
+	p.mutex.RUnlock()
+	if selfPoolOnly && oldPool == nil {
+		return nil
+	}
+
+	// load pool
+	newPool, err := LoadPool(p.poolController, ctx, id)
+	if err != nil {
+		c.SetMessage("failed to load pool")
+		return err
+	}
+	if newPool == nil {
+		ctx.ClearError()
+		if selfPoolOnly {
+			c.Logger().Warn("self pool was deleted, keep it until restart")
+			return nil
+		}
+	}
+
+	// replace pool
+	p.mutex.Lock()
+	if oldPool != nil {
+		delete(p.poolsById, id)
+		delete(p.poolsByName, oldPool.Name())
+	}
+	if newPool != nil {
+		p.poolsById[id] = newPool
+		p.poolsByName[newPool.Name()] = newPool
+		if selfPoolOnly {
+			p.selfPool = newPool
+		}
+	}
+	p.mutex.Unlock()
+	c.Logger().Info("pool reloaded")
+
+	// invoke handlers, failure of one handler must not prevent invocation of others
+	for _, handler := range p.reloadHandlers {
+		handlerErr := handler(ctx, id, oldPool, newPool)
+		if handlerErr != nil {
+			err = handlerErr
+		}
+	}
+	if err != nil {
+		c.SetMessage("failed to handle pool reload")
+		return err
+	}
+
+	// done
+	return nil
+}
+
 func FindPool(store PoolStore, id string) (Pool, error) {
 	pool, err := store.Pool(id)
 	if err != nil {
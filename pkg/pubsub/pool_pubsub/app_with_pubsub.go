@@ -2,7 +2,9 @@ package pool_pubsub
 
 import (
 	"github.com/evgeniums/go-utils/pkg/app_context"
+	"github.com/evgeniums/go-utils/pkg/logger"
 	"github.com/evgeniums/go-utils/pkg/op_context"
+	"github.com/evgeniums/go-utils/pkg/pool"
 	"github.com/evgeniums/go-utils/pkg/pool/app_with_pools"
 	"github.com/evgeniums/go-utils/pkg/pubsub/pubsub_providers/pubsub_factory"
 )
@@ -14,7 +16,8 @@ type AppWithPubsub interface {
 
 type AppWithPubsubBase struct {
 	*app_with_pools.AppWithPoolsBase
-	pubsub *PoolPubsubBase
+	pubsub       *PoolPubsubBase
+	poolNotifier *PoolNotifier
 }
 
 func (a *AppWithPubsubBase) Pubsub() PoolPubsub {
@@ -81,6 +84,32 @@ func (a *AppWithPubsubBase) InitWithArgs(configFile string, args []string, confi
 		return opCtx, opCtx.Logger().PushFatalStack(msg, c.SetError(err))
 	}
 
+	// reconnect pubsub when pools are reloaded
+	a.Pools().AddReloadHandler(a.pubsub.ReloadPool)
+
+	// reload pools on notifications about changes of pools and services
+	a.poolNotifier = NewPoolNotifier(a.pubsub, a.Pools())
+	err = a.poolNotifier.Subscribe(opCtx)
+	if err != nil {
+		msg := "failed to subscribe to pool notifications"
+		c.SetMessage(msg)
+		return opCtx, opCtx.Logger().PushFatalStack(msg, c.SetError(err))
+	}
+	notifying, ok := a.Pools().PoolController().(pool.WithNotifier)
+	if ok {
+		notifying.SetNotifier(a.poolNotifier)
+	}
+
+	// enable invalidation of L1 entries of layered cache in other instances
+	layeredCache := a.LayeredCache()
+	if layeredCache != nil {
+		err = layeredCache.EnableInvalidation(opCtx, a.pubsub)
+		if err != nil {
+			opCtx.Logger().Warn("invalidation of layered cache is not enabled", logger.Fields{"error": err.Error()})
+			opCtx.ClearError()
+		}
+	}
+
 	return opCtx, nil
 }
 
@@ -89,5 +118,8 @@ func (a *AppWithPubsubBase) Init(configFile string, configType ...string) (op_co
 }
 
 func (a *AppWithPubsubBase) Close() {
+	if a.poolNotifier != nil {
+		a.poolNotifier.Unsubscribe()
+	}
 	a.AppWithPoolsBase.Close()
 }
@@ -0,0 +1,131 @@
+package pool_pubsub
+
+import (
+	"github.com/evgeniums/go-utils/pkg/config/object_config"
+	"github.com/evgeniums/go-utils/pkg/logger"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+	"github.com/evgeniums/go-utils/pkg/pool"
+	"github.com/evgeniums/go-utils/pkg/pubsub/pubsub_subscriber"
+	"github.com/evgeniums/go-utils/pkg/utils"
+)
+
+// PoolNotifier posts notifications about changes of pools and services to pool pubsub and reloads pools in pool store
+// when notifications are received. Changes are applied to local pool store after saving, so own notifications are skipped.
+type PoolNotifier struct {
+	pubsub   PoolPubsub
+	store    pool.PoolStore
+	instance string
+	topic    *pool.PubsubTopic
+	handler  *poolNotificationHandler
+}
+
+func NewPoolNotifier(pubsub PoolPubsub, store pool.PoolStore) *PoolNotifier {
+	n := &PoolNotifier{pubsub: pubsub, store: store}
+	n.instance = utils.GenerateID()
+	n.topic = &pool.PubsubTopic{}
+	n.topic.TopicBase = pubsub_subscriber.New(pool.PubsubTopicName, pool.NewPubsubNotification)
+	n.handler = &poolNotificationHandler{notifier: n}
+	n.handler.Init("pool_store")
+	return n
+}
+
+// Post notification to the affected pool. Pool that has no pubsub yet is notified via all pools, so that instances subscribed to all pools
+// can load it. If pool pubsub has outbox then notification is written to outbox within database transaction of the context.
+func (n *PoolNotifier) Post(ctx op_context.Context, msg *pool.PubsubNotification) error {
+
+	c := ctx.TraceInMethod("PoolNotifier.Post", logger.Fields{"pool_id": msg.Pool, "service_id": msg.Service, "operation": msg.Operation})
+	defer ctx.TraceOutMethod()
+
+	var poolIds []string
+	if n.pubsub.PoolPublisher(msg.Pool) != nil {
+		poolIds = []string{msg.Pool}
+	}
+
+	msg.Instance = n.instance
+	err := n.pubsub.PostPools(ctx, pool.PubsubTopicName, msg, poolIds...)
+	if err != nil {
+		if n.Transactional() {
+			return c.SetError(err)
+		}
+		// the change is already saved, so failed publishing must not fail the operation
+		c.Logger().Error("failed to publish pool notification", err)
+	}
+	return nil
+}
+
+// Reload changed pool in local pool store.
+func (n *PoolNotifier) Apply(ctx op_context.Context, msg *pool.PubsubNotification) {
+
+	c := ctx.TraceInMethod("PoolNotifier.Apply", logger.Fields{"pool_id": msg.Pool, "service_id": msg.Service, "operation": msg.Operation})
+	defer ctx.TraceOutMethod()
+
+	// the change is already saved, so failed reloading must not fail the operation
+	err := n.store.ReloadPool(ctx, msg.Pool)
+	if err != nil {
+		c.Logger().Error("failed to reload pool", err)
+		ctx.ClearError()
+	}
+}
+
+func (n *PoolNotifier) Transactional() bool {
+	return n.pubsub.Outbox() != nil
+}
+
+// Subscribe to notifications in self pool if it is defined or in all pools otherwise.
+func (n *PoolNotifier) Subscribe(ctx op_context.Context, configPath ...string) error {
+
+	c := ctx.TraceInMethod("PoolNotifier.Subscribe")
+	defer ctx.TraceOutMethod()
+
+	app := ctx.App()
+	err := n.topic.LoadConfig(app.Cfg(), app.Logger(), app.Validator(), object_config.Key(utils.OptionalArg("pools", configPath...), "pubsub_topic"))
+	if err != nil {
+		return c.SetError(err)
+	}
+	n.topic.Subscribe(n.handler)
+
+	_, err = n.store.SelfPool()
+	if err == nil {
+		_, err = n.pubsub.SubscribeSelfPool(ctx, n.topic)
+		if err != nil {
+			// pool can not be notified without pubsub, so it is reloaded only on restart
+			c.Logger().Warn("pool notifications are disabled in self pool", logger.Fields{"error": err.Error()})
+			ctx.ClearError()
+		}
+		return nil
+	}
+
+	_, err = n.pubsub.SubscribePools(ctx, n.topic)
+	if err != nil {
+		return c.SetError(err)
+	}
+	return nil
+}
+
+func (n *PoolNotifier) Unsubscribe() {
+	n.pubsub.UnsubscribePools(n.topic.Name())
+	n.pubsub.UnsubscribeSelfPool(n.topic.Name())
+}
+
+type poolNotificationHandler struct {
+	pubsub_subscriber.SubscriberClientBase
+	notifier *PoolNotifier
+}
+
+func (h *poolNotificationHandler) Handle(ctx op_context.Context, msg *pool.PubsubNotification) error {
+
+	// own notifications are already applied
+	if msg.Instance == h.notifier.instance {
+		return nil
+	}
+
+	c := ctx.TraceInMethod("PoolNotificationHandler.Handle", logger.Fields{"pool_id": msg.Pool, "operation": msg.Operation})
+	defer ctx.TraceOutMethod()
+
+	err := h.notifier.store.ReloadPool(ctx, msg.Pool)
+	if err != nil {
+		return c.SetError(err)
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/evgeniums/go-utils/pkg/app_context"
 	"github.com/evgeniums/go-utils/pkg/db"
@@ -13,14 +14,28 @@ import (
 	"github.com/evgeniums/go-utils/pkg/pubsub"
 	"github.com/evgeniums/go-utils/pkg/pubsub/pubsub_providers/pubsub_factory"
 	"github.com/evgeniums/go-utils/pkg/pubsub/pubsub_subscriber"
+	"github.com/evgeniums/go-utils/pkg/utils"
 )
 
+// Outbox of messages that are published to pools in background, see pubsub_outbox.
+type Outbox interface {
+	Post(ctx op_context.Context, topicName string, msg interface{}, targets ...string) error
+}
+
 type PoolPubsub interface {
 	Shutdown(ctx context.Context) error
 
 	PublishSelfPool(topicName string, msg interface{}) error
 	PublishPools(topicName string, msg interface{}, poolIds ...string) error
 
+	// Post message to pools within database transaction of the context. If outbox is not set then message is published directly.
+	PostPools(ctx op_context.Context, topicName string, msg interface{}, poolIds ...string) error
+	SetOutbox(outbox Outbox)
+	Outbox() Outbox
+
+	PoolPublisher(poolId string) pubsub.Publisher
+	PoolIds() []string
+
 	SubscribeSelfPool(ctx op_context.Context, topic pubsub_subscriber.Topic) (string, error)
 	UnsubscribeSelfPool(topicName string)
 
@@ -30,10 +45,19 @@ type PoolPubsub interface {
 
 type PoolPubsubBase struct {
 	factory            pubsub_factory.PubsubFactory
+	app                app_context.Context
+	mutex              sync.RWMutex
+	selfPoolId         string
 	selfPoolSubscriber pubsub_subscriber.Subscriber
 	publishers         map[string]pubsub.Publisher
 	selfPoolPublisher  pubsub.Publisher
 	subscribers        map[string]pubsub_subscriber.Subscriber
+	outbox             Outbox
+
+	// subscribed topics are kept to subscribe them again after reconnection
+	selfPoolTopics map[string][]pubsub_subscriber.Topic
+	allPoolsTopics map[string][]pubsub_subscriber.Topic
+	poolTopics     map[string]map[string][]pubsub_subscriber.Topic
 }
 
 func NewPubsub(factory ...pubsub_factory.PubsubFactory) *PoolPubsubBase {
@@ -46,11 +70,18 @@ func NewPubsub(factory ...pubsub_factory.PubsubFactory) *PoolPubsubBase {
 	}
 	p.subscribers = make(map[string]pubsub_subscriber.Subscriber)
 	p.publishers = make(map[string]pubsub.Publisher)
+	p.selfPoolTopics = make(map[string][]pubsub_subscriber.Topic)
+	p.allPoolsTopics = make(map[string][]pubsub_subscriber.Topic)
+	p.poolTopics = make(map[string]map[string][]pubsub_subscriber.Topic)
 	return p
 }
 
 func (p *PoolPubsubBase) Init(app app_context.Context, pools pool.PoolStore) error {
 
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.app = app
+
 	makePublisher := func(poo pool.Pool) (pubsub.Publisher, *pubsub_factory.PubsubConfig, error) {
 
 		fields := db.Fields{"pool_id": poo.GetID(), "pool_name": poo.Name()}
@@ -90,6 +121,8 @@ func (p *PoolPubsubBase) Init(app app_context.Context, pools pool.PoolStore) err
 	selfPool, err := pools.SelfPool()
 	if err == nil {
 
+		p.selfPoolId = selfPool.GetID()
+
 		if !selfPool.IsActive() {
 			fields := db.Fields{"pool_id": selfPool.GetID(), "pool_name": selfPool.Name()}
 			app.Logger().Warn("Pubsub skipped for inactive pool", fields)
@@ -132,6 +165,8 @@ func (p *PoolPubsubBase) Init(app app_context.Context, pools pool.PoolStore) err
 }
 
 func (p *PoolPubsubBase) Shutdown(ctx context.Context) error {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
 	var err error
 	if p.selfPoolSubscriber != nil {
 		err1 := p.selfPoolSubscriber.Shutdown(ctx)
@@ -149,16 +184,28 @@ func (p *PoolPubsubBase) Shutdown(ctx context.Context) error {
 }
 
 func (p *PoolPubsubBase) PublishSelfPool(topicName string, msg interface{}) error {
-	if p.selfPoolPublisher == nil {
+	p.mutex.RLock()
+	publisher := p.selfPoolPublisher
+	p.mutex.RUnlock()
+	if publisher == nil {
 		return errors.New("self publisher not set")
 	}
-	return p.selfPoolPublisher.Publish(topicName, msg)
+	return publisher.Publish(topicName, msg)
 }
 
 func (p *PoolPubsubBase) PublishPools(topicName string, msg interface{}, poolIds ...string) error {
+
+	// publishing is done without lock because some providers handle messages synchronously
+	p.mutex.RLock()
+	publishers := make(map[string]pubsub.Publisher, len(p.publishers))
+	for poolId, publisher := range p.publishers {
+		publishers[poolId] = publisher
+	}
+	p.mutex.RUnlock()
+
 	if len(poolIds) == 0 {
 		// publish to all pools
-		for poolId, publisher := range p.publishers {
+		for poolId, publisher := range publishers {
 			err := publisher.Publish(topicName, msg)
 			if err != nil {
 				return fmt.Errorf("failed to publish to %s pool", poolId)
@@ -167,7 +214,7 @@ func (p *PoolPubsubBase) PublishPools(topicName string, msg interface{}, poolIds
 	} else {
 		// publish to specific pools
 		for _, poolId := range poolIds {
-			publisher, ok := p.publishers[poolId]
+			publisher, ok := publishers[poolId]
 			if ok {
 				err := publisher.Publish(topicName, msg)
 				if err != nil {
@@ -179,11 +226,48 @@ func (p *PoolPubsubBase) PublishPools(topicName string, msg interface{}, poolIds
 	return nil
 }
 
+func (p *PoolPubsubBase) SetOutbox(outbox Outbox) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.outbox = outbox
+}
+
+func (p *PoolPubsubBase) Outbox() Outbox {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.outbox
+}
+
+func (p *PoolPubsubBase) PostPools(ctx op_context.Context, topicName string, msg interface{}, poolIds ...string) error {
+	outbox := p.Outbox()
+	if outbox != nil {
+		return outbox.Post(ctx, topicName, msg, poolIds...)
+	}
+	return p.PublishPools(topicName, msg, poolIds...)
+}
+
+// Get publisher of pool, nil is returned if pool has no active pubsub service.
+func (p *PoolPubsubBase) PoolPublisher(poolId string) pubsub.Publisher {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.publishers[poolId]
+}
+
+// Get IDs of pools that have publishers.
+func (p *PoolPubsubBase) PoolIds() []string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return utils.AllMapKeys(p.publishers)
+}
+
 func (p *PoolPubsubBase) SubscribeSelfPool(ctx op_context.Context, topic pubsub_subscriber.Topic) (string, error) {
 
 	c := ctx.TraceInMethod("PoolPubsub.SubscribeSelfPool", logger.Fields{"topic": topic.Name(), "app": ctx.App().Application(), "app_instance": ctx.App().AppInstance()})
 	defer ctx.TraceOutMethod()
 
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
 	if p.selfPoolSubscriber == nil {
 		return "", c.SetErrorStr("self pool subscriber not set")
 	}
@@ -191,12 +275,16 @@ func (p *PoolPubsubBase) SubscribeSelfPool(ctx op_context.Context, topic pubsub_
 	if err != nil {
 		return "", c.SetError(err)
 	}
+	p.selfPoolTopics[topic.Name()] = append(p.selfPoolTopics[topic.Name()], topic)
 	c.SetLoggerField("subscription_id", subscriptionId)
 	c.Logger().Info("topic was subscribed to self pool")
 	return subscriptionId, nil
 }
 
 func (p *PoolPubsubBase) UnsubscribeSelfPool(topicName string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	delete(p.selfPoolTopics, topicName)
 	if p.selfPoolSubscriber != nil {
 		p.selfPoolSubscriber.Unsubscribe(topicName)
 	}
@@ -207,6 +295,9 @@ func (p *PoolPubsubBase) SubscribePools(ctx op_context.Context, topic pubsub_sub
 	c := ctx.TraceInMethod("PoolPubsub.SubscribePools", logger.Fields{"topic": topic.Name(), "app": ctx.App().Application(), "app_instance": ctx.App().AppInstance()})
 	defer ctx.TraceOutMethod()
 
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
 	poolSubscriptions := make(map[string]string)
 
 	if len(poolIds) == 0 {
@@ -222,10 +313,12 @@ func (p *PoolPubsubBase) SubscribePools(ctx op_context.Context, topic pubsub_sub
 			poolSubscriptions[poolId] = subscriptionId
 			c.Logger().Info("topic was subscribed to pool")
 		}
+		p.allPoolsTopics[topic.Name()] = append(p.allPoolsTopics[topic.Name()], topic)
 	} else {
 		// subscribe to specific pools
 		for _, poolId := range poolIds {
 			c.SetLoggerField("pool_id", poolId)
+			p.addPoolTopic(poolId, topic)
 			subscriber, ok := p.subscribers[poolId]
 			if ok {
 				subscriptionId, err := subscriber.Subscribe(topic)
@@ -245,14 +338,21 @@ func (p *PoolPubsubBase) SubscribePools(ctx op_context.Context, topic pubsub_sub
 }
 
 func (p *PoolPubsubBase) UnsubscribePools(topicName string, poolIds ...string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
 	if len(poolIds) == 0 {
 		// unsubscribe from all pools
+		delete(p.allPoolsTopics, topicName)
+		for _, topics := range p.poolTopics {
+			delete(topics, topicName)
+		}
 		for _, subscriber := range p.subscribers {
 			subscriber.Unsubscribe(topicName)
 		}
 	} else {
 		// unsubscribe from specific pools
 		for _, poolId := range poolIds {
+			delete(p.poolTopics[poolId], topicName)
 			subscriber, ok := p.subscribers[poolId]
 			if ok {
 				subscriber.Unsubscribe(topicName)
@@ -260,3 +360,141 @@ func (p *PoolPubsubBase) UnsubscribePools(topicName string, poolIds ...string) {
 		}
 	}
 }
+
+func (p *PoolPubsubBase) addPoolTopic(poolId string, topic pubsub_subscriber.Topic) {
+	topics, ok := p.poolTopics[poolId]
+	if !ok {
+		topics = make(map[string][]pubsub_subscriber.Topic)
+		p.poolTopics[poolId] = topics
+	}
+	topics[topic.Name()] = append(topics[topic.Name()], topic)
+}
+
+// Get topics that must be subscribed to subscriber of the pool.
+func (p *PoolPubsubBase) topicsOfPool(poolId string) []pubsub_subscriber.Topic {
+	var result []pubsub_subscriber.Topic
+	collect := func(topics map[string][]pubsub_subscriber.Topic) {
+		for _, t := range topics {
+			result = append(result, t...)
+		}
+	}
+	if p.selfPoolId != "" {
+		collect(p.selfPoolTopics)
+	} else {
+		collect(p.allPoolsTopics)
+		collect(p.poolTopics[poolId])
+	}
+	return result
+}
+
+func activePubsubService(p pool.Pool) *pool.PoolServiceBinding {
+	if p == nil || !p.IsActive() {
+		return nil
+	}
+	service := pool.FindPoolService(p, pool.TypePubsub)
+	if service == nil || !service.IsActive() {
+		return nil
+	}
+	return service
+}
+
+// Reconnect pubsub of reloaded pool if pubsub service of the pool was changed.
+// Topics subscribed to the pool are subscribed to new subscriber, then old publisher and subscriber are shut down in background.
+// Can be used as handler of pool reloads in pool store.
+func (p *PoolPubsubBase) ReloadPool(ctx op_context.Context, poolId string, oldPool pool.Pool, newPool pool.Pool) error {
+
+	p.mutex.RLock()
+	app := p.app
+	selfPoolId := p.selfPoolId
+	p.mutex.RUnlock()
+	if app == nil || selfPoolId != "" && selfPoolId != poolId {
+		return nil
+	}
+
+	oldService := activePubsubService(oldPool)
+	newService := activePubsubService(newPool)
+	if !pool.ServiceChanged(oldService, newService) {
+		return nil
+	}
+
+	c := ctx.TraceInMethod("PoolPubsub.ReloadPool", logger.Fields{"pool_id": poolId})
+	var err error
+	var publisher pubsub.Publisher
+	var subscriber pubsub_subscriber.Subscriber
+	onExit := func() {
+		if err != nil {
+			if publisher != nil {
+				publisher.Shutdown(context.Background())
+			}
+			c.SetError(err)
+		}
+		ctx.TraceOutMethod()
+	}
+	defer onExit()
+
+	// connect to new service
+	if newService != nil {
+		cfg := &pubsub_factory.PubsubConfig{PoolService: newService}
+		publisher, err = p.factory.MakePublisher(app, cfg)
+		if err != nil {
+			c.SetMessage("failed to make pubsub publisher for pool")
+			return err
+		}
+		subscriber, err = p.factory.MakeSubscriber(app, cfg)
+		if err != nil {
+			c.SetMessage("failed to make pubsub subscriber for pool")
+			return err
+		}
+	}
+
+	p.mutex.Lock()
+	oldPublisher := p.publishers[poolId]
+	oldSubscriber := p.subscribers[poolId]
+
+	// some providers return the same object for the same service
+	if subscriber != nil && subscriber != oldSubscriber {
+		for _, topic := range p.topicsOfPool(poolId) {
+			_, err = subscriber.Subscribe(topic)
+			if err != nil {
+				c.SetLoggerField("topic", topic.Name())
+				c.SetMessage("failed to subscribe topic to new pubsub service")
+				p.mutex.Unlock()
+				subscriber.Shutdown(context.Background())
+				return err
+			}
+		}
+	}
+
+	// replace publisher and subscriber
+	if publisher != nil {
+		p.publishers[poolId] = publisher
+		p.subscribers[poolId] = subscriber
+	} else {
+		delete(p.publishers, poolId)
+		delete(p.subscribers, poolId)
+	}
+	if selfPoolId != "" {
+		p.selfPoolPublisher = publisher
+		p.selfPoolSubscriber = subscriber
+	}
+	p.mutex.Unlock()
+	if newService != nil {
+		c.Logger().Info("pubsub reconnected in pool", logger.Fields{"service_name": newService.Name()})
+	} else {
+		c.Logger().Info("pubsub disconnected in pool")
+	}
+
+	// close old connections in background because reload can be invoked by reader of old subscriber
+	// and shutdown of subscriber waits for its readers
+	go func() {
+		if oldSubscriber != nil && oldSubscriber != subscriber {
+			oldSubscriber.Shutdown(context.Background())
+		}
+		if oldPublisher != nil && oldPublisher != publisher && interface{}(oldPublisher) != interface{}(oldSubscriber) {
+			oldPublisher.Shutdown(context.Background())
+		}
+	}()
+
+	// done
+	return nil
+}
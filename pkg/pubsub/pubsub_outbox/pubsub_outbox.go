@@ -0,0 +1,442 @@
+package pubsub_outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/evgeniums/go-utils/pkg/app_context"
+	"github.com/evgeniums/go-utils/pkg/background_worker"
+	"github.com/evgeniums/go-utils/pkg/cache"
+	"github.com/evgeniums/go-utils/pkg/cache/cache_locker"
+	"github.com/evgeniums/go-utils/pkg/cache/db_locker"
+	"github.com/evgeniums/go-utils/pkg/common"
+	"github.com/evgeniums/go-utils/pkg/config/object_config"
+	"github.com/evgeniums/go-utils/pkg/crud"
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/logger"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+	"github.com/evgeniums/go-utils/pkg/op_context/default_op_context"
+	"github.com/evgeniums/go-utils/pkg/pubsub"
+	"github.com/evgeniums/go-utils/pkg/pubsub/pool_pubsub"
+	"github.com/evgeniums/go-utils/pkg/utils"
+)
+
+type OutboxMessage struct {
+	common.ObjectBase
+	Sequence  int64     `gorm:"index" json:"sequence"`
+	Topic     string    `gorm:"index" json:"topic"`
+	Target    string    `gorm:"index" json:"target"`
+	Payload   string    `json:"payload"`
+	Sent      bool      `gorm:"index" json:"sent"`
+	SentAt    time.Time `gorm:"index" json:"sent_at"`
+	Dead      bool      `gorm:"index" json:"dead"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error"`
+	NextTime  time.Time `json:"next_time"`
+}
+
+// Last sequence number of messages of outbox. ID of object is the name of outbox.
+type OutboxSequence struct {
+	common.ObjectBase
+	Value int64 `json:"value"`
+}
+
+// Models of outbox including lock table used by database locker.
+func DbModels() []interface{} {
+	return append([]interface{}{&OutboxMessage{}, &OutboxSequence{}}, db_locker.DbModels()...)
+}
+
+// Publishers of outbox messages. Target is an ID of pool for pool pubsub or empty string for single publisher.
+type Publishers interface {
+	Publisher(target string) pubsub.Publisher
+	Targets() []string
+}
+
+type singlePublisher struct {
+	publisher pubsub.Publisher
+}
+
+func (s *singlePublisher) Publisher(target string) pubsub.Publisher {
+	return s.publisher
+}
+
+func (s *singlePublisher) Targets() []string {
+	return []string{""}
+}
+
+func SinglePublisher(publisher pubsub.Publisher) Publishers {
+	return &singlePublisher{publisher: publisher}
+}
+
+type poolPublishers struct {
+	pubsub pool_pubsub.PoolPubsub
+}
+
+func (p *poolPublishers) Publisher(target string) pubsub.Publisher {
+	return p.pubsub.PoolPublisher(target)
+}
+
+func (p *poolPublishers) Targets() []string {
+	return p.pubsub.PoolIds()
+}
+
+func PoolPublishers(poolPubsub pool_pubsub.PoolPubsub) Publishers {
+	return &poolPublishers{pubsub: poolPubsub}
+}
+
+type Outbox interface {
+	// Write message to outbox. Call it within the same database transaction that saves the business changes.
+	// If targets are not specified then message is posted to all targets of publishers.
+	Post(ctx op_context.Context, topicName string, msg interface{}, targets ...string) error
+}
+
+type OutboxConfig struct {
+	PERIOD                  int    `default:"1"`
+	BATCH_SIZE              int    `default:"100" validate:"gt=0"`
+	MAX_ATTEMPTS            int    `default:"20" validate:"gte=0"`
+	BACKOFF_INITIAL_SECONDS int    `default:"1" validate:"gte=0"`
+	BACKOFF_MAX_SECONDS     int    `default:"300" validate:"gte=0"`
+	RETENTION_SECONDS       int    `default:"86400" validate:"gte=0"`
+	LOCKER                  string `default:"redis" validate:"oneof=redis inmem db"`
+	LOCK_TTL_SECONDS        int    `default:"60" validate:"gt=0"`
+}
+
+// Transactional outbox of pubsub messages.
+//
+// Messages are written to database by Post and then relayed to publishers in background with at-least-once delivery.
+// Messages of the same topic and target are published in the order they were posted. A failed message blocks the following
+// messages of its topic and target until it is published or until MAX_ATTEMPTS is reached (0 means no limit), after that the message is marked as dead.
+// Sent messages are deleted after RETENTION_SECONDS.
+type OutboxBase struct {
+	OutboxConfig
+	app_context.WithAppBase
+	crud.WithCRUDBase
+	background_worker.JobRunnerBase
+
+	name       string
+	publishers Publishers
+	locker     cache.Locker
+	running    atomic.Bool
+}
+
+func NewOutbox(name string, publishers Publishers, cruds ...crud.CRUD) *OutboxBase {
+	o := &OutboxBase{name: name, publishers: publishers}
+	o.WithCRUDBase.Construct(cruds...)
+	return o
+}
+
+func (o *OutboxBase) Config() interface{} {
+	return &o.OutboxConfig
+}
+
+func (o *OutboxBase) Init(app app_context.Context, configPath ...string) error {
+
+	o.WithAppBase.Init(app)
+
+	err := object_config.LoadLogValidateApp(app, o, "pubsub_outbox", configPath...)
+	if err != nil {
+		return app.Logger().PushFatalStack("failed to load configuration of pubsub outbox", err)
+	}
+
+	// init locker if it was not injected
+	if o.locker == nil {
+		o.locker, err = cache_locker.New(app, o.LOCKER)
+		if err != nil {
+			return app.Logger().PushFatalStack("failed to init locker for pubsub outbox", err)
+		}
+	}
+
+	return nil
+}
+
+// Set locker of relay. Must be called before Init, otherwise locker is created according to configuration.
+func (o *OutboxBase) SetLocker(locker cache.Locker) {
+	o.locker = locker
+}
+
+func (o *OutboxBase) RunJob() {
+	o.Relay()
+}
+
+// Reserve count numbers in sequence of outbox and return the first of them.
+// Sequence row stays locked until the transaction is committed, so that sequence numbers follow commit order even with multiple instances.
+func (o *OutboxBase) reserveSequence(ctx op_context.Context, count int) (int64, error) {
+
+	seq := &OutboxSequence{}
+	found, err := o.CRUD().ReadForUpdate(ctx, db.Fields{"id": o.name}, seq)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		seq.InitObject()
+		seq.SetID(o.name)
+		_, err = o.CRUD().CreateDup(ctx, seq, true)
+		if err != nil {
+			return 0, err
+		}
+		found, err = o.CRUD().ReadForUpdate(ctx, db.Fields{"id": o.name}, seq)
+		if err != nil {
+			return 0, err
+		}
+		if !found {
+			return 0, errors.New("sequence of outbox not found")
+		}
+	}
+
+	first := seq.Value + 1
+	err = o.CRUD().Update(ctx, seq, db.Fields{"value": seq.Value + int64(count)})
+	if err != nil {
+		return 0, err
+	}
+	return first, nil
+}
+
+// Post message to outbox. If context has no database transaction then message is posted within a new transaction.
+func (o *OutboxBase) Post(ctx op_context.Context, topicName string, msg interface{}, targets ...string) error {
+
+	c := ctx.TraceInMethod("Outbox.Post", logger.Fields{"topic": topicName})
+	defer ctx.TraceOutMethod()
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		c.SetMessage("failed to serialize message")
+		return c.SetError(err)
+	}
+
+	if len(targets) == 0 {
+		targets = o.publishers.Targets()
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	handler := func() error {
+
+		sequence, err := o.reserveSequence(ctx, len(targets))
+		if err != nil {
+			c.SetMessage("failed to reserve sequence numbers of outbox")
+			return err
+		}
+
+		for i, target := range targets {
+			m := &OutboxMessage{}
+			m.InitObject()
+			m.Sequence = sequence + int64(i)
+			m.Topic = topicName
+			m.Target = target
+			m.Payload = string(payload)
+			m.NextTime = m.GetCreatedAt()
+			err = o.CRUD().Create(ctx, m)
+			if err != nil {
+				c.SetLoggerField("target", target)
+				c.SetMessage("failed to save message in outbox")
+				return err
+			}
+		}
+		return nil
+	}
+
+	if ctx.DbTransaction() != nil {
+		err = handler()
+	} else {
+		err = op_context.ExecDbTransaction(ctx, handler)
+	}
+	if err != nil {
+		return c.SetError(err)
+	}
+	return nil
+}
+
+func (o *OutboxBase) BackoffDelay(attempts int) time.Duration {
+	delay := time.Duration(o.BACKOFF_INITIAL_SECONDS) * time.Second
+	max := time.Duration(o.BACKOFF_MAX_SECONDS) * time.Second
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// Publish pending messages and delete old sent messages. Only one instance relays messages at a time.
+func (o *OutboxBase) Relay() {
+
+	if !o.running.CompareAndSwap(false, true) {
+		return
+	}
+	defer o.running.Store(false)
+
+	ctx := default_op_context.BackgroundOpContext(o.App(), o.name)
+	ctx.SetWriteCloseLog(false)
+	defer ctx.Close()
+
+	lock, err := cache.LockObject(o.locker, "pubsub_outbox", o.name, o.LOCK_TTL_SECONDS)
+	if err != nil {
+		ctx.Logger().Error("failed to lock pubsub outbox", err)
+		return
+	}
+	if lock == nil {
+		// relay is running on another instance
+		return
+	}
+	defer lock.Release()
+	lockTtl := time.Second * time.Duration(o.LOCK_TTL_SECONDS)
+	stopRenewal := cache.KeepLock(lock, lockTtl, lockTtl/2, func(err error) {
+		ctx.Logger().Error("failed to renew pubsub outbox lock", err)
+	})
+	defer stopRenewal()
+
+	err = o.relayMessages(ctx)
+	if err != nil {
+		ctx.Logger().Error("failed to relay outbox messages", err)
+	}
+
+	err = o.cleanup(ctx)
+	if err != nil {
+		ctx.Logger().Error("failed to delete sent outbox messages", err)
+	}
+}
+
+func (o *OutboxBase) isStopped() bool {
+	return o.Stopper() != nil && o.Stopper().IsStopped()
+}
+
+func (o *OutboxBase) relayMessages(ctx op_context.Context) error {
+
+	now := time.Now()
+	blocked := make(map[string]bool)
+	var fromSequence int64
+	for !o.isStopped() {
+
+		filter := db.NewFilter()
+		filter.AddField("sent", false)
+		filter.AddField("dead", false)
+		filter.Intervals = map[string]*db.Interval{"sequence": {From: fromSequence, FromOpen: true}}
+		filter.SetSorting("sequence", db.SORT_ASC)
+		filter.Limit = o.BATCH_SIZE
+		var messages []*OutboxMessage
+		_, err := o.CRUD().List(ctx, filter, &messages)
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range messages {
+			if o.isStopped() {
+				break
+			}
+			fromSequence = msg.Sequence
+
+			key := utils.ConcatStrings(msg.Topic, "/", msg.Target)
+			if blocked[key] {
+				continue
+			}
+			if msg.NextTime.After(now) || !o.relayMessage(ctx, msg) {
+				// keep order of messages within topic and target
+				blocked[key] = true
+			}
+		}
+
+		if len(messages) < o.BATCH_SIZE {
+			break
+		}
+	}
+
+	return nil
+}
+
+// Publish message and save result. Returns true if message was sent or marked as dead.
+func (o *OutboxBase) relayMessage(ctx op_context.Context, msg *OutboxMessage) bool {
+
+	c := ctx.TraceInMethod("Outbox.relayMessage", logger.Fields{"topic": msg.Topic, "target": msg.Target, "message": msg.GetID()})
+	defer ctx.TraceOutMethod()
+
+	var err error
+	publisher := o.publishers.Publisher(msg.Target)
+	if publisher == nil {
+		err = errors.New("publisher not found for outbox message target")
+	} else {
+		err = publisher.Publish(msg.Topic, json.RawMessage(msg.Payload))
+	}
+
+	fields := db.Fields{}
+	if err == nil {
+		fields["sent"] = true
+		fields["sent_at"] = time.Now()
+	} else {
+		msg.Attempts++
+		fields["attempts"] = msg.Attempts
+		fields["last_error"] = err.Error()
+		if o.MAX_ATTEMPTS > 0 && msg.Attempts >= o.MAX_ATTEMPTS {
+			msg.Dead = true
+			fields["dead"] = true
+			c.Logger().Error("outbox message is dead after max number of attempts", err)
+		} else {
+			fields["next_time"] = time.Now().Add(o.BackoffDelay(msg.Attempts))
+			c.Logger().Warn("failed to publish outbox message", logger.Fields{"error": err.Error(), "attempts": msg.Attempts})
+		}
+	}
+
+	err1 := o.CRUD().Update(ctx, msg, fields)
+	if err1 != nil {
+		c.SetMessage("failed to update outbox message")
+		c.SetError(err1)
+		return false
+	}
+
+	return err == nil || msg.Dead
+}
+
+// Delete messages that were sent earlier than RETENTION_SECONDS ago.
+func (o *OutboxBase) cleanup(ctx op_context.Context) error {
+
+	before := time.Now().Add(-time.Duration(o.RETENTION_SECONDS) * time.Second)
+	for !o.isStopped() {
+
+		filter := db.NewFilter()
+		filter.AddField("sent", true)
+		filter.AddInterval("sent_at", nil, before)
+		filter.Limit = o.BATCH_SIZE
+		var messages []*OutboxMessage
+		_, err := o.CRUD().List(ctx, filter, &messages)
+		if err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			break
+		}
+
+		ids := make([]string, len(messages))
+		for i, msg := range messages {
+			ids[i] = msg.GetID()
+		}
+		err = o.CRUD().DeleteByFields(ctx, db.Fields{"id": ids}, &OutboxMessage{})
+		if err != nil {
+			return err
+		}
+
+		if len(messages) < o.BATCH_SIZE {
+			break
+		}
+	}
+
+	return nil
+}
+
+// List messages in outbox.
+func (o *OutboxBase) List(ctx op_context.Context, filter *db.Filter) ([]*OutboxMessage, int64, error) {
+
+	c := ctx.TraceInMethod("Outbox.List")
+	defer ctx.TraceOutMethod()
+
+	var messages []*OutboxMessage
+	count, err := o.CRUD().List(ctx, filter, &messages)
+	if err != nil {
+		c.SetMessage("failed to list outbox messages")
+		return nil, 0, c.SetError(err)
+	}
+
+	return messages, count, nil
+}
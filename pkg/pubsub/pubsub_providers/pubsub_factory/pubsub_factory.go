@@ -11,6 +11,7 @@ import (
 	"github.com/evgeniums/go-utils/pkg/pubsub"
 	"github.com/evgeniums/go-utils/pkg/pubsub/pubsub_providers/pubsub_inmem"
 	"github.com/evgeniums/go-utils/pkg/pubsub/pubsub_providers/pubsub_redis"
+	"github.com/evgeniums/go-utils/pkg/pubsub/pubsub_providers/pubsub_redis_streams"
 	"github.com/evgeniums/go-utils/pkg/pubsub/pubsub_subscriber"
 	"github.com/evgeniums/go-utils/pkg/utils"
 )
@@ -113,6 +114,17 @@ func (p *PubsubFactoryBase) MakePublisher(app app_context.Context, config ...Pub
 			return nil, err
 		}
 		return publisher, nil
+	} else if provider == pubsub_redis_streams.Provider {
+		publisher := pubsub_redis_streams.NewPublisher(p.serializer)
+		err := publisher.InitStreams(app.Cfg(), app.Logger(), app.Validator(), configPath)
+		if err != nil {
+			return nil, err
+		}
+		err = initRedis(app, &publisher.RedisClient, poolService, configPath)
+		if err != nil {
+			return nil, err
+		}
+		return publisher, nil
 	} else if provider == pubsub_inmem.Provider {
 		return p.MakeInmemPubsub(app, poolService)
 	} else if provider == SingletonInmemProvider {
@@ -156,6 +168,17 @@ func (p *PubsubFactoryBase) MakeSubscriber(app app_context.Context, config ...Pu
 			return nil, err
 		}
 		return subsciber, nil
+	} else if provider == pubsub_redis_streams.Provider {
+		subsciber := pubsub_redis_streams.NewSubscriber(app, p.serializer)
+		err := subsciber.InitStreams(app.Cfg(), app.Logger(), app.Validator(), configPath)
+		if err != nil {
+			return nil, err
+		}
+		err = initRedis(app, &subsciber.RedisClient, poolService, configPath)
+		if err != nil {
+			return nil, err
+		}
+		return subsciber, nil
 	} else if provider == pubsub_inmem.Provider {
 		return p.MakeInmemPubsub(app, poolService)
 	} else if provider == SingletonInmemProvider {
@@ -87,6 +87,10 @@ func (r *RedisClient) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+func (r *RedisClient) SetMode(mode string) {
+	r.mode = mode
+}
+
 func (r *RedisClient) NativeHandler() *redis.Client {
 	return r.redisClient
 }
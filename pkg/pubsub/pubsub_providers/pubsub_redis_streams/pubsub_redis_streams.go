@@ -0,0 +1,462 @@
+package pubsub_redis_streams
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evgeniums/go-utils/pkg/app_context"
+	"github.com/evgeniums/go-utils/pkg/config"
+	"github.com/evgeniums/go-utils/pkg/config/object_config"
+	"github.com/evgeniums/go-utils/pkg/logger"
+	"github.com/evgeniums/go-utils/pkg/message"
+	"github.com/evgeniums/go-utils/pkg/pubsub"
+	"github.com/evgeniums/go-utils/pkg/pubsub/pubsub_providers/pubsub_redis"
+	"github.com/evgeniums/go-utils/pkg/pubsub/pubsub_subscriber"
+	"github.com/evgeniums/go-utils/pkg/utils"
+	"github.com/evgeniums/go-utils/pkg/validator"
+	"github.com/redis/go-redis/v9"
+)
+
+const Provider string = "redis_streams"
+
+const payloadField string = "payload"
+
+const (
+	// Each message is delivered to every subscribed instance.
+	DeliveryBroadcast string = "broadcast"
+	// Each message is delivered to one of subscribed instances of application.
+	DeliveryQueue string = "queue"
+)
+
+type StreamsConfig struct {
+	STREAM_PREFIX          string `default:"pubsub_"`
+	MAX_LEN                int64  `default:"10000" validate:"gte=0"`
+	DELIVERY               string `default:"broadcast" validate:"oneof=broadcast queue"`
+	GROUP                  string
+	CONSUMER               string
+	READ_COUNT             int64 `default:"16" validate:"gt=0"`
+	BLOCK_MS               int   `default:"5000" validate:"gt=0"`
+	CLAIM_IDLE_SECONDS     int   `default:"60" validate:"gt=0"`
+	CLAIM_INTERVAL_SECONDS int   `default:"30" validate:"gt=0"`
+	MAX_DELIVERIES         int64 `default:"0" validate:"gte=0"`
+	GROUP_BACKLOG_SECONDS  int   `default:"86400" validate:"gte=0"`
+	GROUP_EXPIRE_SECONDS   int   `default:"86400" validate:"gte=0"`
+}
+
+type WithStreamsConfig struct {
+	StreamsConfig
+}
+
+func (w *WithStreamsConfig) Config() interface{} {
+	return &w.StreamsConfig
+}
+
+func (w *WithStreamsConfig) InitStreams(cfg config.Config, log logger.Logger, vld validator.Validator, configPath ...string) error {
+	err := object_config.LoadLogValidate(cfg, log, vld, w, "pubsub", configPath...)
+	if err != nil {
+		return log.PushFatalStack("failed to load configuration of Redis streams", err)
+	}
+	return nil
+}
+
+func (w *WithStreamsConfig) StreamName(topicName string) string {
+	return utils.ConcatStrings(w.STREAM_PREFIX, topicName)
+}
+
+//---------------------------------------
+
+// Publisher appends messages to Redis stream of topic. Streams are trimmed approximately to MAX_LEN entries.
+type Publisher struct {
+	pubsub_redis.RedisClient
+	WithStreamsConfig
+	pubsub.PublisherBase
+}
+
+func NewPublisher(serializer ...message.Serializer) *Publisher {
+	p := &Publisher{}
+	p.PublisherBase.Construct(serializer...)
+	p.SetMode("streams_publisher")
+	return p
+}
+
+func (p *Publisher) Config() interface{} {
+	return p.WithStreamsConfig.Config()
+}
+
+func (p *Publisher) Publish(topicName string, obj interface{}) error {
+
+	payload, err := p.Serialize(obj)
+	if err != nil {
+		return err
+	}
+
+	args := &redis.XAddArgs{
+		Stream: p.StreamName(topicName),
+		Values: map[string]interface{}{payloadField: payload},
+	}
+	if p.MAX_LEN > 0 {
+		args.MaxLen = p.MAX_LEN
+		args.Approx = true
+	}
+	return p.NativeHandler().XAdd(p.Context(), args).Err()
+}
+
+//---------------------------------------
+
+type streamReader struct {
+	topicName string
+	stream    string
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// Subscriber reads Redis streams of topics within consumer group.
+//
+// In broadcast delivery mode each instance has its own consumer group named GROUP:CONSUMER, so every instance gets all messages.
+// In queue delivery mode all instances share consumer group GROUP and each message is handled by one of them.
+// GROUP defaults to the name of application and CONSUMER defaults to the name of application instance.
+// CONSUMER should be set explicitly to a stable name if names of instances change on restart.
+//
+// Message is acknowledged only after it was successfully handled, so messages published while all consumers were offline
+// are delivered later. Messages that stay pending for CLAIM_IDLE_SECONDS, e.g. of crashed consumers, are reclaimed and handled again.
+// If MAX_DELIVERIES is set then messages delivered that many times are acknowledged and dropped.
+//
+// New consumer group also gets messages published within GROUP_BACKLOG_SECONDS before the group was created,
+// so that messages published while new or renamed instance was starting are not lost. If GROUP_BACKLOG_SECONDS is 0 then
+// new group gets only messages published after it was created.
+// In broadcast mode consumer groups of other instances that were not active for GROUP_EXPIRE_SECONDS are destroyed,
+// if GROUP_EXPIRE_SECONDS is 0 then such groups are kept.
+type Subscriber struct {
+	pubsub_redis.RedisClient
+	WithStreamsConfig
+	pubsub_subscriber.SubscriberBase
+
+	mutex   sync.Mutex
+	readers map[string]*streamReader
+
+	groupPrefix string
+}
+
+func NewSubscriber(app app_context.Context, serializer ...message.Serializer) *Subscriber {
+	s := &Subscriber{}
+	s.SubscriberBase.Construct(app, serializer...)
+	s.SetMode("streams_subscriber")
+	s.readers = make(map[string]*streamReader)
+	return s
+}
+
+func (s *Subscriber) Config() interface{} {
+	return s.WithStreamsConfig.Config()
+}
+
+func (s *Subscriber) InitStreams(cfg config.Config, log logger.Logger, vld validator.Validator, configPath ...string) error {
+
+	err := s.WithStreamsConfig.InitStreams(cfg, log, vld, configPath...)
+	if err != nil {
+		return err
+	}
+
+	if s.GROUP == "" {
+		s.GROUP = s.App().Application()
+	}
+	if s.CONSUMER == "" {
+		s.CONSUMER = s.App().AppInstance()
+		if s.CONSUMER == "" {
+			s.CONSUMER = s.App().Hostname()
+		}
+	}
+	if s.DELIVERY == DeliveryBroadcast {
+		s.groupPrefix = utils.ConcatStrings(s.GROUP, ":")
+		s.GROUP = utils.ConcatStrings(s.groupPrefix, s.CONSUMER)
+	}
+
+	return nil
+}
+
+func (s *Subscriber) Subscribe(topic pubsub_subscriber.Topic) (string, error) {
+
+	subscriptionId, err := s.AddTopic(topic)
+	if err != nil {
+		return "", err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, exists := s.readers[topic.Name()]
+	if exists {
+		return subscriptionId, nil
+	}
+
+	stream := s.StreamName(topic.Name())
+	err = s.createGroup(s.Context(), stream)
+	if err != nil {
+		s.DeleteTopic(topic.Name(), subscriptionId)
+		return "", err
+	}
+
+	ctx, cancel := context.WithCancel(s.Context())
+	reader := &streamReader{topicName: topic.Name(), stream: stream, cancel: cancel}
+	s.readers[topic.Name()] = reader
+
+	reader.wg.Add(2)
+	go s.readMessages(ctx, reader)
+	go s.reclaimMessages(ctx, reader)
+
+	return subscriptionId, nil
+}
+
+// Create consumer group if it does not exist yet.
+func (s *Subscriber) createGroup(ctx context.Context, stream string) error {
+
+	start := "$"
+	if s.GROUP_BACKLOG_SECONDS > 0 {
+		start = fmt.Sprintf("%d-0", time.Now().Add(-time.Duration(s.GROUP_BACKLOG_SECONDS)*time.Second).UnixMilli())
+	}
+
+	err := s.NativeHandler().XGroupCreateMkStream(ctx, stream, s.GROUP, start).Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	return nil
+}
+
+func (s *Subscriber) Unsubscribe(topicName string, subscriptionId ...string) {
+
+	unsubscribe := s.DeleteTopic(topicName, subscriptionId...)
+	if !unsubscribe {
+		return
+	}
+
+	s.mutex.Lock()
+	reader, ok := s.readers[topicName]
+	delete(s.readers, topicName)
+	s.mutex.Unlock()
+
+	if ok {
+		reader.cancel()
+	}
+}
+
+func (s *Subscriber) Shutdown(ctx context.Context) error {
+
+	s.mutex.Lock()
+	readers := utils.AllMapValues(s.readers)
+	s.readers = make(map[string]*streamReader)
+	s.mutex.Unlock()
+
+	for _, reader := range readers {
+		reader.cancel()
+	}
+	for _, reader := range readers {
+		reader.wg.Wait()
+	}
+
+	return s.RedisClient.Shutdown(ctx)
+}
+
+func (s *Subscriber) readMessages(ctx context.Context, reader *streamReader) {
+
+	defer reader.wg.Done()
+
+	for ctx.Err() == nil {
+		streams, err := s.NativeHandler().XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    s.GROUP,
+			Consumer: s.CONSUMER,
+			Streams:  []string{reader.stream, ">"},
+			Count:    s.READ_COUNT,
+			Block:    time.Duration(s.BLOCK_MS) * time.Millisecond,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) || ctx.Err() != nil {
+				continue
+			}
+			s.App().Logger().Error("failed to read from redis stream", err, logger.Fields{"stream": reader.stream, "group": s.GROUP})
+			if strings.HasPrefix(err.Error(), "NOGROUP") {
+				// group was destroyed, e.g. as expired or together with stream
+				err = s.createGroup(ctx, reader.stream)
+				if err != nil && ctx.Err() == nil {
+					s.App().Logger().Error("failed to create consumer group of redis stream", err, logger.Fields{"stream": reader.stream, "group": s.GROUP})
+				}
+			}
+			sleep(ctx, time.Second)
+			continue
+		}
+
+		for _, stream := range streams {
+			for _, msg := range stream.Messages {
+				s.handleMessage(reader, msg)
+			}
+		}
+	}
+}
+
+func (s *Subscriber) reclaimMessages(ctx context.Context, reader *streamReader) {
+
+	defer reader.wg.Done()
+
+	minIdle := time.Duration(s.CLAIM_IDLE_SECONDS) * time.Second
+	for sleep(ctx, time.Duration(s.CLAIM_INTERVAL_SECONDS)*time.Second) {
+
+		if s.MAX_DELIVERIES > 0 {
+			s.dropUndeliverable(ctx, reader, minIdle)
+		}
+		if s.groupPrefix != "" && s.GROUP_EXPIRE_SECONDS > 0 {
+			s.dropExpiredGroups(ctx, reader)
+		}
+
+		start := "0-0"
+		for ctx.Err() == nil {
+			messages, next, err := s.NativeHandler().XAutoClaim(ctx, &redis.XAutoClaimArgs{
+				Stream:   reader.stream,
+				Group:    s.GROUP,
+				Consumer: s.CONSUMER,
+				MinIdle:  minIdle,
+				Start:    start,
+				Count:    s.READ_COUNT,
+			}).Result()
+			if err != nil {
+				if ctx.Err() == nil {
+					s.App().Logger().Error("failed to reclaim pending messages from redis stream", err, logger.Fields{"stream": reader.stream, "group": s.GROUP})
+				}
+				break
+			}
+
+			for _, msg := range messages {
+				s.handleMessage(reader, msg)
+			}
+
+			if next == "0-0" || next == "" {
+				break
+			}
+			start = next
+		}
+	}
+}
+
+// Acknowledge and drop pending messages that were delivered MAX_DELIVERIES times.
+func (s *Subscriber) dropUndeliverable(ctx context.Context, reader *streamReader, minIdle time.Duration) {
+
+	start := "-"
+	for ctx.Err() == nil {
+		pending, err := s.NativeHandler().XPendingExt(ctx, &redis.XPendingExtArgs{
+			Stream: reader.stream,
+			Group:  s.GROUP,
+			Idle:   minIdle,
+			Start:  start,
+			End:    "+",
+			Count:  s.READ_COUNT,
+		}).Result()
+		if err != nil {
+			if ctx.Err() == nil {
+				s.App().Logger().Error("failed to read pending messages from redis stream", err, logger.Fields{"stream": reader.stream, "group": s.GROUP})
+			}
+			return
+		}
+
+		for _, entry := range pending {
+			if entry.RetryCount < s.MAX_DELIVERIES {
+				continue
+			}
+			fields := logger.Fields{"stream": reader.stream, "group": s.GROUP, "message_id": entry.ID, "deliveries": entry.RetryCount}
+			s.App().Logger().Error("dropping redis stream message after max number of deliveries", errors.New("message not handled"), fields)
+			err = s.NativeHandler().XAck(ctx, reader.stream, s.GROUP, entry.ID).Err()
+			if err != nil {
+				s.App().Logger().Error("failed to acknowledge redis stream message", err, fields)
+			}
+		}
+
+		if int64(len(pending)) < s.READ_COUNT {
+			return
+		}
+		// continue after the last entry, exclusive range requires Redis 6.2 or later
+		start = utils.ConcatStrings("(", pending[len(pending)-1].ID)
+	}
+}
+
+// Destroy broadcast consumer groups of other instances whose consumers were not active for GROUP_EXPIRE_SECONDS,
+// e.g. groups left by renamed or rescheduled instances.
+func (s *Subscriber) dropExpiredGroups(ctx context.Context, reader *streamReader) {
+
+	groups, err := s.NativeHandler().XInfoGroups(ctx, reader.stream).Result()
+	if err != nil {
+		if ctx.Err() == nil {
+			s.App().Logger().Error("failed to read consumer groups of redis stream", err, logger.Fields{"stream": reader.stream})
+		}
+		return
+	}
+
+	expire := time.Duration(s.GROUP_EXPIRE_SECONDS) * time.Second
+	for _, group := range groups {
+		if group.Name == s.GROUP || !strings.HasPrefix(group.Name, s.groupPrefix) {
+			continue
+		}
+
+		fields := logger.Fields{"stream": reader.stream, "group": group.Name}
+		consumers, err := s.NativeHandler().XInfoConsumers(ctx, reader.stream, group.Name).Result()
+		if err != nil {
+			if ctx.Err() == nil {
+				s.App().Logger().Error("failed to read consumers of redis stream group", err, fields)
+			}
+			return
+		}
+		if len(consumers) == 0 {
+			// group was just created by starting instance
+			continue
+		}
+		expired := true
+		for _, consumer := range consumers {
+			if consumer.Idle < expire {
+				expired = false
+				break
+			}
+		}
+		if !expired {
+			continue
+		}
+
+		s.App().Logger().Warn("destroying expired consumer group of redis stream", fields)
+		err = s.NativeHandler().XGroupDestroy(ctx, reader.stream, group.Name).Err()
+		if err != nil && ctx.Err() == nil {
+			s.App().Logger().Error("failed to destroy consumer group of redis stream", err, fields)
+		}
+	}
+}
+
+func (s *Subscriber) handleMessage(reader *streamReader, msg redis.XMessage) {
+
+	opCtx := s.NewOpContext(reader.topicName)
+	opCtx.SetLoggerField("redis_stream", reader.stream)
+	opCtx.SetLoggerField("redis_message_id", msg.ID)
+	opCtx.Logger().Debug("begin Redis stream message")
+	defer opCtx.Close("Served Redis stream")
+
+	payload, _ := msg.Values[payloadField].(string)
+	err := s.Handle(opCtx, reader.topicName, []byte(payload))
+	if err != nil {
+		// message stays pending and will be reclaimed later
+		opCtx.Logger().Error("failed to handle redis stream message", err)
+		return
+	}
+
+	err = s.NativeHandler().XAck(s.Context(), reader.stream, s.GROUP, msg.ID).Err()
+	if err != nil {
+		opCtx.Logger().Error("failed to acknowledge redis stream message", err)
+	}
+}
+
+// Sleep for duration or until context is done. Returns false if context is done.
+func sleep(ctx context.Context, duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
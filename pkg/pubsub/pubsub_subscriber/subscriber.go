@@ -57,13 +57,15 @@ func (s *SubscriberBase) Handle(ctx op_context.Context, topicName string, msg []
 	if !ok {
 		return nil
 	}
+	// failure of one topic must not prevent handling of message by other topics
+	var err error
 	for _, topic := range topics {
-		err := topic.Handle(ctx, msg, s.serializer)
-		if err != nil {
-			return err
+		topicErr := topic.Handle(ctx, msg, s.serializer)
+		if topicErr != nil {
+			err = topicErr
 		}
 	}
-	return nil
+	return err
 }
 
 func (s *SubscriberBase) AddTopic(topic Topic) (string, error) {
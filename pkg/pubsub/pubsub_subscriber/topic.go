@@ -2,10 +2,17 @@ package pubsub_subscriber
 
 import (
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/evgeniums/go-utils/pkg/config"
+	"github.com/evgeniums/go-utils/pkg/config/config_viper"
+	"github.com/evgeniums/go-utils/pkg/config/object_config"
+	"github.com/evgeniums/go-utils/pkg/logger"
 	"github.com/evgeniums/go-utils/pkg/message"
 	"github.com/evgeniums/go-utils/pkg/op_context"
 	"github.com/evgeniums/go-utils/pkg/utils"
+	"github.com/evgeniums/go-utils/pkg/validator"
 )
 
 type SubscriberClient[T any] interface {
@@ -36,21 +43,79 @@ type TopicT[T any] interface {
 	Subscribe(subscriber SubscriberClient[T])
 }
 
+const (
+	TopicModeSequential string = "sequential"
+	TopicModeConcurrent string = "concurrent"
+)
+
+type TopicConfig struct {
+	MODE               string `default:"sequential" validate:"oneof=sequential concurrent"`
+	MAX_RETRIES        int    `default:"0" validate:"gte=0"`
+	RETRY_DELAY_MS     int    `default:"100" validate:"gte=0"`
+	RETRY_MAX_DELAY_MS int    `default:"5000" validate:"gte=0"`
+}
+
+// DeadLetterHandler is invoked when subscriber failed to handle message after all retries.
+type DeadLetterHandler[T any] func(ctx op_context.Context, topicName string, subscriberName string, msg T, err error)
+
+type SubscriberStats struct {
+	Handled uint64
+	Failed  uint64
+	Retried uint64
+}
+
+type subscriberEntry[T any] struct {
+	subscriber SubscriberClient[T]
+	stats      SubscriberStats
+}
+
+// TopicBase dispatches messages of topic to subscribers.
+//
+// Subscribers are invoked either sequentially or concurrently depending on MODE. In concurrent mode each subscriber
+// is invoked with its own operation context forked from the context of message. Subscribers of context with database transaction
+// or of context that can not be forked are always invoked sequentially.
+//
+// Failed subscriber is retried up to MAX_RETRIES times with exponential backoff before Handle returns, so that providers with durable delivery
+// acknowledge the message only after all retries. Retries of subscriber in context with database transaction are not performed.
+// If all retries failed then message is passed to dead-letter handler. If dead-letter handler is not set then Handle returns error,
+// so that providers with durable delivery can redeliver the message.
 type TopicBase[T any] struct {
+	TopicConfig
 	mutex       sync.RWMutex
 	name        string
-	subscribers map[string]SubscriberClient[T]
+	subscribers map[string]*subscriberEntry[T]
 	builder     func() T
+	deadLetter  DeadLetterHandler[T]
 }
 
 func New[T any](name string, builder func() T) *TopicBase[T] {
 	t := &TopicBase[T]{}
 	t.name = name
 	t.builder = builder
-	t.subscribers = make(map[string]SubscriberClient[T])
+	t.subscribers = make(map[string]*subscriberEntry[T])
+	object_config.Load(config_viper.New(), t, "")
 	return t
 }
 
+func (t *TopicBase[T]) Config() interface{} {
+	return &t.TopicConfig
+}
+
+// Load configuration of topic, default configuration path is pubsub.topics.<topic_name>.
+func (t *TopicBase[T]) LoadConfig(cfg config.Config, log logger.Logger, vld validator.Validator, configPath ...string) error {
+	err := object_config.LoadLogValidate(cfg, log, vld, t, object_config.Key("pubsub.topics", t.name), configPath...)
+	if err != nil {
+		return log.PushFatalStack("failed to load configuration of pubsub topic", err, logger.Fields{"topic": t.name})
+	}
+	return nil
+}
+
+func (t *TopicBase[T]) SetDeadLetterHandler(handler DeadLetterHandler[T]) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.deadLetter = handler
+}
+
 func (t *TopicBase[T]) Name() string {
 	return t.name
 }
@@ -64,7 +129,22 @@ func (t *TopicBase[T]) Unsubscribe(id string) {
 func (t *TopicBase[T]) Subscribe(subscriber SubscriberClient[T]) {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
-	t.subscribers[subscriber.Name()] = subscriber
+	t.subscribers[subscriber.Name()] = &subscriberEntry[T]{subscriber: subscriber}
+}
+
+// Stats returns counters of messages handled, failed and retried by each subscriber.
+func (t *TopicBase[T]) Stats() map[string]SubscriberStats {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	stats := make(map[string]SubscriberStats, len(t.subscribers))
+	for name, entry := range t.subscribers {
+		stats[name] = SubscriberStats{
+			Handled: atomic.LoadUint64(&entry.stats.Handled),
+			Failed:  atomic.LoadUint64(&entry.stats.Failed),
+			Retried: atomic.LoadUint64(&entry.stats.Retried),
+		}
+	}
+	return stats
 }
 
 func (t *TopicBase[T]) Handle(ctx op_context.Context, msg []byte, serializer message.Serializer) error {
@@ -81,16 +161,97 @@ func (t *TopicBase[T]) Handle(ctx op_context.Context, msg []byte, serializer mes
 
 	t.mutex.RLock()
 	subscribers := utils.AllMapValues(t.subscribers)
+	deadLetter := t.deadLetter
 	t.mutex.RUnlock()
 
-	for _, subscriber := range subscribers {
-		err = subscriber.Handle(ctx, obj)
-		if err != nil {
-			c.SetLoggerField("subscriber", subscriber.Name())
-			c.SetMessage("failed to handle message")
-			ctx.DumpLog()
+	failed := false
+	if t.MODE == TopicModeConcurrent && len(subscribers) > 1 && ctx.DbTransaction() == nil && op_context.Fork(ctx) != nil {
+		var wg sync.WaitGroup
+		var failedMutex sync.Mutex
+		for _, subscriber := range subscribers {
+			wg.Add(1)
+			go func(entry *subscriberEntry[T]) {
+				defer wg.Done()
+				subCtx := subscriberContext(ctx, entry.subscriber.Name())
+				defer subCtx.Close()
+				if !t.handleSubscriber(subCtx, entry, obj, deadLetter) {
+					failedMutex.Lock()
+					failed = true
+					failedMutex.Unlock()
+				}
+			}(subscriber)
+		}
+		wg.Wait()
+	} else {
+		for _, subscriber := range subscribers {
+			if !t.handleSubscriber(ctx, subscriber, obj, deadLetter) {
+				failed = true
+			}
 		}
 	}
 
+	if failed && deadLetter == nil {
+		return c.SetErrorStr("failed to handle message by some subscribers")
+	}
 	return nil
 }
+
+// Invoke subscriber and retry it if it failed. Returns false if message was not handled and was not passed to dead-letter handler.
+func (t *TopicBase[T]) handleSubscriber(ctx op_context.Context, entry *subscriberEntry[T], obj T, deadLetter DeadLetterHandler[T]) bool {
+
+	c := ctx.TraceInMethod("pubsub.Topic.handleSubscriber", logger.Fields{"subscriber": entry.subscriber.Name()})
+	defer ctx.TraceOutMethod()
+
+	var err error
+	for attempt := 0; ; attempt++ {
+
+		if attempt > 0 {
+			atomic.AddUint64(&entry.stats.Retried, 1)
+			time.Sleep(t.RetryDelay(attempt - 1))
+		}
+
+		err = entry.subscriber.Handle(ctx, obj)
+		if err == nil {
+			atomic.AddUint64(&entry.stats.Handled, 1)
+			return true
+		}
+
+		c.SetLoggerField("attempt", attempt+1)
+		c.SetMessage("failed to handle message")
+		ctx.DumpLog()
+		ctx.ClearError()
+
+		if attempt >= t.MAX_RETRIES || ctx.DbTransaction() != nil {
+			break
+		}
+	}
+
+	atomic.AddUint64(&entry.stats.Failed, 1)
+	if deadLetter != nil {
+		deadLetter(ctx, t.name, entry.subscriber.Name(), obj, err)
+		return true
+	}
+
+	c.Logger().Error("subscriber failed to handle pubsub message", err, logger.Fields{"topic": t.name})
+	return false
+}
+
+// Delay before retry after given failed attempt.
+func (t *TopicBase[T]) RetryDelay(attempt int) time.Duration {
+	delay := time.Duration(t.RETRY_DELAY_MS) * time.Millisecond
+	maxDelay := time.Duration(t.RETRY_MAX_DELAY_MS) * time.Millisecond
+	for i := 0; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// Fork operation context for subscriber invoked concurrently with other subscribers.
+func subscriberContext(ctx op_context.Context, subscriberName string) op_context.Context {
+	subCtx := op_context.Fork(ctx)
+	subCtx.SetLoggerField("subscriber", subscriberName)
+	return subCtx
+}
@@ -6,6 +6,13 @@ import (
 	"github.com/evgeniums/go-utils/pkg/user"
 )
 
+// Formats of stored messages. Empty format is used by records stored before the format was persisted.
+const (
+	MessageFormatPlain         string = "plain"
+	MessageFormatEncrypted     string = "encrypted"
+	MessageFormatZstdEncrypted string = "zstd_encrypted"
+)
+
 type MessageSignature struct {
 	common.ObjectWithMonthBase
 	auth.WithUserBase
@@ -13,6 +20,7 @@ type MessageSignature struct {
 	Operation  string `gorm:"index"`
 	Algorithm  string `gorm:"index"`
 	Message    string
+	Format     string
 	Signature  string
 	ExtraData  string `gorm:"index"`
 	PubKeyHash string `gorm:"index"`
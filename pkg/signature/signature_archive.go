@@ -0,0 +1,291 @@
+package signature
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/evgeniums/go-utils/pkg/crypt_utils"
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/logger"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+	"github.com/evgeniums/go-utils/pkg/utils"
+)
+
+// Finder of public key of user by hash of the key. Used to re-verify stored signed messages.
+type PubKeyByHashFinder = func(ctx op_context.Context, userId string, keyHash string) (UserWithPubkey, error)
+
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+type SignatureQuery struct {
+	UserId    string
+	Context   string
+	Operation string
+	Month     string
+}
+
+// Make filter for looking up stored signatures. Month can be in YYYY-MM or YYYYMM format.
+func (q *SignatureQuery) Filter(filter ...*db.Filter) (*db.Filter, error) {
+
+	f := utils.OptionalArg(nil, filter...)
+	if f == nil {
+		f = db.NewFilter()
+	}
+
+	if q.UserId != "" {
+		f.AddField("user_id", q.UserId)
+	}
+	if q.Context != "" {
+		f.AddField("context", q.Context)
+		month, err := utils.MonthFromId(q.Context)
+		if err == nil {
+			f.AddField("month", month)
+		}
+	}
+	if q.Operation != "" {
+		f.AddField("operation", q.Operation)
+	}
+	if q.Month != "" {
+		month, err := utils.MonthFromString(q.Month)
+		if err != nil {
+			return nil, err
+		}
+		f.AddField("month", month)
+	}
+
+	return f, nil
+}
+
+// SignatureEvidence is a self-contained bundle with signed message, signature and public key of signer.
+//
+// Signature is calculated over message concatenated with extra data, Digest is SHA-256 digest of the same data.
+// BundleHash is a SHA-256 hash of the bundle serialized to JSON with empty BundleHash.
+type SignatureEvidence struct {
+	Signature         *MessageSignature `json:"signature"`
+	Message           string            `json:"message"`
+	MessageBase64     string            `json:"message_base64"`
+	ExtraData         []string          `json:"extra_data"`
+	Digest            string            `json:"digest"`
+	DigestAlgorithm   string            `json:"digest_algorithm"`
+	PubKey            string            `json:"public_key"`
+	PubKeyAlgorithm   string            `json:"public_key_algorithm"`
+	Verified          bool              `json:"verified"`
+	VerificationError string            `json:"verification_error,omitempty"`
+	ExportedAt        time.Time         `json:"exported_at"`
+	BundleHash        string            `json:"bundle_hash"`
+}
+
+func (s *SignatureManagerBase) SetPubKeyByHashFinder(finder PubKeyByHashFinder) {
+	s.pubKeyByHashFinder = finder
+}
+
+func (s *SignatureManagerBase) ListSignatures(ctx op_context.Context, filter *db.Filter) ([]*MessageSignature, int64, error) {
+
+	c := ctx.TraceInMethod("SignatureManagerBase.ListSignatures")
+	defer ctx.TraceOutMethod()
+
+	var signatures []*MessageSignature
+	count, err := op_context.DB(ctx).FindWithFilter(ctx, filter, &signatures)
+	if err != nil {
+		c.SetMessage("failed to find signatures in database")
+		return nil, 0, c.SetError(err)
+	}
+
+	return signatures, count, nil
+}
+
+// Read original message from stored signature, decrypting and decompressing it according to format of the record.
+func (s *SignatureManagerBase) ReadMessage(obj *MessageSignature) ([]byte, error) {
+
+	switch obj.Format {
+	case MessageFormatPlain:
+		return []byte(obj.Message), nil
+	case MessageFormatEncrypted:
+		return s.decryptMessage(obj.Message)
+	case MessageFormatZstdEncrypted:
+		message, err := s.decryptMessage(obj.Message)
+		if err != nil {
+			return nil, err
+		}
+		return s.Decompress(message)
+	case "":
+		return s.readLegacyMessage(obj)
+	}
+
+	return nil, fmt.Errorf("unknown format of stored message: %s", obj.Format)
+}
+
+func (s *SignatureManagerBase) decryptMessage(message string) ([]byte, error) {
+	if s.keyRing == nil {
+		return nil, errors.New("key ring for signed messages is not initialized")
+	}
+	return s.keyRing.DecryptB64(message)
+}
+
+// Records stored without format are read according to current configuration.
+func (s *SignatureManagerBase) readLegacyMessage(obj *MessageSignature) ([]byte, error) {
+
+	if !s.ENCRYPT_MESSAGE_STORE {
+		return []byte(obj.Message), nil
+	}
+
+	message, err := s.decryptMessage(obj.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	// messages are compressed only before encryption
+	if bytes.HasPrefix(message, zstdMagic) {
+		return s.Decompress(message)
+	}
+
+	return message, nil
+}
+
+// Find public key that was used for signature and verify the signature of message again.
+func (s *SignatureManagerBase) VerifyStored(ctx op_context.Context, obj *MessageSignature, message []byte) (UserWithPubkey, error) {
+
+	c := ctx.TraceInMethod("SignatureManagerBase.VerifyStored", logger.Fields{"signature_context_id": obj.Context})
+	var err error
+	onExit := func() {
+		if err != nil {
+			c.SetError(err)
+		}
+		ctx.TraceOutMethod()
+	}
+	defer onExit()
+
+	if s.pubKeyByHashFinder == nil {
+		err = errors.New("finder of public keys by hash is not set")
+		return nil, err
+	}
+	userKey, err := s.pubKeyByHashFinder(ctx, obj.UserId, obj.PubKeyHash)
+	if err != nil {
+		c.SetMessage("failed to find public key")
+		return nil, err
+	}
+	if crypt_utils.H256B64([]byte(userKey.PubKey())) != obj.PubKeyHash {
+		err = errors.New("hash of public key mismatch")
+		return userKey, err
+	}
+
+	extraData, err := decodeExtraData(obj)
+	if err != nil {
+		c.SetMessage("failed to decode extra data")
+		return userKey, err
+	}
+	algorithm := utils.OptionalString(userKeyAlgorithm(userKey), obj.Algorithm)
+	verifier, err := s.MakeVerifier(ctx, userKey.PubKey(), algorithm)
+	if err != nil {
+		return userKey, err
+	}
+	err = crypt_utils.VerifySignature(verifier, message, obj.Signature, extraData...)
+	if err != nil {
+		c.SetMessage("invalid signature")
+		return userKey, err
+	}
+
+	return userKey, nil
+}
+
+// Find signature by context and build evidence bundle. Bundle is returned even if verification fails, with failure reason in VerificationError.
+func (s *SignatureManagerBase) Evidence(ctx op_context.Context, contextId string) (*SignatureEvidence, error) {
+
+	c := ctx.TraceInMethod("SignatureManagerBase.Evidence", logger.Fields{"signature_context_id": contextId})
+	var err error
+	onExit := func() {
+		if err != nil {
+			c.SetError(err)
+		}
+		ctx.TraceOutMethod()
+	}
+	defer onExit()
+
+	obj, err := s.Find(ctx, contextId)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := s.ReadMessage(obj)
+	if err != nil {
+		c.SetMessage("failed to read message")
+		return nil, err
+	}
+
+	extraData, err := decodeExtraData(obj)
+	if err != nil {
+		c.SetMessage("failed to decode extra data")
+		return nil, err
+	}
+
+	evidence := &SignatureEvidence{}
+	evidence.Signature = obj
+	evidence.Message = string(message)
+	evidence.MessageBase64 = utils.Base64Encode(message)
+	evidence.ExtraData = extraData
+	evidence.Digest = crypt_utils.H256Hex(message, evidence.ExtraData...)
+	evidence.DigestAlgorithm = "sha256"
+	evidence.ExportedAt = time.Now().UTC()
+
+	userKey, verifyErr := s.VerifyStored(ctx, obj, message)
+	if userKey != nil {
+		evidence.PubKey = userKey.PubKey()
+		evidence.PubKeyAlgorithm = utils.OptionalString(userKeyAlgorithm(userKey), obj.Algorithm)
+	}
+	if verifyErr != nil {
+		evidence.VerificationError = verifyErr.Error()
+		ctx.ClearError()
+	} else {
+		evidence.Verified = true
+	}
+
+	b, err := json.Marshal(evidence)
+	if err != nil {
+		c.SetMessage("failed to serialize evidence")
+		return nil, err
+	}
+	evidence.BundleHash = crypt_utils.H256Hex(b)
+
+	return evidence, nil
+}
+
+// Check that bundle hash matches its content.
+func (e *SignatureEvidence) CheckBundleHash() bool {
+	unhashed := *e
+	unhashed.BundleHash = ""
+	b, err := json.Marshal(&unhashed)
+	if err != nil {
+		return false
+	}
+	return crypt_utils.H256Hex(b) == e.BundleHash
+}
+
+// Extra data are stored as JSON array. Records stored without format have extra data joined with "+".
+func encodeExtraData(extraData []string) (string, error) {
+	if len(extraData) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(extraData)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func decodeExtraData(obj *MessageSignature) ([]string, error) {
+	if obj.ExtraData == "" {
+		return nil, nil
+	}
+	if obj.Format == "" {
+		return strings.Split(obj.ExtraData, "+"), nil
+	}
+	var extraData []string
+	err := json.Unmarshal([]byte(obj.ExtraData), &extraData)
+	if err != nil {
+		return nil, err
+	}
+	return extraData, nil
+}
@@ -0,0 +1,56 @@
+package signature_console
+
+import (
+	"github.com/evgeniums/go-utils/pkg/app_context"
+	"github.com/evgeniums/go-utils/pkg/console_tool"
+	"github.com/evgeniums/go-utils/pkg/multitenancy"
+	"github.com/evgeniums/go-utils/pkg/signature"
+)
+
+// Builder of signature manager. Finder of public keys by hash must be set in returned manager.
+type SignatureManagerBuilder func(app app_context.Context) (*signature.SignatureManagerBase, error)
+
+type SignatureCommands struct {
+	console_tool.Commands[*SignatureCommands]
+
+	MakeManager SignatureManagerBuilder
+}
+
+func NewSignatureCommands(name string, description string, makeManager SignatureManagerBuilder) *SignatureCommands {
+	c := &SignatureCommands{}
+	c.Construct(c, name, description)
+	c.MakeManager = makeManager
+	c.LoadHandlers()
+	return c
+}
+
+func (c *SignatureCommands) LoadHandlers() {
+	c.AddHandlers(
+		List,
+		Show,
+		Export,
+	)
+}
+
+type HandlerBase struct {
+	console_tool.HandlerBase[*SignatureCommands]
+}
+
+func (b *HandlerBase) Context(data interface{}) (multitenancy.TenancyContext, *signature.SignatureManagerBase, error) {
+
+	ctx, err := b.HandlerBase.Context(data)
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	manager, err := b.Group.MakeManager(ctx.App())
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	return ctx, manager, nil
+}
+
+type ContextData struct {
+	ContextId string `long:"context" description:"ID of operation context where message was signed" required:"true" validate:"required"`
+}
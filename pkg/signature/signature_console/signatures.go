@@ -0,0 +1,157 @@
+package signature_console
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/evgeniums/go-utils/pkg/console_tool"
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/signature"
+)
+
+const ListCmd string = "list"
+const ListDescription string = "List signed messages"
+
+func List() console_tool.Handler[*SignatureCommands] {
+	a := &ListHandler{}
+	a.Init(ListCmd, ListDescription)
+	return a
+}
+
+type ListData struct {
+	console_tool.QueryData
+	User      string `long:"user" description:"ID of user who signed messages"`
+	ContextId string `long:"context" description:"ID of operation context where message was signed"`
+	Operation string `long:"operation" description:"Name of signed operation"`
+	Month     string `long:"month" description:"Month when messages were signed in format YYYY-MM"`
+}
+
+type ListHandler struct {
+	HandlerBase
+	ListData
+}
+
+func (a *ListHandler) Data() interface{} {
+	return &a.ListData
+}
+
+func (a *ListHandler) Execute(args []string) error {
+
+	ctx, manager, err := a.Context(a.Data())
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+
+	filter, err := db.ParseQuery(ctx.Db(), a.Query, &signature.MessageSignature{}, "")
+	if err != nil {
+		return fmt.Errorf("failed to parse query: %s", err)
+	}
+	query := &signature.SignatureQuery{UserId: a.User, Context: a.ContextId, Operation: a.Operation, Month: a.Month}
+	filter, err = query.Filter(filter)
+	if err != nil {
+		return fmt.Errorf("invalid filter: %s", err)
+	}
+
+	signatures, count, err := manager.ListSignatures(ctx, filter)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(signatures, "", "   ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize result: %s", err)
+	}
+	fmt.Printf("********************\n\n%s\n\nCount %d\n\n********************\n\n", string(b), count)
+	return nil
+}
+
+//-------------------------------------------------
+
+const ShowCmd string = "show"
+const ShowDescription string = "Show decrypted signed message and verify its signature"
+
+func Show() console_tool.Handler[*SignatureCommands] {
+	a := &ShowHandler{}
+	a.Init(ShowCmd, ShowDescription)
+	return a
+}
+
+type ShowHandler struct {
+	HandlerBase
+	ContextData
+}
+
+func (a *ShowHandler) Data() interface{} {
+	return &a.ContextData
+}
+
+func (a *ShowHandler) Execute(args []string) error {
+
+	ctx, manager, err := a.Context(a.Data())
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+
+	evidence, err := manager.Evidence(ctx, a.ContextId)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(evidence, "", "   ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize result: %s", err)
+	}
+	fmt.Printf("********************\n\n%s\n\n********************\n\n", string(b))
+	return nil
+}
+
+//-------------------------------------------------
+
+const ExportCmd string = "export"
+const ExportDescription string = "Export evidence bundle of signed message to file"
+
+func Export() console_tool.Handler[*SignatureCommands] {
+	a := &ExportHandler{}
+	a.Init(ExportCmd, ExportDescription)
+	return a
+}
+
+type ExportData struct {
+	ContextData
+	File string `long:"file" description:"Path to output file" required:"true" validate:"required"`
+}
+
+type ExportHandler struct {
+	HandlerBase
+	ExportData
+}
+
+func (a *ExportHandler) Data() interface{} {
+	return &a.ExportData
+}
+
+func (a *ExportHandler) Execute(args []string) error {
+
+	ctx, manager, err := a.Context(a.Data())
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+
+	evidence, err := manager.Evidence(ctx, a.ContextId)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(evidence, "", "   ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize evidence: %s", err)
+	}
+	err = os.WriteFile(a.File, b, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write file: %s", err)
+	}
+
+	fmt.Printf("Evidence exported to %s, signature verified: %v, bundle hash: %s\n", a.File, evidence.Verified, evidence.BundleHash)
+	return nil
+}
@@ -3,12 +3,12 @@ package signature
 import (
 	"errors"
 	"net/http"
-	"strings"
 
 	"github.com/evgeniums/go-utils/pkg/auth"
 	"github.com/evgeniums/go-utils/pkg/config"
 	"github.com/evgeniums/go-utils/pkg/config/object_config"
 	"github.com/evgeniums/go-utils/pkg/crypt_utils"
+	"github.com/evgeniums/go-utils/pkg/crypt_utils/key_ring_db"
 	"github.com/evgeniums/go-utils/pkg/db"
 	"github.com/evgeniums/go-utils/pkg/generic_error"
 	"github.com/evgeniums/go-utils/pkg/logger"
@@ -23,6 +23,20 @@ type UserWithPubkey interface {
 	PubKeyHash() string
 }
 
+// User key with recorded signature algorithm. If user key does not implement it then algorithm is detected from the key.
+type UserWithPubkeyAlgorithm interface {
+	UserWithPubkey
+	PubKeyAlgorithm() string
+}
+
+func userKeyAlgorithm(userKey UserWithPubkey) string {
+	k, ok := userKey.(UserWithPubkeyAlgorithm)
+	if !ok {
+		return ""
+	}
+	return k.PubKeyAlgorithm()
+}
+
 type SignatureManager interface {
 	generic_error.ErrorDefinitions
 
@@ -31,6 +45,12 @@ type SignatureManager interface {
 	SetUserKeyFinder(userKeyFinder func(ctx auth.AuthContext) (UserWithPubkey, error))
 }
 
+// Signature manager that can detect signature algorithm of public key.
+type SignatureManagerWithPubKeyAlgorithm interface {
+	SignatureManager
+	PubKeyAlgorithm(ctx op_context.Context, key string) (string, error)
+}
+
 type WithSignatureManager interface {
 	SignatureManager() SignatureManager
 }
@@ -50,7 +70,7 @@ var ErrorHttpCodes = map[string]int{
 }
 
 type SignatureManagerBaseConfig struct {
-	ALGORITHM               string `validate:"required,oneof=rsa_h256_signature" default:"rsa_h256_signature"`
+	ALGORITHM               string `validate:"required,oneof=rsa_h256_signature ed25519_signature ecdsa_p256_h256_signature" default:"rsa_h256_signature"`
 	ENCRYPT_MESSAGE_STORE   bool
 	COMPRESS_BEFORE_ENCRYPT bool   `default:"true"`
 	SECRET                  string `mask:"true"`
@@ -59,12 +79,13 @@ type SignatureManagerBaseConfig struct {
 
 type SignatureManagerBase struct {
 	SignatureManagerBaseConfig
-	cipher *crypt_utils.AEAD
+	keyRing *crypt_utils.KeyRing
 
 	zstdEncoder *zstd.Encoder
 	zstdDecoder *zstd.Decoder
 
-	userKeyFinder func(ctx auth.AuthContext) (UserWithPubkey, error)
+	userKeyFinder      func(ctx auth.AuthContext) (UserWithPubkey, error)
+	pubKeyByHashFinder PubKeyByHashFinder
 }
 
 func NewSignatureManager() *SignatureManagerBase {
@@ -84,24 +105,27 @@ func (s *SignatureManagerBase) Init(cfg config.Config, log logger.Logger, vld va
 		return log.PushFatalStack("failed to init signature manager", err)
 	}
 
-	// init cipher
+	// init key ring
 	if s.ENCRYPT_MESSAGE_STORE {
-		if s.SECRET == "" {
-			return log.PushFatalStack("encryption secret must not be empty", nil)
-		}
-		if s.SALT == "" {
+		if s.SECRET != "" && s.SALT == "" {
 			return log.PushFatalStack("encryption salt must not be empty", nil)
 		}
-		s.cipher, err = crypt_utils.NewAEAD(s.SECRET, []byte(s.SALT))
+		s.keyRing, err = crypt_utils.LoadKeyRing(cfg, log, vld, path, s.SECRET, s.SALT)
 		if err != nil {
-			return log.PushFatalStack("failed to init cipher for signature manager", err)
+			return log.PushFatalStack("failed to init key ring for signature manager", err)
+		}
+		if s.keyRing.IsEmpty() {
+			return log.PushFatalStack("encryption secret must not be empty", nil)
 		}
 	}
 
-	// init compressor
+	// init compressor, decompressor is always needed to read messages that were compressed with previous configuration
 	if s.COMPRESS_BEFORE_ENCRYPT {
 		s.zstdEncoder, _ = zstd.NewWriter(nil)
-		s.zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+	}
+	s.zstdDecoder, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return log.PushFatalStack("failed to init decompressor for signature manager", err)
 	}
 
 	// done
@@ -113,9 +137,15 @@ func (s *SignatureManagerBase) SetUserKeyFinder(userKeyFinder func(ctx auth.Auth
 }
 
 func (s *SignatureManagerBase) CheckPubKey(ctx op_context.Context, key string) error {
+	_, err := s.PubKeyAlgorithm(ctx, key)
+	return err
+}
+
+// Detect signature algorithm of public key and check that the key can be loaded.
+func (s *SignatureManagerBase) PubKeyAlgorithm(ctx op_context.Context, key string) (string, error) {
 
 	// setup
-	c := ctx.TraceInMethod("SignatureManagerBase.CheckPubKey")
+	c := ctx.TraceInMethod("SignatureManagerBase.PubKeyAlgorithm")
 	var err error
 	onExit := func() {
 		if err != nil {
@@ -125,17 +155,38 @@ func (s *SignatureManagerBase) CheckPubKey(ctx op_context.Context, key string) e
 	}
 	defer onExit()
 
-	// try tp make verifier
-	_, err = s.MakeVerifier(ctx, key)
+	// detect algorithm
+	algorithm, err := crypt_utils.PubKeyAlgorithm([]byte(key))
 	if err != nil {
-		return err
+		ctx.SetGenericErrorCode(ErrorCodeInvalidKey)
+		c.SetMessage("failed to detect algorithm of public key")
+		return "", err
+	}
+
+	// try to make verifier
+	_, err = s.MakeVerifier(ctx, key, algorithm)
+	if err != nil {
+		return "", err
 	}
 
 	// done
-	return nil
+	return algorithm, nil
+}
+
+// Algorithm of key. If algorithm was not recorded with the key then it is detected from the key itself,
+// default algorithm is used for keys that cannot be detected.
+func (s *SignatureManagerBase) keyAlgorithm(key string, algorithm string) string {
+	if algorithm != "" {
+		return algorithm
+	}
+	algorithm, err := crypt_utils.PubKeyAlgorithm([]byte(key))
+	if err != nil {
+		return s.ALGORITHM
+	}
+	return algorithm
 }
 
-func (s *SignatureManagerBase) MakeVerifier(ctx op_context.Context, key string) (crypt_utils.EVerifier, error) {
+func (s *SignatureManagerBase) MakeVerifier(ctx op_context.Context, key string, algorithm ...string) (crypt_utils.EVerifier, error) {
 
 	// setup
 	c := ctx.TraceInMethod("SignatureManagerBase.MakeVerfier")
@@ -148,15 +199,15 @@ func (s *SignatureManagerBase) MakeVerifier(ctx op_context.Context, key string)
 	}
 	defer onExit()
 
-	// TODO support other algorithms
-	if s.ALGORITHM != crypt_utils.RSA_H256_SIGNATURE {
-		err = errors.New("unsupported algorithm")
+	// create verifier
+	alg := s.keyAlgorithm(key, utils.OptionalArg("", algorithm...))
+	c.SetLoggerField("algorithm", alg)
+	verifier, err := crypt_utils.NewVerifier(alg)
+	if err != nil {
+		ctx.SetGenericErrorCode(ErrorCodeInvalidKey)
 		return nil, err
 	}
 
-	// create verifier
-	verifier := crypt_utils.NewRsaVerifier()
-
 	// load public key
 	err = verifier.LoadKey([]byte(key))
 	if err != nil {
@@ -205,8 +256,9 @@ func (s *SignatureManagerBase) Verify(ctx auth.AuthContext, signature string, me
 		return err
 	}
 
-	// make verifier
-	verifier, err := s.MakeVerifier(ctx, userKey.PubKey())
+	// make verifier for algorithm of user key
+	algorithm := s.keyAlgorithm(userKey.PubKey(), userKeyAlgorithm(userKey))
+	verifier, err := s.MakeVerifier(ctx, userKey.PubKey(), algorithm)
 	if err != nil {
 		return err
 	}
@@ -225,16 +277,23 @@ func (s *SignatureManagerBase) Verify(ctx auth.AuthContext, signature string, me
 	obj.Context = ctx.ID()
 	obj.SetUser(ctx.AuthUser())
 	obj.Operation = ctx.Name()
-	obj.Algorithm = s.ALGORITHM
+	obj.Algorithm = algorithm
 	obj.Signature = signature
-	obj.ExtraData = strings.Join(extraData, "+")
+	obj.ExtraData, err = encodeExtraData(extraData)
+	if err != nil {
+		c.SetMessage("failed to encode extra data")
+		ctx.SetGenericErrorCode(generic_error.ErrorCodeInternalServerError)
+		return err
+	}
 	obj.PubKeyHash = userKey.PubKeyHash()
 	if s.ENCRYPT_MESSAGE_STORE {
 		src := []byte(message)
+		obj.Format = MessageFormatEncrypted
 		if s.COMPRESS_BEFORE_ENCRYPT {
 			src = s.Compress(src)
+			obj.Format = MessageFormatZstdEncrypted
 		}
-		ciphertext, err := s.cipher.Encrypt(src)
+		ciphertext, err := s.keyRing.Encrypt(src)
 		if err != nil {
 			c.SetMessage("failed to encrypt message")
 			ctx.SetGenericErrorCode(generic_error.ErrorCodeInternalServerError)
@@ -244,6 +303,7 @@ func (s *SignatureManagerBase) Verify(ctx auth.AuthContext, signature string, me
 		obj.Message = enc.Encode(ciphertext)
 	} else {
 		obj.Message = string(message)
+		obj.Format = MessageFormatPlain
 	}
 	err = op_context.DB(ctx).Create(ctx, obj)
 	if err != nil {
@@ -293,7 +353,35 @@ func (s *SignatureManagerBase) Find(ctx op_context.Context, contextId string) (*
 		return nil, err
 	}
 
-	// TODO decrypt and decompress data
-
+	// message is returned as stored, use ReadMessage() to decrypt and decompress it
 	return obj, nil
 }
+
+func (s *SignatureManagerBase) KeyRing() *crypt_utils.KeyRing {
+	return s.keyRing
+}
+
+// Re-encrypt stored signed messages with active key of key ring.
+// Only records with encrypted format are processed, legacy records without format are left as is.
+func ReencryptMessages(ctx op_context.Context, keyRing *crypt_utils.KeyRing, batchSize ...int) (*key_ring_db.ReencryptStats, error) {
+
+	c := ctx.TraceInMethod("signature.ReencryptMessages", logger.Fields{"active_key": keyRing.ActiveKeyId()})
+	var err error
+	onExit := func() {
+		if err != nil {
+			c.SetError(err)
+		}
+		ctx.TraceOutMethod()
+	}
+	defer onExit()
+
+	filter := db.NewFilter()
+	filter.AddFieldIn("format", MessageFormatEncrypted, MessageFormatZstdEncrypted)
+	stats, err := key_ring_db.ReencryptFilteredObjects(ctx, op_context.DB(ctx), keyRing, filter, "message", func(obj *MessageSignature) *string { return &obj.Message }, batchSize...)
+	if err != nil {
+		c.SetMessage("failed to re-encrypt signed messages")
+		return stats, err
+	}
+
+	return stats, nil
+}
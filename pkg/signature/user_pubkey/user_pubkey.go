@@ -1,8 +1,11 @@
 package user_pubkey
 
 import (
+	"errors"
+
 	"github.com/evgeniums/go-utils/pkg/auth"
 	"github.com/evgeniums/go-utils/pkg/common"
+	"github.com/evgeniums/go-utils/pkg/op_context"
 	"github.com/evgeniums/go-utils/pkg/signature"
 )
 
@@ -13,6 +16,12 @@ type PubkeyI interface {
 	SetPubKeyHash(hash string)
 }
 
+// Public key with recorded signature algorithm.
+type PubkeyWithAlgorithm interface {
+	PubKeyAlgorithm() string
+	SetPubKeyAlgorithm(algorithm string)
+}
+
 type UserPubkeyI interface {
 	common.Object
 	common.WithActive
@@ -27,8 +36,9 @@ type PubkeyData struct {
 
 type PubkeyEssentials struct {
 	PubkeyData
-	PublicKeyHash  string `json:"public_key_hash" gorm:"index;index:,unique,composite:u" display:"Hash"`
-	PublicKeyOwner string `json:"public_key_owner" gorm:"index;index:,unique,composite:u" display:"Owner ID"`
+	PublicKeyHash      string `json:"public_key_hash" gorm:"index;index:,unique,composite:u" display:"Hash"`
+	PublicKeyOwner     string `json:"public_key_owner" gorm:"index;index:,unique,composite:u" display:"Owner ID"`
+	PublicKeyAlgorithm string `json:"public_key_algorithm" gorm:"index" display:"Algorithm"`
 }
 
 type UserPubkey struct {
@@ -53,6 +63,14 @@ func (u *UserPubkey) SetPubKeyHash(hash string) {
 	u.PublicKeyHash = hash
 }
 
+func (u *UserPubkey) PubKeyAlgorithm() string {
+	return u.PublicKeyAlgorithm
+}
+
+func (u *UserPubkey) SetPubKeyAlgorithm(algorithm string) {
+	u.PublicKeyAlgorithm = algorithm
+}
+
 func (u *UserPubkey) PubKeyOwner() string {
 	return u.PublicKeyOwner
 }
@@ -74,3 +92,18 @@ func FindUserPubKey[T UserPubkeyI](ctrl PubkeyController[T], ctx auth.AuthContex
 
 	return pubKey, nil
 }
+
+func FindUserPubKeyByHash[T UserPubkeyI](ctrl PubkeyController[T], ctx op_context.Context, userId string, keyHash string) (signature.UserWithPubkey, error) {
+
+	hashCtrl, ok := ctrl.(PubkeyControllerWithHash[T])
+	if !ok {
+		return nil, errors.New("public key controller does not support search by hash")
+	}
+
+	pubKey, err := hashCtrl.FindPubKeyByHash(ctx, userId, keyHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return pubKey, nil
+}
@@ -35,6 +35,12 @@ type PubkeyController[T UserPubkeyI] interface {
 	FindActivePubKey(ctx op_context.Context, userId string, idIsLogin ...bool) (T, error)
 }
 
+// Public key controller that can find keys by hash.
+type PubkeyControllerWithHash[T UserPubkeyI] interface {
+	PubkeyController[T]
+	FindPubKeyByHash(ctx op_context.Context, userId string, keyHash string) (T, error)
+}
+
 type PubkeyControllerBase[T UserPubkeyI, U user.User] struct {
 	crud             crud.CRUD
 	objectBuilder    func() T
@@ -73,8 +79,15 @@ func (p *PubkeyControllerBase[T, U]) AddPubKey(ctx op_context.Context, userId st
 	}
 	defer onExit()
 
-	// check key
-	err = p.signatureManager.CheckPubKey(ctx, key)
+	// check key and detect its algorithm
+	algorithm := ""
+	manager, ok := p.signatureManager.(signature.SignatureManagerWithPubKeyAlgorithm)
+	if ok {
+		algorithm, err = manager.PubKeyAlgorithm(ctx, key)
+		c.SetLoggerField("key_algorithm", algorithm)
+	} else {
+		err = p.signatureManager.CheckPubKey(ctx, key)
+	}
 	if err != nil {
 		c.SetMessage("invalid key format")
 		return "", err
@@ -121,6 +134,10 @@ func (p *PubkeyControllerBase[T, U]) AddPubKey(ctx op_context.Context, userId st
 		doc.SetActive(true)
 		doc.SetPubKey(key)
 		doc.SetPubKeyHash(hash)
+		withAlgorithm, ok := any(doc).(PubkeyWithAlgorithm)
+		if ok {
+			withAlgorithm.SetPubKeyAlgorithm(algorithm)
+		}
 		doc.SetPubKeyOwner(user.GetID())
 		err = p.crud.Create(ctx, doc)
 		if err != nil {
@@ -237,6 +254,37 @@ func (p *PubkeyControllerBase[T, U]) FindActivePubKey(ctx op_context.Context, us
 	return doc, nil
 }
 
+// Find public key of user by hash, the key can be inactive.
+func (p *PubkeyControllerBase[T, U]) FindPubKeyByHash(ctx op_context.Context, userId string, keyHash string) (T, error) {
+
+	// setup
+	c := ctx.TraceInMethod("PubkeyController.FindPubKeyByHash", logger.Fields{"key_hash": keyHash})
+	var err error
+	onExit := func() {
+		if err != nil {
+			c.SetError(err)
+		}
+		ctx.TraceOutMethod()
+	}
+	defer onExit()
+
+	// find key
+	doc := p.objectBuilder()
+	fields := db.Fields{"public_key_owner": userId, "public_key_hash": keyHash}
+	found, err := p.crud.Read(ctx, fields, doc)
+	if err != nil {
+		c.SetMessage("failed to find public key")
+		return *new(T), err
+	}
+	if !found {
+		err = errors.New("key not found")
+		return *new(T), err
+	}
+
+	// done
+	return doc, nil
+}
+
 func (p *PubkeyControllerBase[T, U]) ListPubKeys(ctx op_context.Context, filter *db.Filter) ([]T, int64, error) {
 
 	// setup
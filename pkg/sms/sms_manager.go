@@ -11,6 +11,7 @@ import (
 	"github.com/evgeniums/go-utils/pkg/config"
 	"github.com/evgeniums/go-utils/pkg/config/object_config"
 	"github.com/evgeniums/go-utils/pkg/crypt_utils"
+	"github.com/evgeniums/go-utils/pkg/crypt_utils/key_ring_db"
 	"github.com/evgeniums/go-utils/pkg/db"
 	"github.com/evgeniums/go-utils/pkg/generic_error"
 	"github.com/evgeniums/go-utils/pkg/logger"
@@ -91,7 +92,7 @@ func (s *SmsDestination) Init(cfg config.Config, log logger.Logger, vld validato
 type SmsManagerBase struct {
 	SmsManagerBaseConfig
 	destinations    []*SmsDestination
-	cipher          *crypt_utils.AEAD
+	keyRing         *crypt_utils.KeyRing
 	defaultProvider Provider
 
 	db db.DB
@@ -114,17 +115,17 @@ func (s *SmsManagerBase) Init(cfg config.Config, log logger.Logger, vld validato
 		return log.PushFatalStack("failed to init SMS manager", err)
 	}
 
-	// init cipher
+	// init key ring
 	if s.ENCRYPT_MESSAGE_STORE {
-		if s.SECRET == "" {
-			return log.PushFatalStack("encryption secret must not be empty", nil)
-		}
-		if s.SALT == "" {
+		if s.SECRET != "" && s.SALT == "" {
 			return log.PushFatalStack("encryption salt must not be empty", nil)
 		}
-		s.cipher, err = crypt_utils.NewAEAD(s.SECRET, []byte(s.SALT))
+		s.keyRing, err = crypt_utils.LoadKeyRing(cfg, log, vld, path, s.SECRET, s.SALT)
 		if err != nil {
-			return log.PushFatalStack("failed to init cipher for SMS manager", err)
+			return log.PushFatalStack("failed to init key ring for SMS manager", err)
+		}
+		if s.keyRing.IsEmpty() {
+			return log.PushFatalStack("encryption secret must not be empty", nil)
 		}
 	}
 
@@ -243,7 +244,7 @@ func (s *SmsManagerBase) Send(ctx auth.UserContext, message string, recipient st
 	sms.Status = StatusSending
 	c.LoggerFields()["sms_id"] = sms.GetID()
 	if s.ENCRYPT_MESSAGE_STORE {
-		ciphertext, err := s.cipher.Encrypt([]byte(message))
+		ciphertext, err := s.keyRing.Encrypt([]byte(message))
 		if err != nil {
 			c.SetMessage("failed to encrypt message")
 			ctx.SetGenericErrorCode(generic_error.ErrorCodeInternalServerError)
@@ -316,3 +317,29 @@ func (s *SmsManagerBase) FindSms(ctx op_context.Context, smsId string) (*SmsMess
 
 	return msg, nil
 }
+
+func (s *SmsManagerBase) KeyRing() *crypt_utils.KeyRing {
+	return s.keyRing
+}
+
+// Re-encrypt stored SMS messages with active key of key ring.
+func ReencryptMessages(ctx op_context.Context, keyRing *crypt_utils.KeyRing, batchSize ...int) (*key_ring_db.ReencryptStats, error) {
+
+	c := ctx.TraceInMethod("sms.ReencryptMessages", logger.Fields{"active_key": keyRing.ActiveKeyId()})
+	var err error
+	onExit := func() {
+		if err != nil {
+			c.SetError(err)
+		}
+		ctx.TraceOutMethod()
+	}
+	defer onExit()
+
+	stats, err := key_ring_db.ReencryptObjects(ctx, op_context.DB(ctx), keyRing, "message", func(obj *SmsMessage) *string { return &obj.Message }, batchSize...)
+	if err != nil {
+		c.SetMessage("failed to re-encrypt SMS messages")
+		return stats, err
+	}
+
+	return stats, nil
+}
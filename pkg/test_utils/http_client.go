@@ -224,7 +224,7 @@ func (c *HttpClient) Post(path string, cmd interface{}, headers ...map[string]st
 	return c.RequestBody(http.MethodPost, path, cmd, headers...)
 }
 
-func (c *HttpClient) PostSigned(t *testing.T, signer *crypt_utils.RsaSigner, path string, cmd interface{}, headers ...map[string]string) *HttpResponse {
+func (c *HttpClient) PostSigned(t *testing.T, signer crypt_utils.ESigner, path string, cmd interface{}, headers ...map[string]string) *HttpResponse {
 
 	content, err := json.Marshal(cmd)
 	require.NoError(t, err)
@@ -238,6 +238,20 @@ func (c *HttpClient) PostSigned(t *testing.T, signer *crypt_utils.RsaSigner, pat
 	return c.RequestBody(http.MethodPost, path, cmd, h)
 }
 
+func (c *HttpClient) PostSignedWithNonce(t *testing.T, signer crypt_utils.ESigner, path string, timestamp string, nonce string, cmd interface{}, headers ...map[string]string) *HttpResponse {
+
+	content, err := json.Marshal(cmd)
+	require.NoError(t, err)
+	sig, err := signer.SignB64(content, http.MethodPost, path, timestamp, nonce)
+	h := map[string]string{"x-auth-signature": sig, "x-auth-timestamp": timestamp, "x-auth-nonce": nonce}
+	require.NoError(t, err)
+	if len(headers) > 0 {
+		utils.AppendMap(h, headers[0])
+	}
+
+	return c.RequestBody(http.MethodPost, path, cmd, h)
+}
+
 func (c *HttpClient) Put(t *testing.T, path string, cmd interface{}, headers ...map[string]string) *HttpResponse {
 	return c.RequestBody(http.MethodPatch, path, cmd, headers...)
 }
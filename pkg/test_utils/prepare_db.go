@@ -12,10 +12,7 @@ import (
 	"github.com/evgeniums/go-utils/pkg/db/db_gorm"
 	"github.com/evgeniums/go-utils/pkg/logger"
 	"github.com/evgeniums/go-utils/pkg/utils"
-	"github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/require"
-	"gorm.io/driver/postgres"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
@@ -43,15 +40,7 @@ func SqlitePath(config *db.DBConfig) string {
 }
 
 func DbGormOpener(provider string, dsn string) (gorm.Dialector, error) {
-
-	switch provider {
-	case "postgres":
-		return postgres.Open(dsn), nil
-	case "sqlite":
-		return sqlite.Open(dsn), nil
-	}
-
-	return nil, errors.New("unknown database provider")
+	return db_gorm.SqlDbConnector().DialectorOpener(provider, dsn)
 }
 
 func DbDsnBuilder(t *testing.T, config *db.DBConfig) (string, error) {
@@ -69,56 +58,24 @@ func DbDsnBuilder(t *testing.T, config *db.DBConfig) (string, error) {
 }
 
 func DbCreator(provider string, db *gorm.DB, dbName string) error {
-
-	switch provider {
-	case "postgres":
-		return db_gorm.PostgresDbCreator(provider, db, dbName)
-	case "sqlite":
-		return nil
-	}
-
-	return errors.New("unknown database provider")
+	return db_gorm.SqlDbConnector().DbCreator(provider, db, dbName)
 }
 
 func CheckDuplicateKeyError(provider string, result *gorm.DB) (bool, error) {
-
-	switch provider {
-	case "postgres":
-		return db_gorm.PostgresCheckDuplicateKeyError(provider, result)
-	case "sqlite":
-		if err, ok := result.Error.(sqlite3.Error); ok {
-			if err.ExtendedCode == sqlite3.ErrConstraintUnique {
-				return true, errors.New("record already exists")
-			}
-		}
-	}
-
-	return false, result.Error
+	return db_gorm.SqlDbConnector().CheckDuplicateKeyError(provider, result)
 }
 
 func PartitionedMonthMigrator(provider string, ctx logger.WithLogger, db *gorm.DB, models ...interface{}) error {
-
-	switch provider {
-	case "postgres":
-		return db_gorm.PostgresPartitionedMonthAutoMigrate(ctx, db, models...)
-	case "sqlite":
-		return db.AutoMigrate(models...)
-	}
-
-	return errors.New("unknown database provider")
+	return db_gorm.SqlDbConnector().PartitionedMonthMigrator(provider, ctx, db, models...)
 }
 
 func SetupGormDB(t *testing.T) {
 	db_gorm.NewModelStore(true)
 	db_gorm.DefaultDbConnector = func() *db_gorm.DbConnector {
-		c := &db_gorm.DbConnector{}
-		c.DialectorOpener = DbGormOpener
+		c := db_gorm.SqlDbConnector()
 		c.DsnBuilder = func(config *db.DBConfig) (string, error) {
 			return DbDsnBuilder(t, config)
 		}
-		c.DbCreator = DbCreator
-		c.PartitionedMonthMigrator = PartitionedMonthMigrator
-		c.CheckDuplicateKeyError = CheckDuplicateKeyError
 		return c
 	}
 }
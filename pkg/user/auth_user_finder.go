@@ -1,8 +1,15 @@
 package user
 
 import (
+	"errors"
+	"strings"
+
 	"github.com/evgeniums/go-utils/pkg/auth"
+	"github.com/evgeniums/go-utils/pkg/auth/auth_methods/auth_login_phash"
+	"github.com/evgeniums/go-utils/pkg/auth/auth_methods/auth_totp"
+	"github.com/evgeniums/go-utils/pkg/auth/auth_session"
 	"github.com/evgeniums/go-utils/pkg/crud"
+	"github.com/evgeniums/go-utils/pkg/db"
 	"github.com/evgeniums/go-utils/pkg/op_context"
 )
 
@@ -23,6 +30,38 @@ func (a *AuthUserFinderBase) FindAuthUser(ctx op_context.Context, login string)
 	return user, nil
 }
 
+func (a *AuthUserFinderBase) UpdatePasswordHash(ctx op_context.Context, authUser auth.User) error {
+	user, ok := authUser.(User)
+	if !ok {
+		return errors.New("invalid user type")
+	}
+	return a.CRUD().Update(ctx, user, db.Fields{"password_hash": user.PasswordHash()})
+}
+
+func (a *AuthUserFinderBase) UpdateTotp(ctx op_context.Context, authUser auth.User) error {
+	user, ok := authUser.(User)
+	if !ok {
+		return errors.New("invalid user type")
+	}
+	return a.CRUD().Update(ctx, user, db.Fields{"totp_secret": user.TotpSecret(), "totp_recovery_codes": strings.Join(user.TotpRecoveryCodes(), ",")})
+}
+
+func UpdatePasswordHash(finder auth_session.AuthUserFinder, ctx op_context.Context, authUser auth.User) error {
+	updater, ok := finder.(auth_login_phash.PasswordHashUpdater)
+	if !ok {
+		return errors.New("auth user finder does not support password hash update")
+	}
+	return updater.UpdatePasswordHash(ctx, authUser)
+}
+
+func UpdateTotp(finder auth_session.AuthUserFinder, ctx op_context.Context, authUser auth.User) error {
+	updater, ok := finder.(auth_totp.TotpUpdater)
+	if !ok {
+		return errors.New("auth user finder does not support TOTP update")
+	}
+	return updater.UpdateTotp(ctx, authUser)
+}
+
 func NewAuthUserFinder(userBuilder func() User, cruds ...crud.CRUD) *AuthUserFinderBase {
 	a := &AuthUserFinderBase{userBuilder: userBuilder}
 	a.Construct(cruds...)
@@ -5,6 +5,7 @@ import (
 	"github.com/evgeniums/go-utils/pkg/auth"
 	"github.com/evgeniums/go-utils/pkg/auth/auth_methods/auth_login_phash"
 	"github.com/evgeniums/go-utils/pkg/auth/auth_methods/auth_sms"
+	"github.com/evgeniums/go-utils/pkg/auth/auth_methods/auth_totp"
 	"github.com/evgeniums/go-utils/pkg/common"
 	"github.com/evgeniums/go-utils/pkg/crud"
 	"github.com/evgeniums/go-utils/pkg/db"
@@ -15,6 +16,7 @@ type User interface {
 	common.Object
 	auth.User
 	auth_login_phash.User
+	auth_totp.User
 	auth_sms.UserWithPhone
 
 	SetLogin(login string)
@@ -128,6 +130,7 @@ type UserBaseDB struct {
 	common.ObjectBase
 	UserBaseFields
 	auth_login_phash.UserBase
+	auth_totp.UserTotpBase
 	api.ResponseBase
 }
 
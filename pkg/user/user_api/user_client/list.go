@@ -54,6 +54,9 @@ func (u *UserClient[U]) FindUsers(ctx op_context.Context, filter *db.Filter) ([]
 		return nil, 0, err
 	}
 
+	// keep keyset cursors
+	handler.result.UpdateFilter(filter)
+
 	// return result
 	return handler.result.Items, handler.result.Count, nil
 }
@@ -3,6 +3,7 @@ package user_service
 import (
 	"github.com/evgeniums/go-utils/pkg/api"
 	"github.com/evgeniums/go-utils/pkg/api/api_server"
+	"github.com/evgeniums/go-utils/pkg/db"
 	"github.com/evgeniums/go-utils/pkg/user"
 	"github.com/evgeniums/go-utils/pkg/user/user_api"
 )
@@ -10,6 +11,17 @@ import (
 type ListEndpoint[U user.User] struct {
 	api_server.EndpointBase
 	UserEndpoint[U]
+	search *db.FilterValidator
+}
+
+// Users can be searched by logins, emails and phones.
+func searchUsers() *db.FilterValidator {
+	vld := &db.FilterValidator{}
+	vld.AllowSearch("login", db.SEARCH_PREFIX, "max=64")
+	vld.AllowSearch("login", db.SEARCH_LIKE, "max=64")
+	vld.AllowSearch("email", db.SEARCH_LIKE, "max=128")
+	vld.AllowSearch("phone", db.SEARCH_PREFIX, "max=32")
+	return vld
 }
 
 func (e *ListEndpoint[U]) HandleRequest(request api_server.Request) error {
@@ -20,7 +32,7 @@ func (e *ListEndpoint[U]) HandleRequest(request api_server.Request) error {
 	u := Users(e.service, request)
 
 	queryName := request.Endpoint().Resource().ServicePathPrototype()
-	filter, err := api_server.ParseDbQuery(request, u.MakeUser(), queryName)
+	filter, err := api_server.ParseDbQueryWithValidator(request, u.MakeUser(), queryName, e.search)
 	if err != nil {
 		return c.SetError(err)
 	}
@@ -31,6 +43,7 @@ func (e *ListEndpoint[U]) HandleRequest(request api_server.Request) error {
 		return c.SetError(err)
 	}
 
+	resp.SetCursors(filter)
 	api_server.SetResponseList(request, resp, e.service.UserTypeName)
 	return nil
 }
@@ -38,6 +51,7 @@ func (e *ListEndpoint[U]) HandleRequest(request api_server.Request) error {
 func List[U user.User](service *UserService[U]) *ListEndpoint[U] {
 	e := &ListEndpoint[U]{}
 	e.service = service
+	e.search = searchUsers()
 	e.Construct(user_api.List())
 	return e
 }
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/evgeniums/go-utils/pkg/auth"
+	"github.com/evgeniums/go-utils/pkg/auth/auth_methods/auth_login_phash"
 	"github.com/evgeniums/go-utils/pkg/auth/auth_session"
 	"github.com/evgeniums/go-utils/pkg/crud"
 	"github.com/evgeniums/go-utils/pkg/db"
@@ -62,6 +64,7 @@ type UserControllerBase[UserType User] struct {
 	oplogBuilder   func() OpLogUserI
 	crudController crud.CRUD
 	userValidators auth_session.UserValidators
+	hashers        *auth_login_phash.PasswordHashers
 }
 
 func LocalUserController[UserType User]() *UserControllerBase[UserType] {
@@ -80,6 +83,17 @@ func (u *UserControllerBase[UserType]) SetUserValidators(validators auth_session
 	u.userValidators = validators
 }
 
+func (u *UserControllerBase[UserType]) SetPasswordHashers(hashers *auth_login_phash.PasswordHashers) {
+	u.hashers = hashers
+}
+
+func (u *UserControllerBase[UserType]) setPassword(user UserType, password string) error {
+	if u.hashers == nil {
+		return user.SetPassword(password)
+	}
+	return user.SetPassword(password, u.hashers)
+}
+
 func (u *UserControllerBase[UserType]) CRUD() crud.CRUD {
 	return u.crudController
 }
@@ -135,7 +149,11 @@ func (u *UserControllerBase[UserType]) Add(ctx op_context.Context, login string,
 	user := u.MakeUser()
 	user.InitObject()
 	user.SetLogin(login)
-	user.SetPassword(password)
+	err = u.setPassword(user, password)
+	if err != nil {
+		c.SetMessage("failed to set password")
+		return *new(UserType), err
+	}
 	for _, setter := range extraFieldsSetters {
 		checkDuplicateFields, err1 := setter(ctx, user)
 		err = err1
@@ -280,7 +298,11 @@ func (u *UserControllerBase[UserType]) SetPassword(ctx op_context.Context, id st
 	}
 
 	// set password
-	user.SetPassword(password)
+	err = u.setPassword(user, password)
+	if err != nil {
+		c.SetMessage("failed to set password")
+		return err
+	}
 	err = u.crudController.Update(ctx, user, db.Fields{"password_hash": user.PasswordHash(), "password_salt": user.PasswordSalt()})
 	if err != nil {
 		return err
@@ -442,6 +464,21 @@ func (m *UsersBase[UserType]) AuthUserManager() auth_session.AuthUserManager {
 	return m
 }
 
+func (u *UsersBase[UserType]) UpdatePasswordHash(ctx op_context.Context, user auth.User) error {
+	return UpdatePasswordHash(u.AuthUserFinder, ctx, user)
+}
+
+func (u *UsersBase[UserType]) UpdateTotp(ctx op_context.Context, user auth.User) error {
+	return UpdateTotp(u.AuthUserFinder, ctx, user)
+}
+
+func (u *UsersBase[UserType]) SetPasswordHashers(hashers *auth_login_phash.PasswordHashers) {
+	withHashers, ok := u.UserController.(auth_login_phash.WithPasswordHashers)
+	if ok {
+		withHashers.SetPasswordHashers(hashers)
+	}
+}
+
 func (u *UsersBase[UserType]) SetAuthUserFinder(authUserFinder auth_session.AuthUserFinder) {
 	u.AuthUserFinder = authUserFinder
 }
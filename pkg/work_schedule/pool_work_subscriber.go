@@ -56,13 +56,17 @@ func NewPoolSubscriber[T Work](tenancies multitenancy.Multitenancy, controller *
 	return p
 }
 
-func (p *PoolWorkSubscriber[T]) Init(ctx op_context.Context, pubsub pool_pubsub.PoolPubsub, topicName string) error {
+func (p *PoolWorkSubscriber[T]) Init(ctx op_context.Context, pubsub pool_pubsub.PoolPubsub, topicName string, configPath ...string) error {
 
 	c := ctx.TraceInMethod("PoolWorkSubscriber.Init")
 	defer ctx.TraceOutMethod()
 
 	p.topic.TopicBase = pubsub_subscriber.New(topicName, MakePubsubWork[T])
-	_, err := pubsub.SubscribeSelfPool(ctx, p.topic)
+	err := p.topic.LoadConfig(ctx.App().Cfg(), ctx.App().Logger(), ctx.App().Validator(), configPath...)
+	if err != nil {
+		return c.SetError(err)
+	}
+	_, err = pubsub.SubscribeSelfPool(ctx, p.topic)
 	if err != nil {
 		c.SetError(err)
 		return ctx.Logger().PushFatalStack("failed to subscribe to pubsub notifications in self pool", err)
@@ -2,17 +2,22 @@ package work_schedule
 
 import (
 	"errors"
+	"math"
+	"math/rand"
+	"net/http"
 	"sync/atomic"
 	"time"
 
 	"github.com/evgeniums/go-utils/pkg/app_context"
 	"github.com/evgeniums/go-utils/pkg/background_worker"
 	"github.com/evgeniums/go-utils/pkg/cache"
-	"github.com/evgeniums/go-utils/pkg/cache/redis_cache"
+	"github.com/evgeniums/go-utils/pkg/cache/cache_locker"
+	"github.com/evgeniums/go-utils/pkg/cache/db_locker"
 	"github.com/evgeniums/go-utils/pkg/common"
 	"github.com/evgeniums/go-utils/pkg/config/object_config"
 	"github.com/evgeniums/go-utils/pkg/crud"
 	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/logger"
 	"github.com/evgeniums/go-utils/pkg/multitenancy"
 	"github.com/evgeniums/go-utils/pkg/multitenancy/app_with_multitenancy"
 	"github.com/evgeniums/go-utils/pkg/op_context"
@@ -20,6 +25,16 @@ import (
 	"github.com/evgeniums/go-utils/pkg/utils"
 )
 
+const ErrorCodeDeadWorkNotFound = "dead_work_not_found"
+
+var ErrorDescriptions = map[string]string{
+	ErrorCodeDeadWorkNotFound: "Dead work not found",
+}
+
+var ErrorHttpCodes = map[string]int{
+	ErrorCodeDeadWorkNotFound: http.StatusNotFound,
+}
+
 type PostMode int
 
 const (
@@ -61,6 +76,15 @@ type Work interface {
 
 	SetNoDb(enable bool)
 	IsNoDb() bool
+
+	GetAttempts() int
+	SetAttempts(int)
+
+	GetLastError() string
+	SetLastError(string)
+
+	IsDead() bool
+	SetDead(bool)
 }
 
 type WorkBuilder[T Work] func() T
@@ -73,6 +97,10 @@ type WorkScheduler[T Work] interface {
 	ReleaseWork(ctx op_context.Context, work T) error
 	PostWork(ctx op_context.Context, work T, postMode PostMode, tenancy ...multitenancy.Tenancy) error
 	RemoveWork(ctx op_context.Context, referenceId string, referenceType string) error
+
+	ListDeadWorks(ctx op_context.Context, filter *db.Filter) ([]T, int64, error)
+	RetryDeadWork(ctx op_context.Context, workId string) error
+	PurgeDeadWorks(ctx op_context.Context, workIds ...string) error
 }
 
 type WorkSchedulerBase[T Work] struct {
@@ -107,6 +135,18 @@ func (s *WorkSchedulerBase[T]) RemoveWork(ctx op_context.Context, referenceId st
 	return nil
 }
 
+func (s *WorkSchedulerBase[T]) ListDeadWorks(ctx op_context.Context, filter *db.Filter) ([]T, int64, error) {
+	return nil, 0, nil
+}
+
+func (s *WorkSchedulerBase[T]) RetryDeadWork(ctx op_context.Context, workId string) error {
+	return nil
+}
+
+func (s *WorkSchedulerBase[T]) PurgeDeadWorks(ctx op_context.Context, workIds ...string) error {
+	return nil
+}
+
 type WorkRunner[T Work] interface {
 	Run(ctx op_context.Context, work T) (bool, error)
 }
@@ -117,6 +157,9 @@ type WorkBase struct {
 	ReferenceType string    `json:"reference_type" gorm:"index;index:,unique,composite:ref"`
 	NextTime      time.Time `json:"next_time" gorm:"index"`
 	NextTimeSet   bool      `json:"next_time_set" gorm:"index;default:false"`
+	Attempts      int       `json:"attempts" gorm:"default:0"`
+	LastError     string    `json:"last_error"`
+	Dead          bool      `json:"dead" gorm:"index;default:false"`
 
 	lock  cache.Lock `json:"-" gorm:"-:all"`
 	delay int        `json:"-" gorm:"-:all"`
@@ -176,6 +219,30 @@ func (w *WorkBase) IsNoDb() bool {
 	return w.noDb
 }
 
+func (w *WorkBase) GetAttempts() int {
+	return w.Attempts
+}
+
+func (w *WorkBase) SetAttempts(attempts int) {
+	w.Attempts = attempts
+}
+
+func (w *WorkBase) GetLastError() string {
+	return w.LastError
+}
+
+func (w *WorkBase) SetLastError(lastError string) {
+	w.LastError = lastError
+}
+
+func (w *WorkBase) IsDead() bool {
+	return w.Dead
+}
+
+func (w *WorkBase) SetDead(dead bool) {
+	w.Dead = dead
+}
+
 type WorkInTenancyBase struct {
 	WorkBase
 	TenancyId string `json:"tenancy_id" gorm:"index"`
@@ -189,6 +256,11 @@ func (w *WorkInTenancyBase) GetTenancyId() string {
 	return w.TenancyId
 }
 
+// Models that must be migrated in database of work schedule besides models of works, i.e. lock table used by database locker.
+func DbModels() []interface{} {
+	return db_locker.DbModels()
+}
+
 type WorkScheduleConfig struct {
 	PARALLEL_JOBS               int `default:"8"`
 	BUCKET_SIZE                 int `default:"32"`
@@ -197,6 +269,15 @@ type WorkScheduleConfig struct {
 	LOCK_TTL_SECONDS            int `default:"300"`
 	PERIOD                      int `default:"5"`
 	LOG_EMPTY_WORKS             bool
+	LOCKER                      string `default:"redis" validate:"oneof=redis inmem db"`
+
+	MAX_ATTEMPTS            int     `default:"10" validate:"gte=0"`
+	BACKOFF_INITIAL_SECONDS int     `default:"10" validate:"gte=0"`
+	BACKOFF_MAX_SECONDS     int     `default:"3600" validate:"gte=0"`
+	BACKOFF_MULTIPLIER      float64 `default:"2" validate:"gte=1"`
+	BACKOFF_JITTER          float64 `default:"0.2" validate:"gte=0,lte=1"`
+
+	PROCESS_MAIN_DB bool `default:"true"`
 }
 
 type workItem[T Work] struct {
@@ -226,12 +307,17 @@ type WorkSchedule[T Work] struct {
 
 	locker cache.Locker
 	db     db.DB
+
+	tenancies    multitenancy.Multitenancy
+	sourceOffset atomic.Uint32
 }
 
 type Config[T Work] struct {
 	WorkBuilder WorkBuilder[T]
 	WorkRunner  WorkRunner[T]
 	WorkInvoker WorkInvoker[T]
+	Locker      cache.Locker
+	Tenancies   multitenancy.Multitenancy
 }
 
 func NewWorkSchedule[T Work](name string, config Config[T], cruds ...crud.CRUD) *WorkSchedule[T] {
@@ -246,6 +332,8 @@ func NewWorkSchedule[T Work](name string, config Config[T], cruds ...crud.CRUD)
 	if s.invoker == nil {
 		s.invoker = s.InvokeWork
 	}
+	s.locker = config.Locker
+	s.tenancies = config.Tenancies
 
 	return s
 }
@@ -263,13 +351,21 @@ func (s *WorkSchedule[T]) Init(app app_context.Context, configPath ...string) er
 		return app.Logger().PushFatalStack("failed to load configuration of WorkSchedule", err)
 	}
 
-	// init locker
-	redisCache := redis_cache.NewCache()
-	err = redisCache.Init(app.Cfg(), app.Logger(), app.Validator(), redis_cache.RedisCacheConfigPath)
-	if err != nil {
-		return app.Logger().PushFatalStack("failed to init redis cache for WorkSchedule", err)
+	// use tenancies of application if they were not set explicitly
+	if s.tenancies == nil {
+		a, ok := app.(app_with_multitenancy.AppWithMultitenancy)
+		if ok {
+			s.tenancies = a.Multitenancy()
+		}
+	}
+
+	// init locker if it was not injected
+	if s.locker == nil {
+		s.locker, err = cache_locker.New(app, s.LOCKER, s.db)
+		if err != nil {
+			return app.Logger().PushFatalStack("failed to init locker for WorkSchedule", err)
+		}
 	}
-	s.locker = redis_cache.NewLocker(redisCache)
 
 	// run workers
 	for i := 0; i < s.PARALLEL_JOBS; i++ {
@@ -292,6 +388,20 @@ func (s *WorkSchedule[T]) SetRunner(runner WorkRunner[T]) {
 	s.workRunner = runner
 }
 
+// Set locker of works. Must be called before Init, otherwise locker is created according to configuration.
+func (s *WorkSchedule[T]) SetLocker(locker cache.Locker) {
+	s.locker = locker
+}
+
+func (s *WorkSchedule[T]) Locker() cache.Locker {
+	return s.locker
+}
+
+// Set tenancies whose databases are polled for works. Must be called before Init, otherwise tenancies of application are used.
+func (s *WorkSchedule[T]) SetMultitenancy(tenancies multitenancy.Multitenancy) {
+	s.tenancies = tenancies
+}
+
 func (s *WorkSchedule[T]) AcquireWork(ctx op_context.Context, work T) error {
 
 	// setup
@@ -300,7 +410,12 @@ func (s *WorkSchedule[T]) AcquireWork(ctx op_context.Context, work T) error {
 	defer ctx.TraceOutMethod()
 
 	// lock work in cache
-	lock, err := cache.LockObject(s.locker, "work_lock", work.GetReferenceId(), s.LOCK_TTL_SECONDS)
+	lockPrefix := "work_lock"
+	tenancyCtx, ok := ctx.(multitenancy.TenancyContext)
+	if ok && tenancyCtx.GetTenancy() != nil {
+		lockPrefix = utils.ConcatStrings(lockPrefix, "_", tenancyCtx.GetTenancy().GetID())
+	}
+	lock, err := cache.LockObject(s.locker, lockPrefix, work.GetReferenceId(), s.LOCK_TTL_SECONDS)
 	if err != nil {
 		c.SetLoggerField("work_reference_id", work.GetReferenceId())
 		c.SetMessage("failed to lock work")
@@ -353,6 +468,24 @@ func (s *WorkSchedule[T]) SetNextWorkTime(work T, reset ...bool) {
 	}
 }
 
+// Calculate delay before next attempt of failed work using exponential backoff with jitter.
+func (s *WorkSchedule[T]) BackoffDelay(attempts int) time.Duration {
+
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	delay := float64(s.BACKOFF_INITIAL_SECONDS) * math.Pow(s.BACKOFF_MULTIPLIER, float64(attempts-1))
+	if s.BACKOFF_MAX_SECONDS > 0 && delay > float64(s.BACKOFF_MAX_SECONDS) {
+		delay = float64(s.BACKOFF_MAX_SECONDS)
+	}
+	if s.BACKOFF_JITTER > 0 {
+		delay += delay * s.BACKOFF_JITTER * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(delay * float64(time.Second))
+}
+
 func (s *WorkSchedule[T]) PostWork(ctx op_context.Context, work T, postMode PostMode, tenancy ...multitenancy.Tenancy) error {
 
 	// setup
@@ -439,6 +572,50 @@ func (s *WorkSchedule[T]) SetOverrideDb(db db.DB) {
 	s.db = db
 }
 
+// Source of works, either main database or database of tenancy.
+type workSource struct {
+	ctx     op_context.Context
+	tenancy multitenancy.Tenancy
+	empty   bool
+}
+
+func (s *WorkSchedule[T]) workSources() []*workSource {
+
+	sources := make([]*workSource, 0)
+
+	if s.PROCESS_MAIN_DB || !multitenancy.IsMultiTenancy(s.tenancies) {
+		ctx := default_op_context.BackgroundOpContext(s.App(), s.name)
+		if s.db != nil {
+			ctx.SetOverrideDb(s.db)
+		}
+		ctx.SetWriteCloseLog(s.LOG_EMPTY_WORKS)
+		sources = append(sources, &workSource{ctx: ctx})
+	}
+
+	if multitenancy.IsMultiTenancy(s.tenancies) {
+		for _, tenancy := range s.tenancies.Tenancies() {
+			if !tenancy.IsActive() {
+				continue
+			}
+			baseCtx := default_op_context.BackgroundOpContext(s.App(), s.name)
+			baseCtx.SetWriteCloseLog(s.LOG_EMPTY_WORKS)
+			ctx := multitenancy.NewContext(baseCtx)
+			ctx.SetTenancy(tenancy)
+			sources = append(sources, &workSource{ctx: ctx, tenancy: tenancy})
+		}
+	}
+
+	// rotate sources so that each source gets its turn to be polled first
+	if len(sources) > 1 {
+		offset := int(s.sourceOffset.Add(1) % uint32(len(sources)))
+		rotated := make([]*workSource, 0, len(sources))
+		rotated = append(rotated, sources[offset:]...)
+		sources = append(rotated, sources[:offset]...)
+	}
+
+	return sources
+}
+
 func (s *WorkSchedule[T]) ProcessWorks() {
 
 	if !s.running.CompareAndSwap(false, true) {
@@ -446,15 +623,12 @@ func (s *WorkSchedule[T]) ProcessWorks() {
 	}
 	defer s.running.Store(false)
 
-	// TODO support multitenancy
-
-	ctx := default_op_context.BackgroundOpContext(s.App(), s.name)
-	if s.db != nil {
-		ctx.SetOverrideDb(s.db)
-	}
-	ctx.SetWriteCloseLog(s.LOG_EMPTY_WORKS)
-	defer ctx.Close()
-	c := ctx.TraceInMethod("WorkSchedule.ProcessWorks")
+	sources := s.workSources()
+	defer func() {
+		for _, source := range sources {
+			source.ctx.Close()
+		}
+	}()
 
 	// process works
 	for {
@@ -464,82 +638,126 @@ func (s *WorkSchedule[T]) ProcessWorks() {
 			break
 		}
 
-		// prepare filter
-		filter := db.NewFilter()
-		filter.SetSorting("next_time", db.SORT_ASC)
-		filter.AddInterval("next_time", nil, time.Now())
-
 		// check number of works currently pending or being processed
-		filter.Limit = s.BUCKET_SIZE - int(s.runningWorkCount.Load()) - int(s.workQueueSize.Load())
-		if filter.Limit <= 0 {
-			c.Logger().Info("all bucket size is used, skipping")
+		available := s.BUCKET_SIZE - int(s.runningWorkCount.Load()) - int(s.workQueueSize.Load())
+		if available <= 0 {
+			if len(sources) != 0 {
+				sources[0].ctx.Logger().Info("all bucket size is used, skipping")
+			}
 			break
 		}
 
-		// read works from database
-		var works []T
-		handler := func() error {
+		// split available bucket size between sources that still have works
+		pending := 0
+		for _, source := range sources {
+			if !source.empty {
+				pending++
+			}
+		}
+		if pending == 0 {
+			break
+		}
+		share := available / pending
+		if share == 0 {
+			share = 1
+		}
 
-			var works1 []T
-			_, err := s.CRUD().List(ctx, filter, &works1)
-			if err != nil {
-				c.SetMessage("failed to read works from database 1")
-				return err
+		// poll sources
+		for _, source := range sources {
+			if source.empty {
+				continue
+			}
+			if s.Stopper().IsStopped() || available <= 0 {
+				break
 			}
 
-			// hold works
-			nextTime := time.Now().Add(time.Second * time.Duration(s.HOLD_WORK_SECONDS))
-			workIds := []string{}
-			for _, w := range works1 {
-				dbWork := s.workBuilder()
-				found, err := s.CRUD().ReadForUpdate(ctx, db.Fields{"id": w.GetID()}, dbWork)
-				if err != nil {
-					c.SetMessage("failed to read work for hold from database")
-					return err
-				}
-				if found {
-					err = s.CRUD().Update(ctx, dbWork, db.Fields{"next_time": nextTime, "next_time_set": false})
-					if err != nil {
-						c.SetLoggerField("work_reference_id", dbWork.GetReferenceId())
-						c.SetMessage("failed to hold work in database")
-						return err
-					}
-					workIds = append(workIds, dbWork.GetID())
+			limit := share
+			if limit > available {
+				limit = available
+			}
+			works, err := s.holdWorks(source.ctx, limit)
+			if err != nil || len(works) < limit {
+				source.empty = true
+			}
+			available -= len(works)
+
+			// enqueue works to workers
+			for _, work := range works {
+				if s.Stopper().IsStopped() {
+					break
 				}
+				s.enqueuWork(work, source.tenancy)
 			}
+		}
+	}
+}
+
+// Read works ready to run from database of context and hold them for HOLD_WORK_SECONDS.
+func (s *WorkSchedule[T]) holdWorks(ctx op_context.Context, limit int) ([]T, error) {
+
+	c := ctx.TraceInMethod("WorkSchedule.holdWorks")
+	defer ctx.TraceOutMethod()
+
+	// prepare filter
+	filter := db.NewFilter()
+	filter.SetSorting("next_time", db.SORT_ASC)
+	filter.AddInterval("next_time", nil, time.Now())
+	filter.AddField("dead", false)
+	filter.Limit = limit
+
+	// read works from database
+	var works []T
+	handler := func() error {
 
-			// read updated works
-			f := db.NewFilter()
-			f.AddFieldIn("id", utils.ListInterfaces(workIds...)...)
-			_, err = s.CRUD().List(ctx, f, &works)
+		var works1 []T
+		_, err := s.CRUD().List(ctx, filter, &works1)
+		if err != nil {
+			c.SetMessage("failed to read works from database 1")
+			return err
+		}
+
+		// hold works
+		nextTime := time.Now().Add(time.Second * time.Duration(s.HOLD_WORK_SECONDS))
+		workIds := []string{}
+		for _, w := range works1 {
+			dbWork := s.workBuilder()
+			found, err := s.CRUD().ReadForUpdate(ctx, db.Fields{"id": w.GetID()}, dbWork)
 			if err != nil {
-				c.SetMessage("failed to read works from database 2")
+				c.SetMessage("failed to read work for hold from database")
 				return err
 			}
+			if found {
+				err = s.CRUD().Update(ctx, dbWork, db.Fields{"next_time": nextTime, "next_time_set": false})
+				if err != nil {
+					c.SetLoggerField("work_reference_id", dbWork.GetReferenceId())
+					c.SetMessage("failed to hold work in database")
+					return err
+				}
+				workIds = append(workIds, dbWork.GetID())
+			}
+		}
 
-			// done
+		// read updated works
+		if len(workIds) == 0 {
 			return nil
 		}
-		err := op_context.ExecDbTransaction(ctx, handler)
+		f := db.NewFilter()
+		f.AddFieldIn("id", utils.ListInterfaces(workIds...)...)
+		_, err = s.CRUD().List(ctx, f, &works)
 		if err != nil {
-			c.SetError(err)
-			break
-		}
-
-		// stop cycle if there are no works
-		if len(works) == 0 || s.Stopper().IsStopped() {
-			break
+			c.SetMessage("failed to read works from database 2")
+			return err
 		}
 
-		// enqueu works to workers
-		for _, work := range works {
-			if s.Stopper().IsStopped() {
-				break
-			}
-			// TODO support multitenancy
-			s.enqueuWork(work, nil)
-		}
+		// done
+		return nil
+	}
+	err := op_context.ExecDbTransaction(ctx, handler)
+	if err != nil {
+		return nil, c.SetError(err)
 	}
+
+	return works, nil
 }
 
 func (s *WorkSchedule[T]) DoWork(ctx op_context.Context, work T) error {
@@ -569,16 +787,47 @@ func (s *WorkSchedule[T]) DoWork(ctx op_context.Context, work T) error {
 	}
 
 	// acquire work
-	s.AcquireWork(ctx, work)
+	err = s.AcquireWork(ctx, work)
 	if err != nil {
 		return err
 	}
 	releaseWork = true
 
+	// keep work locked while it is running
+	lockTtl := time.Second * time.Duration(s.LOCK_TTL_SECONDS)
+	stopRenewal := cache.KeepLock(work.GetLock(), lockTtl, lockTtl/2, func(err error) {
+		c.Logger().Error("failed to renew work lock", err)
+	})
+	defer stopRenewal()
+
 	// run work
 	work.ResetNextTime()
 	done, err := s.workRunner.Run(ctx, work)
+	fields := db.Fields{}
+	if err != nil {
+		// retry failed work with backoff until max number of attempts is reached
+		done = false
+		work.SetAttempts(work.GetAttempts() + 1)
+		work.SetLastError(err.Error())
+		if s.MAX_ATTEMPTS > 0 && work.GetAttempts() >= s.MAX_ATTEMPTS {
+			work.SetDead(true)
+			c.SetLoggerField("work_attempts", work.GetAttempts())
+			c.Logger().Warn("work is dead after max number of attempts")
+		} else {
+			work.SetNextTime(time.Now().Add(s.BackoffDelay(work.GetAttempts())))
+		}
+		fields["attempts"] = work.GetAttempts()
+		fields["last_error"] = work.GetLastError()
+		fields["dead"] = work.IsDead()
+	} else if work.GetAttempts() != 0 {
+		work.SetAttempts(0)
+		work.SetLastError("")
+		fields["attempts"] = 0
+		fields["last_error"] = ""
+	}
 	s.SetNextWorkTime(work)
+	fields["next_time"] = work.GetNextTime()
+	fields["next_time_set"] = true
 	updateProcessedWork := func() error {
 
 		// read work from database
@@ -607,7 +856,7 @@ func (s *WorkSchedule[T]) DoWork(ctx op_context.Context, work T) error {
 		f := db.NewFilter()
 		f.AddField("id", work.GetID())
 		f.AddField("next_time_set", false)
-		err = s.CRUD().UpdateWithFilter(ctx, s.workBuilder(), f, db.Fields{"next_time": work.GetNextTime(), "next_time_set": true})
+		err = s.CRUD().UpdateWithFilter(ctx, s.workBuilder(), f, fields)
 		if err != nil {
 			c.SetMessage("failed to save next work time in database")
 			return err
@@ -636,8 +885,17 @@ func (s *WorkSchedule[T]) InvokeWork(ctx op_context.Context, work T, postMode Po
 
 	switch postMode {
 	case DIRECT:
-		// TODO support multitenancy
-		err := s.DoWork(ctx, work)
+		// run work in context of tenancy if tenancy is given but context is not in tenancy
+		workCtx := ctx
+		t := utils.OptionalArg(nil, tenancy...)
+		if t != nil {
+			tenancyCtx, ok := ctx.(multitenancy.TenancyContext)
+			if !ok || tenancyCtx.GetTenancy() == nil {
+				workCtx = app_with_multitenancy.BackgroundOpContext(s.App(), t, s.name)
+				defer workCtx.Close()
+			}
+		}
+		err := s.DoWork(workCtx, work)
 		if err != nil {
 			return c.SetError(err)
 		}
@@ -661,6 +919,95 @@ func (s *WorkSchedule[T]) UpdateWorkNextTime(ctx op_context.Context, work T, ten
 	return nil
 }
 
+func (s *WorkSchedule[T]) ListDeadWorks(ctx op_context.Context, filter *db.Filter) ([]T, int64, error) {
+
+	c := ctx.TraceInMethod("WorkSchedule.ListDeadWorks")
+	defer ctx.TraceOutMethod()
+
+	if filter == nil {
+		filter = db.NewFilter()
+	}
+	filter.AddField("dead", true)
+
+	var works []T
+	count, err := s.CRUD().List(ctx, filter, &works)
+	if err != nil {
+		c.SetMessage("failed to list dead works")
+		return nil, 0, c.SetError(err)
+	}
+
+	return works, count, nil
+}
+
+func (s *WorkSchedule[T]) RetryDeadWork(ctx op_context.Context, workId string) error {
+
+	c := ctx.TraceInMethod("WorkSchedule.RetryDeadWork", logger.Fields{"work_id": workId})
+	defer ctx.TraceOutMethod()
+
+	work := s.workBuilder()
+	found, err := s.CRUD().Read(ctx, db.Fields{"id": workId, "dead": true}, work)
+	if err != nil {
+		c.SetMessage("failed to find dead work")
+		return c.SetError(err)
+	}
+	if !found {
+		ctx.SetGenericErrorCode(ErrorCodeDeadWorkNotFound)
+		return c.SetErrorStr("dead work not found")
+	}
+
+	err = s.CRUD().Update(ctx, work, db.Fields{"dead": false, "attempts": 0, "last_error": "", "next_time": time.Now(), "next_time_set": true})
+	if err != nil {
+		c.SetMessage("failed to update dead work")
+		return c.SetError(err)
+	}
+
+	return nil
+}
+
+func (s *WorkSchedule[T]) PurgeDeadWorks(ctx op_context.Context, workIds ...string) error {
+
+	c := ctx.TraceInMethod("WorkSchedule.PurgeDeadWorks")
+	defer ctx.TraceOutMethod()
+
+	if len(workIds) == 0 {
+		err := s.CRUD().DeleteByFields(ctx, db.Fields{"dead": true}, s.workBuilder())
+		if err != nil {
+			c.SetMessage("failed to delete dead works")
+			return c.SetError(err)
+		}
+		return nil
+	}
+
+	// all works must be found before deleting any of them
+	works := make([]T, 0, len(workIds))
+	for _, workId := range workIds {
+		work := s.workBuilder()
+		found, err := s.CRUD().Read(ctx, db.Fields{"id": workId, "dead": true}, work)
+		if err != nil {
+			c.SetLoggerField("work_id", workId)
+			c.SetMessage("failed to find dead work")
+			return c.SetError(err)
+		}
+		if !found {
+			c.SetLoggerField("work_id", workId)
+			ctx.SetGenericErrorCode(ErrorCodeDeadWorkNotFound)
+			return c.SetErrorStr("dead work not found")
+		}
+		works = append(works, work)
+	}
+
+	for _, work := range works {
+		err := s.CRUD().Delete(ctx, work)
+		if err != nil {
+			c.SetLoggerField("work_id", work.GetID())
+			c.SetMessage("failed to delete dead work")
+			return c.SetError(err)
+		}
+	}
+
+	return nil
+}
+
 func (s *WorkSchedule[T]) worker() {
 	for work := range s.queue {
 
@@ -0,0 +1,12 @@
+package work_schedule_api
+
+import (
+	"github.com/evgeniums/go-utils/pkg/api"
+)
+
+var (
+	ListDeadWorks  = func() api.Operation { return api.List("list_dead_works") }
+	PurgeDeadWorks = func() api.Operation { return api.Delete("purge_dead_works") }
+	DeleteDeadWork = func() api.Operation { return api.Delete("delete_dead_work") }
+	RetryDeadWork  = func() api.Operation { return api.Post("retry_dead_work") }
+)
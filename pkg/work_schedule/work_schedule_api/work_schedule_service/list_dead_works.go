@@ -0,0 +1,46 @@
+package work_schedule_service
+
+import (
+	"github.com/evgeniums/go-utils/pkg/api"
+	"github.com/evgeniums/go-utils/pkg/api/api_server"
+	"github.com/evgeniums/go-utils/pkg/work_schedule"
+	"github.com/evgeniums/go-utils/pkg/work_schedule/work_schedule_api"
+)
+
+type ListDeadWorksEndpoint[T work_schedule.Work] struct {
+	WorkScheduleEndpoint[T]
+}
+
+func (e *ListDeadWorksEndpoint[T]) HandleRequest(request api_server.Request) error {
+
+	// setup
+	c := request.TraceInMethod("work_schedule.ListDeadWorks")
+	defer request.TraceOutMethod()
+
+	// parse query
+	queryName := request.Endpoint().Resource().ServicePathPrototype()
+	filter, err := api_server.ParseDbQuery(request, e.service.Scheduler.NewWork("", ""), queryName)
+	if err != nil {
+		return c.SetError(err)
+	}
+
+	// get dead works
+	resp := &api.ResponseList[T]{}
+	resp.Items, resp.Count, err = e.service.Scheduler.ListDeadWorks(request, filter)
+	if err != nil {
+		return c.SetError(err)
+	}
+
+	// set response message
+	resp.SetCursors(filter)
+	api_server.SetResponseList(request, resp)
+
+	// done
+	return nil
+}
+
+func ListDeadWorks[T work_schedule.Work](s *WorkScheduleService[T]) *ListDeadWorksEndpoint[T] {
+	e := &ListDeadWorksEndpoint[T]{}
+	e.Construct(s, work_schedule_api.ListDeadWorks())
+	return e
+}
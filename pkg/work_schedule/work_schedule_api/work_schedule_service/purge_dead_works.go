@@ -0,0 +1,63 @@
+package work_schedule_service
+
+import (
+	"github.com/evgeniums/go-utils/pkg/api/api_server"
+	"github.com/evgeniums/go-utils/pkg/work_schedule"
+	"github.com/evgeniums/go-utils/pkg/work_schedule/work_schedule_api"
+)
+
+type PurgeDeadWorksEndpoint[T work_schedule.Work] struct {
+	WorkScheduleEndpoint[T]
+}
+
+func (e *PurgeDeadWorksEndpoint[T]) HandleRequest(request api_server.Request) error {
+
+	// setup
+	c := request.TraceInMethod("work_schedule.PurgeDeadWorks")
+	defer request.TraceOutMethod()
+
+	// delete all dead works
+	err := e.service.Scheduler.PurgeDeadWorks(request)
+	if err != nil {
+		c.SetMessage("failed to purge dead works")
+		return c.SetError(err)
+	}
+
+	// done
+	return nil
+}
+
+func PurgeDeadWorks[T work_schedule.Work](s *WorkScheduleService[T]) *PurgeDeadWorksEndpoint[T] {
+	e := &PurgeDeadWorksEndpoint[T]{}
+	e.Construct(s, work_schedule_api.PurgeDeadWorks())
+	return e
+}
+
+//-------------------------------------------------
+
+type DeleteDeadWorkEndpoint[T work_schedule.Work] struct {
+	WorkScheduleEndpoint[T]
+}
+
+func (e *DeleteDeadWorkEndpoint[T]) HandleRequest(request api_server.Request) error {
+
+	// setup
+	c := request.TraceInMethod("work_schedule.DeleteDeadWork")
+	defer request.TraceOutMethod()
+
+	// delete dead work
+	err := e.service.Scheduler.PurgeDeadWorks(request, request.GetResourceId("dead_work"))
+	if err != nil {
+		c.SetMessage("failed to delete dead work")
+		return c.SetError(err)
+	}
+
+	// done
+	return nil
+}
+
+func DeleteDeadWork[T work_schedule.Work](s *WorkScheduleService[T]) *DeleteDeadWorkEndpoint[T] {
+	e := &DeleteDeadWorkEndpoint[T]{}
+	e.Construct(s, work_schedule_api.DeleteDeadWork())
+	return e
+}
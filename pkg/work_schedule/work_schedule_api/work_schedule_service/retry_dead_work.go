@@ -0,0 +1,35 @@
+package work_schedule_service
+
+import (
+	"github.com/evgeniums/go-utils/pkg/api/api_server"
+	"github.com/evgeniums/go-utils/pkg/work_schedule"
+	"github.com/evgeniums/go-utils/pkg/work_schedule/work_schedule_api"
+)
+
+type RetryDeadWorkEndpoint[T work_schedule.Work] struct {
+	api_server.ResourceEndpoint
+	service *WorkScheduleService[T]
+}
+
+func (e *RetryDeadWorkEndpoint[T]) HandleRequest(request api_server.Request) error {
+
+	// setup
+	c := request.TraceInMethod("work_schedule.RetryDeadWork")
+	defer request.TraceOutMethod()
+
+	// retry dead work
+	err := e.service.Scheduler.RetryDeadWork(request, request.GetResourceId("dead_work"))
+	if err != nil {
+		c.SetMessage("failed to retry dead work")
+		return c.SetError(err)
+	}
+
+	// done
+	return nil
+}
+
+func RetryDeadWork[T work_schedule.Work](s *WorkScheduleService[T]) *RetryDeadWorkEndpoint[T] {
+	e := &RetryDeadWorkEndpoint[T]{service: s}
+	api_server.ConstructResourceEndpoint(e, "retry", work_schedule_api.RetryDeadWork())
+	return e
+}
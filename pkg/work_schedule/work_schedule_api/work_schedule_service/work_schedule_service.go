@@ -0,0 +1,43 @@
+package work_schedule_service
+
+import (
+	"github.com/evgeniums/go-utils/pkg/api"
+	"github.com/evgeniums/go-utils/pkg/api/api_server"
+	"github.com/evgeniums/go-utils/pkg/work_schedule"
+)
+
+type WorkScheduleEndpoint[T work_schedule.Work] struct {
+	service *WorkScheduleService[T]
+	api_server.EndpointBase
+}
+
+func (e *WorkScheduleEndpoint[T]) Construct(service *WorkScheduleService[T], op api.Operation) {
+	e.service = service
+	e.EndpointBase.Construct(op)
+}
+
+type WorkScheduleService[T work_schedule.Work] struct {
+	api_server.ServiceBase
+	Scheduler work_schedule.WorkScheduler[T]
+
+	DeadWorksResource api.Resource
+	DeadWorkResource  api.Resource
+}
+
+func NewWorkScheduleService[T work_schedule.Work](serviceName string, scheduler work_schedule.WorkScheduler[T]) *WorkScheduleService[T] {
+
+	s := &WorkScheduleService[T]{}
+	s.ErrorsExtenderBase.Init(work_schedule.ErrorDescriptions, work_schedule.ErrorHttpCodes)
+	s.Scheduler = scheduler
+
+	s.Init(serviceName)
+
+	_, s.DeadWorksResource, s.DeadWorkResource = api.PrepareCollectionAndNameResource("dead_work")
+	s.AddChild(s.DeadWorksResource)
+
+	s.DeadWorksResource.AddOperations(ListDeadWorks(s), PurgeDeadWorks(s))
+	s.DeadWorkResource.AddOperation(DeleteDeadWork(s))
+	s.DeadWorkResource.AddChild(RetryDeadWork(s))
+
+	return s
+}
@@ -0,0 +1,123 @@
+package work_schedule_console
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/evgeniums/go-utils/pkg/console_tool"
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/work_schedule"
+)
+
+const ListDeadWorksCmd string = "list-dead"
+const ListDeadWorksDescription string = "List dead works"
+
+func ListDeadWorks[T work_schedule.Work]() console_tool.Handler[*WorkScheduleCommands[T]] {
+	a := &ListDeadWorksHandler[T]{}
+	a.Init(ListDeadWorksCmd, ListDeadWorksDescription)
+	return a
+}
+
+type ListDeadWorksHandler[T work_schedule.Work] struct {
+	HandlerBase[T]
+	console_tool.QueryData
+}
+
+func (a *ListDeadWorksHandler[T]) Data() interface{} {
+	return &a.QueryData
+}
+
+func (a *ListDeadWorksHandler[T]) Execute(args []string) error {
+
+	ctx, controller, err := a.Context(a.Data())
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+
+	filter, err := db.ParseQuery(ctx.Db(), a.Query, controller.NewWork("", ""), "")
+	if err != nil {
+		return fmt.Errorf("failed to parse query: %s", err)
+	}
+
+	works, count, err := controller.ListDeadWorks(ctx, filter)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(works, "", "   ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize result: %s", err)
+	}
+	fmt.Printf("********************\n\n%s\n\nCount %d\n\n********************\n\n", string(b), count)
+	return nil
+}
+
+//-------------------------------------------------
+
+const RetryDeadWorkCmd string = "retry-dead"
+const RetryDeadWorkDescription string = "Retry dead work"
+
+func RetryDeadWork[T work_schedule.Work]() console_tool.Handler[*WorkScheduleCommands[T]] {
+	a := &RetryDeadWorkHandler[T]{}
+	a.Init(RetryDeadWorkCmd, RetryDeadWorkDescription)
+	return a
+}
+
+type WorkIdData struct {
+	Id string `long:"id" description:"Work ID" required:"true" validate:"required,id" vmessage:"Invalid work ID"`
+}
+
+type RetryDeadWorkHandler[T work_schedule.Work] struct {
+	HandlerBase[T]
+	WorkIdData
+}
+
+func (a *RetryDeadWorkHandler[T]) Data() interface{} {
+	return &a.WorkIdData
+}
+
+func (a *RetryDeadWorkHandler[T]) Execute(args []string) error {
+
+	ctx, controller, err := a.Context(a.Data())
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+
+	return controller.RetryDeadWork(ctx, a.Id)
+}
+
+//-------------------------------------------------
+
+const PurgeDeadWorksCmd string = "purge-dead"
+const PurgeDeadWorksDescription string = "Delete dead works"
+
+func PurgeDeadWorks[T work_schedule.Work]() console_tool.Handler[*WorkScheduleCommands[T]] {
+	a := &PurgeDeadWorksHandler[T]{}
+	a.Init(PurgeDeadWorksCmd, PurgeDeadWorksDescription)
+	return a
+}
+
+type PurgeDeadWorksData struct {
+	Ids []string `long:"id" description:"ID of dead work to delete, can be specified multiple times. If not set then all dead works are deleted" validate:"dive,id" vmessage:"Invalid work ID"`
+}
+
+type PurgeDeadWorksHandler[T work_schedule.Work] struct {
+	HandlerBase[T]
+	PurgeDeadWorksData
+}
+
+func (a *PurgeDeadWorksHandler[T]) Data() interface{} {
+	return &a.PurgeDeadWorksData
+}
+
+func (a *PurgeDeadWorksHandler[T]) Execute(args []string) error {
+
+	ctx, controller, err := a.Context(a.Data())
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+
+	return controller.PurgeDeadWorks(ctx, a.Ids...)
+}
@@ -29,6 +29,9 @@ func NewWorkScheduleCommands[T work_schedule.Work](name string, description stri
 func (w *WorkScheduleCommands[T]) LoadHandlers() {
 	w.AddHandlers(
 		PostWork[T],
+		ListDeadWorks[T],
+		RetryDeadWork[T],
+		PurgeDeadWorks[T],
 	)
 }
 
@@ -0,0 +1,242 @@
+package acl_api_test
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/evgeniums/go-utils/pkg/access_control"
+	"github.com/evgeniums/go-utils/pkg/access_control/acl"
+	"github.com/evgeniums/go-utils/pkg/access_control/acl/acl_api/acl_client"
+	"github.com/evgeniums/go-utils/pkg/access_control/acl/acl_api/acl_service"
+	"github.com/evgeniums/go-utils/pkg/admin"
+	"github.com/evgeniums/go-utils/pkg/api/api_server"
+	"github.com/evgeniums/go-utils/pkg/crud"
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/generic_error"
+	"github.com/evgeniums/go-utils/pkg/test_utils"
+	"github.com/evgeniums/go-utils/pkg/utils"
+	"github.com/evgeniums/go-utils/test/api_test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _, testBasePath, _, _ = runtime.Caller(0)
+var testDir = filepath.Dir(testBasePath)
+
+func dbModels() []interface{} {
+	return utils.ConcatSlices([]interface{}{}, admin.DbModels(), acl.DbModels())
+}
+
+type testContext struct {
+	*api_test.TestContext
+	LocalAclController  acl.AclController
+	RemoteAclController acl.AclController
+}
+
+func initTest(t *testing.T) *testContext {
+
+	ctx := &testContext{}
+	ctx.TestContext = api_test.InitTest(t, "acl", testDir, dbModels())
+	ctx.LocalAclController = acl.NewAclController(&crud.DbCRUD{})
+	ctx.RemoteAclController = acl_client.NewAclClient(ctx.RestApiClient)
+
+	aclService := acl_service.NewAclService(ctx.LocalAclController)
+	api_server.AddServiceToServer(ctx.Server.ApiServer(), aclService)
+
+	return ctx
+}
+
+func addRole(t *testing.T, ctx *testContext, name string) *acl.AclRole {
+	r := acl.NewRole()
+	r.SetName(name)
+	role, err := ctx.RemoteAclController.AddRole(ctx.ClientOp, r)
+	require.NoError(t, err)
+	require.NotNil(t, role)
+	assert.Equal(t, name, role.Name())
+	return role
+}
+
+func setRule(t *testing.T, ctx *testContext, resource string, tag string, role string, access access_control.AccessType) *acl.AclRule {
+	r := &acl.AclRuleData{RESOURCE: resource, TAG: tag, ROLE: role, ACCESS: uint32(access)}
+	rule, err := ctx.RemoteAclController.SetRule(ctx.ClientOp, r)
+	require.NoError(t, err)
+	require.NotNil(t, rule)
+	return rule
+}
+
+func TestRoles(t *testing.T) {
+	ctx := initTest(t)
+	defer ctx.Close()
+
+	addRole(t, ctx, "role1")
+	role2 := addRole(t, ctx, "role2")
+
+	r := acl.NewRole()
+	r.SetName("role1")
+	_, err := ctx.RemoteAclController.AddRole(ctx.ClientOp, r)
+	test_utils.CheckGenericError(t, err, acl.ErrorCodeRoleNameConflict)
+
+	filter := db.NewFilter()
+	filter.SortField = "name"
+	roles, _, err := ctx.RemoteAclController.GetRoles(ctx.ClientOp, filter)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(roles))
+	assert.Equal(t, "role1", roles[0].Name())
+	assert.Equal(t, "role2", roles[1].Name())
+
+	rule := setRule(t, ctx, "/resource1", "", "role1", access_control.Read)
+	err = ctx.RemoteAclController.DeleteRole(ctx.ClientOp, "role1", true)
+	test_utils.CheckGenericError(t, err, acl.ErrorCodeRoleRulesExist)
+
+	require.NoError(t, ctx.RemoteAclController.DeleteRule(ctx.ClientOp, rule.GetID()))
+	require.NoError(t, ctx.RemoteAclController.DeleteRole(ctx.ClientOp, "role1", true))
+	require.NoError(t, ctx.RemoteAclController.DeleteRole(ctx.ClientOp, role2.GetID()))
+	roles, _, err = ctx.RemoteAclController.GetRoles(ctx.ClientOp, nil)
+	require.NoError(t, err)
+	assert.Empty(t, roles)
+
+	// deleting of unknown role fails
+	err = ctx.RemoteAclController.DeleteRole(ctx.ClientOp, "role1", true)
+	test_utils.CheckGenericError(t, err, acl.ErrorCodeRoleNotFound)
+	err = ctx.RemoteAclController.DeleteRole(ctx.ClientOp, role2.GetID())
+	test_utils.CheckGenericError(t, err, acl.ErrorCodeRoleNotFound)
+
+	_, err = ctx.RemoteAclController.SetRule(ctx.ClientOp, &acl.AclRuleData{RESOURCE: "/resource1", ROLE: "role1"})
+	test_utils.CheckGenericError(t, err, acl.ErrorCodeRoleNotFound)
+}
+
+func TestCheckAccess(t *testing.T) {
+	ctx := initTest(t)
+	defer ctx.Close()
+
+	addRole(t, ctx, "reader")
+	addRole(t, ctx, "writer")
+
+	accessControl := acl.NewAccessControl(&crud.DbCRUD{})
+	reader := access_control.NewSubject("reader")
+	writer := access_control.NewSubject("writer")
+	both := access_control.NewSubject("guest", "writer")
+
+	check := func(path string, subject access_control.Subject, accessType access_control.AccessType, expected bool) {
+		t.Helper()
+		allowed, err := accessControl.CheckAccess(ctx.AdminOp, access_control.NewResource(path), subject, accessType)
+		require.NoError(t, err)
+		assert.Equal(t, expected, allowed)
+	}
+
+	// default access denies everything
+	check("/docs/doc1", reader, access_control.Read, false)
+
+	// rules for parent path are applied to children
+	setRule(t, ctx, "/docs", "", "reader", access_control.Read)
+	setRule(t, ctx, "/docs", "", "writer", access_control.Read|access_control.Create|access_control.Update)
+	check("/docs/doc1", reader, access_control.Read, true)
+	check("/docs/doc1", reader, access_control.Create, false)
+	check("/docs/doc1", writer, access_control.Create, true)
+	check("/docs/doc1", both, access_control.UpdatePartial, true)
+	check("/docs/doc1", writer, access_control.Delete, false)
+
+	// more specific path overrides parent rule
+	setRule(t, ctx, "/docs/doc1", "", "reader", 0)
+	check("/docs/doc1", reader, access_control.Read, false)
+	check("/docs/doc2", reader, access_control.Read, true)
+
+	// rule for tag of parent resource
+	tag, err := ctx.RemoteAclController.AddResourceTag(ctx.ClientOp, &acl.AclResourceTagData{RESOURCE: "/docs", TAG: "secret"})
+	require.NoError(t, err)
+	_, err = ctx.RemoteAclController.AddResourceTag(ctx.ClientOp, &acl.AclResourceTagData{RESOURCE: "/docs", TAG: "secret"})
+	test_utils.CheckGenericError(t, err, acl.ErrorCodeResourceTagConflict)
+	secretRule := setRule(t, ctx, "/docs/doc2", "secret", "writer", access_control.All)
+	check("/docs/doc2", writer, access_control.Delete, true)
+	check("/docs/doc3", writer, access_control.Delete, false)
+
+	// update of rule invalidates cache
+	setRule(t, ctx, "/docs/doc2", "secret", "writer", access_control.Read)
+	check("/docs/doc2", writer, access_control.Delete, false)
+	rules, _, err := ctx.RemoteAclController.GetRules(ctx.ClientOp, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 4, len(rules))
+
+	// deleting of tag and rule invalidates cache
+	require.NoError(t, ctx.RemoteAclController.DeleteResourceTag(ctx.ClientOp, tag.GetID()))
+	check("/docs/doc2", writer, access_control.Delete, false)
+	check("/docs/doc2", writer, access_control.Create, true)
+	require.NoError(t, ctx.RemoteAclController.DeleteRule(ctx.ClientOp, secretRule.GetID()))
+	tags, _, err := ctx.RemoteAclController.GetResourceTags(ctx.ClientOp, nil)
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+
+	// deleting of unknown tag and rule fails
+	err = ctx.RemoteAclController.DeleteResourceTag(ctx.ClientOp, tag.GetID())
+	test_utils.CheckGenericError(t, err, acl.ErrorCodeResourceTagNotFound)
+	err = ctx.RemoteAclController.DeleteRule(ctx.ClientOp, secretRule.GetID())
+	test_utils.CheckGenericError(t, err, acl.ErrorCodeRuleNotFound)
+
+	// default access is used if rule not found
+	accessControl.SetDefaultAccess(&access_control.AccessBase{})
+	accessControl.DefaultAccess().Grant(access_control.Read)
+	check("/other", writer, access_control.Read, true)
+	check("/other", writer, access_control.Create, false)
+}
+
+func TestEnforceAccess(t *testing.T) {
+	ctx := initTest(t)
+	defer ctx.Close()
+
+	addRole(t, ctx, "superadmin")
+	ctx.Server.ApiServer().SetAccessControl(acl.NewAccessControl(&crud.DbCRUD{}))
+
+	newRole := func(name string) *acl.AclRole {
+		r := acl.NewRole()
+		r.SetName(name)
+		return r
+	}
+	setLocalRule := func(resource string, access access_control.AccessType) {
+		_, err := ctx.LocalAclController.SetRule(ctx.AdminOp, &acl.AclRuleData{RESOURCE: resource, ROLE: "superadmin", ACCESS: uint32(access)})
+		require.NoError(t, err)
+	}
+
+	// everything is denied without rules
+	_, _, err := ctx.RemoteAclController.GetRoles(ctx.ClientOp, nil)
+	test_utils.CheckGenericError(t, err, generic_error.ErrorCodeForbidden)
+
+	// rule for service path
+	setLocalRule("/acl", access_control.Read)
+	roles, _, err := ctx.RemoteAclController.GetRoles(ctx.ClientOp, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(roles))
+	_, err = ctx.RemoteAclController.AddRole(ctx.ClientOp, newRole("role1"))
+	test_utils.CheckGenericError(t, err, generic_error.ErrorCodeForbidden)
+
+	// rule for more specific path overrides rule for service path
+	setLocalRule("/acl/role", access_control.Create)
+	role1 := addRole(t, ctx, "role1")
+	_, _, err = ctx.RemoteAclController.GetRoles(ctx.ClientOp, nil)
+	test_utils.CheckGenericError(t, err, generic_error.ErrorCodeForbidden)
+	_, _, err = ctx.RemoteAclController.GetRules(ctx.ClientOp, nil)
+	require.NoError(t, err)
+
+	// rule for path prototype of named resource
+	setLocalRule("/acl/role/:role", access_control.Delete)
+	require.NoError(t, ctx.RemoteAclController.DeleteRole(ctx.ClientOp, role1.GetID()))
+	err = ctx.RemoteAclController.DeleteRule(ctx.ClientOp, role1.GetID())
+	test_utils.CheckGenericError(t, err, generic_error.ErrorCodeForbidden)
+
+	// public resource and its nested resources are accessible without rules
+	ctx.Server.ApiServer().AddPublicResources("/acl/role")
+	_, _, err = ctx.RemoteAclController.GetRoles(ctx.ClientOp, nil)
+	require.NoError(t, err)
+	err = ctx.RemoteAclController.DeleteRule(ctx.ClientOp, role1.GetID())
+	test_utils.CheckGenericError(t, err, generic_error.ErrorCodeForbidden)
+
+	// root public resource makes all resources accessible without rules
+	ctx.Server.ApiServer().AddPublicResources("/")
+	err = ctx.RemoteAclController.DeleteRule(ctx.ClientOp, role1.GetID())
+	test_utils.CheckGenericError(t, err, acl.ErrorCodeRuleNotFound)
+
+	// disable access control
+	ctx.Server.ApiServer().SetAccessControl(nil)
+	_, _, err = ctx.RemoteAclController.GetRoles(ctx.ClientOp, nil)
+	require.NoError(t, err)
+}
@@ -218,6 +218,26 @@ func TestFindUsers(t *testing.T) {
 	assert.Equal(t, 1, len(admins))
 	assert.Equal(t, targetAdminEmail, admins[0].Email())
 	assert.Equal(t, int64(1), count)
+
+	// search
+	filter = db.NewFilter()
+	filter.AddPrefix("login", "target_")
+	admins, _, err = ctx.RemoteAdminManager.FindUsers(ctx.ClientOp, filter)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(admins))
+	assert.Equal(t, targetAdminLogin, admins[0].Login())
+
+	filter = db.NewFilter()
+	filter.AddLike("email", "%@EXAMPLE.com", true)
+	admins, _, err = ctx.RemoteAdminManager.FindUsers(ctx.ClientOp, filter)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(admins))
+	assert.Equal(t, targetAdminLogin, admins[0].Login())
+
+	filter = db.NewFilter()
+	filter.AddPrefix("email", "target")
+	_, _, err = ctx.RemoteAdminManager.FindUsers(ctx.ClientOp, filter)
+	test_utils.CheckGenericError(t, err, generic_error.ErrorCodeFormat)
 }
 
 func TestFindSingleUser(t *testing.T) {
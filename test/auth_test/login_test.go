@@ -324,3 +324,92 @@ func TestTokens(t *testing.T) {
 	resp = client1.Get("/status/logged", nil)
 	test_utils.CheckResponse(t, resp, &test_utils.Expected{Error: auth_token.ErrorCodeTokenExpired, HttpCode: http.StatusUnauthorized})
 }
+
+func TestLoginPasswordHashUpgrade(t *testing.T) {
+	app, users, server, opCtx := initOpTest(t)
+	defer app.Close()
+
+	// create user with strong password hash
+	login1 := "user1"
+	password1 := "password1"
+	user1, err := users.Add(opCtx, login1, password1, user.Phone("12345678", &User{}), user.Email("user1@example.com", &User{}))
+	require.NoErrorf(t, err, "failed to add user")
+	assert.Equal(t, auth_login_phash.HasherArgon2id, auth_login_phash.HasherName(user1.PasswordHash()))
+	assert.False(t, user1.PasswordHashNeedsUpgrade())
+
+	// replace hash with legacy one
+	legacyHash := auth_login_phash.Phash(password1, user1.PasswordSalt())
+	require.NoError(t, db.Update(opCtx.Db(), opCtx, user1, db.Fields{"password_hash": legacyHash}))
+	user1, err = users.FindByLogin(opCtx, login1)
+	require.NoError(t, err)
+	assert.Equal(t, auth_login_phash.HasherLegacy, auth_login_phash.HasherName(user1.PasswordHash()))
+	assert.True(t, user1.PasswordHashNeedsUpgrade())
+
+	// login with legacy hash
+	client := test_utils.PrepareHttpClient(t, test_utils.BBGinEngine(t, server))
+	client.Login(login1, password1)
+
+	// hash must be upgraded
+	user1, err = users.FindByLogin(opCtx, login1)
+	require.NoError(t, err)
+	assert.Equal(t, auth_login_phash.HasherArgon2id, auth_login_phash.HasherName(user1.PasswordHash()))
+	assert.False(t, user1.PasswordHashNeedsUpgrade())
+	assert.False(t, user1.CheckPasswordHash(legacyHash+"a"))
+	assert.True(t, user1.CheckPasswordHash(legacyHash))
+
+	// login with upgraded hash
+	client.Logout()
+	client.Login(login1, password1)
+}
+
+func TestLoginPasswordHasherConfig(t *testing.T) {
+	app, users, server, opCtx := initOpTest(t, "auth_scrypt_test.jsonc")
+	defer app.Close()
+
+	// hasher of login handler must not change default hasher
+	assert.Equal(t, auth_login_phash.HasherArgon2id, auth_login_phash.DefaultPasswordHashers.Default().Name())
+
+	// new password is hashed with hasher of login handler
+	login1 := "user1"
+	password1 := "password1"
+	user1, err := users.Add(opCtx, login1, password1, user.Phone("12345678", &User{}), user.Email("user1@example.com", &User{}))
+	require.NoErrorf(t, err, "failed to add user")
+	assert.Equal(t, auth_login_phash.HasherScrypt, auth_login_phash.HasherName(user1.PasswordHash()))
+	client := test_utils.PrepareHttpClient(t, test_utils.BBGinEngine(t, server))
+	client.Login(login1, password1)
+	client.Logout()
+
+	// changed password is hashed with hasher of login handler
+	password2 := "password2"
+	require.NoError(t, users.SetPassword(opCtx, login1, password2, true))
+	user1, err = users.FindByLogin(opCtx, login1)
+	require.NoError(t, err)
+	assert.Equal(t, auth_login_phash.HasherScrypt, auth_login_phash.HasherName(user1.PasswordHash()))
+	assert.True(t, user1.CheckPasswordHash(auth_login_phash.Phash(password2, user1.PasswordSalt())))
+
+	// hash of other hasher is upgraded to hasher of login handler after login
+	require.NoError(t, user1.SetPassword(password2))
+	assert.Equal(t, auth_login_phash.HasherArgon2id, auth_login_phash.HasherName(user1.PasswordHash()))
+	require.NoError(t, db.Update(opCtx.Db(), opCtx, user1, db.Fields{"password_hash": user1.PasswordHash(), "password_salt": user1.PasswordSalt()}))
+	client.Login(login1, password2)
+	user1, err = users.FindByLogin(opCtx, login1)
+	require.NoError(t, err)
+	assert.Equal(t, auth_login_phash.HasherScrypt, auth_login_phash.HasherName(user1.PasswordHash()))
+
+	client.Logout()
+	client.Login(login1, password2)
+}
+
+func TestPasswordHashers(t *testing.T) {
+	secret := auth_login_phash.Phash("password1", "salt")
+	hashers := []auth_login_phash.PasswordHasher{auth_login_phash.NewArgon2idHasher(), auth_login_phash.NewScryptHasher(), auth_login_phash.NewBcryptHasher()}
+	for _, hasher := range hashers {
+		hash, err := hasher.Hash(secret)
+		require.NoError(t, err)
+		assert.Equal(t, hasher.Name(), auth_login_phash.HasherName(hash))
+		assert.True(t, auth_login_phash.DefaultPasswordHashers.Verify(hash, secret))
+		assert.False(t, auth_login_phash.DefaultPasswordHashers.Verify(hash, secret+"a"))
+		assert.False(t, hasher.NeedsRehash(hash))
+		assert.Equal(t, hasher.Name() != auth_login_phash.HasherArgon2id, auth_login_phash.DefaultPasswordHashers.NeedsRehash(hash))
+	}
+}
@@ -1,18 +1,24 @@
 package auth_test
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/evgeniums/go-utils/pkg/auth"
 	"github.com/evgeniums/go-utils/pkg/crypt_utils"
+	"github.com/evgeniums/go-utils/pkg/op_context"
 	"github.com/evgeniums/go-utils/pkg/signature"
 	"github.com/evgeniums/go-utils/pkg/signature/user_pubkey"
 	"github.com/evgeniums/go-utils/pkg/test_utils"
 	"github.com/evgeniums/go-utils/pkg/user"
+	"github.com/evgeniums/go-utils/pkg/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -111,3 +117,235 @@ func TestSignature(t *testing.T) {
 	resp = client.Post(path, cmd1, h)
 	test_utils.CheckResponse(t, resp, &test_utils.Expected{HttpCode: http.StatusUnauthorized, Error: signature.ErrorCodeInvalidSignature})
 }
+
+func TestSignatureReplay(t *testing.T) {
+	app, users, server, opCtx := initOpTest(t, "sig_test.jsonc")
+	defer app.Close()
+
+	pubKeyBuilder := func() *UserPubKey { return &UserPubKey{} }
+	pubkeyController := user_pubkey.NewPubkeyController[*UserPubKey, *User](pubKeyBuilder, server.SignatureManager(), users)
+	pubKeyFinder := func(ctx auth.AuthContext) (signature.UserWithPubkey, error) {
+		return user_pubkey.FindUserPubKey[*UserPubKey](pubkeyController, ctx)
+	}
+	server.SignatureManager().SetUserKeyFinder(pubKeyFinder)
+
+	// create user1
+	login1 := "user1"
+	password1 := "password1"
+	user1, err := users.Add(opCtx, login1, password1, user.Phone("12345678", &User{}), user.Email("user1@example.com", &User{}))
+	require.NoErrorf(t, err, "failed to add user")
+
+	// add pubkey for user 1
+	pubKey1, err := os.ReadFile(pubkey1Path)
+	require.NoError(t, err)
+	_, err = pubkeyController.AddPubKey(opCtx, user1.GetID(), string(pubKey1))
+	require.NoError(t, err)
+
+	signer1 := crypt_utils.NewRsaSigner()
+	err = signer1.LoadKeyFromFile(privkey1Path, "")
+	require.NoError(t, err)
+
+	client := test_utils.PrepareHttpClient(t, test_utils.BBGinEngine(t, server))
+	client.Login(login1, password1)
+	path := "/status/echo"
+	cmd1 := &Cmd{Param1: "value1_1", Param2: "value1_2"}
+
+	// good signature with nonce
+	timestamp, nonce := auth.MakeTimestampNonce()
+	resp := client.PostSignedWithNonce(t, signer1, path, timestamp, nonce, cmd1)
+	test_utils.CheckResponse(t, resp, &test_utils.Expected{HttpCode: http.StatusOK})
+
+	// replayed request
+	resp = client.PostSignedWithNonce(t, signer1, path, timestamp, nonce, cmd1)
+	test_utils.CheckResponse(t, resp, &test_utils.Expected{HttpCode: http.StatusUnauthorized, Error: auth.ErrorCodeReplayedRequest})
+
+	// new nonce
+	_, nonce2 := auth.MakeTimestampNonce()
+	resp = client.PostSignedWithNonce(t, signer1, path, timestamp, nonce2, cmd1)
+	test_utils.CheckResponse(t, resp, &test_utils.Expected{HttpCode: http.StatusOK})
+
+	// nonce not included into signature
+	content, err := json.Marshal(cmd1)
+	require.NoError(t, err)
+	sig, err := signer1.SignB64(content, http.MethodPost, path)
+	require.NoError(t, err)
+	_, nonce3 := auth.MakeTimestampNonce()
+	resp = client.Post(path, cmd1, map[string]string{"x-auth-signature": sig, "x-auth-timestamp": timestamp, "x-auth-nonce": nonce3})
+	test_utils.CheckResponse(t, resp, &test_utils.Expected{HttpCode: http.StatusUnauthorized, Error: signature.ErrorCodeInvalidSignature})
+
+	// timestamp out of window
+	oldTimestamp := fmt.Sprintf("%d", time.Now().Add(-time.Hour).Unix())
+	_, nonce4 := auth.MakeTimestampNonce()
+	resp = client.PostSignedWithNonce(t, signer1, path, oldTimestamp, nonce4, cmd1)
+	test_utils.CheckResponse(t, resp, &test_utils.Expected{HttpCode: http.StatusUnauthorized, Error: auth.ErrorCodeInvalidTimestamp})
+
+	// missing nonce
+	resp = client.Post(path, cmd1, map[string]string{"x-auth-signature": sig, "x-auth-timestamp": timestamp})
+	test_utils.CheckResponse(t, resp, &test_utils.Expected{HttpCode: http.StatusUnauthorized, Error: auth.ErrorCodeInvalidTimestamp})
+}
+
+func TestSignatureEd25519(t *testing.T) {
+	app, users, server, opCtx := initOpTest(t, "sig_test.jsonc")
+	defer app.Close()
+
+	pubKeyBuilder := func() *UserPubKey { return &UserPubKey{} }
+	pubkeyController := user_pubkey.NewPubkeyController[*UserPubKey, *User](pubKeyBuilder, server.SignatureManager(), users)
+	pubKeyFinder := func(ctx auth.AuthContext) (signature.UserWithPubkey, error) {
+		return user_pubkey.FindUserPubKey[*UserPubKey](pubkeyController, ctx)
+	}
+	server.SignatureManager().SetUserKeyFinder(pubKeyFinder)
+
+	// create user1
+	login1 := "user1"
+	password1 := "password1"
+	user1, err := users.Add(opCtx, login1, password1, user.Phone("12345678", &User{}), user.Email("user1@example.com", &User{}))
+	require.NoErrorf(t, err, "failed to add user")
+
+	// add compact Ed25519 pubkey for user 1
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, err = pubkeyController.AddPubKey(opCtx, user1.GetID(), utils.Base64Encode(pub))
+	require.NoError(t, err)
+	key, err := pubkeyController.FindActivePubKey(opCtx, user1.GetID())
+	require.NoError(t, err)
+	assert.Equal(t, crypt_utils.ED25519_SIGNATURE, key.PubKeyAlgorithm())
+
+	signer1 := crypt_utils.NewEd25519Signer()
+	signer1.SetKey(priv)
+
+	// good signature
+	client := test_utils.PrepareHttpClient(t, test_utils.BBGinEngine(t, server))
+	client.Login(login1, password1)
+	path := "/status/echo"
+	cmd1 := &Cmd{Param1: "value1_1", Param2: "value1_2"}
+	resp := client.PostSigned(t, signer1, path, cmd1)
+	test_utils.CheckResponse(t, resp, &test_utils.Expected{HttpCode: http.StatusOK})
+
+	// RSA signature does not match Ed25519 key
+	signer2 := crypt_utils.NewRsaSigner()
+	err = signer2.LoadKeyFromFile(privkey1Path, "")
+	require.NoError(t, err)
+	resp = client.PostSigned(t, signer2, path, cmd1)
+	test_utils.CheckResponse(t, resp, &test_utils.Expected{HttpCode: http.StatusUnauthorized, Error: signature.ErrorCodeInvalidSignature})
+
+	// invalid key is rejected
+	_, err = pubkeyController.AddPubKey(opCtx, user1.GetID(), "invalid key")
+	assert.Error(t, err)
+}
+
+func TestSignatureArchive(t *testing.T) {
+	app, users, server, opCtx := initOpTest(t, "sig_test.jsonc")
+	defer app.Close()
+
+	manager, ok := server.SignatureManager().(*signature.SignatureManagerBase)
+	require.True(t, ok)
+	pubKeyBuilder := func() *UserPubKey { return &UserPubKey{} }
+	pubkeyController := user_pubkey.NewPubkeyController[*UserPubKey, *User](pubKeyBuilder, manager, users)
+	manager.SetUserKeyFinder(func(ctx auth.AuthContext) (signature.UserWithPubkey, error) {
+		return user_pubkey.FindUserPubKey[*UserPubKey](pubkeyController, ctx)
+	})
+	manager.SetPubKeyByHashFinder(func(ctx op_context.Context, userId string, keyHash string) (signature.UserWithPubkey, error) {
+		return user_pubkey.FindUserPubKeyByHash[*UserPubKey](pubkeyController, ctx, userId, keyHash)
+	})
+
+	// create user1 with pubkey
+	login1 := "user1"
+	password1 := "password1"
+	user1, err := users.Add(opCtx, login1, password1, user.Phone("12345678", &User{}), user.Email("user1@example.com", &User{}))
+	require.NoErrorf(t, err, "failed to add user")
+	pubKey1, err := os.ReadFile(pubkey1Path)
+	require.NoError(t, err)
+	_, err = pubkeyController.AddPubKey(opCtx, user1.GetID(), string(pubKey1))
+	require.NoError(t, err)
+	signer1 := crypt_utils.NewRsaSigner()
+	err = signer1.LoadKeyFromFile(privkey1Path, "")
+	require.NoError(t, err)
+
+	// sign message with extra data containing separator of legacy records
+	client := test_utils.PrepareHttpClient(t, test_utils.BBGinEngine(t, server))
+	client.Login(login1, password1)
+	path := "/status/echo"
+	cmd1 := &Cmd{Param1: "value1_1", Param2: "Long text Long text Long text Long text Long text Long text Long text Long text"}
+	timestamp, _ := auth.MakeTimestampNonce()
+	nonce := "nonce+with+plus"
+	resp := client.PostSignedWithNonce(t, signer1, path, timestamp, nonce, cmd1)
+	test_utils.CheckResponse(t, resp, &test_utils.Expected{HttpCode: http.StatusOK})
+
+	// deactivate key, stored signatures must still be verifiable
+	key, err := pubkeyController.FindActivePubKey(opCtx, user1.GetID())
+	require.NoError(t, err)
+	require.NoError(t, pubkeyController.DeactivatePubKey(opCtx, user1.GetID(), key.GetID()))
+
+	// find signature
+	query := &signature.SignatureQuery{UserId: user1.GetID(), Month: time.Now().Format("2006-01")}
+	filter, err := query.Filter()
+	require.NoError(t, err)
+	signatures, _, err := manager.ListSignatures(opCtx, filter)
+	require.NoError(t, err)
+	require.Len(t, signatures, 1)
+	obj := signatures[0]
+	content, err := json.Marshal(cmd1)
+	require.NoError(t, err)
+	assert.NotEqual(t, string(content), obj.Message)
+	assert.Equal(t, signature.MessageFormatZstdEncrypted, obj.Format)
+
+	// read message
+	message, err := manager.ReadMessage(obj)
+	require.NoError(t, err)
+	assert.Equal(t, string(content), string(message))
+	_, err = manager.VerifyStored(opCtx, obj, message)
+	assert.NoError(t, err)
+
+	// record is read according to its own format after encryption is disabled in configuration
+	manager.ENCRYPT_MESSAGE_STORE = false
+	message, err = manager.ReadMessage(obj)
+	require.NoError(t, err)
+	assert.Equal(t, string(content), string(message))
+	manager.ENCRYPT_MESSAGE_STORE = true
+
+	// legacy record without format is read according to configuration
+	legacy := *obj
+	legacy.Format = ""
+	message, err = manager.ReadMessage(&legacy)
+	require.NoError(t, err)
+	assert.Equal(t, string(content), string(message))
+
+	// tampered record is not verified
+	tampered := *obj
+	extraData, err := json.Marshal([]string{http.MethodPut, path, timestamp, nonce})
+	require.NoError(t, err)
+	tampered.ExtraData = string(extraData)
+	_, err = manager.VerifyStored(opCtx, &tampered, message)
+	assert.Error(t, err)
+	opCtx.ClearError()
+
+	// export evidence
+	evidence, err := manager.Evidence(opCtx, obj.Context)
+	require.NoError(t, err)
+	assert.True(t, evidence.Verified)
+	assert.Equal(t, string(content), evidence.Message)
+	assert.Equal(t, []string{http.MethodPost, path, timestamp, nonce}, evidence.ExtraData)
+	assert.Equal(t, string(pubKey1), evidence.PubKey)
+	assert.Equal(t, crypt_utils.RSA_H256_SIGNATURE, evidence.PubKeyAlgorithm)
+	assert.True(t, evidence.CheckBundleHash())
+
+	b, err := json.Marshal(evidence)
+	require.NoError(t, err)
+	restored := &signature.SignatureEvidence{}
+	require.NoError(t, json.Unmarshal(b, restored))
+	assert.True(t, restored.CheckBundleHash())
+	restored.Message = "forged"
+	assert.False(t, restored.CheckBundleHash())
+
+	// only records with encrypted format are re-encrypted
+	plain := *obj
+	plain.InitObject()
+	plain.Context = "plain_context"
+	plain.Format = signature.MessageFormatPlain
+	plain.Message = string(content)
+	require.NoError(t, op_context.DB(opCtx).Create(opCtx, &plain))
+	stats, err := signature.ReencryptMessages(opCtx, manager.KeyRing())
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Total)
+	assert.Equal(t, 0, stats.Failed)
+}
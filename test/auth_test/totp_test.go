@@ -0,0 +1,131 @@
+package auth_test
+
+import (
+	"encoding/base32"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evgeniums/go-utils/pkg/auth/auth_methods/auth_totp"
+	"github.com/evgeniums/go-utils/pkg/test_utils"
+	"github.com/evgeniums/go-utils/pkg/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTotpCodes(t *testing.T) {
+
+	// test vectors from RFC 6238
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	params := auth_totp.DefaultTotpParams()
+	params.Digits = 8
+
+	vectors := map[int64]string{
+		59:          "94287082",
+		1111111109:  "07081804",
+		1111111111:  "14050471",
+		1234567890:  "89005924",
+		2000000000:  "69279037",
+		20000000000: "65353130",
+	}
+	for ts, expected := range vectors {
+		code, err := auth_totp.TotpCode(secret, time.Unix(ts, 0), params)
+		require.NoError(t, err)
+		assert.Equal(t, expected, code)
+	}
+
+	now := time.Now()
+	code, err := auth_totp.TotpCode(secret, now.Add(-30*time.Second), params)
+	require.NoError(t, err)
+	ok, _, err := auth_totp.ValidateTotpCode(secret, code, now, params)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	code, err = auth_totp.TotpCode(secret, now.Add(-90*time.Second), params)
+	require.NoError(t, err)
+	ok, _, err = auth_totp.ValidateTotpCode(secret, code, now, params)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	uri := auth_totp.OtpauthUri("Issuer", "user1", secret, params)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Issuer:user1?"))
+}
+
+func TestTotp(t *testing.T) {
+	app, users, server, opCtx := initOpTest(t, "totp_test.jsonc")
+	defer app.Close()
+
+	// create user1
+	login1 := "user1"
+	password1 := "password1"
+	user1, err := users.Add(opCtx, login1, password1, user.Phone("12345678", &User{}), user.Email("user1@example.com", &User{}))
+	require.NoErrorf(t, err, "failed to add user")
+	require.NotNil(t, user1)
+
+	// prepare client
+	client := test_utils.PrepareHttpClient(t, test_utils.BBGinEngine(t, server))
+	client.Login(login1, password1)
+
+	// start enrollment
+	resp := client.Get("/status/logged", nil)
+	test_utils.CheckResponse(t, resp, &test_utils.Expected{HttpCode: http.StatusUnauthorized, Error: auth_totp.ErrorCodeEnrollmentRequired})
+	enrollToken := resp.Object.Header().Get("x-auth-totp-enroll-token")
+	secret := resp.Object.Header().Get("x-auth-totp-secret")
+	uri := resp.Object.Header().Get("x-auth-totp-uri")
+	require.NotEmpty(t, enrollToken)
+	require.NotEmpty(t, secret)
+	assert.Contains(t, uri, secret)
+	params := auth_totp.DefaultTotpParams()
+
+	// confirm enrollment with invalid code
+	resp = client.Get("/status/logged", nil, map[string]string{"x-auth-totp-enroll-token": enrollToken, "x-auth-totp-code": "000000"})
+	test_utils.CheckResponse(t, resp, &test_utils.Expected{HttpCode: http.StatusUnauthorized, Error: auth_totp.ErrorCodeInvalidCode})
+
+	// confirm enrollment
+	code, err := auth_totp.TotpCode(secret, time.Now(), params)
+	require.NoError(t, err)
+	resp = client.Get("/status/logged", nil, map[string]string{"x-auth-totp-enroll-token": enrollToken, "x-auth-totp-code": code})
+	test_utils.CheckResponse(t, resp, &test_utils.Expected{HttpCode: http.StatusOK})
+	recoveryCodes := strings.Split(resp.Object.Header().Get("x-auth-totp-recovery-codes"), ",")
+	require.Len(t, recoveryCodes, 3)
+
+	// secret must be encrypted in database
+	dbUser, err := users.FindByLogin(opCtx, login1)
+	require.NoError(t, err)
+	assert.NotEmpty(t, dbUser.TotpSecret())
+	assert.NotEqual(t, secret, dbUser.TotpSecret())
+	assert.Len(t, dbUser.TotpRecoveryCodes(), 3)
+
+	// request without code
+	resp = client.Get("/status/logged", nil)
+	test_utils.CheckResponse(t, resp, &test_utils.Expected{HttpCode: http.StatusUnauthorized, Error: auth_totp.ErrorCodeTotpRequired})
+
+	// replay the same code
+	resp = client.Get("/status/logged", nil, map[string]string{"x-auth-totp-code": code})
+	test_utils.CheckResponse(t, resp, &test_utils.Expected{HttpCode: http.StatusUnauthorized, Error: auth_totp.ErrorCodeInvalidCode})
+
+	// next code
+	code, err = auth_totp.TotpCode(secret, time.Now().Add(time.Duration(params.Period)*time.Second), params)
+	require.NoError(t, err)
+	resp = client.Get("/status/logged", nil, map[string]string{"x-auth-totp-code": code})
+	test_utils.CheckResponse(t, resp, &test_utils.Expected{HttpCode: http.StatusOK})
+
+	// recovery code can be used only once
+	resp = client.Get("/status/logged", nil, map[string]string{"x-auth-totp-recovery-code": strings.ToUpper(recoveryCodes[1])})
+	test_utils.CheckResponse(t, resp, &test_utils.Expected{HttpCode: http.StatusOK})
+	resp = client.Get("/status/logged", nil, map[string]string{"x-auth-totp-recovery-code": recoveryCodes[1]})
+	test_utils.CheckResponse(t, resp, &test_utils.Expected{HttpCode: http.StatusUnauthorized, Error: auth_totp.ErrorCodeInvalidRecoveryCode})
+	dbUser, err = users.FindByLogin(opCtx, login1)
+	require.NoError(t, err)
+	assert.Len(t, dbUser.TotpRecoveryCodes(), 2)
+
+	// too many tries, invalid recovery code above is counted too
+	resp = client.Get("/status/logged", nil, map[string]string{"x-auth-totp-code": "000000"})
+	test_utils.CheckResponse(t, resp, &test_utils.Expected{HttpCode: http.StatusUnauthorized, Error: auth_totp.ErrorCodeInvalidCode})
+	resp = client.Get("/status/logged", nil, map[string]string{"x-auth-totp-code": "000000"})
+	test_utils.CheckResponse(t, resp, &test_utils.Expected{HttpCode: http.StatusUnauthorized, Error: auth_totp.ErrorCodeInvalidCode})
+	resp = client.Get("/status/logged", nil, map[string]string{"x-auth-totp-code": "000000"})
+	test_utils.CheckResponse(t, resp, &test_utils.Expected{HttpCode: http.StatusUnauthorized, Error: auth_totp.ErrorCodeTooManyTries})
+	resp = client.Get("/status/logged", nil, map[string]string{"x-auth-totp-recovery-code": recoveryCodes[0]})
+	test_utils.CheckResponse(t, resp, &test_utils.Expected{HttpCode: http.StatusUnauthorized, Error: auth_totp.ErrorCodeTooManyTries})
+}
@@ -0,0 +1,182 @@
+package cache_test
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/evgeniums/go-utils/pkg/cache/inmem_cache"
+	"github.com/evgeniums/go-utils/pkg/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func inmemKeys(t *testing.T, c *inmem_cache.InmemCache[string]) []string {
+	keys, err := c.Keys()
+	require.NoError(t, err)
+	return keys
+}
+
+func TestInmemCacheLRU(t *testing.T) {
+
+	c := inmem_cache.New[string](inmem_cache.InmemCacheConfig{MAX_ENTRIES: 3})
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, c.Set(fmt.Sprintf("key%d", i), "value"))
+	}
+
+	// recently used entry is kept, least recently used is evicted
+	var val string
+	found, _ := c.Get("key1", &val)
+	require.True(t, found)
+	require.NoError(t, c.Set("key4", "value"))
+	assert.ElementsMatch(t, []string{"key1", "key3", "key4"}, inmemKeys(t, c))
+
+	found, _ = c.Get("key2", &val)
+	assert.False(t, found)
+
+	stats := c.Stats()
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, uint64(1), stats.Evictions)
+	assert.Equal(t, 3, stats.Entries)
+}
+
+func TestInmemCacheLFU(t *testing.T) {
+
+	c := inmem_cache.New[string](inmem_cache.InmemCacheConfig{MAX_ENTRIES: 3, EVICTION_POLICY: inmem_cache.EvictionLFU})
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, c.Set(fmt.Sprintf("key%d", i), "value"))
+	}
+
+	// frequently used entries are kept even if they were not used recently
+	var val string
+	for i := 0; i < 3; i++ {
+		c.Get("key1", &val)
+		c.Get("key2", &val)
+	}
+	c.Get("key3", &val)
+	require.NoError(t, c.Set("key4", "value"))
+	assert.ElementsMatch(t, []string{"key1", "key2", "key4"}, inmemKeys(t, c))
+
+	require.NoError(t, c.Set("key5", "value"))
+	assert.ElementsMatch(t, []string{"key1", "key2", "key5"}, inmemKeys(t, c))
+	assert.Equal(t, uint64(2), c.Stats().Evictions)
+}
+
+func TestInmemCacheBytesAndExpiration(t *testing.T) {
+
+	c := inmem_cache.New[string](inmem_cache.InmemCacheConfig{MAX_BYTES: 100})
+	c.SetSizer(func(key string, value string) int64 { return int64(len(value)) })
+
+	require.NoError(t, c.Set("key1", string(make([]byte, 40))))
+	require.NoError(t, c.Set("key2", string(make([]byte, 40))))
+	assert.Equal(t, int64(80), c.Stats().Bytes)
+	require.NoError(t, c.Set("key3", string(make([]byte, 40))))
+	assert.ElementsMatch(t, []string{"key2", "key3"}, inmemKeys(t, c))
+	assert.Equal(t, int64(80), c.Stats().Bytes)
+
+	// expired entries are removed
+	require.NoError(t, c.Set("key2", "short", 1))
+	time.Sleep(1100 * time.Millisecond)
+	c.DeleteExpired()
+	assert.ElementsMatch(t, []string{"key3"}, inmemKeys(t, c))
+	stats := c.Stats()
+	assert.Equal(t, uint64(1), stats.Expirations)
+	assert.Equal(t, int64(40), stats.Bytes)
+}
+
+func TestInmemCacheConfig(t *testing.T) {
+
+	app := test_utils.InitAppContext(t, testDir, nil, "cache_test.json")
+	defer app.Close()
+
+	c := inmem_cache.New[string]()
+	require.NoError(t, c.Init(app.Cfg(), app.Logger(), app.Validator()))
+	assert.Equal(t, 2, c.MAX_ENTRIES)
+	assert.Equal(t, inmem_cache.EvictionLFU, c.EVICTION_POLICY)
+
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, app.Cache().Set(fmt.Sprintf("key%d", i), i))
+	}
+	keys, err := app.Cache().Keys()
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+}
+
+func TestInmemCacheDefaultLimits(t *testing.T) {
+
+	app := test_utils.InitAppContext(t, testDir, nil, "cache_default_test.json")
+	defer app.Close()
+
+	c := inmem_cache.New[string]()
+	assert.Equal(t, inmem_cache.DefaultMaxEntries, c.MAX_ENTRIES)
+	assert.Equal(t, inmem_cache.DefaultMaxBytes, c.MAX_BYTES)
+
+	require.NoError(t, c.Init(app.Cfg(), app.Logger(), app.Validator()))
+	assert.Equal(t, inmem_cache.DefaultMaxEntries, c.MAX_ENTRIES)
+	assert.Equal(t, inmem_cache.DefaultMaxBytes, c.MAX_BYTES)
+}
+
+func TestInmemCacheEntryTooLarge(t *testing.T) {
+
+	c := inmem_cache.New[string](inmem_cache.InmemCacheConfig{MAX_BYTES: 100})
+	c.SetSizer(func(key string, value string) int64 { return int64(len(value)) })
+
+	require.NoError(t, c.Set("key1", string(make([]byte, 40))))
+	require.NoError(t, c.Set("key2", string(make([]byte, 40))))
+
+	// too large entry is rejected without evicting other entries
+	assert.ErrorIs(t, c.Set("key3", string(make([]byte, 101))), inmem_cache.ErrEntryTooLarge)
+	set, err := c.SetNX("key3", string(make([]byte, 101)))
+	assert.ErrorIs(t, err, inmem_cache.ErrEntryTooLarge)
+	assert.False(t, set)
+	assert.ElementsMatch(t, []string{"key1", "key2"}, inmemKeys(t, c))
+	stats := c.Stats()
+	assert.Equal(t, uint64(0), stats.Evictions)
+	assert.Equal(t, int64(80), stats.Bytes)
+
+	// previous value of updated key is dropped
+	assert.ErrorIs(t, c.Set("key1", string(make([]byte, 101))), inmem_cache.ErrEntryTooLarge)
+	assert.ElementsMatch(t, []string{"key2"}, inmemKeys(t, c))
+	assert.Equal(t, int64(40), c.Stats().Bytes)
+}
+
+func TestInmemCacheSetNX(t *testing.T) {
+
+	c := inmem_cache.New[string]()
+
+	set, err := c.SetNX("key1", "value1", 1)
+	require.NoError(t, err)
+	assert.True(t, set)
+	set, err = c.SetNX("key1", "value2")
+	require.NoError(t, err)
+	assert.False(t, set)
+	var val string
+	found, _ := c.Get("key1", &val)
+	require.True(t, found)
+	assert.Equal(t, "value1", val)
+
+	// expired key can be set again
+	time.Sleep(1100 * time.Millisecond)
+	set, err = c.SetNX("key1", "value2")
+	require.NoError(t, err)
+	assert.True(t, set)
+
+	// only one of concurrent callers sets the key
+	var count int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			set, _ := c.SetNX("key2", "value")
+			if set {
+				atomic.AddInt32(&count, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), count)
+}
@@ -0,0 +1,179 @@
+package cache_test
+
+import (
+	"testing"
+
+	"github.com/evgeniums/go-utils/pkg/app_context"
+	"github.com/evgeniums/go-utils/pkg/cache"
+	"github.com/evgeniums/go-utils/pkg/cache/inmem_cache"
+	"github.com/evgeniums/go-utils/pkg/cache/layered_cache"
+	"github.com/evgeniums/go-utils/pkg/message/message_json"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+	"github.com/evgeniums/go-utils/pkg/pubsub/pubsub_subscriber"
+	"github.com/evgeniums/go-utils/pkg/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSelfPoolPubsub delivers messages synchronously to all subscribed topics.
+type testSelfPoolPubsub struct {
+	app       app_context.Context
+	topics    []pubsub_subscriber.Topic
+	published int
+}
+
+func (p *testSelfPoolPubsub) PublishSelfPool(topicName string, msg interface{}) error {
+	p.published++
+	data, err := message_json.Serializer.SerializeMessage(msg)
+	if err != nil {
+		return err
+	}
+	ctx := test_utils.SimpleOpContext(p.app, "PublishSelfPool")
+	defer ctx.Close()
+	for _, topic := range p.topics {
+		if topic.Name() == topicName {
+			err = topic.Handle(ctx, data, message_json.Serializer)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (p *testSelfPoolPubsub) SubscribeSelfPool(ctx op_context.Context, topic pubsub_subscriber.Topic) (string, error) {
+	p.topics = append(p.topics, topic)
+	return topic.Name(), nil
+}
+
+func (p *testSelfPoolPubsub) UnsubscribeSelfPool(topicName string) {
+}
+
+func TestLayeredCache(t *testing.T) {
+
+	app := test_utils.InitAppContext(t, testDir, nil, "cache_test.json")
+	defer app.Close()
+	ctx := test_utils.SimpleOpContext(app, "TestLayeredCache")
+	defer ctx.Close()
+
+	// two instances share the same L2
+	l2 := inmem_cache.New[string]()
+	pubsub := &testSelfPoolPubsub{app: app}
+	layered1 := layered_cache.New(l2)
+	layered2 := layered_cache.New(l2)
+	require.NoError(t, layered1.EnableInvalidation(ctx, pubsub))
+	require.NoError(t, layered2.EnableInvalidation(ctx, pubsub))
+	cache1 := cache.New(layered1)
+	cache2 := cache.New(layered2)
+
+	obj := &loadedObject{}
+	require.NoError(t, cache1.Set("key1", &loadedObject{Name: "object1", Count: 1}))
+	found, err := cache2.Get("key1", obj)
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, 1, obj.Count)
+
+	// L1 is used when L2 entry is gone
+	require.NoError(t, l2.Unset("key1"))
+	found, err = cache2.Get("key1", obj)
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	// update in one instance evicts L1 copy in other instance
+	require.NoError(t, cache1.Set("key1", &loadedObject{Name: "object1", Count: 2}))
+	found, err = cache2.Get("key1", obj)
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, 2, obj.Count)
+
+	// unset in one instance is seen by other instance
+	require.NoError(t, cache2.Unset("key1"))
+	found, err = cache1.Get("key1", obj)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	// clear evicts all L1 entries in other instance
+	require.NoError(t, cache1.Set("key2", &loadedObject{Name: "object2"}))
+	found, _ = cache2.Get("key2", obj)
+	require.True(t, found)
+	require.NoError(t, cache1.Clear())
+	found, err = cache2.Get("key2", obj)
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestLayeredCacheL2OnlyKeys(t *testing.T) {
+
+	app := test_utils.InitAppContext(t, testDir, nil, "cache_test.json")
+	defer app.Close()
+	ctx := test_utils.SimpleOpContext(app, "TestLayeredCacheL2OnlyKeys")
+	defer ctx.Close()
+
+	l2 := inmem_cache.New[string]()
+	pubsub := &testSelfPoolPubsub{app: app}
+	layered := layered_cache.New(l2)
+	require.NoError(t, layered.Init(app.Cfg(), app.Logger(), app.Validator()))
+	assert.Equal(t, layered_cache.DefaultL2OnlyPrefixes, layered.L2_ONLY_PREFIXES)
+	require.NoError(t, layered.EnableInvalidation(ctx, pubsub))
+
+	// nonce is neither kept in L1 nor published
+	set, err := layered.SetNX("auth-nonce/signature/user1/nonce1", "1", 60)
+	require.NoError(t, err)
+	assert.True(t, set)
+	set, err = layered.SetNX("auth-nonce/signature/user1/nonce1", "1", 60)
+	require.NoError(t, err)
+	assert.False(t, set)
+	require.NoError(t, layered.Set("totp-last/user1", "1"))
+	assert.Equal(t, 0, pubsub.published)
+	assert.Equal(t, 0, layered.L1Stats().Entries)
+	var value string
+	found, err := layered.Get("totp-last/user1", &value)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 0, layered.L1Stats().Entries)
+
+	// other keys are cached in L1 and published
+	require.NoError(t, layered.Set("key1", "value1"))
+	assert.Equal(t, 1, pubsub.published)
+	assert.Equal(t, 1, layered.L1Stats().Entries)
+}
+
+// hookedL2 invokes hook after each read of value.
+type hookedL2 struct {
+	cache.StringCache
+	hook func()
+}
+
+func (h *hookedL2) Get(key string, value *string) (bool, error) {
+	found, err := h.StringCache.Get(key, value)
+	if h.hook != nil {
+		h.hook()
+	}
+	return found, err
+}
+
+func TestLayeredCacheInvalidationDuringRead(t *testing.T) {
+
+	l2 := inmem_cache.New[string]()
+	hooked := &hookedL2{StringCache: l2}
+	layered := layered_cache.New(hooked)
+	require.NoError(t, l2.Set("key1", "old"))
+
+	// invalidation arrives after old value was read from L2
+	hooked.hook = func() {
+		hooked.hook = nil
+		require.NoError(t, l2.Set("key1", "new"))
+		layered.Evict("key1")
+	}
+	var value string
+	found, err := layered.Get("key1", &value)
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "old", value)
+
+	// old value was not put to L1
+	found, err = layered.Get("key1", &value)
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "new", value)
+}
@@ -0,0 +1,160 @@
+package cache_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/evgeniums/go-utils/pkg/cache"
+	"github.com/evgeniums/go-utils/pkg/cache/inmem_cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type loadedObject struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func newLoadingCache(staleSeconds ...int) *cache.LoadingCache {
+	return cache.NewLoadingCache(cache.New(inmem_cache.New[string]()), staleSeconds...)
+}
+
+func TestGetOrLoad(t *testing.T) {
+
+	c := newLoadingCache()
+
+	var calls int32
+	loader := func() (*loadedObject, error) {
+		count := atomic.AddInt32(&calls, 1)
+		return &loadedObject{Name: "object1", Count: int(count)}, nil
+	}
+
+	// value is loaded once and then taken from cache
+	obj, err := cache.GetOrLoad(c, "key1", loader, 10)
+	require.NoError(t, err)
+	assert.Equal(t, &loadedObject{Name: "object1", Count: 1}, obj)
+	obj, err = cache.GetOrLoad(c, "key1", loader, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, obj.Count)
+
+	// invalidated value is reloaded
+	require.NoError(t, c.Invalidate("key1"))
+	obj, err = cache.GetOrLoad(c, "key1", loader, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, obj.Count)
+
+	// loader errors are not cached
+	_, err = cache.GetOrLoad(c, "key2", func() (int, error) { return 0, errors.New("failed") }, 10)
+	assert.Error(t, err)
+	v, err := cache.GetOrLoad(c, "key2", func() (int, error) { return 5, nil }, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 5, v)
+}
+
+func TestGetOrLoadSingleFlight(t *testing.T) {
+
+	c := newLoadingCache()
+
+	var calls int32
+	loader := func() (string, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(100 * time.Millisecond)
+		return "value", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := cache.GetOrLoad(c, "key1", loader, 10)
+			assert.NoError(t, err)
+			assert.Equal(t, "value", v)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), calls)
+}
+
+func TestGetOrLoadStaleWhileRevalidate(t *testing.T) {
+
+	c := newLoadingCache(10)
+
+	var calls int32
+	loader := func() (int, error) {
+		return int(atomic.AddInt32(&calls, 1)), nil
+	}
+
+	v, err := cache.GetOrLoad(c, "key1", loader, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+
+	// stale value is returned and reloaded in background
+	time.Sleep(1100 * time.Millisecond)
+	v, err = cache.GetOrLoad(c, "key1", loader, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 2 }, time.Second, 10*time.Millisecond)
+
+	v, err = cache.GetOrLoad(c, "key1", loader, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, v)
+}
+
+func TestGetOrLoadTags(t *testing.T) {
+
+	c := newLoadingCache()
+
+	var calls int32
+	loader := func() (int, error) {
+		return int(atomic.AddInt32(&calls, 1)), nil
+	}
+
+	tenancy1 := cache.TenancyTag("tenancy1")
+	tenancy2 := cache.TenancyTag("tenancy2")
+	user1 := cache.UserTag("user1")
+
+	v1, err := cache.GetOrLoad(c, "key1", loader, 0, tenancy1, user1)
+	require.NoError(t, err)
+	v2, err := cache.GetOrLoad(c, "key2", loader, 0, tenancy1)
+	require.NoError(t, err)
+	v3, err := cache.GetOrLoad(c, "key3", loader, 0, tenancy2)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, []int{v1, v2, v3})
+
+	// entries of invalidated user are reloaded
+	require.NoError(t, c.InvalidateTags(user1))
+	v1, _ = cache.GetOrLoad(c, "key1", loader, 0, tenancy1, user1)
+	v2, _ = cache.GetOrLoad(c, "key2", loader, 0, tenancy1)
+	v3, _ = cache.GetOrLoad(c, "key3", loader, 0, tenancy2)
+	assert.Equal(t, []int{4, 2, 3}, []int{v1, v2, v3})
+
+	// entries of invalidated tenancy are reloaded
+	require.NoError(t, c.InvalidateTags(tenancy1))
+	v1, _ = cache.GetOrLoad(c, "key1", loader, 0, tenancy1, user1)
+	v2, _ = cache.GetOrLoad(c, "key2", loader, 0, tenancy1)
+	v3, _ = cache.GetOrLoad(c, "key3", loader, 0, tenancy2)
+	assert.Equal(t, []int{5, 6, 3}, []int{v1, v2, v3})
+
+	// entries of evicted tag are reloaded
+	require.NoError(t, c.Cache().Unset("cache_tag:"+tenancy2))
+	v3, _ = cache.GetOrLoad(c, "key3", loader, 0, tenancy2)
+	assert.Equal(t, 7, v3)
+	v3, _ = cache.GetOrLoad(c, "key3", loader, 0, tenancy2)
+	assert.Equal(t, 7, v3)
+}
+
+func TestGetOrLoadNilInterface(t *testing.T) {
+
+	c := newLoadingCache()
+
+	loader := func() (error, error) {
+		return nil, nil
+	}
+	v, err := cache.GetOrLoad(c, "key1", loader, 0)
+	require.NoError(t, err)
+	assert.Nil(t, v)
+}
@@ -0,0 +1,123 @@
+package cache_test
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/evgeniums/go-utils/pkg/cache"
+	"github.com/evgeniums/go-utils/pkg/cache/cache_locker"
+	"github.com/evgeniums/go-utils/pkg/cache/db_locker"
+	"github.com/evgeniums/go-utils/pkg/cache/inmem_cache"
+	"github.com/evgeniums/go-utils/pkg/cron_scheduler"
+	"github.com/evgeniums/go-utils/pkg/pubsub/pubsub_outbox"
+	"github.com/evgeniums/go-utils/pkg/test_utils"
+	"github.com/evgeniums/go-utils/pkg/work_schedule"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _, testBasePath, _, _ = runtime.Caller(0)
+var testDir = filepath.Dir(testBasePath)
+
+func testLocker(t *testing.T, locker cache.Locker) {
+
+	ttl := time.Millisecond * 300
+
+	// obtain lock
+	lock1, err := cache.LockObject(locker, "test_lock", "object1", 1)
+	require.NoError(t, err)
+	require.NotNil(t, lock1)
+
+	// lock is busy
+	lock2, err := cache.LockObject(locker, "test_lock", "object1", 1)
+	require.NoError(t, err)
+	assert.Nil(t, lock2)
+
+	// other object can be locked
+	lock3, err := cache.LockObject(locker, "test_lock", "object2", 1)
+	require.NoError(t, err)
+	require.NotNil(t, lock3)
+	assert.NoError(t, lock3.Release())
+
+	// release and lock again
+	assert.NoError(t, lock1.Release())
+	lock1, err = locker.Lock("test_lock_object1", ttl)
+	require.NoError(t, err)
+	require.False(t, lock1.NotObtained())
+
+	// renew prolongs lock
+	time.Sleep(ttl / 2)
+	require.NoError(t, lock1.Renew(ttl))
+	time.Sleep(ttl / 2)
+	lock2, err = locker.Lock("test_lock_object1", ttl)
+	require.NoError(t, err)
+	assert.True(t, lock2.NotObtained())
+	assert.ErrorIs(t, lock2.Renew(ttl), cache.ErrLockNotHeld)
+
+	// expired lock can be taken over and can not be renewed by previous holder
+	time.Sleep(ttl)
+	lock2, err = locker.Lock("test_lock_object1", ttl)
+	require.NoError(t, err)
+	require.False(t, lock2.NotObtained())
+	assert.ErrorIs(t, lock1.Renew(ttl), cache.ErrLockNotHeld)
+
+	// release by previous holder does not release new lock
+	assert.NoError(t, lock1.Release())
+	lock3, err = locker.Lock("test_lock_object1", ttl)
+	require.NoError(t, err)
+	assert.True(t, lock3.NotObtained())
+	assert.NoError(t, lock2.Release())
+}
+
+func TestInmemLocker(t *testing.T) {
+	testLocker(t, inmem_cache.NewLocker())
+}
+
+func TestDbLocker(t *testing.T) {
+	app := test_utils.InitAppContext(t, testDir, db_locker.DbModels(), "cache_test.json")
+	defer app.Close()
+	testLocker(t, db_locker.NewLocker(app, app.Db()))
+}
+
+func TestDbLockerModels(t *testing.T) {
+
+	// lock table is migrated with models of components that can select database locker
+	models := map[string][]interface{}{
+		"cron_scheduler": cron_scheduler.DbModels(),
+		"pubsub_outbox":  pubsub_outbox.DbModels(),
+		"work_schedule":  work_schedule.DbModels(),
+	}
+	for name, dbModels := range models {
+		t.Run(name, func(t *testing.T) {
+			app := test_utils.InitAppContext(t, testDir, dbModels, "cache_test.json")
+			defer app.Close()
+			locker, err := cache_locker.New(app, cache_locker.LockerDb)
+			require.NoError(t, err)
+			testLocker(t, locker)
+		})
+	}
+}
+
+func TestKeepLock(t *testing.T) {
+
+	locker := inmem_cache.NewLocker()
+	ttl := time.Millisecond * 200
+
+	lock, err := locker.Lock("keep_lock", ttl)
+	require.NoError(t, err)
+	require.False(t, lock.NotObtained())
+	stop := cache.KeepLock(lock, ttl, ttl/4)
+
+	time.Sleep(ttl * 2)
+	lock2, err := locker.Lock("keep_lock", ttl)
+	require.NoError(t, err)
+	assert.True(t, lock2.NotObtained())
+
+	stop()
+	time.Sleep(ttl * 2)
+	lock2, err = locker.Lock("keep_lock", ttl)
+	require.NoError(t, err)
+	assert.False(t, lock2.NotObtained())
+}
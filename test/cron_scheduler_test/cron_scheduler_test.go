@@ -0,0 +1,250 @@
+package cron_scheduler_test
+
+import (
+	"errors"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/evgeniums/go-utils/pkg/app_context"
+	"github.com/evgeniums/go-utils/pkg/background_worker"
+	"github.com/evgeniums/go-utils/pkg/cron_scheduler"
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+	"github.com/evgeniums/go-utils/pkg/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _, testBasePath, _, _ = runtime.Caller(0)
+var testDir = filepath.Dir(testBasePath)
+
+func TestCronExpression(t *testing.T) {
+
+	type testCase struct {
+		expr string
+		from string
+		next string
+	}
+
+	cases := []testCase{
+		{"* * * * *", "2024-03-10T10:15:30Z", "2024-03-10T10:16:00Z"},
+		{"*/15 * * * *", "2024-03-10T10:15:00Z", "2024-03-10T10:30:00Z"},
+		{"0 9-17/4 * * *", "2024-03-10T13:00:00Z", "2024-03-10T17:00:00Z"},
+		{"0 0 * * MON-FRI", "2024-03-09T12:00:00Z", "2024-03-11T00:00:00Z"},
+		{"0 0 1,15 * *", "2024-03-02T00:00:00Z", "2024-03-15T00:00:00Z"},
+		{"0 0 31 * *", "2024-04-01T00:00:00Z", "2024-05-31T00:00:00Z"},
+		{"0 0 29 FEB *", "2024-03-01T00:00:00Z", "2028-02-29T00:00:00Z"},
+		{"0 0 13 * 5", "2024-03-10T00:00:00Z", "2024-03-13T00:00:00Z"},
+		{"0 12 * * 7", "2024-03-10T12:00:00Z", "2024-03-17T12:00:00Z"},
+		{"@daily", "2024-12-31T23:59:00Z", "2025-01-01T00:00:00Z"},
+	}
+
+	for _, c := range cases {
+		expr, err := cron_scheduler.ParseCronExpression(c.expr)
+		require.NoError(t, err, c.expr)
+		from, _ := time.Parse(time.RFC3339, c.from)
+		next, _ := time.Parse(time.RFC3339, c.next)
+		assert.Equal(t, next, expr.Next(from), c.expr)
+	}
+
+	for _, invalid := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "*/0 * * * *", "5-1 * * * *", "* * * * FOO"} {
+		_, err := cron_scheduler.ParseCronExpression(invalid)
+		assert.Error(t, err, invalid)
+	}
+
+	// time zone
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	expr, err := cron_scheduler.ParseCronExpression("30 2 * * *")
+	require.NoError(t, err)
+	from := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	next := expr.Next(from.In(berlin))
+	assert.Equal(t, time.Date(2024, 1, 11, 1, 30, 0, 0, time.UTC), next.UTC())
+}
+
+func TestCronExpressionDST(t *testing.T) {
+
+	type testCase struct {
+		location string
+		expr     string
+		from     string
+		next     string
+	}
+
+	cases := []testCase{
+		// wall clock time in the gap is delayed to the end of the gap
+		{"Europe/Berlin", "30 2 * * *", "2024-03-30T03:00:00+01:00", "2024-03-31T03:00:00+02:00"},
+		{"Europe/Berlin", "30 2 * * *", "2024-03-31T03:00:00+02:00", "2024-04-01T02:30:00+02:00"},
+		{"America/New_York", "30 2 * * *", "2024-03-09T03:00:00-05:00", "2024-03-10T03:00:00-04:00"},
+		{"Europe/Berlin", "* * * * *", "2024-03-31T01:59:00+01:00", "2024-03-31T03:00:00+02:00"},
+		{"Europe/Berlin", "0 3 * * *", "2024-03-30T04:00:00+01:00", "2024-03-31T03:00:00+02:00"},
+		{"Europe/Berlin", "15 4 * * *", "2024-03-31T01:00:00+01:00", "2024-03-31T04:15:00+02:00"},
+		// midnight in the gap
+		{"America/Santiago", "0 0 * * *", "2024-09-07T12:00:00-04:00", "2024-09-08T01:00:00-03:00"},
+		{"America/Santiago", "0 12 * * *", "2024-09-07T13:00:00-04:00", "2024-09-08T12:00:00-03:00"},
+		// repeated wall clock time
+		{"Europe/Berlin", "30 2 * * *", "2024-10-27T02:30:00+02:00", "2024-10-27T02:30:00+01:00"},
+	}
+
+	for _, c := range cases {
+		loc, err := time.LoadLocation(c.location)
+		require.NoError(t, err)
+		expr, err := cron_scheduler.ParseCronExpression(c.expr)
+		require.NoError(t, err, c.expr)
+		from, _ := time.Parse(time.RFC3339, c.from)
+		next, _ := time.Parse(time.RFC3339, c.next)
+		assert.True(t, next.Equal(expr.Next(from.In(loc))), "%s %s from %s: expected %s, got %s", c.location, c.expr, c.from, next, expr.Next(from.In(loc)))
+	}
+}
+
+type testJob struct {
+	runs  []time.Time
+	fail  bool
+	onRun func()
+}
+
+func (j *testJob) Run(ctx op_context.Context, scheduledTime time.Time) error {
+	j.runs = append(j.runs, scheduledTime)
+	if j.onRun != nil {
+		j.onRun()
+	}
+	if j.fail {
+		return errors.New("job failed")
+	}
+	return nil
+}
+
+func initScheduler(t *testing.T) (app_context.Context, *cron_scheduler.CronScheduler, *testJob, *testJob) {
+	app := test_utils.InitAppContext(t, testDir, cron_scheduler.DbModels(), "cron_scheduler_test.json")
+	everyMinute := &testJob{}
+	daily := &testJob{}
+	scheduler := cron_scheduler.NewCronScheduler("test_cron")
+	scheduler.RegisterJob("every_minute", "", everyMinute.Run)
+	scheduler.RegisterJob("daily", "", daily.Run)
+	scheduler.SetStopper(&background_worker.BackgroundStopperStub{})
+	require.NoError(t, scheduler.Init(app))
+	return app, scheduler, everyMinute, daily
+}
+
+func readState(t *testing.T, ctx op_context.Context, name string) *cron_scheduler.CronJobState {
+	state := &cron_scheduler.CronJobState{}
+	found, err := ctx.Db().FindByField(ctx, "name", name, state)
+	require.NoError(t, err)
+	require.True(t, found)
+	return state
+}
+
+func setNextRunAt(t *testing.T, ctx op_context.Context, name string, nextRunAt time.Time) {
+	state := readState(t, ctx, name)
+	require.NoError(t, db.Update(ctx.Db(), ctx, state, db.Fields{"next_run_at": nextRunAt}))
+}
+
+func TestMissedRunsPolicies(t *testing.T) {
+	app, scheduler, everyMinute, daily := initScheduler(t)
+	defer app.Close()
+	ctx := test_utils.SimpleOpContext(app, "TestMissedRunsPolicies")
+	defer ctx.Close()
+
+	// states are created on init
+	state := readState(t, ctx, "daily")
+	assert.Equal(t, "Europe/Berlin", state.Timezone)
+	assert.True(t, state.NextRunAt.After(time.Now()))
+
+	// not due jobs are not run
+	scheduler.ProcessJobs()
+	assert.Empty(t, everyMinute.runs)
+	assert.Empty(t, daily.runs)
+
+	// skip policy: runs missed for too long are skipped
+	job, ok := scheduler.Job("every_minute")
+	require.True(t, ok)
+	job.MISFIRE_THRESHOLD_SECONDS = 0
+	setNextRunAt(t, ctx, "every_minute", time.Now().Add(-time.Hour))
+	scheduler.ProcessJobs()
+	assert.Empty(t, everyMinute.runs)
+	state = readState(t, ctx, "every_minute")
+	assert.True(t, state.NextRunAt.After(time.Now()))
+	assert.Equal(t, 0, state.RunCount)
+
+	// skip policy: run within misfire threshold is executed
+	job.MISFIRE_THRESHOLD_SECONDS = 60
+	due := time.Now().Add(-10 * time.Second)
+	setNextRunAt(t, ctx, "every_minute", due)
+	scheduler.ProcessJobs()
+	require.Len(t, everyMinute.runs, 1)
+	state = readState(t, ctx, "every_minute")
+	assert.Equal(t, 1, state.RunCount)
+	assert.True(t, state.NextRunAt.After(time.Now()))
+
+	// once policy: only the latest of missed runs is executed
+	setNextRunAt(t, ctx, "daily", time.Now().Add(-72*time.Hour))
+	daily.fail = true
+	scheduler.ProcessJobs()
+	require.Len(t, daily.runs, 1)
+	assert.True(t, time.Since(daily.runs[0]) <= 24*time.Hour)
+	state = readState(t, ctx, "daily")
+	assert.Equal(t, 1, state.RunCount)
+	assert.Equal(t, 1, state.FailCount)
+	assert.Equal(t, "job failed", state.LastError)
+
+	// all policy: each missed run is executed
+	job.MISSED_RUNS = cron_scheduler.MissedRunsAll
+	job.MAX_CATCH_UP_RUNS = 3
+	everyMinute.runs = nil
+	setNextRunAt(t, ctx, "every_minute", time.Now().Add(-time.Hour))
+	scheduler.ProcessJobs()
+	assert.Len(t, everyMinute.runs, 3)
+
+	// all policy: interrupted runs are continued after the last executed run
+	stopper := &background_worker.BackgroundStopperStub{}
+	scheduler.SetStopper(stopper)
+	everyMinute.runs = nil
+	everyMinute.onRun = func() {
+		if len(everyMinute.runs) == 2 {
+			stopper.Stop()
+		}
+	}
+	setNextRunAt(t, ctx, "every_minute", time.Now().Add(-time.Hour))
+	scheduler.ProcessJobs()
+	require.Len(t, everyMinute.runs, 2)
+	state = readState(t, ctx, "every_minute")
+	assert.WithinDuration(t, everyMinute.runs[1].Add(time.Minute), state.NextRunAt, time.Second)
+}
+
+func TestPauseTrigger(t *testing.T) {
+	app, scheduler, everyMinute, _ := initScheduler(t)
+	defer app.Close()
+	ctx := test_utils.SimpleOpContext(app, "TestPauseTrigger")
+	defer ctx.Close()
+
+	require.NoError(t, scheduler.Pause(ctx, "every_minute"))
+	setNextRunAt(t, ctx, "every_minute", time.Now().Add(-5*time.Second))
+	scheduler.ProcessJobs()
+	assert.Empty(t, everyMinute.runs)
+
+	// paused job still can be triggered manually
+	require.NoError(t, scheduler.Trigger(ctx, "every_minute"))
+	assert.Len(t, everyMinute.runs, 1)
+	state := readState(t, ctx, "every_minute")
+	assert.True(t, state.Paused)
+	assert.Equal(t, 1, state.RunCount)
+
+	// locked job can not be triggered
+	lock, err := scheduler.Locker().Lock("cron_job_every_minute", time.Minute)
+	require.NoError(t, err)
+	assert.Error(t, scheduler.Trigger(ctx, "every_minute"))
+	require.NoError(t, lock.Release())
+
+	require.NoError(t, scheduler.Resume(ctx, "every_minute"))
+	state = readState(t, ctx, "every_minute")
+	assert.False(t, state.Paused)
+	assert.True(t, state.NextRunAt.After(time.Now()))
+
+	assert.Error(t, scheduler.Trigger(ctx, "unknown"))
+
+	jobs, _, err := scheduler.ListJobs(ctx, db.NewFilter())
+	require.NoError(t, err)
+	assert.Len(t, jobs, 2)
+}
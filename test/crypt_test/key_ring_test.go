@@ -0,0 +1,81 @@
+package crypt_test
+
+import (
+	"testing"
+
+	"github.com/evgeniums/go-utils/pkg/crypt_utils"
+	"github.com/evgeniums/go-utils/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyRing(t *testing.T) {
+
+	secret1 := "kjh^V3Aj*1oij'oaasd"
+	salt1 := []byte("982uJde3")
+	plaintext := []byte("Hello world")
+
+	// legacy ciphertext
+	legacyCipher, err := crypt_utils.NewAEAD(secret1, salt1)
+	require.NoError(t, err)
+	legacyCiphertext, err := legacyCipher.Encrypt(plaintext)
+	require.NoError(t, err)
+
+	keyRing := crypt_utils.NewKeyRing()
+	assert.True(t, keyRing.IsEmpty())
+	require.NoError(t, keyRing.AddKey(crypt_utils.KeyRingDefaultKeyId, secret1, salt1))
+	assert.Error(t, keyRing.AddKey(crypt_utils.KeyRingDefaultKeyId, secret1, salt1))
+	assert.Equal(t, crypt_utils.KeyRingDefaultKeyId, keyRing.ActiveKeyId())
+
+	decrypted, err := keyRing.Decrypt(legacyCiphertext)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	ciphertext1, err := keyRing.Encrypt(plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, crypt_utils.KeyRingDefaultKeyId, keyRing.KeyIdOf(ciphertext1))
+
+	// rotate key
+	require.NoError(t, keyRing.AddKey("key2", "Nd8*2jd91Kla;s0q1", []byte("1jd8Hw2Q")))
+	assert.Equal(t, crypt_utils.KeyRingDefaultKeyId, keyRing.ActiveKeyId())
+	assert.ErrorIs(t, keyRing.SetActiveKey("unknown"), crypt_utils.ErrUnknownKey)
+	require.NoError(t, keyRing.SetActiveKey("key2"))
+	assert.Equal(t, []string{crypt_utils.KeyRingDefaultKeyId, "key2"}, keyRing.KeyIds())
+
+	ciphertext2, err := keyRing.Encrypt(plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, "key2", keyRing.KeyIdOf(ciphertext2))
+	for _, ciphertext := range [][]byte{legacyCiphertext, ciphertext1, ciphertext2} {
+		decrypted, err = keyRing.Decrypt(ciphertext)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+	}
+
+	// re-encrypt
+	b64 := utils.Base64StringCoding{}
+	reencrypted, changed, err := keyRing.ReencryptB64(b64.Encode(ciphertext1))
+	require.NoError(t, err)
+	assert.True(t, changed)
+	decrypted, err = keyRing.DecryptB64(reencrypted)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+	_, changed, err = keyRing.ReencryptB64(reencrypted)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	// salt per ciphertext
+	salt := []byte("per-ciphertext-salt")
+	ciphertext3, err := keyRing.EncryptWithSalt(salt, plaintext)
+	require.NoError(t, err)
+	decrypted, err = keyRing.DecryptWithSalt(salt, ciphertext3)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+	_, err = keyRing.DecryptWithSalt([]byte("other salt"), ciphertext3)
+	assert.Error(t, err)
+
+	// key ring without old key can not decrypt old data
+	newRing := crypt_utils.NewKeyRing()
+	require.NoError(t, newRing.AddKey("key3", "abcdefgh12345678", salt1))
+	_, err = newRing.Decrypt(ciphertext1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,117 @@
+package crypt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/evgeniums/go-utils/pkg/crypt_utils"
+	"github.com/evgeniums/go-utils/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pemEncode(pemType string, der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: pemType, Bytes: der})
+}
+
+func pemPublicKey(t *testing.T, key interface{}) []byte {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return pemEncode("PUBLIC KEY", der)
+}
+
+func pemPrivateKey(t *testing.T, key interface{}) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return pemEncode("PRIVATE KEY", der)
+}
+
+func checkSignature(t *testing.T, algorithm string, privKey []byte, pubKey []byte) {
+
+	signer, err := crypt_utils.NewSigner(algorithm)
+	require.NoError(t, err)
+	require.NoError(t, signer.LoadKey(privKey, ""))
+
+	detected, err := crypt_utils.PubKeyAlgorithm(pubKey)
+	require.NoError(t, err)
+	assert.Equal(t, algorithm, detected)
+
+	verifier, err := crypt_utils.NewVerifier(algorithm)
+	require.NoError(t, err)
+	require.NoError(t, verifier.LoadKey(pubKey))
+
+	data := []byte("message to sign")
+	sig, err := crypt_utils.Sign(signer, data, "POST", "/path")
+	require.NoError(t, err)
+	assert.NoError(t, crypt_utils.VerifySignature(verifier, data, sig, "POST", "/path"))
+	assert.Error(t, crypt_utils.VerifySignature(verifier, data, sig, "PUT", "/path"))
+	assert.Error(t, crypt_utils.VerifySignature(verifier, []byte("other message"), sig, "POST", "/path"))
+}
+
+func TestEd25519Signature(t *testing.T) {
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	// PEM keys
+	checkSignature(t, crypt_utils.ED25519_SIGNATURE, pemPrivateKey(t, priv), pemPublicKey(t, pub))
+
+	// compact raw keys
+	checkSignature(t, crypt_utils.ED25519_SIGNATURE, []byte(utils.Base64Encode(priv.Seed())), []byte(utils.Base64Encode(pub)))
+
+	// pure Ed25519 over data followed by extra data
+	signer := crypt_utils.NewEd25519Signer()
+	signer.SetKey(priv)
+	sig, err := signer.Sign([]byte("message to sign"), "POST", "/path")
+	require.NoError(t, err)
+	assert.True(t, ed25519.Verify(pub, []byte("message to signPOST/path"), sig))
+	sig, err = signer.Sign([]byte("message to sign"))
+	require.NoError(t, err)
+	assert.Equal(t, ed25519.Sign(priv, []byte("message to sign")), sig)
+}
+
+func TestEcdsaSignature(t *testing.T) {
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	checkSignature(t, crypt_utils.ECDSA_P256_H256_SIGNATURE, pemPrivateKey(t, priv), pemPublicKey(t, &priv.PublicKey))
+
+	// SEC1 private key
+	der, err := x509.MarshalECPrivateKey(priv)
+	require.NoError(t, err)
+	checkSignature(t, crypt_utils.ECDSA_P256_H256_SIGNATURE, pemEncode("EC PRIVATE KEY", der), pemPublicKey(t, &priv.PublicKey))
+
+	// other curves are not supported
+	priv384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	_, err = crypt_utils.PubKeyAlgorithm(pemPublicKey(t, &priv384.PublicKey))
+	assert.Error(t, err)
+	verifier := crypt_utils.NewEcdsaVerifier()
+	assert.Error(t, verifier.LoadKey(pemPublicKey(t, &priv384.PublicKey)))
+}
+
+func TestMismatchedAlgorithm(t *testing.T) {
+
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	verifier := crypt_utils.NewEcdsaVerifier()
+	assert.Error(t, verifier.LoadKey(pemPublicKey(t, pub)))
+
+	_, err = crypt_utils.NewVerifier("unknown")
+	assert.Error(t, err)
+}
+
+func TestVerifierWithoutKey(t *testing.T) {
+
+	for _, algorithm := range []string{crypt_utils.ED25519_SIGNATURE, crypt_utils.ECDSA_P256_H256_SIGNATURE} {
+		verifier, err := crypt_utils.NewVerifier(algorithm)
+		require.NoError(t, err)
+		assert.Error(t, verifier.Verify([]byte("message"), []byte("signature")), algorithm)
+	}
+}
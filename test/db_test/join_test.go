@@ -0,0 +1,130 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/evgeniums/go-utils/pkg/common"
+	"github.com/evgeniums/go-utils/pkg/crud"
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Transfer struct {
+	common.ObjectBase
+	Amount         int    `gorm:"index" json:"amount"`
+	FromTerminalId string `gorm:"index" json:"from_terminal_id"`
+	ToTerminalId   string `gorm:"index" json:"to_terminal_id"`
+}
+
+type TransferItem struct {
+	Transfer `source:"transfers"`
+	FromName string `source:"from_terminal.name" json:"from_name" gorm:"index"`
+	ToName   string `source:"to_terminal.name" json:"to_name" gorm:"index"`
+}
+
+func TestJoinTypes(t *testing.T) {
+	app := test_utils.InitAppContext(t, testDir, append(dbModels(), &Transfer{}), "maindb.json")
+	defer app.Close()
+	crud := &crud.DbCRUD{}
+
+	addTerminal := func(name string) *Terminal {
+		terminal := &Terminal{}
+		terminal.InitObject()
+		terminal.SetName(name)
+		require.NoError(t, app.Db().Create(app, terminal))
+		return terminal
+	}
+	terminal1 := addTerminal("terminal1")
+	terminal2 := addTerminal("terminal2")
+	addTerminal("terminal3")
+
+	addTransfer := func(amount int, from string, to string) {
+		transfer := &Transfer{Amount: amount, FromTerminalId: from, ToTerminalId: to}
+		transfer.InitObject()
+		require.NoError(t, app.Db().Create(app, transfer))
+	}
+	addTransfer(10, terminal1.GetID(), terminal2.GetID())
+	addTransfer(20, terminal2.GetID(), terminal1.GetID())
+	addTransfer(30, terminal1.GetID(), "unknown")
+
+	// tables joined twice with aliases
+	join := func(name string, joinType db.JoinType, filter *db.Filter) []*TransferItem {
+		queryBuilder := func() (db.JoinQuery, error) {
+			return app.Db().Joiner().
+				Join(&Transfer{}, "from_terminal_id").On(&Terminal{}, "id").As("from_terminal").
+				Join(&Transfer{}, "to_terminal_id").On(&Terminal{}, "id").As("to_terminal").Type(joinType).
+				Destination(&TransferItem{})
+		}
+		opCtx := test_utils.SimpleOpContext(app, name)
+		var items []*TransferItem
+		_, err := crud.Join(opCtx, db.NewJoin(queryBuilder, name), filter, &items)
+		require.NoError(t, err)
+		return items
+	}
+	sorted := func() *db.Filter {
+		filter := db.NewFilter()
+		filter.SetSorting("amount")
+		return filter
+	}
+
+	items := join("LeftJoin", db.JoinLeft, sorted())
+	require.Len(t, items, 3)
+	assert.Equal(t, "terminal1", items[0].FromName)
+	assert.Equal(t, "terminal2", items[0].ToName)
+	assert.Equal(t, "terminal2", items[1].FromName)
+	assert.Equal(t, "terminal1", items[1].ToName)
+	assert.Equal(t, "terminal1", items[2].FromName)
+	assert.Equal(t, "", items[2].ToName)
+
+	items = join("InnerJoin", db.JoinInner, sorted())
+	require.Len(t, items, 2)
+	assert.Equal(t, 10, items[0].Amount)
+	assert.Equal(t, 20, items[1].Amount)
+
+	// terminal3 has no incoming transfers
+	items = join("RightJoin", db.JoinRight, sorted())
+	require.Len(t, items, 3)
+	assert.Equal(t, 0, items[0].Amount)
+	assert.Equal(t, "terminal3", items[0].ToName)
+
+	items = join("FullJoin", db.JoinFull, sorted())
+	require.Len(t, items, 4)
+
+	// plain names of filter fields are qualified with joined tables
+	filter := db.NewFilter()
+	filter.AddField("amount", 10)
+	items = join("LeftJoin", db.JoinLeft, filter)
+	require.Len(t, items, 1)
+	assert.Equal(t, "terminal2", items[0].ToName)
+
+	filter = db.NewFilter()
+	filter.AddFieldIn("from_name", "terminal2")
+	filter.AddInterval("amount", 5, 25)
+	items = join("LeftJoin", db.JoinLeft, filter)
+	require.Len(t, items, 1)
+	assert.Equal(t, 20, items[0].Amount)
+
+	// queries with joined fields
+	query := &db.Query{Fields: map[string]string{"from_name": "terminal1"}}
+	query.SortField = "to_name"
+	query.SortDirection = db.SORT_DESC
+	filter, err := app.Db().ParseFilterDirect(query, &TransferItem{}, "TransferItems")
+	require.NoError(t, err)
+	items = join("LeftJoin", db.JoinLeft, filter)
+	require.Len(t, items, 2)
+	assert.Equal(t, "terminal2", items[0].ToName)
+	assert.Equal(t, "", items[1].ToName)
+
+	// invalid join type and unknown alias
+	_, err = app.Db().Joiner().
+		Join(&Transfer{}, "from_terminal_id").On(&Terminal{}, "id").Type("CROSS").
+		Destination(&TransferItem{})
+	assert.Error(t, err)
+	_, err = app.Db().Joiner().
+		Join(&Transfer{}, "from_terminal_id").On(&Terminal{}, "id").
+		JoinAlias("to_terminal", "id").On(&Terminal{}, "id").
+		Destination(&TransferItem{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,135 @@
+package db_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/evgeniums/go-utils/pkg/crud"
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeysetPagination(t *testing.T) {
+	app := test_utils.InitAppContext(t, testDir, dbModels(), "maindb.json")
+	defer app.Close()
+
+	for i := 0; i < 7; i++ {
+		doc := &SampleModel1{}
+		doc.InitObject()
+		doc.Field1 = fmt.Sprintf("value%d", i)
+		doc.Field2 = "keyset"
+		require.NoError(t, app.Db().Create(app, doc))
+	}
+
+	filter := db.NewFilter()
+	filter.AddField("field2", "keyset")
+	filter.SetSorting("field1", db.SORT_DESC)
+	filter.Keyset = true
+	filter.Limit = 3
+	filter.Count = true
+
+	page := func(expected ...string) {
+		var docs []*SampleModel1
+		count, err := app.Db().FindWithFilter(app, filter, &docs)
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), count)
+		require.Len(t, docs, len(expected))
+		for i, doc := range docs {
+			assert.Equal(t, expected[i], doc.Field1)
+		}
+	}
+
+	page("value6", "value5", "value4")
+	assert.Empty(t, filter.PrevCursor)
+	require.NotEmpty(t, filter.NextCursor)
+
+	filter.SetCursor(filter.NextCursor)
+	page("value3", "value2", "value1")
+	require.NotEmpty(t, filter.PrevCursor)
+	require.NotEmpty(t, filter.NextCursor)
+	prevCursor := filter.PrevCursor
+
+	filter.SetCursor(filter.NextCursor)
+	page("value0")
+	assert.Empty(t, filter.NextCursor)
+	require.NotEmpty(t, filter.PrevCursor)
+
+	filter.SetCursor(filter.PrevCursor)
+	page("value3", "value2", "value1")
+
+	filter.SetCursor(prevCursor)
+	page("value6", "value5", "value4")
+	assert.Empty(t, filter.PrevCursor)
+	require.NotEmpty(t, filter.NextCursor)
+
+	// no next page if the last page is full
+	filter.SetCursor(filter.NextCursor)
+	filter.Limit = 4
+	page("value3", "value2", "value1", "value0")
+	assert.Empty(t, filter.NextCursor)
+	require.NotEmpty(t, filter.PrevCursor)
+
+	// no previous page if the first page is full
+	filter.SetCursor(filter.PrevCursor)
+	filter.Limit = 3
+	page("value6", "value5", "value4")
+	assert.Empty(t, filter.PrevCursor)
+	require.NotEmpty(t, filter.NextCursor)
+
+	query := filter.ToQuery()
+	query.Cursor = "invalid cursor"
+	_, err := app.Db().ParseFilterDirect(query, &SampleModel1{}, "keyset")
+	assert.Error(t, err)
+}
+
+type SampleJoinItem struct {
+	SampleModel1 `source:"sample_model1"`
+	Field3       int `source:"sample_model2.field2" json:"field3"`
+}
+
+func TestKeysetJoin(t *testing.T) {
+	app := test_utils.InitAppContext(t, testDir, dbModels(), "maindb.json")
+	defer app.Close()
+
+	for i := 0; i < 5; i++ {
+		doc1 := &SampleModel1{}
+		doc1.InitObject()
+		doc1.Field1 = fmt.Sprintf("join%d", i)
+		require.NoError(t, app.Db().Create(app, doc1))
+		doc2 := &SampleModel2{}
+		doc2.InitObject()
+		doc2.Field1 = doc1.Field1
+		doc2.Field2 = i
+		require.NoError(t, app.Db().Create(app, doc2))
+	}
+
+	queryBuilder := func() (db.JoinQuery, error) {
+		return app.Db().Joiner().
+			Join(&SampleModel1{}, "field1").On(&SampleModel2{}, "field1").Type(db.JoinLeft).
+			Destination(&SampleJoinItem{})
+	}
+
+	// pages are ordered by ID of destination without sort field
+	filter := db.NewFilter()
+	filter.Keyset = true
+	filter.Limit = 2
+	crud := &crud.DbCRUD{}
+	opCtx := test_utils.SimpleOpContext(app, "keyset_join")
+	var ids []string
+	for {
+		var items []*SampleJoinItem
+		_, err := crud.Join(opCtx, db.NewJoin(queryBuilder, "KeysetJoin"), filter, &items)
+		require.NoError(t, err)
+		for _, item := range items {
+			ids = append(ids, item.GetID())
+		}
+		if filter.NextCursor == "" {
+			break
+		}
+		filter.SetCursor(filter.NextCursor)
+	}
+	require.Len(t, ids, 5)
+	assert.IsIncreasing(t, ids)
+}
@@ -0,0 +1,479 @@
+package db_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/evgeniums/go-utils/pkg/app_context"
+	"github.com/evgeniums/go-utils/pkg/customer"
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/db/db_factory"
+	"github.com/evgeniums/go-utils/pkg/db/db_gorm"
+	"github.com/evgeniums/go-utils/pkg/db/db_mongo"
+	"github.com/evgeniums/go-utils/pkg/multitenancy"
+	"github.com/evgeniums/go-utils/pkg/pool"
+	"github.com/evgeniums/go-utils/pkg/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func joinPipeline(t *testing.T, q db.JoinQuery, filter *db.Filter) mongo.Pipeline {
+	joinQuery, ok := q.(*db_mongo.JoinQuery)
+	require.True(t, ok)
+	pipeline, err := joinQuery.Pipeline(filter)
+	require.NoError(t, err)
+	return pipeline
+}
+
+func stage(pipeline mongo.Pipeline, name string) interface{} {
+	for _, s := range pipeline {
+		if s[0].Key == name {
+			return s[0].Value
+		}
+	}
+	return nil
+}
+
+func projection(pipeline mongo.Pipeline) map[string]interface{} {
+	result := make(map[string]interface{})
+	project, _ := stage(pipeline, "$project").(bson.D)
+	for _, e := range project {
+		result[e.Key] = e.Value
+	}
+	return result
+}
+
+func TestMongoFilter(t *testing.T) {
+
+	filter := db.NewFilter()
+	filter.AddField("field1", "value1")
+	filter.AddFieldIn("sample_model2s.field2", 1, 2)
+	filter.AddInterval("field2", 1, 10)
+	filter.Intervals["field2"].ToOpen = true
+	filter.SetSorting("field1", db.SORT_DESC)
+	filter.Offset = 10
+	filter.Limit = 5
+
+	match, opts, err := db_mongo.FindQuery(filter, &db_mongo.Paginator{MaxLimit: 3}, &SampleModel2{})
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{"$and": []bson.M{
+		{"field1": "value1"},
+		{"field2": bson.M{"$in": []interface{}{1, 2}}},
+		{"field2": bson.M{"$gte": 1, "$lt": 10}},
+	}}, match)
+	assert.Equal(t, bson.D{{Key: "field1", Value: -1}}, opts.Sort)
+	assert.Equal(t, int64(10), *opts.Skip)
+	assert.Equal(t, int64(3), *opts.Limit)
+
+	filter = db.NewFilter()
+	filter.AddLike("field1", "val_e%", true)
+	match, _, err = db_mongo.FindQuery(filter, nil, &SampleModel1{})
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{"field1": primitive.Regex{Pattern: "^val.e.*$", Options: "i"}}, match)
+
+	// cursor value is converted to type of sort field
+	filter = db.NewFilter()
+	filter.AddField("field1", "value1")
+	filter.SetSorting("field2", db.SORT_DESC)
+	filter.SetCursor(db.EncodeKeysetCursor(5, "id5", false))
+	filter.Offset = 10
+	filter.Limit = 3
+	match, opts, err = db_mongo.FindQuery(filter, nil, &SampleModel2{})
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{"$and": bson.A{
+		bson.M{"field1": "value1"},
+		bson.M{"$or": bson.A{
+			bson.M{"field2": bson.M{"$lt": int64(5)}},
+			bson.M{"field2": int64(5), "id": bson.M{"$lt": "id5"}},
+		}},
+	}}, match)
+	assert.Equal(t, bson.D{{Key: "field2", Value: -1}, {Key: "id", Value: -1}}, opts.Sort)
+	assert.Nil(t, opts.Skip)
+	assert.Equal(t, int64(3), *opts.Limit)
+
+	// backward cursor reverses order
+	filter.SetCursor(db.EncodeKeysetCursor(5, "id5", true))
+	match, opts, err = db_mongo.FindQuery(filter, nil, &SampleModel2{})
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{"$gt": int64(5)}, match["$and"].(bson.A)[1].(bson.M)["$or"].(bson.A)[0].(bson.M)["field2"])
+	assert.Equal(t, bson.D{{Key: "field2", Value: 1}, {Key: "id", Value: 1}}, opts.Sort)
+
+	filter.SetCursor("invalid cursor")
+	_, _, err = db_mongo.FindQuery(filter, nil, &SampleModel2{})
+	assert.Error(t, err)
+}
+
+func TestMongoExpression(t *testing.T) {
+
+	m := db_mongo.New()
+
+	expr, ok := m.MakeExpression(`{"$add": ["$counter", ?]}`, 5).(*db_mongo.Expression)
+	require.True(t, ok)
+	value, err := expr.Value()
+	require.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "$add", Value: bson.A{"$counter", bson.D{{Key: "$literal", Value: 5}}}}}, value)
+
+	// question marks in strings are not placeholders
+	expr = m.MakeExpression(`{"$concat": ["$name", "?", ?]}`, "$suffix").(*db_mongo.Expression)
+	value, err = expr.Value()
+	require.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "$concat", Value: bson.A{"$name", "?", bson.D{{Key: "$literal", Value: "$suffix"}}}}}, value)
+
+	_, err = m.MakeExpression(`{"$add": ["$counter", ?, ?]}`, 5).(*db_mongo.Expression).Value()
+	assert.Error(t, err)
+	_, err = m.MakeExpression(`{"$add": ["$counter", 1]}`, 5).(*db_mongo.Expression).Value()
+	assert.Error(t, err)
+	_, err = m.MakeExpression(`{"$add": `).(*db_mongo.Expression).Value()
+	assert.Error(t, err)
+}
+
+func TestMongoJoinPipeline(t *testing.T) {
+
+	app := test_utils.InitAppContext(t, testDir, dbModels(), "maindb.json")
+	defer app.Close()
+	m := db_mongo.New()
+
+	// tables joined twice with aliases
+	joinQuery := func(joinType db.JoinType) db.JoinQuery {
+		q, err := m.Joiner().
+			Join(&Transfer{}, "from_terminal_id").On(&Terminal{}, "id").As("from_terminal").
+			Join(&Transfer{}, "to_terminal_id").On(&Terminal{}, "id").As("to_terminal").Type(joinType).
+			Destination(&TransferItem{})
+		require.NoError(t, err)
+		return q
+	}
+	q := joinQuery(db.JoinInner)
+
+	filter := db.NewFilter()
+	filter.AddField("from_name", "terminal1")
+	filter.AddField("transfers.amount", 10)
+	filter.SetSorting("to_name", db.SORT_DESC)
+	filter.Offset = 1
+	filter.Limit = 2
+	pipeline := joinPipeline(t, q, filter)
+	require.Len(t, pipeline, 9)
+	assert.Equal(t, bson.D{{Key: "$lookup", Value: bson.D{
+		{Key: "from", Value: "terminals"},
+		{Key: "localField", Value: "from_terminal_id"},
+		{Key: "foreignField", Value: "id"},
+		{Key: "as", Value: "from_terminal"},
+	}}}, pipeline[0])
+	assert.Equal(t, bson.D{{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$from_terminal"}, {Key: "preserveNullAndEmptyArrays", Value: true}}}}, pipeline[1])
+	assert.Equal(t, "to_terminal", pipeline[2][0].Value.(bson.D).Map()["as"])
+	assert.Equal(t, bson.D{{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$to_terminal"}, {Key: "preserveNullAndEmptyArrays", Value: false}}}}, pipeline[3])
+	assert.Equal(t, bson.M{"from_terminal.name": "terminal1", "amount": 10}, stage(pipeline, "$match"))
+	assert.Equal(t, bson.D{{Key: "to_terminal.name", Value: -1}}, stage(pipeline, "$sort"))
+	assert.Equal(t, int64(1), stage(pipeline, "$skip"))
+	assert.Equal(t, int64(2), stage(pipeline, "$limit"))
+	project := projection(pipeline)
+	assert.Equal(t, 0, project["_id"])
+	assert.Equal(t, "$id", project["id"])
+	assert.Equal(t, "$amount", project["amount"])
+	assert.Equal(t, "$from_terminal.name", project["from_name"])
+	assert.Equal(t, "$to_terminal.name", project["to_name"])
+
+	// keyset pagination selects one more document to detect next page
+	filter = db.NewFilter()
+	filter.SetSorting("to_name")
+	filter.SetCursor(db.EncodeKeysetCursor("terminal2", "id2", false))
+	filter.Limit = 2
+	pipeline = joinPipeline(t, q, filter)
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"to_terminal.name": bson.M{"$gt": "terminal2"}},
+		bson.M{"to_terminal.name": "terminal2", "id": bson.M{"$gt": "id2"}},
+	}}, stage(pipeline, "$match"))
+	assert.Equal(t, bson.D{{Key: "to_terminal.name", Value: 1}, {Key: "id", Value: 1}}, stage(pipeline, "$sort"))
+	assert.Nil(t, stage(pipeline, "$skip"))
+	assert.Equal(t, int64(3), stage(pipeline, "$limit"))
+
+	// left join is default
+	pipeline = joinPipeline(t, joinQuery(""), nil)
+	assert.Equal(t, true, pipeline[3][0].Value.(bson.D).Map()["preserveNullAndEmptyArrays"])
+
+	// right and full outer joins are not supported
+	for _, joinType := range []db.JoinType{db.JoinRight, db.JoinFull, "CROSS"} {
+		_, err := m.Joiner().
+			Join(&Transfer{}, "from_terminal_id").On(&Terminal{}, "id").Type(joinType).
+			Destination(&TransferItem{})
+		assert.Error(t, err)
+	}
+
+	// unknown alias and table that was not joined
+	_, err := m.Joiner().
+		Join(&Transfer{}, "from_terminal_id").On(&Terminal{}, "id").
+		JoinAlias("to_terminal", "id").On(&Terminal{}, "id").
+		Destination(&TransferItem{})
+	assert.Error(t, err)
+	_, err = m.Joiner().
+		Join(&Transfer{}, "from_terminal_id").On(&Terminal{}, "id").
+		Join(&SampleModel1{}, "id").On(&Terminal{}, "id").
+		Destination(&TransferItem{})
+	assert.Error(t, err)
+
+	// sums are grouped by destination fields
+	q, err = m.Joiner().
+		Join(&WithAmount{}, "terminal_id").On(&Terminal{}, "id").
+		Sum([]string{"terminal_id", "terminal_name"}, []string{"amount1"}).
+		Destination(&WithAmountItem{})
+	require.NoError(t, err)
+	filter = db.NewFilter()
+	filter.AddFieldIn("terminal_name", "terminal1", "terminal3")
+	filter.SetSorting("terminal_name")
+	pipeline = joinPipeline(t, q, filter)
+	assert.Equal(t, bson.M{"terminals.name": bson.M{"$in": []interface{}{"terminal1", "terminal3"}}}, stage(pipeline, "$match"))
+	assert.Equal(t, bson.D{
+		{Key: "_id", Value: bson.D{{Key: "terminal_id", Value: "$terminal_id"}, {Key: "terminal_name", Value: "$terminals.name"}}},
+		{Key: "amount1", Value: bson.M{"$sum": "$amount1"}},
+	}, stage(pipeline, "$group"))
+	assert.Equal(t, bson.D{{Key: "terminal_name", Value: 1}}, stage(pipeline, "$sort"))
+}
+
+func TestMongoPoolAndTenancyJoinPipeline(t *testing.T) {
+
+	app := test_utils.InitAppContext(t, testDir, dbModels(), "maindb.json")
+	defer app.Close()
+	m := db_mongo.New()
+
+	// pool bindings
+	q, err := m.Joiner().
+		Join(&pool.PoolServiceAssociationBase{}, "pool_id").On(&pool.PoolBase{}, "id").
+		Join(&pool.PoolServiceAssociationBase{}, "service_id").On(&pool.PoolServiceBase{}, "id").
+		Destination(&pool.PoolServiceBinding{})
+	require.NoError(t, err)
+	filter := db.NewFilter()
+	filter.AddField("pools.id", "pool1")
+	pipeline := joinPipeline(t, q, filter)
+	assert.Equal(t, "pool_id", pipeline[0][0].Value.(bson.D).Map()["localField"])
+	assert.Equal(t, "service_id", pipeline[2][0].Value.(bson.D).Map()["localField"])
+	assert.Equal(t, bson.M{"pools.id": "pool1"}, stage(pipeline, "$match"))
+	project := projection(pipeline)
+	assert.Equal(t, "$id", project["id"])
+	assert.Equal(t, "$role", project["role"])
+	assert.Equal(t, "$pools.id", project["pool_id"])
+	assert.Equal(t, "$pools.name", project["pool_name"])
+	assert.Equal(t, "$pool_services.id", project["service_id"])
+	assert.Equal(t, "$pool_services.name", project["service_name"])
+
+	// tenancy listing
+	q, err = m.Joiner().
+		Join(&multitenancy.TenancyDb{}, "customer_id").On(&customer.Customer{}, "id").
+		Join(&multitenancy.TenancyDb{}, "pool_id").On(&pool.PoolBase{}, "id").
+		Destination(&multitenancy.TenancyItem{})
+	require.NoError(t, err)
+	filter = db.NewFilter()
+	filter.AddField("customer_login", "customer1")
+	filter.SetSorting("pool_name")
+	pipeline = joinPipeline(t, q, filter)
+	assert.Equal(t, bson.M{"customers.login": "customer1"}, stage(pipeline, "$match"))
+	assert.Equal(t, bson.D{{Key: "pools.name", Value: 1}}, stage(pipeline, "$sort"))
+	project = projection(pipeline)
+	assert.Equal(t, "$path", project["path"])
+	assert.Equal(t, "$customers.login", project["customer_login"])
+	assert.Equal(t, "$pools.name", project["pool_name"])
+
+	// tenancy IP addresses are joined via tenancies
+	q, err = m.Joiner().
+		Join(&multitenancy.TenancyIpAddress{}, "tenancy_id").On(&multitenancy.TenancyDb{}, "id").
+		Join(&multitenancy.TenancyDb{}, "customer_id").On(&customer.Customer{}, "id").
+		Join(&multitenancy.TenancyDb{}, "pool_id").On(&pool.PoolBase{}, "id").
+		Destination(&multitenancy.TenancyIpAddressItem{})
+	require.NoError(t, err)
+	pipeline = joinPipeline(t, q, nil)
+	assert.Equal(t, "tenancies.customer_id", pipeline[2][0].Value.(bson.D).Map()["localField"])
+	assert.Equal(t, "$tenancies.role", projection(pipeline)["tenancy_role"])
+}
+
+// Test runs only if MongoDB is available at localhost.
+func connectMongo(t *testing.T, app app_context.Context) *db_mongo.MongoDB {
+	m := db_mongo.New()
+	m.CONNECT_TIMEOUT = 2 * time.Second
+	err := m.InitWithConfig(app, app.Validator(), &db.DBConfig{DB_PROVIDER: "mongodb", DB_HOST: "localhost", DB_PORT: 27017, DB_NAME: "go_utils_test"})
+	if err != nil {
+		t.Skip("Skip MongoDB test because MongoDB is not available at localhost")
+	}
+	require.NoError(t, m.NativeHandler().(*mongo.Database).Drop(context.Background()))
+	require.NoError(t, m.AutoMigrate(app, append(dbModels(), &Transfer{})))
+	return m
+}
+
+func TestMongoDatabase(t *testing.T) {
+
+	app := test_utils.InitAppContext(t, testDir, dbModels(), "maindb.json")
+	defer app.Close()
+	m := connectMongo(t, app)
+	defer m.Close()
+
+	// keyset pagination
+	for i := 0; i < 7; i++ {
+		doc := &SampleModel1{}
+		doc.InitObject()
+		doc.Field1 = fmt.Sprintf("value%d", i)
+		doc.Field2 = "keyset"
+		require.NoError(t, m.Create(app, doc))
+	}
+	filter := db.NewFilter()
+	filter.AddField("field2", "keyset")
+	filter.SetSorting("field1", db.SORT_DESC)
+	filter.Keyset = true
+	filter.Limit = 3
+	page := func(expected ...string) {
+		var docs []*SampleModel1
+		count, err := m.FindWithFilter(app, filter, &docs)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(expected)), count)
+		require.Len(t, docs, len(expected))
+		for i, doc := range docs {
+			assert.Equal(t, expected[i], doc.Field1)
+		}
+	}
+	page("value6", "value5", "value4")
+	assert.Empty(t, filter.PrevCursor)
+	filter.SetCursor(filter.NextCursor)
+	page("value3", "value2", "value1")
+	filter.SetCursor(filter.NextCursor)
+	page("value0")
+	assert.Empty(t, filter.NextCursor)
+	filter.SetCursor(filter.PrevCursor)
+	page("value3", "value2", "value1")
+	filter.SetCursor(filter.PrevCursor)
+	page("value6", "value5", "value4")
+	assert.Empty(t, filter.PrevCursor)
+
+	// update with expression
+	doc := &SampleModel2{Field1: "counter", Field2: 10}
+	doc.InitObject()
+	require.NoError(t, m.Create(app, doc))
+	require.NoError(t, m.Update(app, doc, nil, db.Fields{"field2": m.MakeExpression(`{"$add": ["$field2", ?]}`, 5), "field1": "$counter"}))
+	found := &SampleModel2{}
+	ok, err := m.FindByField(app, "id", doc.GetID(), found)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, 15, found.Field2)
+	assert.Equal(t, "$counter", found.Field1)
+
+	// joins
+	addTerminal := func(name string) *Terminal {
+		terminal := &Terminal{}
+		terminal.InitObject()
+		terminal.SetName(name)
+		require.NoError(t, m.Create(app, terminal))
+		return terminal
+	}
+	terminal1 := addTerminal("terminal1")
+	terminal2 := addTerminal("terminal2")
+	addTransfer := func(amount int, from string, to string) {
+		transfer := &Transfer{Amount: amount, FromTerminalId: from, ToTerminalId: to}
+		transfer.InitObject()
+		require.NoError(t, m.Create(app, transfer))
+	}
+	addTransfer(10, terminal1.GetID(), terminal2.GetID())
+	addTransfer(20, terminal2.GetID(), terminal1.GetID())
+	addTransfer(30, terminal1.GetID(), "unknown")
+
+	join := func(name string, joinType db.JoinType, filter *db.Filter) ([]*TransferItem, int64) {
+		queryBuilder := func() (db.JoinQuery, error) {
+			return m.Joiner().
+				Join(&Transfer{}, "from_terminal_id").On(&Terminal{}, "id").As("from_terminal").
+				Join(&Transfer{}, "to_terminal_id").On(&Terminal{}, "id").As("to_terminal").Type(joinType).
+				Destination(&TransferItem{})
+		}
+		var items []*TransferItem
+		count, err := m.Join(app, db.NewJoin(queryBuilder, name), filter, &items)
+		require.NoError(t, err)
+		return items, count
+	}
+	sorted := func() *db.Filter {
+		filter := db.NewFilter()
+		filter.SetSorting("amount")
+		filter.Count = true
+		return filter
+	}
+
+	items, count := join("LeftJoin", db.JoinLeft, sorted())
+	assert.Equal(t, int64(3), count)
+	require.Len(t, items, 3)
+	assert.Equal(t, "terminal1", items[0].FromName)
+	assert.Equal(t, "terminal2", items[0].ToName)
+	assert.Equal(t, "", items[2].ToName)
+
+	items, count = join("InnerJoin", db.JoinInner, sorted())
+	assert.Equal(t, int64(2), count)
+	require.Len(t, items, 2)
+	assert.Equal(t, 10, items[0].Amount)
+	assert.Equal(t, 20, items[1].Amount)
+
+	filter = db.NewFilter()
+	filter.AddFieldIn("from_name", "terminal1")
+	filter.SetSorting("amount", db.SORT_DESC)
+	filter.Keyset = true
+	filter.Limit = 1
+	items, _ = join("LeftJoin", db.JoinLeft, filter)
+	require.Len(t, items, 1)
+	assert.Equal(t, 30, items[0].Amount)
+	require.NotEmpty(t, filter.NextCursor)
+	filter.SetCursor(filter.NextCursor)
+	items, _ = join("LeftJoin", db.JoinLeft, filter)
+	require.Len(t, items, 1)
+	assert.Equal(t, 10, items[0].Amount)
+	assert.Empty(t, filter.NextCursor)
+
+	// pool bindings
+	p := pool.NewPool()
+	p.InitObject()
+	p.SetName("pool1")
+	require.NoError(t, m.Create(app, p))
+	service := pool.NewService()
+	service.InitObject()
+	service.SetName("service1")
+	require.NoError(t, m.Create(app, service))
+	association := &pool.PoolServiceAssociationBase{}
+	association.InitObject()
+	association.POOL_ID = p.GetID()
+	association.SERVICE_ID = service.GetID()
+	association.ROLE = "role1"
+	require.NoError(t, m.Create(app, association))
+
+	queryBuilder := func() (db.JoinQuery, error) {
+		return m.Joiner().
+			Join(&pool.PoolServiceAssociationBase{}, "pool_id").On(&pool.PoolBase{}, "id").
+			Join(&pool.PoolServiceAssociationBase{}, "service_id").On(&pool.PoolServiceBase{}, "id").
+			Destination(&pool.PoolServiceBinding{})
+	}
+	filter = db.NewFilter()
+	filter.AddField("pools.id", p.GetID())
+	var bindings []*pool.PoolServiceBinding
+	_, err = m.Join(app, db.NewJoin(queryBuilder, "GetPoolBindings"), filter, &bindings)
+	require.NoError(t, err)
+	require.Len(t, bindings, 1)
+	assert.Equal(t, association.GetID(), bindings[0].GetID())
+	assert.Equal(t, "pool1", bindings[0].PoolName)
+	assert.Equal(t, "service1", bindings[0].ServiceName)
+	assert.Equal(t, "role1", bindings[0].Role())
+}
+
+func TestMongoProvider(t *testing.T) {
+
+	app := test_utils.InitAppContext(t, testDir, dbModels(), "maindb.json")
+	defer app.Close()
+
+	// database is selected by provider
+	_, ok := db_factory.New(db_mongo.Provider).(*db_mongo.MongoDB)
+	assert.True(t, ok)
+	_, ok = db_factory.New("sqlite").(*db_gorm.GormDB)
+	assert.True(t, ok)
+
+	// database of pool service is created for provider of the service
+	_, ok = db_factory.Clone(app.Db(), db_mongo.Provider).(*db_mongo.MongoDB)
+	assert.True(t, ok)
+	_, ok = db_factory.Clone(app.Db(), "").(*db_gorm.GormDB)
+	assert.True(t, ok)
+	_, ok = db_factory.Clone(db_mongo.New(), "").(*db_mongo.MongoDB)
+	assert.True(t, ok)
+	_, ok = db_factory.Clone(db_mongo.New(), "postgres").(*db_gorm.GormDB)
+	assert.True(t, ok)
+}
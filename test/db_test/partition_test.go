@@ -0,0 +1,62 @@
+package db_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/evgeniums/go-utils/pkg/common"
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/test_utils"
+	"github.com/evgeniums/go-utils/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type PartitionedDoc struct {
+	common.ObjectBase
+	Month  utils.Month `gorm:"index"`
+	Field1 string      `gorm:"index"`
+}
+
+func TestPartitionedMonths(t *testing.T) {
+	app := test_utils.InitAppContext(t, testDir, nil, "maindb.json")
+	defer app.Close()
+
+	require.NoError(t, app.Db().PartitionedMonthAutoMigrate(app, []interface{}{&PartitionedDoc{}}))
+
+	month := utils.CurrentMonth()
+	prevMonth := month.Prev()
+	for i := 0; i < 3; i++ {
+		for _, m := range []utils.Month{month, prevMonth} {
+			doc := &PartitionedDoc{Month: m, Field1: fmt.Sprintf("value%d", i)}
+			doc.InitObject()
+			require.NoError(t, app.Db().Create(app, doc))
+		}
+	}
+
+	native := app.Db().NativeHandler().(*gorm.DB)
+	countRows := func(table string) int64 {
+		var count int64
+		require.NoError(t, native.Table(table).Count(&count).Error)
+		return count
+	}
+	countDocs := func(m utils.Month) int {
+		var docs []*PartitionedDoc
+		_, err := app.Db().FindWithFilter(app, &db.Filter{Fields: db.Fields{"month": m}}, &docs)
+		require.NoError(t, err)
+		return len(docs)
+	}
+
+	table := "partitioned_docs"
+	partition := fmt.Sprintf("%s_%d", table, prevMonth)
+
+	require.NoError(t, app.Db().PartitionedMonthsDetach(app, table, []utils.Month{prevMonth}))
+	assert.Equal(t, 0, countDocs(prevMonth))
+	assert.Equal(t, 3, countDocs(month))
+	assert.Equal(t, int64(3), countRows(partition))
+
+	require.NoError(t, app.Db().PartitionedMonthsDelete(app, table, []utils.Month{prevMonth, month}))
+	assert.Equal(t, 0, countDocs(month))
+	assert.False(t, native.Migrator().HasTable(partition))
+}
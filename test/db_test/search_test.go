@@ -0,0 +1,65 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchOperators(t *testing.T) {
+	app := test_utils.InitAppContext(t, testDir, dbModels(), "maindb.json")
+	defer app.Close()
+
+	for _, value := range []string{"Quick brown fox", "quick red fox", "lazy dog", "100% quick"} {
+		doc := &SampleModel1{}
+		doc.InitObject()
+		doc.Field1 = value
+		doc.Field2 = "search"
+		require.NoError(t, app.Db().Create(app, doc))
+	}
+
+	find := func(filter *db.Filter, expected ...string) {
+		filter.SetSorting("field1")
+		var docs []*SampleModel1
+		_, err := app.Db().FindWithFilter(app, filter, &docs)
+		require.NoError(t, err)
+		require.Len(t, docs, len(expected))
+		for i, doc := range docs {
+			assert.Equal(t, expected[i], doc.Field1)
+		}
+	}
+
+	filter := db.NewFilter()
+	filter.AddLike("field1", "%fox")
+	find(filter, "Quick brown fox", "quick red fox")
+
+	filter = db.NewFilter()
+	filter.AddPrefix("field1", "quick", true)
+	find(filter, "Quick brown fox", "quick red fox")
+
+	filter = db.NewFilter()
+	filter.AddPrefix("field1", "100%")
+	find(filter, "100% quick")
+
+	filter = db.NewFilter()
+	filter.AddFullTextSearch("field1", "fox QUICK")
+	find(filter, "Quick brown fox", "quick red fox")
+
+	// search operators must be whitelisted
+	query := &db.Query{Prefix: map[string]db.QueryPattern{"field1": {Pattern: "lazy"}}}
+	_, err := app.Db().ParseFilterDirect(query, &SampleModel1{}, "search_not_allowed", db.EmptyFilterValidator(app.Validator()))
+	assert.Error(t, err)
+
+	vld := db.EmptyFilterValidator(app.Validator())
+	vld.AllowSearch("field1", db.SEARCH_PREFIX, "max=8")
+	filter, err = app.Db().ParseFilterDirect(query, &SampleModel1{}, "search_allowed", vld)
+	require.NoError(t, err)
+	find(filter, "lazy dog")
+
+	query.Prefix["field1"] = db.QueryPattern{Pattern: "too long prefix"}
+	_, err = app.Db().ParseFilterDirect(query, &SampleModel1{}, "search_allowed")
+	assert.Error(t, err)
+}
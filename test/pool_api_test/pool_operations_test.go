@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/evgeniums/go-utils/pkg/admin"
@@ -149,58 +150,41 @@ func TestBindings(t *testing.T) {
 	role := "main_webservice"
 	role2 := "pubsub"
 
-	checkList := func(bindings []*pool.PoolServiceBinding) {
+	// services were added via API, so returned services have masked secrets
+	checkBinding := func(binding *pool.PoolServiceBinding, expectedPool pool.Pool, expectedService pool.PoolService, expectedRole string, masked bool) {
+		assert.Equal(t, expectedPool.Name(), binding.PoolName)
+		assert.Equal(t, expectedPool.GetID(), binding.PoolId)
+		assert.Equal(t, expectedService.Name(), binding.ServiceName)
+		assert.Equal(t, expectedService.GetID(), binding.ServiceId)
+		assert.Equal(t, expectedRole, binding.Role())
+		if masked {
+			assert.Equal(t, pool.MaskedSecret, binding.Secret1())
+			assert.Equal(t, pool.MaskedSecret, binding.Secret2())
+		} else {
+			assert.Equal(t, "secret1", binding.Secret1())
+			assert.Equal(t, "secret2", binding.Secret2())
+		}
+		data := binding.PoolServiceBaseData
+		data.MaskSecrets()
+		serviceB := expectedService.(*pool.PoolServiceBase)
+		assert.Equal(t, serviceB.PoolServiceBaseData, data)
+	}
+
+	checkList := func(bindings []*pool.PoolServiceBinding, masked ...bool) {
 		require.Equal(t, 1, len(bindings))
-		binding := bindings[0]
-		assert.Equal(t, p.Name(), binding.PoolName)
-		assert.Equal(t, p.GetID(), binding.PoolId)
-		assert.Equal(t, service.Name(), binding.ServiceName)
-		assert.Equal(t, service.GetID(), binding.ServiceId)
-		assert.Equal(t, role, binding.Role())
-		serviceB := service.(*pool.PoolServiceBase)
-		assert.Equal(t, serviceB.PoolServiceBaseData, binding.PoolServiceBaseData)
+		checkBinding(bindings[0], p, service, role, utils.OptionalArg(false, masked...))
 	}
 
 	checkList2 := func(bindings []*pool.PoolServiceBinding) {
 		require.Equal(t, 2, len(bindings))
-		binding0 := bindings[0]
-		assert.Equal(t, p.Name(), binding0.PoolName)
-		assert.Equal(t, p.GetID(), binding0.PoolId)
-		assert.Equal(t, service.Name(), binding0.ServiceName)
-		assert.Equal(t, service.GetID(), binding0.ServiceId)
-		assert.Equal(t, role, binding0.Role())
-		serviceB := service.(*pool.PoolServiceBase)
-		assert.Equal(t, serviceB.PoolServiceBaseData, binding0.PoolServiceBaseData)
-
-		binding1 := bindings[1]
-		assert.Equal(t, p.Name(), binding1.PoolName)
-		assert.Equal(t, p.GetID(), binding1.PoolId)
-		assert.Equal(t, service2.Name(), binding1.ServiceName)
-		assert.Equal(t, service2.GetID(), binding1.ServiceId)
-		assert.Equal(t, role2, binding1.Role())
-		serviceB = service2.(*pool.PoolServiceBase)
-		assert.Equal(t, serviceB.PoolServiceBaseData, binding1.PoolServiceBaseData)
+		checkBinding(bindings[0], p, service, role, false)
+		checkBinding(bindings[1], p, service2, role2, false)
 	}
 
 	checkList3 := func(bindings []*pool.PoolServiceBinding) {
 		require.Equal(t, 2, len(bindings))
-		binding0 := bindings[0]
-		assert.Equal(t, p.Name(), binding0.PoolName)
-		assert.Equal(t, p.GetID(), binding0.PoolId)
-		assert.Equal(t, service.Name(), binding0.ServiceName)
-		assert.Equal(t, service.GetID(), binding0.ServiceId)
-		assert.Equal(t, role, binding0.Role())
-		serviceB := service.(*pool.PoolServiceBase)
-		assert.Equal(t, serviceB.PoolServiceBaseData, binding0.PoolServiceBaseData)
-
-		binding1 := bindings[1]
-		assert.Equal(t, p2.Name(), binding1.PoolName)
-		assert.Equal(t, p2.GetID(), binding1.PoolId)
-		assert.Equal(t, service.Name(), binding1.ServiceName)
-		assert.Equal(t, service.GetID(), binding1.ServiceId)
-		assert.Equal(t, role, binding1.Role())
-		serviceB = service.(*pool.PoolServiceBase)
-		assert.Equal(t, serviceB.PoolServiceBaseData, binding1.PoolServiceBaseData)
+		checkBinding(bindings[0], p, service, role, false)
+		checkBinding(bindings[1], p2, service, role, false)
 	}
 
 	// add service to pool
@@ -219,6 +203,14 @@ func TestBindings(t *testing.T) {
 	require.NoError(t, err)
 	checkList(bindings)
 
+	// secrets are masked in API
+	bindings, err = ctx.RemotePoolController.GetPoolBindings(ctx.ClientOp, p.GetID())
+	require.NoError(t, err)
+	checkList(bindings, true)
+	bindings, err = ctx.RemotePoolController.GetServiceBindings(ctx.ClientOp, service.GetID())
+	require.NoError(t, err)
+	checkList(bindings, true)
+
 	// try to add duplicate service to pool
 	err = ctx.RemotePoolController.AddServiceToPool(ctx.ClientOp, p.GetID(), service.GetID(), role)
 	test_utils.CheckGenericError(t, err, pool.ErrorCodeServiceRoleConflict, "Pool already has service for that role")
@@ -385,8 +377,8 @@ func TestUpdateService(t *testing.T) {
 	assert.Equal(t, "updated long_name", updatedS.LongName())
 	assert.Equal(t, "updated description", updatedS.Description())
 	assert.Equal(t, "new type", updatedS.TypeName())
-	assert.Equal(t, "new secret 1", updatedS.Secret1())
-	assert.Equal(t, "new secret 2", updatedS.Secret2())
+	assert.Equal(t, pool.MaskedSecret, updatedS.Secret1())
+	assert.Equal(t, pool.MaskedSecret, updatedS.Secret2())
 	assert.Equal(t, "new provider", updatedS.Provider())
 	assert.Equal(t, "new public host", updatedS.PublicHost())
 	assert.Equal(t, uint16(1010), updatedS.PublicPort())
@@ -411,8 +403,8 @@ func TestUpdateService(t *testing.T) {
 	assert.Equal(t, "updated long_name", remoteService1.LongName())
 	assert.Equal(t, "updated description", remoteService1.Description())
 	assert.Equal(t, "new type", remoteService1.TypeName())
-	assert.Equal(t, "new secret 1", remoteService1.Secret1())
-	assert.Equal(t, "new secret 2", remoteService1.Secret2())
+	assert.Equal(t, pool.MaskedSecret, remoteService1.Secret1())
+	assert.Equal(t, pool.MaskedSecret, remoteService1.Secret2())
 	assert.Equal(t, "new provider", remoteService1.Provider())
 	assert.Equal(t, "new public host", remoteService1.PublicHost())
 	assert.Equal(t, uint16(1010), remoteService1.PublicPort())
@@ -447,3 +439,150 @@ func TestUpdateService(t *testing.T) {
 	require.Error(t, err)
 	test_utils.CheckGenericError(t, err, pool.ErrorCodeServiceNameConflict, "Service with such name already exists, choose another name")
 }
+
+func TestListPoolsKeyset(t *testing.T) {
+	ctx := initTest(t)
+	defer ctx.Close()
+
+	for _, name := range []string{"pool1", "pool2", "pool3", "pool4", "pool5"} {
+		addPool(t, ctx, name)
+	}
+
+	filter := db.NewFilter()
+	filter.SetSorting("name")
+	filter.Keyset = true
+	filter.Limit = 2
+
+	page := func(expected ...string) {
+		pools, _, err := ctx.RemotePoolController.GetPools(ctx.ClientOp, filter)
+		require.NoError(t, err)
+		require.Equal(t, len(expected), len(pools))
+		for i, p := range pools {
+			assert.Equal(t, expected[i], p.Name())
+		}
+	}
+
+	page("pool1", "pool2")
+	filter.SetCursor(filter.NextCursor)
+	page("pool3", "pool4")
+	filter.SetCursor(filter.NextCursor)
+	page("pool5")
+	assert.Empty(t, filter.NextCursor)
+	filter.SetCursor(filter.PrevCursor)
+	page("pool3", "pool4")
+}
+
+func TestSearchPools(t *testing.T) {
+	ctx := initTest(t)
+	defer ctx.Close()
+
+	for _, name := range []string{"pool1", "pool2", "other3"} {
+		addPool(t, ctx, name)
+	}
+
+	search := func(filter *db.Filter, expected ...string) {
+		filter.SetSorting("name")
+		pools, _, err := ctx.RemotePoolController.GetPools(ctx.ClientOp, filter)
+		require.NoError(t, err)
+		require.Equal(t, len(expected), len(pools))
+		for i, p := range pools {
+			assert.Equal(t, expected[i], p.Name())
+		}
+	}
+
+	filter := db.NewFilter()
+	filter.AddPrefix("name", "pool")
+	search(filter, "pool1", "pool2")
+
+	filter = db.NewFilter()
+	filter.AddLike("name", "%THER%", true)
+	search(filter, "other3")
+
+	// search operators must be allowed for fields
+	filter = db.NewFilter()
+	filter.AddPrefix("description", "pool")
+	_, _, err := ctx.RemotePoolController.GetPools(ctx.ClientOp, filter)
+	test_utils.CheckGenericError(t, err, generic_error.ErrorCodeFormat)
+
+	filter = db.NewFilter()
+	filter.AddPrefix("name", strings.Repeat("p", 65))
+	_, _, err = ctx.RemotePoolController.GetPools(ctx.ClientOp, filter)
+	test_utils.CheckGenericError(t, err, generic_error.ErrorCodeFormat)
+}
+
+func TestServiceSecretsEncryption(t *testing.T) {
+	ctx := initTest(t)
+	defer ctx.Close()
+
+	s := addService(t, ctx)
+	findRaw := func() *pool.PoolServiceBase {
+		raw := &pool.PoolServiceBase{}
+		found, err := ctx.AdminOp.Db().FindByField(ctx.AdminOp, "id", s.GetID(), raw)
+		require.NoError(t, err)
+		require.True(t, found)
+		return raw
+	}
+
+	// secrets are encrypted in database
+	raw := findRaw()
+	assert.True(t, pool.IsEncryptedSecret(raw.SECRET1))
+	assert.True(t, pool.IsEncryptedSecret(raw.SECRET2))
+
+	// update secrets
+	fields := db.Fields{"secret1": "updated secret 1"}
+	updated, err := ctx.LocalPoolController.UpdateService(ctx.AdminOp, s.Name(), fields, true)
+	require.NoError(t, err)
+	assert.Equal(t, "updated secret 1", updated.Secret1())
+	assert.Equal(t, "secret2", updated.Secret2())
+	raw = findRaw()
+	assert.True(t, pool.IsEncryptedSecret(raw.SECRET1))
+
+	// ciphertext of other field can not be decrypted
+	err = db.Update(ctx.AdminOp.Db(), ctx.AdminOp, raw, db.Fields{"secret1": raw.SECRET2})
+	require.NoError(t, err)
+	_, err = ctx.LocalPoolController.FindService(ctx.AdminOp, s.GetID())
+	require.Error(t, err)
+	require.NotNil(t, ctx.AdminOp.GenericError())
+	assert.Equal(t, pool.ErrorCodeInvalidServiceConfiguration, ctx.AdminOp.GenericError().Code())
+	ctx.AdminOp.ClearError()
+
+	// plaintext secrets are read as is and encrypted with migration, even if they look like prefix of encrypted secret
+	plainSecret := pool.EncryptedSecretPrefix + "plain secret 1"
+	assert.False(t, pool.IsEncryptedSecret(plainSecret))
+	err = db.Update(ctx.AdminOp.Db(), ctx.AdminOp, raw, db.Fields{"secret1": plainSecret})
+	require.NoError(t, err)
+	found, err := ctx.LocalPoolController.FindService(ctx.AdminOp, s.GetID())
+	require.NoError(t, err)
+	assert.Equal(t, plainSecret, found.Secret1())
+
+	// services are processed in pages
+	s2 := addService(t, ctx, "service2")
+	raw2 := &pool.PoolServiceBase{}
+	_, err = ctx.AdminOp.Db().FindByField(ctx.AdminOp, "id", s2.GetID(), raw2)
+	require.NoError(t, err)
+	err = db.Update(ctx.AdminOp.Db(), ctx.AdminOp, raw2, db.Fields{"secret2": "plain secret 2"})
+	require.NoError(t, err)
+
+	encryptor, ok := ctx.LocalPoolController.(*pool.PoolControllerBase)
+	require.True(t, ok)
+	stats, err := encryptor.EncryptSecrets(ctx.AdminOp, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 4, stats.Total)
+	assert.Equal(t, 2, stats.Reencrypted)
+	assert.Equal(t, 0, stats.Failed)
+	raw = findRaw()
+	assert.True(t, pool.IsEncryptedSecret(raw.SECRET1))
+	found, err = ctx.LocalPoolController.FindService(ctx.AdminOp, s.GetID())
+	require.NoError(t, err)
+	assert.Equal(t, plainSecret, found.Secret1())
+	assert.Equal(t, "secret2", found.Secret2())
+	found, err = ctx.LocalPoolController.FindService(ctx.AdminOp, s2.GetID())
+	require.NoError(t, err)
+	assert.Equal(t, "plain secret 2", found.Secret2())
+
+	// secrets are masked in API responses
+	remote, err := ctx.RemotePoolController.FindService(ctx.ClientOp, s.GetID())
+	require.NoError(t, err)
+	assert.Equal(t, pool.MaskedSecret, remote.Secret1())
+	assert.Equal(t, pool.MaskedSecret, remote.Secret2())
+}
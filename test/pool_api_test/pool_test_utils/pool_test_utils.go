@@ -122,14 +122,21 @@ func AddService(t *testing.T, ctx *PoolTestContext, serviceConfig *pool.PoolServ
 	assert.NotEmpty(t, addedService1.GetID())
 	addedB1, ok := addedService1.(*pool.PoolServiceBase)
 	require.True(t, ok)
-	assert.Equal(t, p1.PoolServiceBaseEssentials, addedB1.PoolServiceBaseEssentials)
-	assert.Equal(t, p1.Secret1(), addedService1.Secret1())
-	assert.Equal(t, p1.Secret2(), addedService1.Secret2())
+	masked := *p1
+	masked.MaskSecrets()
+	assert.Equal(t, masked.PoolServiceBaseEssentials, addedB1.PoolServiceBaseEssentials)
+	assert.Equal(t, masked.Secret1(), addedService1.Secret1())
+	assert.Equal(t, masked.Secret2(), addedService1.Secret2())
 
 	// find locally
 	dbService1, err := ctx.LocalPoolController.FindService(ctx.AdminOp, p1Sample.Name(), true)
 	require.NoError(t, err)
 	require.NotNil(t, dbService1)
+	assert.Equal(t, p1.Secret1(), dbService1.Secret1())
+	assert.Equal(t, p1.Secret2(), dbService1.Secret2())
+	dbB1, ok := dbService1.(*pool.PoolServiceBase)
+	require.True(t, ok)
+	dbB1.MaskSecrets()
 
 	b1, _ := json.Marshal(addedService1)
 	b2, _ := json.Marshal(dbService1)
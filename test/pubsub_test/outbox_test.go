@@ -0,0 +1,191 @@
+package pubsub_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/evgeniums/go-utils/pkg/app_context"
+	"github.com/evgeniums/go-utils/pkg/background_worker"
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+	"github.com/evgeniums/go-utils/pkg/pubsub"
+	"github.com/evgeniums/go-utils/pkg/pubsub/pubsub_outbox"
+	"github.com/evgeniums/go-utils/pkg/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _, testBasePath, _, _ = runtime.Caller(0)
+var testDir = filepath.Dir(testBasePath)
+
+type testMessage struct {
+	Value int `json:"value"`
+}
+
+type testPublisher struct {
+	pubsub.PublisherBase
+	failTopic string
+	published map[string][]string
+}
+
+func newTestPublisher() *testPublisher {
+	p := &testPublisher{published: make(map[string][]string)}
+	p.Construct()
+	return p
+}
+
+func (p *testPublisher) Publish(topicName string, obj interface{}) error {
+	if topicName == p.failTopic {
+		return errors.New("publish failed")
+	}
+	msg, err := p.Serialize(obj)
+	if err != nil {
+		return err
+	}
+	p.published[topicName] = append(p.published[topicName], string(msg))
+	return nil
+}
+
+func (p *testPublisher) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func initOutbox(t *testing.T) (app_context.Context, *pubsub_outbox.OutboxBase, *testPublisher) {
+	app := test_utils.InitAppContext(t, testDir, pubsub_outbox.DbModels(), "pubsub_test.json")
+	publisher := newTestPublisher()
+	outbox := pubsub_outbox.NewOutbox("test_outbox", pubsub_outbox.SinglePublisher(publisher))
+	outbox.SetStopper(&background_worker.BackgroundStopperStub{})
+	require.NoError(t, outbox.Init(app))
+	return app, outbox, publisher
+}
+
+func listMessages(t *testing.T, ctx op_context.Context, outbox *pubsub_outbox.OutboxBase) []*pubsub_outbox.OutboxMessage {
+	filter := db.NewFilter()
+	filter.SetSorting("sequence")
+	messages, _, err := outbox.List(ctx, filter)
+	require.NoError(t, err)
+	return messages
+}
+
+func TestOutboxTransaction(t *testing.T) {
+	app, outbox, publisher := initOutbox(t)
+	defer app.Close()
+	ctx := test_utils.SimpleOpContext(app, "TestOutboxTransaction")
+	defer ctx.Close()
+
+	// message of rolled back transaction is not published
+	err := op_context.ExecDbTransaction(ctx, func() error {
+		require.NoError(t, outbox.Post(ctx, "topic1", &testMessage{Value: 1}))
+		return errors.New("rollback")
+	})
+	require.Error(t, err)
+	assert.Empty(t, listMessages(t, ctx, outbox))
+
+	// message of committed transaction is published
+	err = op_context.ExecDbTransaction(ctx, func() error {
+		return outbox.Post(ctx, "topic1", &testMessage{Value: 2})
+	})
+	require.NoError(t, err)
+	outbox.Relay()
+	assert.Equal(t, []string{`{"value":2}`}, publisher.published["topic1"])
+	messages := listMessages(t, ctx, outbox)
+	require.Len(t, messages, 1)
+	assert.True(t, messages[0].Sent)
+
+	// sent messages are deleted after retention period
+	outbox.RETENTION_SECONDS = 0
+	outbox.Relay()
+	assert.Empty(t, listMessages(t, ctx, outbox))
+}
+
+func TestOutboxRetryAndOrdering(t *testing.T) {
+	app, outbox, publisher := initOutbox(t)
+	defer app.Close()
+	ctx := test_utils.SimpleOpContext(app, "TestOutboxRetryAndOrdering")
+	defer ctx.Close()
+
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, outbox.Post(ctx, "topic1", &testMessage{Value: i}))
+		require.NoError(t, outbox.Post(ctx, "topic2", &testMessage{Value: i}))
+	}
+
+	// failed topic does not block other topics and its messages are held in order
+	publisher.failTopic = "topic1"
+	outbox.Relay()
+	assert.Empty(t, publisher.published["topic1"])
+	assert.Equal(t, []string{`{"value":1}`, `{"value":2}`, `{"value":3}`}, publisher.published["topic2"])
+	messages := listMessages(t, ctx, outbox)
+	assert.Equal(t, 1, messages[0].Attempts)
+	assert.Equal(t, "publish failed", messages[0].LastError)
+	assert.Equal(t, 0, messages[2].Attempts)
+
+	// after recovery messages are published in order
+	publisher.failTopic = ""
+	outbox.Relay()
+	assert.Equal(t, []string{`{"value":1}`, `{"value":2}`, `{"value":3}`}, publisher.published["topic1"])
+
+	// message is dead after max attempts and does not block the following messages
+	publisher.failTopic = "topic3"
+	require.NoError(t, outbox.Post(ctx, "topic3", &testMessage{Value: 1}))
+	require.NoError(t, outbox.Post(ctx, "topic3", &testMessage{Value: 2}))
+	for i := 0; i < 3; i++ {
+		outbox.Relay()
+	}
+	publisher.failTopic = ""
+	outbox.Relay()
+	assert.Equal(t, []string{`{"value":2}`}, publisher.published["topic3"])
+	filter := db.NewFilter()
+	filter.AddField("dead", true)
+	dead, _, err := outbox.List(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, 3, dead[0].Attempts)
+}
+
+type testPublishers struct {
+	publishers map[string]*testPublisher
+}
+
+func (p *testPublishers) Publisher(target string) pubsub.Publisher {
+	return p.publishers[target]
+}
+
+func (p *testPublishers) Targets() []string {
+	return []string{"pool1", "pool2"}
+}
+
+func TestOutboxSequence(t *testing.T) {
+	app := test_utils.InitAppContext(t, testDir, pubsub_outbox.DbModels(), "pubsub_test.json")
+	defer app.Close()
+	publishers := &testPublishers{publishers: map[string]*testPublisher{"pool1": newTestPublisher(), "pool2": newTestPublisher()}}
+	outbox := pubsub_outbox.NewOutbox("test_outbox", publishers)
+	outbox.SetStopper(&background_worker.BackgroundStopperStub{})
+	require.NoError(t, outbox.Init(app))
+	ctx := test_utils.SimpleOpContext(app, "TestOutboxSequence")
+	defer ctx.Close()
+
+	// sequence numbers of rolled back transaction are reused
+	require.NoError(t, outbox.Post(ctx, "topic1", &testMessage{Value: 1}))
+	err := op_context.ExecDbTransaction(ctx, func() error {
+		require.NoError(t, outbox.Post(ctx, "topic1", &testMessage{Value: 2}))
+		return errors.New("rollback")
+	})
+	require.Error(t, err)
+	require.NoError(t, outbox.Post(ctx, "topic1", &testMessage{Value: 3}, "pool2"))
+
+	messages := listMessages(t, ctx, outbox)
+	require.Len(t, messages, 3)
+	for i, msg := range messages {
+		assert.Equal(t, int64(i+1), msg.Sequence)
+	}
+	assert.Equal(t, "pool1", messages[0].Target)
+	assert.Equal(t, "pool2", messages[1].Target)
+	assert.Equal(t, "pool2", messages[2].Target)
+
+	outbox.Relay()
+	assert.Equal(t, []string{`{"value":1}`}, publishers.publishers["pool1"].published["topic1"])
+	assert.Equal(t, []string{`{"value":1}`, `{"value":3}`}, publishers.publishers["pool2"].published["topic1"])
+}
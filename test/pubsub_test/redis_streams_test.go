@@ -0,0 +1,208 @@
+package pubsub_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evgeniums/go-utils/pkg/app_context"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+	"github.com/evgeniums/go-utils/pkg/pubsub"
+	"github.com/evgeniums/go-utils/pkg/pubsub/pubsub_providers/pubsub_factory"
+	"github.com/evgeniums/go-utils/pkg/pubsub/pubsub_providers/pubsub_redis_streams"
+	"github.com/evgeniums/go-utils/pkg/pubsub/pubsub_subscriber"
+	"github.com/evgeniums/go-utils/pkg/test_utils"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testSubscriberClient struct {
+	pubsub_subscriber.SubscriberClientBase
+	mutex  sync.Mutex
+	values []int
+	fail   bool
+}
+
+func (c *testSubscriberClient) Handle(ctx op_context.Context, msg *testMessage) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.values = append(c.values, msg.Value)
+	if c.fail {
+		return errors.New("failed to handle message")
+	}
+	return nil
+}
+
+func (c *testSubscriberClient) Values() []int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]int{}, c.values...)
+}
+
+func skipWithoutRedis(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379", DialTimeout: time.Second})
+	defer client.Close()
+	if client.Ping(context.Background()).Err() != nil {
+		t.Skip("Skip Redis streams test because Redis is not available at localhost")
+	}
+}
+
+func initRedisStreams(t *testing.T, topicName string) (app_context.Context, pubsub_factory.PubsubFactory, pubsub.Publisher) {
+	skipWithoutRedis(t)
+
+	app := test_utils.InitAppContext(t, testDir, nil, "pubsub_redis_streams_test.json")
+	factory := pubsub_factory.DefaultPubsubFactory()
+	publisher, err := factory.MakePublisher(app)
+	require.NoError(t, err)
+	streamsPublisher, ok := publisher.(*pubsub_redis_streams.Publisher)
+	require.True(t, ok)
+	streamsPublisher.NativeHandler().Del(streamsPublisher.Context(), streamsPublisher.StreamName(topicName))
+	return app, factory, publisher
+}
+
+func TestRedisStreams(t *testing.T) {
+
+	app, factory, publisher := initRedisStreams(t, "topic1")
+	defer app.Close()
+	defer publisher.Shutdown(context.Background())
+
+	client := &testSubscriberClient{}
+	client.Init("test_client")
+	topic := pubsub_subscriber.New("topic1", func() *testMessage { return &testMessage{} })
+	topic.Subscribe(client)
+
+	// subscribe and unsubscribe to create consumer group
+	subscriber, err := factory.MakeSubscriber(app)
+	require.NoError(t, err)
+	_, err = subscriber.Subscribe(topic)
+	require.NoError(t, err)
+	require.NoError(t, subscriber.Shutdown(context.Background()))
+
+	// messages published while subscriber is offline are delivered after it is back
+	require.NoError(t, publisher.Publish("topic1", &testMessage{Value: 1}))
+	require.NoError(t, publisher.Publish("topic1", &testMessage{Value: 2}))
+
+	subscriber, err = factory.MakeSubscriber(app)
+	require.NoError(t, err)
+	defer subscriber.Shutdown(context.Background())
+	_, err = subscriber.Subscribe(topic)
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return len(client.Values()) == 2 }, 10*time.Second, 100*time.Millisecond)
+	assert.Equal(t, []int{1, 2}, client.Values())
+}
+
+func subscribeRedisStreams(t *testing.T, app app_context.Context, factory pubsub_factory.PubsubFactory, topicName string, configPath string, client *testSubscriberClient) {
+	client.Init("test_client")
+	topic := pubsub_subscriber.New(topicName, func() *testMessage { return &testMessage{} })
+	topic.Subscribe(client)
+	subscriber, err := factory.MakeSubscriber(app, &pubsub_factory.PubsubConfig{ConfigKeyPath: configPath})
+	require.NoError(t, err)
+	t.Cleanup(func() { subscriber.Shutdown(context.Background()) })
+	_, err = subscriber.Subscribe(topic)
+	require.NoError(t, err)
+}
+
+func TestRedisStreamsDelivery(t *testing.T) {
+
+	app, factory, publisher := initRedisStreams(t, "topic2")
+	defer app.Close()
+	defer publisher.Shutdown(context.Background())
+
+	subscribe := func(configPath string) *testSubscriberClient {
+		client := &testSubscriberClient{}
+		subscribeRedisStreams(t, app, factory, "topic2", configPath, client)
+		return client
+	}
+
+	// in broadcast mode each instance gets all messages
+	broadcast1 := subscribe("pubsub_broadcast1")
+	broadcast2 := subscribe("pubsub_broadcast2")
+
+	// in queue mode each message is delivered to one instance
+	queue1 := subscribe("pubsub_queue1")
+	queue2 := subscribe("pubsub_queue2")
+
+	for i := 1; i <= 4; i++ {
+		require.NoError(t, publisher.Publish("topic2", &testMessage{Value: i}))
+	}
+
+	assert.Eventually(t, func() bool {
+		return len(broadcast1.Values()) == 4 && len(broadcast2.Values()) == 4 && len(queue1.Values())+len(queue2.Values()) == 4
+	}, 10*time.Second, 100*time.Millisecond)
+	assert.Equal(t, []int{1, 2, 3, 4}, broadcast1.Values())
+	assert.Equal(t, []int{1, 2, 3, 4}, broadcast2.Values())
+	assert.ElementsMatch(t, []int{1, 2, 3, 4}, append(queue1.Values(), queue2.Values()...))
+}
+
+func TestRedisStreamsNewGroup(t *testing.T) {
+
+	app, factory, publisher := initRedisStreams(t, "topic3")
+	defer app.Close()
+	defer publisher.Shutdown(context.Background())
+
+	// messages published shortly before consumer group was created are delivered to new group
+	require.NoError(t, publisher.Publish("topic3", &testMessage{Value: 1}))
+	client := &testSubscriberClient{}
+	subscribeRedisStreams(t, app, factory, "topic3", "pubsub_broadcast1", client)
+	require.NoError(t, publisher.Publish("topic3", &testMessage{Value: 2}))
+
+	assert.Eventually(t, func() bool { return len(client.Values()) == 2 }, 10*time.Second, 100*time.Millisecond)
+	assert.Equal(t, []int{1, 2}, client.Values())
+}
+
+func TestRedisStreamsExpiredGroups(t *testing.T) {
+
+	app, factory, publisher := initRedisStreams(t, "topic4")
+	defer app.Close()
+	defer publisher.Shutdown(context.Background())
+	streamsPublisher := publisher.(*pubsub_redis_streams.Publisher)
+	redisClient := streamsPublisher.NativeHandler()
+	stream := streamsPublisher.StreamName("topic4")
+	ctx := context.Background()
+
+	// groups of instance that is not running any more and of other application
+	for _, group := range []string{"test_group:stale", "other_group"} {
+		require.NoError(t, redisClient.XGroupCreateMkStream(ctx, stream, group, "$").Err())
+		redisClient.XReadGroup(ctx, &redis.XReadGroupArgs{Group: group, Consumer: "stale", Streams: []string{stream, ">"}, Count: 1, Block: -1})
+	}
+
+	// only expired group of the same application is destroyed
+	client := &testSubscriberClient{}
+	subscribeRedisStreams(t, app, factory, "topic4", "pubsub_expire", client)
+	groupNames := func() []string {
+		groups, err := redisClient.XInfoGroups(ctx, stream).Result()
+		require.NoError(t, err)
+		names := []string{}
+		for _, group := range groups {
+			names = append(names, group.Name)
+		}
+		return names
+	}
+	assert.Eventually(t, func() bool { return len(groupNames()) == 2 }, 10*time.Second, 100*time.Millisecond)
+	assert.ElementsMatch(t, []string{"test_group:consumer1", "other_group"}, groupNames())
+}
+
+func TestRedisStreamsUndeliverable(t *testing.T) {
+
+	app, factory, publisher := initRedisStreams(t, "topic5")
+	defer app.Close()
+	defer publisher.Shutdown(context.Background())
+	streamsPublisher := publisher.(*pubsub_redis_streams.Publisher)
+	stream := streamsPublisher.StreamName("topic5")
+
+	client := &testSubscriberClient{fail: true}
+	subscribeRedisStreams(t, app, factory, "topic5", "pubsub_undeliverable", client)
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, publisher.Publish("topic5", &testMessage{Value: i}))
+	}
+
+	// all messages that failed max number of times are dropped
+	assert.Eventually(t, func() bool {
+		pending, err := streamsPublisher.NativeHandler().XPending(context.Background(), stream, "test_group:consumer1").Result()
+		return err == nil && len(client.Values()) >= 6 && pending.Count == 0
+	}, 20*time.Second, 100*time.Millisecond)
+}
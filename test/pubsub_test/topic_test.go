@@ -0,0 +1,202 @@
+package pubsub_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/evgeniums/go-utils/pkg/generic_error"
+	"github.com/evgeniums/go-utils/pkg/message/message_json"
+	"github.com/evgeniums/go-utils/pkg/multitenancy"
+	"github.com/evgeniums/go-utils/pkg/multitenancy/tenancy_manager"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+	"github.com/evgeniums/go-utils/pkg/pubsub/pubsub_subscriber"
+	"github.com/evgeniums/go-utils/pkg/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testTopicSubscriber struct {
+	pubsub_subscriber.SubscriberClientBase
+	failures int32
+	calls    int32
+	delay    time.Duration
+	started  chan struct{}
+}
+
+func newTestTopicSubscriber(name string, failures int32) *testTopicSubscriber {
+	s := &testTopicSubscriber{failures: failures}
+	s.Init(name)
+	return s
+}
+
+func (s *testTopicSubscriber) Handle(ctx op_context.Context, msg *testMessage) error {
+	calls := atomic.AddInt32(&s.calls, 1)
+	if s.started != nil {
+		s.started <- struct{}{}
+	}
+	if s.delay != 0 {
+		time.Sleep(s.delay)
+	}
+	if calls <= s.failures {
+		return errors.New("subscriber failed")
+	}
+	return nil
+}
+
+func TestTopicRetriesAndDeadLetter(t *testing.T) {
+	app := test_utils.InitAppContext(t, testDir, nil, "pubsub_test.json")
+	defer app.Close()
+	ctx := test_utils.SimpleOpContext(app, "TestTopicRetriesAndDeadLetter")
+	defer ctx.Close()
+
+	topic := pubsub_subscriber.New("topic_test", func() *testMessage { return &testMessage{} })
+	require.NoError(t, topic.LoadConfig(app.Cfg(), app.Logger(), app.Validator()))
+	assert.Equal(t, pubsub_subscriber.TopicModeConcurrent, topic.MODE)
+	assert.Equal(t, 2, topic.MAX_RETRIES)
+
+	ok := newTestTopicSubscriber("ok", 0)
+	flaky := newTestTopicSubscriber("flaky", 1)
+	broken := newTestTopicSubscriber("broken", 100)
+	topic.Subscribe(ok)
+	topic.Subscribe(flaky)
+	topic.Subscribe(broken)
+
+	// failed subscribers are retried before message is reported as handled
+	msg := []byte(`{"value":1}`)
+	err := topic.Handle(ctx, msg, message_json.Serializer)
+	assert.Error(t, err)
+	assert.Equal(t, uint64(1), topic.Stats()["broken"].Failed)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&ok.calls))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&flaky.calls))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&broken.calls))
+
+	// failed message is passed to dead-letter handler
+	var deadMutex sync.Mutex
+	dead := make([]string, 0)
+	deadLetters := func() []string {
+		deadMutex.Lock()
+		defer deadMutex.Unlock()
+		return append([]string{}, dead...)
+	}
+	topic.SetDeadLetterHandler(func(ctx op_context.Context, topicName string, subscriberName string, msg *testMessage, err error) {
+		deadMutex.Lock()
+		defer deadMutex.Unlock()
+		assert.Equal(t, "topic_test", topicName)
+		assert.Equal(t, 2, msg.Value)
+		assert.Error(t, err)
+		dead = append(dead, subscriberName)
+	})
+	err = topic.Handle(ctx, []byte(`{"value":2}`), message_json.Serializer)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"broken"}, deadLetters())
+
+	stats := topic.Stats()
+	assert.Equal(t, pubsub_subscriber.SubscriberStats{Handled: 2}, stats["ok"])
+	assert.Equal(t, pubsub_subscriber.SubscriberStats{Handled: 2, Retried: 1}, stats["flaky"])
+	assert.Equal(t, pubsub_subscriber.SubscriberStats{Failed: 2, Retried: 4}, stats["broken"])
+
+	// without dead-letter handler failure is reported to provider
+	topic.MAX_RETRIES = 0
+	topic.SetDeadLetterHandler(nil)
+	err = topic.Handle(ctx, []byte(`{"value":3}`), message_json.Serializer)
+	assert.Error(t, err)
+	assert.Equal(t, pubsub_subscriber.SubscriberStats{Failed: 3, Retried: 4}, topic.Stats()["broken"])
+}
+
+func TestTopicDefaults(t *testing.T) {
+	topic := pubsub_subscriber.New("topic_defaults", func() *testMessage { return &testMessage{} })
+	assert.Equal(t, pubsub_subscriber.TopicModeSequential, topic.MODE)
+	assert.Equal(t, 0, topic.MAX_RETRIES)
+	assert.Equal(t, 100, topic.RETRY_DELAY_MS)
+	assert.Equal(t, 5000, topic.RETRY_MAX_DELAY_MS)
+}
+
+func TestTopicTenancyContext(t *testing.T) {
+	app := test_utils.InitAppContext(t, testDir, nil, "pubsub_test.json")
+	defer app.Close()
+	ctx := multitenancy.NewInitContext(app, app.Logger(), app.Db())
+	ctx.SetName("TestTopicTenancyContext")
+	ctx.SetErrorManager(&generic_error.ErrorManagerBase{})
+	tenancy := &tenancy_manager.TenancyBase{}
+	tenancy.TenancyDb.InitObject()
+	ctx.SetTenancy(tenancy)
+	defer ctx.Close()
+
+	var mutex sync.Mutex
+	tenancies := make([]multitenancy.Tenancy, 0)
+	handler := func(ctx op_context.Context, msg *testMessage) error {
+		tenancyCtx, ok := ctx.(multitenancy.TenancyContext)
+		require.True(t, ok)
+		mutex.Lock()
+		defer mutex.Unlock()
+		tenancies = append(tenancies, tenancyCtx.GetTenancy())
+		return nil
+	}
+
+	// forked contexts of concurrent subscribers keep tenancy
+	topic := pubsub_subscriber.New("topic_tenancy", func() *testMessage { return &testMessage{} })
+	topic.MODE = pubsub_subscriber.TopicModeConcurrent
+	topic.Subscribe(&testHandlerSubscriber{name: "subscriber1", handler: handler})
+	topic.Subscribe(&testHandlerSubscriber{name: "subscriber2", handler: handler})
+	require.NoError(t, topic.Handle(ctx, []byte(`{"value":1}`), message_json.Serializer))
+	assert.Equal(t, []multitenancy.Tenancy{tenancy, tenancy}, tenancies)
+}
+
+type testHandlerSubscriber struct {
+	name    string
+	handler func(ctx op_context.Context, msg *testMessage) error
+}
+
+func (s *testHandlerSubscriber) Name() string {
+	return s.name
+}
+
+func (s *testHandlerSubscriber) Handle(ctx op_context.Context, msg *testMessage) error {
+	return s.handler(ctx, msg)
+}
+
+func TestTopicConcurrentMode(t *testing.T) {
+	app := test_utils.InitAppContext(t, testDir, nil, "pubsub_test.json")
+	defer app.Close()
+	ctx := test_utils.SimpleOpContext(app, "TestTopicConcurrentMode")
+	defer ctx.Close()
+
+	started := make(chan struct{}, 2)
+	slow1 := newTestTopicSubscriber("slow1", 0)
+	slow1.delay = 200 * time.Millisecond
+	slow1.started = started
+	slow2 := newTestTopicSubscriber("slow2", 0)
+	slow2.delay = 200 * time.Millisecond
+	slow2.started = started
+
+	topic := pubsub_subscriber.New("topic_concurrent", func() *testMessage { return &testMessage{} })
+	topic.Subscribe(slow1)
+	topic.Subscribe(slow2)
+
+	// sequential mode by default
+	assert.Equal(t, pubsub_subscriber.TopicModeSequential, topic.MODE)
+	start := time.Now()
+	require.NoError(t, topic.Handle(ctx, []byte(`{"value":1}`), message_json.Serializer))
+	assert.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+	<-started
+	<-started
+
+	// slow subscriber does not block others in concurrent mode
+	topic.MODE = pubsub_subscriber.TopicModeConcurrent
+	start = time.Now()
+	done := make(chan error)
+	go func() {
+		done <- topic.Handle(ctx, []byte(`{"value":2}`), message_json.Serializer)
+	}()
+	<-started
+	<-started
+	require.NoError(t, <-done)
+	assert.Less(t, time.Since(start), 400*time.Millisecond)
+
+	stats := topic.Stats()
+	assert.Equal(t, uint64(2), stats["slow1"].Handled)
+	assert.Equal(t, uint64(2), stats["slow2"].Handled)
+}
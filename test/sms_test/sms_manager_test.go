@@ -59,7 +59,7 @@ func testSms(t *testing.T, app app_context.Context, manager sms.SmsManager, encr
 	} else {
 		assert.NotEqual(t, message1, sms1.Message)
 		m := manager.(*sms.SmsManagerBase)
-		msg1, err := crypt_utils.DecryptStrings(m.SECRET, m.SALT, sms1.Message)
+		msg1, err := m.KeyRing().DecryptB64(sms1.Message)
 		assert.NoError(t, err, "failed to decrypt message")
 		assert.Equal(t, message1, string(msg1))
 	}
@@ -110,3 +110,76 @@ func TestSendSmsEncrypted(t *testing.T) {
 	defer app.Close()
 	testSms(t, app, manager, true)
 }
+
+func TestReencryptSms(t *testing.T) {
+	app, manager := initSmsManager(t, "sms_rotate_test.json")
+	defer app.Close()
+	m := manager.(*sms.SmsManagerBase)
+	assert.Equal(t, "key2", m.KeyRing().ActiveKeyId())
+
+	ctx := test_utils.SimpleOpContext(app, "TestReencryptSms")
+	defer ctx.Close()
+	b64 := utils.Base64StringCoding{}
+
+	// message encrypted without key ring
+	legacyCipher, err := crypt_utils.NewAEAD(m.SECRET, []byte(m.SALT))
+	require.NoError(t, err)
+	ciphertext, err := legacyCipher.Encrypt([]byte("legacy message"))
+	require.NoError(t, err)
+	sms1 := &sms.SmsMessage{}
+	sms1.InitObject()
+	sms1.Context = sms1.GetID()
+	sms1.Message = b64.Encode(ciphertext)
+	require.NoError(t, ctx.Db().Create(ctx, sms1))
+
+	// message encrypted with old key
+	oldRing := crypt_utils.NewKeyRing()
+	require.NoError(t, oldRing.AddKey(crypt_utils.KeyRingDefaultKeyId, m.SECRET, []byte(m.SALT)))
+	ciphertext, err = oldRing.Encrypt([]byte("old key message"))
+	require.NoError(t, err)
+	sms2 := &sms.SmsMessage{}
+	sms2.InitObject()
+	sms2.Context = sms2.GetID()
+	sms2.Message = b64.Encode(ciphertext)
+	require.NoError(t, ctx.Db().Create(ctx, sms2))
+
+	// message encrypted with active key
+	user1 := user.NewUser()
+	user1.InitObject()
+	user1.LOGIN = "test_login1"
+	user1.PHONE = "555000111"
+	ctx1 := test_utils.UserOpContext(app, "TestReencryptSms", user1)
+	smsId3, err := manager.Send(ctx1, "new key message", user1.PHONE)
+	ctx1.Close()
+	require.NoError(t, err)
+	sms3, err := manager.FindSms(ctx, smsId3)
+	require.NoError(t, err)
+	data, err := b64.Decode(sms3.Message)
+	require.NoError(t, err)
+	assert.Equal(t, "key2", m.KeyRing().KeyIdOf(data))
+
+	stats, err := sms.ReencryptMessages(ctx, m.KeyRing(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Total)
+	assert.Equal(t, 2, stats.Reencrypted)
+	assert.Equal(t, 0, stats.Failed)
+
+	check := func(id string, message string) {
+		obj, err := manager.FindSms(ctx, id)
+		require.NoError(t, err)
+		data, err := b64.Decode(obj.Message)
+		require.NoError(t, err)
+		assert.Equal(t, "key2", m.KeyRing().KeyIdOf(data))
+		plaintext, err := m.KeyRing().Decrypt(data)
+		require.NoError(t, err)
+		assert.Equal(t, message, string(plaintext))
+	}
+	check(sms1.GetID(), "legacy message")
+	check(sms2.GetID(), "old key message")
+	check(smsId3, "new key message")
+
+	stats, err = sms.ReencryptMessages(ctx, m.KeyRing())
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Total)
+	assert.Equal(t, 0, stats.Reencrypted)
+}
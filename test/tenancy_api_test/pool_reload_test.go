@@ -0,0 +1,120 @@
+package tenancy_api_test
+
+import (
+	"testing"
+
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/multitenancy"
+	"github.com/evgeniums/go-utils/pkg/multitenancy/tenancy_manager"
+	"github.com/evgeniums/go-utils/pkg/pool"
+	"github.com/evgeniums/go-utils/pkg/pubsub/pubsub_providers/pubsub_factory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolReload(t *testing.T) {
+
+	// prepare app with multiple pools and single pool
+	multiPoolCtx, singlePoolCtx := PrepareAppWithTenancies(t)
+
+	// add tenancy to the same pool as single pool app
+	tenancyData := &multitenancy.TenancyData{}
+	tenancyData.POOL_ID = "pool2"
+	tenancyData.ROLE = "dev"
+	tenancyData.CUSTOMER_ID = "customer1"
+	addedTenancy, err := multiPoolCtx.RemoteTenancyController.Add(multiPoolCtx.ClientOp, tenancyData)
+	require.NoError(t, err)
+	loadedTenancy, err := singlePoolCtx.AppWithTenancy.Multitenancy().Tenancy(addedTenancy.GetID())
+	require.NoError(t, err)
+
+	// update database service of the pool via multipool app
+	service, err := singlePoolCtx.AppWithTenancy.Pools().SelfPoolService(multitenancy.TENANCY_DATABASE_ROLE)
+	require.NoError(t, err)
+	fields := db.Fields{"description": "updated database service"}
+	_, err = multiPoolCtx.RemotePoolController.UpdateService(multiPoolCtx.ClientOp, service.ServiceId, fields)
+	require.NoError(t, err)
+
+	// check if pool was reloaded in both apps
+	service, err = singlePoolCtx.AppWithTenancy.Pools().SelfPoolService(multitenancy.TENANCY_DATABASE_ROLE)
+	require.NoError(t, err)
+	assert.Equal(t, "updated database service", service.Description())
+	p, err := multiPoolCtx.AppWithTenancy.Pools().PoolByName("pool2")
+	require.NoError(t, err)
+	service, err = p.Service(multitenancy.TENANCY_DATABASE_ROLE)
+	require.NoError(t, err)
+	assert.Equal(t, "updated database service", service.Description())
+
+	// check if tenancy was reloaded with new pool
+	reloadedTenancy, err := singlePoolCtx.AppWithTenancy.Multitenancy().Tenancy(addedTenancy.GetID())
+	require.NoError(t, err)
+	assert.NotSame(t, loadedTenancy, reloadedTenancy)
+	service, err = reloadedTenancy.Pool().Service(multitenancy.TENANCY_DATABASE_ROLE)
+	require.NoError(t, err)
+	assert.Equal(t, "updated database service", service.Description())
+	sample := &InTenancySample{Field1: "after reload", Field2: 20}
+	err = reloadedTenancy.Db().Create(multiPoolCtx.AdminOp, sample)
+	require.NoError(t, err)
+
+	// deactivate the pool, single pool app must be disconnected from pubsub
+	_, err = pool.DeactivatePool(multiPoolCtx.RemotePoolController, multiPoolCtx.ClientOp, "pool2", true)
+	require.NoError(t, err)
+	selfPool, err := singlePoolCtx.AppWithTenancy.Pools().SelfPool()
+	require.NoError(t, err)
+	assert.False(t, selfPool.IsActive())
+	assert.Nil(t, singlePoolCtx.AppWithTenancy.Pubsub().PoolPublisher(selfPool.GetID()))
+
+	// tenancy that failed to reload must stay loaded
+	loadedTenancy, err = singlePoolCtx.AppWithTenancy.Multitenancy().Tenancy(addedTenancy.GetID())
+	require.NoError(t, err)
+	err = singlePoolCtx.AdminOp.Db().DeleteByFields(singlePoolCtx.AdminOp, db.Fields{"id": addedTenancy.GetID()}, &multitenancy.TenancyDb{})
+	require.NoError(t, err)
+	manager, ok := singlePoolCtx.AppWithTenancy.Multitenancy().(*tenancy_manager.TenancyManager)
+	require.True(t, ok)
+	err = manager.ReloadPoolTenancies(singlePoolCtx.AdminOp, selfPool.GetID(), selfPool, selfPool)
+	assert.Error(t, err)
+	singlePoolCtx.AdminOp.ClearError()
+	keptTenancy, err := singlePoolCtx.AppWithTenancy.Multitenancy().Tenancy(addedTenancy.GetID())
+	require.NoError(t, err)
+	assert.Same(t, loadedTenancy, keptTenancy)
+
+	// close apps
+	multiPoolCtx.Close()
+	singlePoolCtx.Close()
+	pubsub_factory.ResetSingletonInmemPubsub()
+}
+
+func TestPoolPubsubReload(t *testing.T) {
+
+	// prepare app with multiple pools and single pool
+	multiPoolCtx, singlePoolCtx := PrepareAppWithTenancies(t)
+	selfPool, err := singlePoolCtx.AppWithTenancy.Pools().SelfPool()
+	require.NoError(t, err)
+	oldPublisher := singlePoolCtx.AppWithTenancy.Pubsub().PoolPublisher(selfPool.GetID())
+	require.NotNil(t, oldPublisher)
+
+	// switch pool to other pubsub, notification is delivered by old pubsub of the pool
+	service, err := selfPool.Service(pool.TypePubsub)
+	require.NoError(t, err)
+	_, err = multiPoolCtx.RemotePoolController.UpdateService(multiPoolCtx.ClientOp, service.ServiceId, db.Fields{"db_name": "2"})
+	require.NoError(t, err)
+	publisher := singlePoolCtx.AppWithTenancy.Pubsub().PoolPublisher(selfPool.GetID())
+	require.NotNil(t, publisher)
+	assert.NotSame(t, oldPublisher, publisher)
+	p, err := multiPoolCtx.AppWithTenancy.Pools().PoolByName("pool2")
+	require.NoError(t, err)
+	assert.Same(t, publisher, multiPoolCtx.AppWithTenancy.Pubsub().PoolPublisher(p.GetID()))
+
+	// next notification is delivered by new pubsub of the pool
+	fields := db.Fields{"description": "updated after pubsub switch"}
+	_, err = multiPoolCtx.RemotePoolController.UpdateService(multiPoolCtx.ClientOp, service.ServiceId, fields)
+	require.NoError(t, err)
+	service, err = singlePoolCtx.AppWithTenancy.Pools().SelfPoolService(pool.TypePubsub)
+	require.NoError(t, err)
+	assert.Equal(t, "2", service.DbName())
+	assert.Equal(t, "updated after pubsub switch", service.Description())
+
+	// close apps
+	multiPoolCtx.Close()
+	singlePoolCtx.Close()
+	pubsub_factory.ResetSingletonInmemPubsub()
+}
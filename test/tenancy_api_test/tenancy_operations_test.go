@@ -12,6 +12,7 @@ import (
 	"github.com/evgeniums/go-utils/pkg/common"
 	"github.com/evgeniums/go-utils/pkg/customer"
 	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/generic_error"
 	"github.com/evgeniums/go-utils/pkg/multitenancy"
 	"github.com/evgeniums/go-utils/pkg/multitenancy/app_with_multitenancy"
 	"github.com/evgeniums/go-utils/pkg/multitenancy/tenancy_api/tenancy_client"
@@ -215,6 +216,9 @@ func preparePoolAndServices(t *testing.T, activatePools bool) (p1 pool.Pool, poo
 
 	prepareCtx.Close()
 
+	// preparing app connected to pubsub when pools were changed, drop its subscriptions
+	pubsub_factory.ResetSingletonInmemPubsub()
+
 	return
 }
 
@@ -460,6 +464,26 @@ func TestListTenancies(t *testing.T) {
 	assert.Equal(t, tenancy1, tenancies[0])
 	assert.Equal(t, tenancy2, tenancies[1])
 
+	// search tenancies
+	filter = db.NewFilter()
+	filter.AddPrefix("pool_name", "pool2")
+	tenancies, _, err = multiPoolCtx.RemoteTenancyController.List(multiPoolCtx.ClientOp, filter)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(tenancies))
+	assert.Equal(t, tenancy1, tenancies[0])
+
+	filter = db.NewFilter()
+	filter.AddFullTextSearch("description", "stage")
+	tenancies, _, err = multiPoolCtx.RemoteTenancyController.List(multiPoolCtx.ClientOp, filter)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(tenancies))
+	assert.Equal(t, tenancy2, tenancies[0])
+
+	filter = db.NewFilter()
+	filter.AddFullTextSearch("customer_login", "customer1")
+	_, _, err = multiPoolCtx.RemoteTenancyController.List(multiPoolCtx.ClientOp, filter)
+	test_utils.CheckGenericError(t, err, generic_error.ErrorCodeFormat)
+
 	// close apps
 	multiPoolCtx.Close()
 	singlePoolCtx.Close()
@@ -0,0 +1,152 @@
+package work_schedule_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/evgeniums/go-utils/pkg/admin"
+	"github.com/evgeniums/go-utils/pkg/api"
+	"github.com/evgeniums/go-utils/pkg/api/api_client/rest_api_client"
+	"github.com/evgeniums/go-utils/pkg/api/api_server"
+	"github.com/evgeniums/go-utils/pkg/app_context"
+	"github.com/evgeniums/go-utils/pkg/background_worker"
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/generic_error"
+	"github.com/evgeniums/go-utils/pkg/multitenancy"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+	"github.com/evgeniums/go-utils/pkg/test_utils"
+	"github.com/evgeniums/go-utils/pkg/work_schedule"
+	"github.com/evgeniums/go-utils/pkg/work_schedule/work_schedule_api/work_schedule_service"
+	"github.com/evgeniums/go-utils/pkg/work_schedule/work_shedule_console"
+	"github.com/evgeniums/go-utils/test/api_test"
+	"github.com/jessevdk/go-flags"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func addDeadWork(t *testing.T, ctx op_context.Context, schedule *work_schedule.WorkSchedule[*TestWork], referenceId string) *TestWork {
+	work := schedule.NewWork(referenceId, "test")
+	require.NoError(t, schedule.PostWork(ctx, work, work_schedule.SCHEDULE))
+	require.NoError(t, db.Update(ctx.Db(), ctx, work, db.Fields{"dead": true, "attempts": 3, "last_error": "work failed"}))
+	return work
+}
+
+func deadWorkIds(t *testing.T, ctx op_context.Context, schedule *work_schedule.WorkSchedule[*TestWork]) []string {
+	works, _, err := schedule.ListDeadWorks(ctx, nil)
+	require.NoError(t, err)
+	ids := make([]string, 0, len(works))
+	for _, work := range works {
+		ids = append(ids, work.GetID())
+	}
+	return ids
+}
+
+func newSchedule(t *testing.T, app app_context.Context) *work_schedule.WorkSchedule[*TestWork] {
+	config := work_schedule.Config[*TestWork]{WorkBuilder: NewTestWork, WorkRunner: &TestRunner{}}
+	schedule := work_schedule.NewWorkSchedule("test_schedule", config)
+	schedule.SetStopper(&background_worker.BackgroundStopperStub{})
+	require.NoError(t, schedule.Init(app))
+	return schedule
+}
+
+func TestDeadWorksApi(t *testing.T) {
+
+	ctx := api_test.InitTest(t, "work_schedule", testDir, append(dbModels(), admin.DbModels()...))
+	defer ctx.Close()
+	schedule := newSchedule(t, ctx.ServerApp)
+	service := work_schedule_service.NewWorkScheduleService[*TestWork]("work_schedule", schedule)
+	api_server.AddServiceToServer(ctx.Server.ApiServer(), service)
+	client, ok := ctx.RestApiClient.Transport().(rest_api_client.RestApiClient)
+	require.True(t, ok)
+
+	work1 := addDeadWork(t, ctx.AdminOp, schedule, "ref1")
+	work2 := addDeadWork(t, ctx.AdminOp, schedule, "ref2")
+	work3 := addDeadWork(t, ctx.AdminOp, schedule, "ref3")
+	alive := schedule.NewWork("ref4", "test")
+	require.NoError(t, schedule.PostWork(ctx.AdminOp, alive, work_schedule.SCHEDULE))
+
+	// list dead works
+	works := &api.ResponseList[*TestWork]{}
+	resp, err := client.Get(ctx.ClientOp, "/work_schedule/dead_work", nil, works)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.Code())
+	assert.Equal(t, int64(3), works.Count)
+	assert.Len(t, works.Items, 3)
+
+	// retry dead work
+	resp, err = client.Post(ctx.ClientOp, "/work_schedule/dead_work/"+work1.GetID()+"/retry", nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.Code())
+	assert.ElementsMatch(t, []string{work2.GetID(), work3.GetID()}, deadWorkIds(t, ctx.AdminOp, schedule))
+
+	resp, err = client.Post(ctx.ClientOp, "/work_schedule/dead_work/"+alive.GetID()+"/retry", nil, nil)
+	require.NoError(t, err)
+	require.NotNil(t, resp.Error())
+	assert.Equal(t, work_schedule.ErrorCodeDeadWorkNotFound, resp.Error().Code())
+
+	// delete dead work
+	resp, err = client.Delete(ctx.ClientOp, "/work_schedule/dead_work/"+work2.GetID(), nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.Code())
+	assert.ElementsMatch(t, []string{work3.GetID()}, deadWorkIds(t, ctx.AdminOp, schedule))
+
+	resp, err = client.Delete(ctx.ClientOp, "/work_schedule/dead_work/"+work2.GetID(), nil, nil)
+	require.NoError(t, err)
+	require.NotNil(t, resp.Error())
+	assert.Equal(t, work_schedule.ErrorCodeDeadWorkNotFound, resp.Error().Code())
+
+	// purge dead works
+	resp, err = client.Delete(ctx.ClientOp, "/work_schedule/dead_work", nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.Code())
+	assert.Empty(t, deadWorkIds(t, ctx.AdminOp, schedule))
+	readWork(t, ctx.AdminOp, alive.GetID())
+}
+
+func TestDeadWorksConsole(t *testing.T) {
+
+	app, schedule, _ := initSchedule(t)
+	defer app.Close()
+	ctx := test_utils.SimpleOpContext(app, t.Name())
+	defer ctx.Close()
+
+	commands := work_schedule_console.NewWorkScheduleCommands("work_schedule", "Work schedule", func(app app_context.Context) (work_schedule.WorkScheduler[*TestWork], error) {
+		return schedule, nil
+	})
+	ctxBuilder := func(group string, command string) multitenancy.TenancyContext {
+		opCtx := multitenancy.NewInitContext(app, app.Logger(), app.Db())
+		opCtx.SetName(command)
+		errManager := &generic_error.ErrorManagerBase{}
+		errManager.Init(http.StatusBadRequest)
+		opCtx.SetErrorManager(errManager)
+		return opCtx
+	}
+	execute := func(args ...string) error {
+		parser := flags.NewParser(&struct{}{}, flags.HelpFlag)
+		commands.Handlers(ctxBuilder, parser)
+		_, err := parser.ParseArgs(append([]string{"work_schedule"}, args...))
+		return err
+	}
+
+	work1 := addDeadWork(t, ctx, schedule, "ref1")
+	work2 := addDeadWork(t, ctx, schedule, "ref2")
+	work3 := addDeadWork(t, ctx, schedule, "ref3")
+
+	require.NoError(t, execute(work_schedule_console.ListDeadWorksCmd))
+
+	// retry dead work
+	require.NoError(t, execute(work_schedule_console.RetryDeadWorkCmd, "--id", work1.GetID()))
+	assert.ElementsMatch(t, []string{work2.GetID(), work3.GetID()}, deadWorkIds(t, ctx, schedule))
+	assert.Error(t, execute(work_schedule_console.RetryDeadWorkCmd, "--id", work1.GetID()))
+
+	// delete selected dead works, nothing is deleted if any of works is not found
+	assert.Error(t, execute(work_schedule_console.PurgeDeadWorksCmd, "--id", work2.GetID(), "--id", work1.GetID()))
+	assert.ElementsMatch(t, []string{work2.GetID(), work3.GetID()}, deadWorkIds(t, ctx, schedule))
+	require.NoError(t, execute(work_schedule_console.PurgeDeadWorksCmd, "--id", work2.GetID()))
+	assert.ElementsMatch(t, []string{work3.GetID()}, deadWorkIds(t, ctx, schedule))
+
+	// purge all dead works
+	require.NoError(t, execute(work_schedule_console.PurgeDeadWorksCmd))
+	assert.Empty(t, deadWorkIds(t, ctx, schedule))
+	readWork(t, ctx, work1.GetID())
+}
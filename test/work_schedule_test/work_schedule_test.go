@@ -0,0 +1,288 @@
+package work_schedule_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evgeniums/go-utils/pkg/app_context"
+	"github.com/evgeniums/go-utils/pkg/background_worker"
+	"github.com/evgeniums/go-utils/pkg/cache"
+	"github.com/evgeniums/go-utils/pkg/db"
+	"github.com/evgeniums/go-utils/pkg/db/db_gorm"
+	"github.com/evgeniums/go-utils/pkg/multitenancy"
+	"github.com/evgeniums/go-utils/pkg/op_context"
+	"github.com/evgeniums/go-utils/pkg/test_utils"
+	"github.com/evgeniums/go-utils/pkg/work_schedule"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _, testBasePath, _, _ = runtime.Caller(0)
+var testDir = filepath.Dir(testBasePath)
+
+type TestWork struct {
+	work_schedule.WorkBase
+}
+
+func NewTestWork() *TestWork {
+	return &TestWork{}
+}
+
+func dbModels() []interface{} {
+	return append([]interface{}{&TestWork{}}, work_schedule.DbModels()...)
+}
+
+type TestRunner struct {
+	Fail  bool
+	Done  bool
+	Calls int
+
+	mutex     sync.Mutex
+	tenancies []string
+}
+
+func (r *TestRunner) Run(ctx op_context.Context, work *TestWork) (bool, error) {
+	r.Calls++
+	tenancyCtx, ok := ctx.(multitenancy.TenancyContext)
+	if ok && tenancyCtx.GetTenancy() != nil {
+		r.mutex.Lock()
+		r.tenancies = append(r.tenancies, tenancyCtx.GetTenancy().GetID())
+		r.mutex.Unlock()
+	}
+	if r.Fail {
+		return false, errors.New("work failed")
+	}
+	return r.Done, nil
+}
+
+func initSchedule(t *testing.T, tenancies ...multitenancy.Multitenancy) (app_context.Context, *work_schedule.WorkSchedule[*TestWork], *TestRunner) {
+	app := test_utils.InitAppContext(t, testDir, dbModels(), "work_schedule_test.json")
+	runner := &TestRunner{}
+	config := work_schedule.Config[*TestWork]{WorkBuilder: NewTestWork, WorkRunner: runner}
+	if len(tenancies) != 0 {
+		config.Tenancies = tenancies[0]
+	}
+	schedule := work_schedule.NewWorkSchedule("test_schedule", config)
+	schedule.SetStopper(&background_worker.BackgroundStopperStub{})
+	require.NoError(t, schedule.Init(app))
+	return app, schedule, runner
+}
+
+func readWork(t *testing.T, ctx op_context.Context, id string) *TestWork {
+	work := NewTestWork()
+	found, err := ctx.Db().FindByField(ctx, "id", id, work)
+	require.NoError(t, err)
+	require.True(t, found)
+	return work
+}
+
+func TestBackoffDelay(t *testing.T) {
+	app, schedule, _ := initSchedule(t)
+	defer app.Close()
+
+	assert.Equal(t, 10*time.Second, schedule.BackoffDelay(1))
+	assert.Equal(t, 20*time.Second, schedule.BackoffDelay(2))
+	assert.Equal(t, 30*time.Second, schedule.BackoffDelay(3))
+	assert.Equal(t, 30*time.Second, schedule.BackoffDelay(10))
+
+	schedule.BACKOFF_JITTER = 0.5
+	for i := 0; i < 20; i++ {
+		delay := schedule.BackoffDelay(1)
+		assert.GreaterOrEqual(t, delay, 5*time.Second)
+		assert.LessOrEqual(t, delay, 15*time.Second)
+	}
+}
+
+func TestDeadWorks(t *testing.T) {
+	app, schedule, runner := initSchedule(t)
+	defer app.Close()
+	ctx := test_utils.SimpleOpContext(app, "TestDeadWorks")
+	defer ctx.Close()
+
+	work := schedule.NewWork("ref1", "test")
+	require.NoError(t, schedule.PostWork(ctx, work, work_schedule.SCHEDULE))
+	work = readWork(t, ctx, work.GetID())
+
+	// failed attempts are rescheduled with backoff
+	runner.Fail = true
+	start := time.Now()
+	schedule.DoWork(ctx, work)
+	work = readWork(t, ctx, work.GetID())
+	assert.Equal(t, 1, work.GetAttempts())
+	assert.Equal(t, "work failed", work.GetLastError())
+	assert.False(t, work.IsDead())
+	assert.WithinDuration(t, start.Add(10*time.Second), work.GetNextTime(), 2*time.Second)
+
+	schedule.DoWork(ctx, work)
+	work = readWork(t, ctx, work.GetID())
+	assert.Equal(t, 2, work.GetAttempts())
+	assert.WithinDuration(t, start.Add(20*time.Second), work.GetNextTime(), 2*time.Second)
+
+	// work is dead after max attempts
+	schedule.DoWork(ctx, work)
+	work = readWork(t, ctx, work.GetID())
+	assert.Equal(t, 3, work.GetAttempts())
+	assert.True(t, work.IsDead())
+
+	other := schedule.NewWork("ref2", "test")
+	require.NoError(t, schedule.PostWork(ctx, other, work_schedule.SCHEDULE))
+
+	deadWorks, count, err := schedule.ListDeadWorks(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	require.Len(t, deadWorks, 1)
+	assert.Equal(t, work.GetID(), deadWorks[0].GetID())
+
+	// retry dead work
+	assert.Error(t, schedule.RetryDeadWork(ctx, other.GetID()))
+	require.NoError(t, schedule.RetryDeadWork(ctx, work.GetID()))
+	work = readWork(t, ctx, work.GetID())
+	assert.False(t, work.IsDead())
+	assert.Equal(t, 0, work.GetAttempts())
+	assert.Empty(t, work.GetLastError())
+
+	// successful run resets attempts
+	runner.Fail = true
+	schedule.DoWork(ctx, work)
+	work = readWork(t, ctx, work.GetID())
+	assert.Equal(t, 1, work.GetAttempts())
+	runner.Fail = false
+	require.NoError(t, schedule.DoWork(ctx, work))
+	work = readWork(t, ctx, work.GetID())
+	assert.Equal(t, 0, work.GetAttempts())
+	assert.Empty(t, work.GetLastError())
+
+	// purge dead works
+	for i := 0; i < 3; i++ {
+		runner.Fail = true
+		schedule.DoWork(ctx, work)
+		work = readWork(t, ctx, work.GetID())
+	}
+	assert.True(t, work.IsDead())
+	assert.Error(t, schedule.PurgeDeadWorks(ctx, other.GetID()))
+	require.NoError(t, schedule.PurgeDeadWorks(ctx))
+	_, count, err = schedule.ListDeadWorks(ctx, db.NewFilter())
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+	found, err := ctx.Db().FindByField(ctx, "id", other.GetID(), NewTestWork())
+	require.NoError(t, err)
+	assert.True(t, found)
+}
+
+type TestTenancy struct {
+	multitenancy.Tenancy
+	id     string
+	active bool
+	db     db.DB
+}
+
+func (t *TestTenancy) GetID() string           { return t.id }
+func (t *TestTenancy) IsActive() bool          { return t.active }
+func (t *TestTenancy) CustomerDisplay() string { return "" }
+func (t *TestTenancy) Path() string            { return t.id }
+func (t *TestTenancy) Db() db.DB               { return t.db }
+func (t *TestTenancy) Cache() cache.Cache      { return nil }
+
+type TestTenancies struct {
+	multitenancy.Multitenancy
+	tenancies []multitenancy.Tenancy
+}
+
+func (t *TestTenancies) IsMultiTenancy() bool              { return true }
+func (t *TestTenancies) Tenancies() []multitenancy.Tenancy { return t.tenancies }
+
+func TestProcessWorksInTenancies(t *testing.T) {
+
+	tenancies := &TestTenancies{}
+	app, schedule, runner := initSchedule(t, tenancies)
+	defer app.Close()
+	schedule.PROCESS_MAIN_DB = false
+	runner.Done = true
+
+	// tenancies share database of application in this test
+	tenancies.tenancies = []multitenancy.Tenancy{
+		&TestTenancy{id: "tenancy1", active: true, db: app.Db()},
+		&TestTenancy{id: "tenancy2", active: false, db: app.Db()},
+	}
+
+	ctx := test_utils.SimpleOpContext(app, "TestProcessWorksInTenancies")
+	defer ctx.Close()
+	for _, ref := range []string{"ref1", "ref2", "ref3"} {
+		work := schedule.NewWork(ref, "test")
+		work.SetNextTime(time.Now().Add(-time.Second))
+		require.NoError(t, ctx.Db().Create(ctx, work))
+	}
+
+	schedule.ProcessWorks()
+	require.Eventually(t, func() bool {
+		runner.mutex.Lock()
+		defer runner.mutex.Unlock()
+		return len(runner.tenancies) == 3
+	}, 5*time.Second, 50*time.Millisecond)
+
+	runner.mutex.Lock()
+	defer runner.mutex.Unlock()
+	sort.Strings(runner.tenancies)
+	assert.Equal(t, []string{"tenancy1", "tenancy1", "tenancy1"}, runner.tenancies)
+}
+
+func TestProcessWorksFairness(t *testing.T) {
+
+	tenancies := &TestTenancies{}
+	app, schedule, runner := initSchedule(t, tenancies)
+	defer app.Close()
+	schedule.PROCESS_MAIN_DB = false
+	schedule.BUCKET_SIZE = 4
+	runner.Done = true
+
+	// second tenancy has its own database
+	tenancyDb := db_gorm.New()
+	require.NoError(t, tenancyDb.InitWithConfig(app, app.Validator(), &db.DBConfig{DB_PROVIDER: "sqlite", DB_NAME: "work_schedule_tenancy2.sqlite"}))
+	require.NoError(t, tenancyDb.AutoMigrate(app, dbModels()))
+	tenancies.tenancies = []multitenancy.Tenancy{
+		&TestTenancy{id: "tenancy1", active: true, db: app.Db()},
+		&TestTenancy{id: "tenancy2", active: true, db: tenancyDb},
+	}
+
+	// first tenancy has large backlog, second tenancy has few works
+	ctx := test_utils.SimpleOpContext(app, "TestProcessWorksFairness")
+	defer ctx.Close()
+	addWorks := func(database db.DB, round int, count int) {
+		for i := 0; i < count; i++ {
+			work := schedule.NewWork(fmt.Sprintf("ref%d_%d", round, i), "test")
+			work.SetNextTime(time.Now().Add(-time.Second))
+			require.NoError(t, database.Create(ctx, work))
+		}
+	}
+
+	// sources are rotated on each call, so each tenancy is polled first in one of the rounds
+	for round := 0; round < 2; round++ {
+		addWorks(app.Db(), round, 20)
+		addWorks(tenancyDb, round, 2)
+		first := round * 22
+
+		schedule.ProcessWorks()
+		require.Eventually(t, func() bool {
+			runner.mutex.Lock()
+			defer runner.mutex.Unlock()
+			return len(runner.tenancies) == first+22
+		}, 5*time.Second, 50*time.Millisecond)
+
+		// bucket is split between tenancies, so works of second tenancy are not delayed by backlog of the first one
+		runner.mutex.Lock()
+		count := 0
+		for _, tenancy := range runner.tenancies[first : first+schedule.BUCKET_SIZE] {
+			if tenancy == "tenancy2" {
+				count++
+			}
+		}
+		runner.mutex.Unlock()
+		assert.Equal(t, 2, count, "round %d", round)
+	}
+}